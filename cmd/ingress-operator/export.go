@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorclient "github.com/openshift/cluster-ingress-operator/pkg/operator/client"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// exportTimeout is how long to wait for the API to return the
+// ingresscontrollers and cluster config objects.
+const exportTimeout = 1 * time.Minute
+
+func NewExportCommand() *cobra.Command {
+	var options struct {
+		Namespace string
+		OutputDir string
+	}
+
+	var command = &cobra.Command{
+		Use:   "export [name...]",
+		Short: "Export ingresscontroller manifests",
+		Long:  `export emits minimal manifests for the named ingresscontrollers, or for all ingresscontrollers if none are named, omitting status, server-populated metadata, and spec fields that the operator defaults to the same values for the cluster.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := export(options.Namespace, options.OutputDir, args); err != nil {
+				log.Error(err, "error exporting")
+				os.Exit(1)
+			}
+		},
+	}
+
+	command.Flags().StringVarP(&options.Namespace, "namespace", "n", operatorcontroller.DefaultOperatorNamespace, "namespace of the ingresscontrollers.")
+	command.Flags().StringVarP(&options.OutputDir, "output-dir", "o", "", "manifest output directory; if omitted, manifests are written to standard output.")
+
+	return command
+}
+
+func export(namespace, dir string, names []string) error {
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kube config: %v", err)
+	}
+	cl, err := client.New(kubeConfig, client.Options{Scheme: operatorclient.GetScheme()})
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	ingressConfig := &configv1.Ingress{}
+	if err := cl.Get(ctx, operatorcontroller.IngressClusterConfigName(), ingressConfig); err != nil {
+		return fmt.Errorf("failed to get ingress 'cluster': %v", err)
+	}
+	infraConfig := &configv1.Infrastructure{}
+	if err := cl.Get(ctx, operatorcontroller.InfrastructureClusterConfigName(), infraConfig); err != nil {
+		return fmt.Errorf("failed to get infrastructure 'cluster': %v", err)
+	}
+	dnsConfig := &configv1.DNS{}
+	if err := cl.Get(ctx, types.NamespacedName{Name: "cluster"}, dnsConfig); err != nil {
+		return fmt.Errorf("failed to get dns 'cluster': %v", err)
+	}
+
+	var ingresscontrollers []operatorv1.IngressController
+	if len(names) == 0 {
+		list := &operatorv1.IngressControllerList{}
+		if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return fmt.Errorf("failed to list ingresscontrollers in namespace %q: %v", namespace, err)
+		}
+		ingresscontrollers = list.Items
+	} else {
+		for _, name := range names {
+			ic := &operatorv1.IngressController{}
+			if err := cl.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, ic); err != nil {
+				return fmt.Errorf("failed to get ingresscontroller %s/%s: %v", namespace, name, err)
+			}
+			ingresscontrollers = append(ingresscontrollers, *ic)
+		}
+	}
+
+	if len(dir) != 0 {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create output directory %q: %v", dir, err)
+		}
+	}
+	for i := range ingresscontrollers {
+		ic := &ingresscontrollers[i]
+		exported, reproducible := ingresscontroller.ExportIngressController(ic, ingressConfig, infraConfig, dnsConfig)
+		if !reproducible {
+			log.Info("warning: the ingresscontroller's admitted domain or endpoint publishing strategy differs from what its spec yields with the cluster's current defaults; the admitted values are written into the exported spec", "namespace", ic.Namespace, "name", ic.Name)
+		}
+		data, err := encodeExportedIngressController(exported)
+		if err != nil {
+			return fmt.Errorf("failed to encode ingresscontroller %s/%s: %v", ic.Namespace, ic.Name, err)
+		}
+		if len(dir) == 0 {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			if _, err := os.Stdout.Write(data); err != nil {
+				return err
+			}
+			continue
+		}
+		outputFile := filepath.Join(dir, ic.Name+".yaml")
+		if err := ioutil.WriteFile(outputFile, data, 0640); err != nil {
+			return fmt.Errorf("failed to write %q: %v", outputFile, err)
+		}
+		fmt.Fprintf(os.Stderr, "wrote %s\n", outputFile)
+	}
+	return nil
+}
+
+// encodeExportedIngressController returns the YAML encoding of the given
+// ingresscontroller without the empty status and creation timestamp that the
+// typed encoding would include.
+func encodeExportedIngressController(ic *operatorv1.IngressController) ([]byte, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ic)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(obj, "status")
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, nil, nil, json.SerializerOptions{Yaml: true})
+	var buf bytes.Buffer
+	if err := serializer.Encode(&unstructured.Unstructured{Object: obj}, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorclient "github.com/openshift/cluster-ingress-operator/pkg/operator/client"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	// routerCtlTimeout is how long to wait for the operation to complete
+	// on all router pods.
+	routerCtlTimeout = 2 * time.Minute
+	// routerCtlEventTimeout is how long to wait for the audit event to be
+	// recorded.  The event gets its own timeout so that it is recorded even
+	// if the operation used up routerCtlTimeout.
+	routerCtlEventTimeout = 10 * time.Second
+
+	// routerCtlEventSource is the component that is recorded as the source
+	// of the audit event for a router-ctl operation.
+	routerCtlEventSource = "ingress-operator-router-ctl"
+
+	// routerCtlEventMessageLimit is the maximum length of the audit event
+	// message.
+	routerCtlEventMessageLimit = 1024
+)
+
+// routerCtlResult is the outcome of a router-ctl operation on one router pod.
+type routerCtlResult struct {
+	pod      string
+	node     string
+	commands []string
+	output   string
+	err      error
+}
+
+func NewRouterCtlCommand() *cobra.Command {
+	var options struct {
+		Namespace         string
+		IngressController string
+		DryRun            bool
+	}
+
+	var names []string
+	for name := range ingresscontroller.RouterRuntimeOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var operations strings.Builder
+	for _, name := range names {
+		op := ingresscontroller.RouterRuntimeOperations[name]
+		fmt.Fprintf(&operations, "\n  %s %s\n        %s", name, op.Usage, op.Description)
+	}
+
+	var command = &cobra.Command{
+		Use:   "router-ctl <operation> [argument]",
+		Short: "Run HAProxy runtime API operations on an ingresscontroller's routers",
+		Long: `router-ctl runs an HAProxy runtime API operation on every router pod of an ingresscontroller, reports the result for each pod, and records an event on the ingresscontroller.
+
+Operations:` + operations.String(),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := routerCtl(options.Namespace, options.IngressController, options.DryRun, args[0], args[1:]); err != nil {
+				log.Error(err, "error running router-ctl")
+				os.Exit(1)
+			}
+		},
+	}
+
+	command.Flags().StringVarP(&options.Namespace, "namespace", "n", operatorcontroller.DefaultOperatorNamespace, "namespace of the ingresscontroller.")
+	command.Flags().StringVarP(&options.IngressController, "ingresscontroller", "i", "default", "name of the ingresscontroller whose routers to operate on.")
+	command.Flags().BoolVar(&options.DryRun, "dry-run", false, "print the runtime API commands for each router pod instead of running them; read-only queries that are needed to determine the commands are still run.")
+
+	return command
+}
+
+func routerCtl(namespace, name string, dryRun bool, opName string, args []string) error {
+	op, ok := ingresscontroller.RouterRuntimeOperations[opName]
+	if !ok {
+		return fmt.Errorf("unknown operation %q", opName)
+	}
+	if err := op.Validate(args); err != nil {
+		return fmt.Errorf("invalid arguments for operation %s: %v", opName, err)
+	}
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kube config: %v", err)
+	}
+	cl, err := client.New(kubeConfig, client.Options{Scheme: operatorclient.GetScheme()})
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), routerCtlTimeout)
+	defer cancel()
+
+	ic := &operatorv1.IngressController{}
+	if err := cl.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, ic); err != nil {
+		return fmt.Errorf("failed to get ingresscontroller %s/%s: %v", namespace, name, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(operatorcontroller.IngressControllerDeploymentPodSelector(ic))
+	if err != nil {
+		return fmt.Errorf("ingresscontroller %s has invalid pod selector: %v", ic.Name, err)
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(operatorcontroller.DefaultOperandNamespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list router pods for ingresscontroller %s: %v", ic.Name, err)
+	}
+	var running []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			fmt.Fprintf(os.Stderr, "skipping router pod %s because it is not running\n", pod.Name)
+			continue
+		}
+		running = append(running, pod)
+	}
+	if len(running) == 0 {
+		return fmt.Errorf("ingresscontroller %s has no running router pods", ic.Name)
+	}
+
+	results := make([]routerCtlResult, len(running))
+	var wg sync.WaitGroup
+	for i := range running {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runRouterRuntimeOperation(ctx, kubeConfig, kubeClient, &running[i], op, args, dryRun)
+		}(i)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].pod < results[j].pod
+	})
+
+	var failed []string
+	for _, result := range results {
+		fmt.Printf("==> %s (%s) <==\n", result.pod, result.node)
+		if dryRun {
+			for _, command := range result.commands {
+				fmt.Printf("would run: %s\n", command)
+			}
+		}
+		if len(result.output) != 0 {
+			fmt.Print(result.output)
+			if !strings.HasSuffix(result.output, "\n") {
+				fmt.Println()
+			}
+		}
+		if result.err != nil {
+			fmt.Printf("error: %v\n", result.err)
+			failed = append(failed, fmt.Sprintf("%s: %v", result.pod, result.err))
+		}
+	}
+	fmt.Printf("%s succeeded on %d of %d router pods\n", opName, len(results)-len(failed), len(results))
+
+	eventCtx, eventCancel := context.WithTimeout(context.Background(), routerCtlEventTimeout)
+	defer eventCancel()
+	if err := recordRouterCtlEvent(eventCtx, cl, ic, opName, args, dryRun, len(results), failed); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record event on ingresscontroller %s: %v\n", ic.Name, err)
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("%s failed on %d of %d router pods", opName, len(failed), len(results))
+	}
+	return nil
+}
+
+// runRouterRuntimeOperation runs the given operation on the given router pod,
+// or only determines the operation's commands if dryRun is true.
+func runRouterRuntimeOperation(ctx context.Context, kubeConfig *rest.Config, kubeClient kubernetes.Interface, pod *corev1.Pod, op ingresscontroller.RouterRuntimeOperation, args []string, dryRun bool) routerCtlResult {
+	result := routerCtlResult{pod: pod.Name, node: pod.Spec.NodeName}
+	var serversState string
+	if op.NeedsServersState {
+		state, err := routerRuntimeExec(ctx, kubeConfig, kubeClient, pod, []string{ingresscontroller.RouterRuntimeServersStateCommand})
+		if err != nil {
+			result.err = fmt.Errorf("failed to get servers state: %v", err)
+			return result
+		}
+		serversState = state
+	}
+	commands, err := op.Commands(args, serversState)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.commands = commands
+	if dryRun {
+		return result
+	}
+	result.output, result.err = routerRuntimeExec(ctx, kubeConfig, kubeClient, pod, commands)
+	return result
+}
+
+// routerRuntimeExec sends the given commands to HAProxy's runtime API socket in
+// the given router pod and returns the output.
+func routerRuntimeExec(ctx context.Context, kubeConfig *rest.Config, kubeClient kubernetes.Interface, pod *corev1.Pod, commands []string) (string, error) {
+	req := kubeClient.CoreV1().RESTClient().Post().Resource("pods").
+		Namespace(pod.Namespace).Name(pod.Name).SubResource("exec").
+		Param("container", "router").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "router",
+			Command:   []string{"socat", "stdio", ingresscontroller.RouterRuntimeSocketPath},
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	exec, err := remotecommand.NewSPDYExecutor(kubeConfig, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	if err := exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  strings.NewReader(strings.Join(commands, ";") + "\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}); err != nil {
+		if stderr.Len() != 0 {
+			return stdout.String(), fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), err
+	}
+	return stdout.String(), nil
+}
+
+// recordRouterCtlEvent records an event on the ingresscontroller for auditing
+// the given router-ctl operation.
+func recordRouterCtlEvent(ctx context.Context, cl client.Client, ic *operatorv1.IngressController, opName string, args []string, dryRun bool, pods int, failed []string) error {
+	eventType, reason := corev1.EventTypeNormal, "RouterRuntimeOperation"
+	if len(failed) != 0 {
+		eventType, reason = corev1.EventTypeWarning, "RouterRuntimeOperationFailed"
+	}
+	operation := strings.Join(append([]string{opName}, args...), " ")
+	if dryRun {
+		operation += " (dry run)"
+	}
+	message := fmt.Sprintf("router-ctl %s succeeded on %d of %d router pods", operation, pods-len(failed), pods)
+	if len(failed) != 0 {
+		message += ": " + strings.Join(failed, "; ")
+	}
+	if len(message) > routerCtlEventMessageLimit {
+		message = message[:routerCtlEventMessageLimit-3] + "..."
+	}
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ic.Namespace,
+			Name:      fmt.Sprintf("%s.%x", ic.Name, now.UnixNano()),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      operatorv1.GroupVersion.String(),
+			Kind:            "IngressController",
+			Namespace:       ic.Namespace,
+			Name:            ic.Name,
+			UID:             ic.UID,
+			ResourceVersion: ic.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: routerCtlEventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	return cl.Create(ctx, event)
+}
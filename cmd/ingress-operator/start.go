@@ -15,6 +15,7 @@ import (
 	operatorconfig "github.com/openshift/cluster-ingress-operator/pkg/operator/config"
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	canarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/canary"
+	dnscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/dns"
 	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 	routemetricscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/route-metrics"
 	statuscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/status"
@@ -138,6 +139,10 @@ func start(opts *StartOptions) error {
 	if err := routemetricscontroller.RegisterMetrics(); err != nil {
 		log.Error(err, "unable to register metrics for route_metrics_controller")
 	}
+	log.Info("registering Prometheus metrics for dns_controller")
+	if err := dnscontroller.RegisterMetrics(); err != nil {
+		log.Error(err, "unable to register metrics for dns_controller")
+	}
 
 	// Set up and start the file watcher.
 	watcher, err := fsnotify.NewWatcher()
@@ -1,6 +1,7 @@
 package alibaba
 
 import (
+	"context"
 	"fmt"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk"
 	configv1 "github.com/openshift/api/config/v1"
@@ -89,19 +90,19 @@ func getRR(dnsName, domainName string) string {
 	return strings.TrimSuffix(dnsName, "."+domainName)
 }
 
-func (p *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.doRequest(zone, record, actionEnsure)
+func (p *provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.doRequest(ctx, zone, record, actionEnsure)
 }
 
-func (p *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.doRequest(zone, record, actionDelete)
+func (p *provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.doRequest(ctx, zone, record, actionDelete)
 }
 
-func (p *provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.doRequest(zone, record, actionReplace)
+func (p *provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.doRequest(ctx, zone, record, actionReplace)
 }
 
-func (p *provider) doRequest(zone configv1.DNSZone, record *iov1.DNSRecord, action action) error {
+func (p *provider) doRequest(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord, action action) error {
 	zoneInfo, err := p.parseZone(zone)
 	if err != nil {
 		return err
@@ -116,11 +117,11 @@ func (p *provider) doRequest(zone configv1.DNSZone, record *iov1.DNSRecord, acti
 
 	switch action {
 	case actionEnsure:
-		err = service.Add(zoneInfo.ID, rr, string(record.Spec.RecordType), record.Spec.Targets[0], record.Spec.RecordTTL)
+		err = service.Add(ctx, zoneInfo.ID, rr, string(record.Spec.RecordType), record.Spec.Targets[0], record.Spec.RecordTTL)
 	case actionReplace:
-		err = service.Update(zoneInfo.ID, rr, string(record.Spec.RecordType), record.Spec.Targets[0], record.Spec.RecordTTL)
+		err = service.Update(ctx, zoneInfo.ID, rr, string(record.Spec.RecordType), record.Spec.Targets[0], record.Spec.RecordTTL)
 	case actionDelete:
-		err = service.Delete(zoneInfo.ID, rr, record.Spec.Targets[0])
+		err = service.Delete(ctx, zoneInfo.ID, rr, record.Spec.Targets[0])
 	default:
 		err = fmt.Errorf("unknown action %q", action)
 	}
@@ -1,6 +1,7 @@
 package alibaba
 
 import (
+	"context"
 	configv1 "github.com/openshift/api/config/v1"
 	iov1 "github.com/openshift/api/operatoringress/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
@@ -16,19 +17,19 @@ type fakeService struct {
 	lastAction string
 }
 
-func (p *fakeService) Add(id, rr, recordType, target string, ttl int64) error {
+func (p *fakeService) Add(ctx context.Context, id, rr, recordType, target string, ttl int64) error {
 	p.records[id+rr] = target
 	p.lastAction = "add"
 	return nil
 }
 
-func (p *fakeService) Update(id, rr, recordType, target string, ttl int64) error {
+func (p *fakeService) Update(ctx context.Context, id, rr, recordType, target string, ttl int64) error {
 	p.records[id+rr] = target
 	p.lastAction = "update"
 	return nil
 }
 
-func (p *fakeService) Delete(id, rr, target string) error {
+func (p *fakeService) Delete(ctx context.Context, id, rr, target string) error {
 	delete(p.records, id+rr)
 	p.lastAction = "delete"
 	return nil
@@ -170,17 +171,17 @@ func TestProvider(t *testing.T) {
 	assert.Equal(t, "", servicePublic.getLastAction())
 
 	// test public zone ensure
-	assert.NoError(t, provider.Ensure(record, dnsZonePublic))
+	assert.NoError(t, provider.Ensure(context.Background(), record, dnsZonePublic))
 	assert.Equal(t, "add", servicePublic.getLastAction())
 	assert.Equal(t, "", servicePrivate.getLastAction())
 
 	// test private zone replace
-	assert.NoError(t, provider.Replace(record, dnsZonePrivate))
+	assert.NoError(t, provider.Replace(context.Background(), record, dnsZonePrivate))
 	assert.Equal(t, "", servicePublic.getLastAction())
 	assert.Equal(t, "update", servicePrivate.getLastAction())
 
 	// test public zone delete
-	assert.NoError(t, provider.Delete(record, dnsZonePublic))
+	assert.NoError(t, provider.Delete(context.Background(), record, dnsZonePublic))
 	assert.Equal(t, "delete", servicePublic.getLastAction())
 	assert.Equal(t, "", servicePrivate.getLastAction())
 
@@ -191,12 +192,12 @@ func TestProvider(t *testing.T) {
 			"type": "unknown",
 		},
 	}
-	assert.Error(t, provider.Ensure(record, dnsZoneUnknown))
+	assert.Error(t, provider.Ensure(context.Background(), record, dnsZoneUnknown))
 
 	// test zone without type, should return error
 	dnsZoneNoType := configv1.DNSZone{
 		ID:   "example.com",
 		Tags: map[string]string{},
 	}
-	assert.Error(t, provider.Ensure(record, dnsZoneNoType))
+	assert.Error(t, provider.Ensure(context.Background(), record, dnsZoneNoType))
 }
@@ -1,6 +1,7 @@
 package alibaba
 
 import (
+	"context"
 	"fmt"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk"
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/endpoints"
@@ -11,6 +12,7 @@ import (
 	"github.com/openshift/cluster-ingress-operator/pkg/dns/alibaba/util"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -23,9 +25,9 @@ var (
 )
 
 type Service interface {
-	Add(id, rr, recordType, target string, ttl int64) error
-	Update(id, rr, recordType, target string, ttl int64) error
-	Delete(id, rr, target string) error
+	Add(ctx context.Context, id, rr, recordType, target string, ttl int64) error
+	Update(ctx context.Context, id, rr, recordType, target string, ttl int64) error
+	Delete(ctx context.Context, id, rr, target string) error
 }
 
 type Client struct {
@@ -39,7 +41,7 @@ type publicZoneService struct {
 	client *Client
 }
 
-func (d *publicZoneService) Add(id, rr, recordType, target string, ttl int64) error {
+func (d *publicZoneService) Add(ctx context.Context, id, rr, recordType, target string, ttl int64) error {
 	request := alidns.CreateAddDomainRecordRequest()
 	request.Scheme = "https"
 	request.DomainName = id
@@ -55,11 +57,11 @@ func (d *publicZoneService) Add(id, rr, recordType, target string, ttl int64) er
 	request.TTL = requests.NewInteger64(clampedTTL)
 
 	response := alidns.CreateAddDomainRecordResponse()
-	return d.client.DoActionWithSetDomain(request, response)
+	return d.client.DoActionWithSetDomain(ctx, request, response)
 }
 
-func (d *publicZoneService) Update(id, rr, recordType, target string, ttl int64) error {
-	recordID, err := d.getRecordID(id, rr, "")
+func (d *publicZoneService) Update(ctx context.Context, id, rr, recordType, target string, ttl int64) error {
+	recordID, err := d.getRecordID(ctx, id, rr, "")
 	if err != nil {
 		return err
 	}
@@ -79,11 +81,11 @@ func (d *publicZoneService) Update(id, rr, recordType, target string, ttl int64)
 	request.TTL = requests.NewInteger64(clampedTTL)
 
 	response := alidns.CreateUpdateDomainRecordResponse()
-	return d.client.DoActionWithSetDomain(request, response)
+	return d.client.DoActionWithSetDomain(ctx, request, response)
 }
 
-func (d *publicZoneService) Delete(id, rr, target string) error {
-	recordID, err := d.getRecordID(id, rr, target)
+func (d *publicZoneService) Delete(ctx context.Context, id, rr, target string) error {
+	recordID, err := d.getRecordID(ctx, id, rr, target)
 	if err != nil {
 		return err
 	}
@@ -93,11 +95,11 @@ func (d *publicZoneService) Delete(id, rr, target string) error {
 	request.RecordId = recordID
 
 	response := alidns.CreateDeleteDomainRecordResponse()
-	return d.client.DoActionWithSetDomain(request, response)
+	return d.client.DoActionWithSetDomain(ctx, request, response)
 }
 
 // getRecordID finds the ID by dns name and an optional argument target.
-func (d *publicZoneService) getRecordID(id, dnsName, target string) (string, error) {
+func (d *publicZoneService) getRecordID(ctx context.Context, id, dnsName, target string) (string, error) {
 	request := alidns.CreateDescribeDomainRecordsRequest()
 	request.Scheme = "https"
 	request.DomainName = id
@@ -105,7 +107,7 @@ func (d *publicZoneService) getRecordID(id, dnsName, target string) (string, err
 	request.SearchMode = "EXACT"
 
 	response := alidns.CreateDescribeDomainRecordsResponse()
-	if err := d.client.DoActionWithSetDomain(request, response); err != nil {
+	if err := d.client.DoActionWithSetDomain(ctx, request, response); err != nil {
 		return "", fmt.Errorf("failed on describe domain records: %w", err)
 	}
 
@@ -127,10 +129,10 @@ type privateZoneService struct {
 	mutex   sync.Mutex
 }
 
-func (p *privateZoneService) Add(zoneName, rr, recordType, target string, ttl int64) error {
+func (p *privateZoneService) Add(ctx context.Context, zoneName, rr, recordType, target string, ttl int64) error {
 	// The first argument "id" in Service is actually zone name in the implementation of private zone.
 	// The zone name is used to lookup zone ID used in following requests.
-	id, err := p.lookupPrivateZoneID(zoneName)
+	id, err := p.lookupPrivateZoneID(ctx, zoneName)
 	if err != nil {
 		return fmt.Errorf("failed lookup private zone id: %w", err)
 	}
@@ -150,16 +152,16 @@ func (p *privateZoneService) Add(zoneName, rr, recordType, target string, ttl in
 	request.Ttl = requests.NewInteger64(clampedTTL)
 
 	response := pvtz.CreateAddZoneRecordResponse()
-	return p.client.DoActionWithSetDomain(request, response)
+	return p.client.DoActionWithSetDomain(ctx, request, response)
 }
 
-func (p *privateZoneService) Update(zoneName, rr, recordType, target string, ttl int64) error {
-	id, err := p.lookupPrivateZoneID(zoneName)
+func (p *privateZoneService) Update(ctx context.Context, zoneName, rr, recordType, target string, ttl int64) error {
+	id, err := p.lookupPrivateZoneID(ctx, zoneName)
 	if err != nil {
 		return fmt.Errorf("failed lookup private zone id: %w", err)
 	}
 
-	recordID, err := p.getRecordID(id, rr, "")
+	recordID, err := p.getRecordID(ctx, id, rr, "")
 	if err != nil {
 		return err
 	}
@@ -179,16 +181,16 @@ func (p *privateZoneService) Update(zoneName, rr, recordType, target string, ttl
 	request.Ttl = requests.NewInteger64(clampedTTL)
 
 	response := pvtz.CreateUpdateZoneRecordResponse()
-	return p.client.DoActionWithSetDomain(request, response)
+	return p.client.DoActionWithSetDomain(ctx, request, response)
 }
 
-func (p *privateZoneService) Delete(zoneName, rr, target string) error {
-	id, err := p.lookupPrivateZoneID(zoneName)
+func (p *privateZoneService) Delete(ctx context.Context, zoneName, rr, target string) error {
+	id, err := p.lookupPrivateZoneID(ctx, zoneName)
 	if err != nil {
 		return fmt.Errorf("failed lookup private zone id: %w", err)
 	}
 
-	recordID, err := p.getRecordID(id, rr, target)
+	recordID, err := p.getRecordID(ctx, id, rr, target)
 	if err != nil {
 		return err
 	}
@@ -198,11 +200,11 @@ func (p *privateZoneService) Delete(zoneName, rr, target string) error {
 	request.RecordId = requests.NewInteger64(recordID)
 
 	response := pvtz.CreateDeleteZoneRecordResponse()
-	return p.client.DoActionWithSetDomain(request, response)
+	return p.client.DoActionWithSetDomain(ctx, request, response)
 }
 
 // getRecordID finds the ID by dns name and an optional argument target.
-func (p *privateZoneService) getRecordID(id, dnsName, target string) (int64, error) {
+func (p *privateZoneService) getRecordID(ctx context.Context, id, dnsName, target string) (int64, error) {
 	request := pvtz.CreateDescribeZoneRecordsRequest()
 	request.Scheme = "https"
 	request.ZoneId = id
@@ -210,7 +212,7 @@ func (p *privateZoneService) getRecordID(id, dnsName, target string) (int64, err
 	request.SearchMode = "EXACT"
 
 	response := pvtz.CreateDescribeZoneRecordsResponse()
-	if err := p.client.DoActionWithSetDomain(request, response); err != nil {
+	if err := p.client.DoActionWithSetDomain(ctx, request, response); err != nil {
 		return 0, fmt.Errorf("failed on describe pvtz records: %w", err)
 	}
 
@@ -224,7 +226,7 @@ func (p *privateZoneService) getRecordID(id, dnsName, target string) (int64, err
 }
 
 // lookupPrivateZoneID finds zone ID, and caches it when the zone ID is retrieved successfully.
-func (p *privateZoneService) lookupPrivateZoneID(zoneName string) (string, error) {
+func (p *privateZoneService) lookupPrivateZoneID(ctx context.Context, zoneName string) (string, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -241,7 +243,7 @@ func (p *privateZoneService) lookupPrivateZoneID(zoneName string) (string, error
 	request.PageSize = requests.NewInteger(100)
 	response := pvtz.CreateDescribeZonesResponse()
 
-	if err := p.client.DoActionWithSetDomain(request, response); err != nil {
+	if err := p.client.DoActionWithSetDomain(ctx, request, response); err != nil {
 		return "", fmt.Errorf("failed on describe private zones: %w", err)
 	}
 
@@ -280,7 +282,14 @@ func NewClient(sdkClient *sdk.Client, regionID string) *Client {
 // DoActionWithSetDomain resolves the endpoint for the given API call, and does the request.
 // For some reason, the SDK will return an error if there's no endpoint for this region,
 // so it's necessary to set a default endpoint manually for now.
-func (client *Client) DoActionWithSetDomain(request requests.AcsRequest, response responses.AcsResponse) error {
+//
+// The SDK does not accept a context, so the context's deadline, if any, is
+// applied as the request's read timeout, and the request is not sent at all if
+// the context is already done.
+func (client *Client) DoActionWithSetDomain(ctx context.Context, request requests.AcsRequest, response responses.AcsResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	endpoint, err := endpoints.Resolve(&endpoints.ResolveParam{
 		Product:  strings.ToLower(request.GetProduct()),
 		RegionId: strings.ToLower(client.RegionID),
@@ -295,6 +304,15 @@ func (client *Client) DoActionWithSetDomain(request requests.AcsRequest, respons
 		}
 	}
 	request.SetDomain(endpoint)
+	if deadline, ok := ctx.Deadline(); ok {
+		request.SetReadTimeout(time.Until(deadline))
+	}
 
-	return client.DoAction(request, response)
+	if err := client.DoAction(request, response); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%v: %w", err, ctxErr)
+		}
+		return err
+	}
+	return nil
 }
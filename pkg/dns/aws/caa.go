@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var _ dns.CAAProvider = &Provider{}
+
+// CAARecords returns the CAA records that are published for domain in zone.
+func (m *Provider) CAARecords(ctx context.Context, domain string, zone configv1.DNSZone) ([]dns.CAARecord, error) {
+	zoneID, err := m.getZoneID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find hosted zone for CAA records: %v", err)
+	}
+	rrset, err := m.getCAAResourceRecordSet(ctx, zoneID, domain)
+	if err != nil || rrset == nil {
+		return nil, err
+	}
+	return caaRecordsFromResourceRecordSet(rrset)
+}
+
+// EnsureCAA creates or replaces the CAA resource record set for domain in zone.
+func (m *Provider) EnsureCAA(ctx context.Context, domain string, records []dns.CAARecord, ttl int64, zone configv1.DNSZone) error {
+	zoneID, err := m.getZoneID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to find hosted zone for CAA records: %v", err)
+	}
+	var resourceRecords []*route53.ResourceRecord
+	for _, record := range records {
+		resourceRecords = append(resourceRecords, &route53.ResourceRecord{Value: aws.String(record.String())})
+	}
+	rrset := &route53.ResourceRecordSet{
+		Name:            aws.String(domain),
+		Type:            aws.String(route53.RRTypeCaa),
+		TTL:             aws.Int64(ttl),
+		ResourceRecords: resourceRecords,
+	}
+	if err := m.changeResourceRecordSet(ctx, zoneID, string(upsertAction), rrset); err != nil {
+		return err
+	}
+	log.Info("upserted CAA records", "zone id", zoneID, "domain", domain, "records", dns.FormatCAARecords(records))
+	return nil
+}
+
+// DeleteCAA deletes the CAA resource record set for domain in zone.  Route 53
+// requires the exact record set to delete it, so the current record set is
+// looked up first.
+func (m *Provider) DeleteCAA(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	zoneID, err := m.getZoneID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to find hosted zone for CAA records: %v", err)
+	}
+	rrset, err := m.getCAAResourceRecordSet(ctx, zoneID, domain)
+	if err != nil {
+		return err
+	}
+	if rrset == nil {
+		log.Info("CAA records not found", "zone id", zoneID, "domain", domain)
+		return nil
+	}
+	if err := m.changeResourceRecordSet(ctx, zoneID, string(deleteAction), rrset); err != nil {
+		return err
+	}
+	log.Info("deleted CAA records", "zone id", zoneID, "domain", domain)
+	return nil
+}
+
+// getCAAResourceRecordSet returns the CAA resource record set for domain in
+// the given hosted zone, or nil if there is none.
+func (m *Provider) getCAAResourceRecordSet(ctx context.Context, zoneID, domain string) (*route53.ResourceRecordSet, error) {
+	return m.getResourceRecordSet(ctx, zoneID, domain, route53.RRTypeCaa)
+}
+
+// getResourceRecordSet returns the resource record set of the given type for
+// domain in the given hosted zone, or nil if there is none.
+func (m *Provider) getResourceRecordSet(ctx context.Context, zoneID, domain, recordType string) (*route53.ResourceRecordSet, error) {
+	output, err := m.route53.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(domain),
+		StartRecordType: aws.String(recordType),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource record sets in zone %s: %v", zoneID, err)
+	}
+	for _, rrset := range output.ResourceRecordSets {
+		if aws.StringValue(rrset.Type) == recordType && sameDomain(aws.StringValue(rrset.Name), domain) {
+			return rrset, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Provider) changeResourceRecordSet(ctx context.Context, zoneID, action string, rrset *route53.ResourceRecordSet) error {
+	input := route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{{
+				Action:            aws.String(action),
+				ResourceRecordSet: rrset,
+			}},
+		},
+	}
+	if _, err := m.route53.ChangeResourceRecordSetsWithContext(ctx, &input); err != nil {
+		return fmt.Errorf("couldn't update %s records in zone %s: %v", aws.StringValue(rrset.Type), zoneID, err)
+	}
+	return nil
+}
+
+// caaRecordsFromResourceRecordSet parses the values of a Route 53 CAA resource
+// record set.
+func caaRecordsFromResourceRecordSet(rrset *route53.ResourceRecordSet) ([]dns.CAARecord, error) {
+	var records []dns.CAARecord
+	for _, rr := range rrset.ResourceRecords {
+		record, err := dns.ParseCAARecord(aws.StringValue(rr.Value))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// sameDomain returns true if the given domain names are equal, ignoring case
+// and any trailing dot.  Route 53 returns the "*" label of wildcard names in
+// octal escaped form, so that form is treated as equal to "*".
+func sameDomain(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.ReplaceAll(strings.TrimSuffix(s, "."), `\052`, "*")
+	}
+	return strings.EqualFold(normalize(a), normalize(b))
+}
@@ -284,7 +284,7 @@ func validateServiceEndpoints(provider *Provider) error {
 // getZoneID finds the ID of given zoneConfig in Route53. If an ID is already
 // known, return that; otherwise, use tags to search for the zone. Returns an
 // error if the zone can't be found.
-func (m *Provider) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
+func (m *Provider) getZoneID(ctx context.Context, zoneConfig configv1.DNSZone) (string, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -304,9 +304,9 @@ func (m *Provider) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
 	var id string
 	var err error
 	if m.tags != nil {
-		id, err = m.lookupZoneID(zoneConfig)
+		id, err = m.lookupZoneID(ctx, zoneConfig)
 	} else {
-		id, err = m.lookupZoneIDWithoutResourceTagging(zoneConfig)
+		id, err = m.lookupZoneIDWithoutResourceTagging(ctx, zoneConfig)
 	}
 	if err != nil {
 		return id, err
@@ -319,7 +319,7 @@ func (m *Provider) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
 	return id, nil
 }
 
-func (m *Provider) lookupZoneID(zoneConfig configv1.DNSZone) (string, error) {
+func (m *Provider) lookupZoneID(ctx context.Context, zoneConfig configv1.DNSZone) (string, error) {
 	var id string
 	// Even though we use filters when getting resources, the resources are still
 	// paginated as though no filter were applied.  If the desired resource is not
@@ -346,7 +346,7 @@ func (m *Provider) lookupZoneID(zoneConfig configv1.DNSZone) (string, error) {
 			Values: []*string{aws.String(v)},
 		})
 	}
-	outerError := m.tags.GetResourcesPages(&resourcegroupstaggingapi.GetResourcesInput{
+	outerError := m.tags.GetResourcesPagesWithContext(ctx, &resourcegroupstaggingapi.GetResourcesInput{
 		ResourceTypeFilters: []*string{aws.String("route53:hostedzone")},
 		TagFilters:          tagFilters,
 	}, f)
@@ -359,7 +359,7 @@ func (m *Provider) lookupZoneID(zoneConfig configv1.DNSZone) (string, error) {
 	return id, nil
 }
 
-func (m *Provider) lookupZoneIDWithoutResourceTagging(zoneConfig configv1.DNSZone) (string, error) {
+func (m *Provider) lookupZoneIDWithoutResourceTagging(ctx context.Context, zoneConfig configv1.DNSZone) (string, error) {
 	var id string
 	var innerError error
 	searchZones := func(resp *route53.ListHostedZonesOutput, lastPage bool) (shouldContinue bool) {
@@ -375,7 +375,7 @@ func (m *Provider) lookupZoneIDWithoutResourceTagging(zoneConfig configv1.DNSZon
 			}
 			input.ResourceIds[i] = &zoneID
 		}
-		output, err := m.route53.ListTagsForResources(input)
+		output, err := m.route53.ListTagsForResourcesWithContext(ctx, input)
 		if err != nil {
 			innerError = err
 			return false
@@ -389,7 +389,8 @@ func (m *Provider) lookupZoneIDWithoutResourceTagging(zoneConfig configv1.DNSZon
 		return true
 	}
 	// the maximum page size is limited to 10 because the call to ListTagsForResources only supports 10 resources in a single call.
-	outerError := m.route53.ListHostedZonesPages(
+	outerError := m.route53.ListHostedZonesPagesWithContext(
+		ctx,
 		&route53.ListHostedZonesInput{MaxItems: aws.String("10")},
 		searchZones,
 	)
@@ -436,7 +437,7 @@ func zoneIDFromResource(resource string) (string, error) {
 
 // getLBHostedZone finds the hosted zone ID of an ELB whose DNS name matches the
 // name parameter. Results are cached.
-func (m *Provider) getLBHostedZone(name string) (string, error) {
+func (m *Provider) getLBHostedZone(ctx context.Context, name string) (string, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -457,7 +458,7 @@ func (m *Provider) getLBHostedZone(name string) (string, error) {
 		}
 		return true
 	}
-	err := m.elb.DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{}, elbFn)
+	err := m.elb.DescribeLoadBalancersPagesWithContext(ctx, &elb.DescribeLoadBalancersInput{}, elbFn)
 	if err != nil {
 		return "", fmt.Errorf("failed to describe classic load balancers: %v", err)
 	}
@@ -474,7 +475,7 @@ func (m *Provider) getLBHostedZone(name string) (string, error) {
 			}
 			return true
 		}
-		err := m.elbv2.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{}, elbv2Fn)
+		err := m.elbv2.DescribeLoadBalancersPagesWithContext(ctx, &elbv2.DescribeLoadBalancersInput{}, elbv2Fn)
 		if err != nil {
 			return "", fmt.Errorf("failed to describe network load balancers: %v", err)
 		}
@@ -494,21 +495,21 @@ const (
 	deleteAction action = "DELETE"
 )
 
-func (m *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return m.change(record, zone, upsertAction)
+func (m *Provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return m.change(ctx, record, zone, upsertAction)
 }
 
-func (m *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return m.change(record, zone, deleteAction)
+func (m *Provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return m.change(ctx, record, zone, deleteAction)
 }
 
-func (m *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return m.change(record, zone, upsertAction)
+func (m *Provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return m.change(ctx, record, zone, upsertAction)
 }
 
 // change will perform an action on a record. The target must correspond to the
 // hostname of an ELB which will be automatically discovered.
-func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action action) error {
+func (m *Provider) change(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone, action action) error {
 	if record.Spec.RecordType != iov1.CNAMERecordType {
 		return fmt.Errorf("unsupported record type %s", record.Spec.RecordType)
 	}
@@ -521,13 +522,13 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 		return fmt.Errorf("target is required")
 	}
 
-	zoneID, err := m.getZoneID(zone)
+	zoneID, err := m.getZoneID(ctx, zone)
 	if err != nil {
 		return fmt.Errorf("failed to find hosted zone for record: %v", err)
 	}
 
 	// Find the target hosted zone of the load balancer attached to the service.
-	targetHostedZoneID, err := m.getLBHostedZone(target)
+	targetHostedZoneID, err := m.getLBHostedZone(ctx, target)
 	if err != nil {
 		err = fmt.Errorf("failed to get hosted zone for load balancer target %q: %v", target, err)
 		if v, ok := record.Annotations[targetHostedZoneIdAnnotationKey]; !ok {
@@ -546,7 +547,7 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 			Namespace: record.Namespace,
 			Name:      record.Name,
 		}
-		if err := m.config.Client.Get(context.TODO(), name, &current); err != nil {
+		if err := m.config.Client.Get(ctx, name, &current); err != nil {
 			// Log the error and continue.  The annotation is only
 			// needed as a fallback mechanism, and anyway we might
 			// succeed in adding it on the next upsert.
@@ -557,7 +558,7 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 				updated.Annotations = map[string]string{}
 			}
 			updated.Annotations[targetHostedZoneIdAnnotationKey] = targetHostedZoneID
-			if err := m.config.Client.Update(context.TODO(), updated); err != nil {
+			if err := m.config.Client.Update(ctx, updated); err != nil {
 				log.Error(err, "failed to annotate dnsrecord", "dnsrecord", name)
 			} else {
 				log.Info("annotated dnsrecord", "dnsrecord", name, "key", targetHostedZoneIdAnnotationKey, "value", targetHostedZoneID)
@@ -566,7 +567,7 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 	}
 
 	// Configure records.
-	err = m.updateRecord(domain, zoneID, target, targetHostedZoneID, string(action), record.Spec.RecordTTL)
+	err = m.updateRecord(ctx, domain, zoneID, target, targetHostedZoneID, string(action), record.Spec.RecordTTL)
 	if err != nil {
 		return fmt.Errorf("failed to update alias in zone %s: %v", zoneID, err)
 	}
@@ -584,7 +585,7 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 // other than GovCloud (CNAME). See the following for additional details:
 // https://docs.aws.amazon.com/govcloud-us/latest/UserGuide/govcloud-r53.html
 // Note that by API contract, TTL cannot be specified for an AliasTarget.
-func (m *Provider) updateRecord(domain, zoneID, target, targetHostedZoneID, action string, ttl int64) error {
+func (m *Provider) updateRecord(ctx context.Context, domain, zoneID, target, targetHostedZoneID, action string, ttl int64) error {
 	input := route53.ChangeResourceRecordSetsInput{HostedZoneId: aws.String(zoneID)}
 	if clientEndpointIsGovCloud(&m.route53.Client.ClientInfo) {
 		record := route53.ResourceRecord{Value: aws.String(target)}
@@ -619,7 +620,7 @@ func (m *Provider) updateRecord(domain, zoneID, target, targetHostedZoneID, acti
 			},
 		}
 	}
-	resp, err := m.route53.ChangeResourceRecordSets(&input)
+	resp, err := m.route53.ChangeResourceRecordSetsWithContext(ctx, &input)
 	if err != nil {
 		if action == string(deleteAction) {
 			if aerr, ok := err.(awserr.Error); ok {
@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var _ dns.ZonalRecordProvider = &Provider{}
+
+// ZonalTargets finds the network load balancer whose DNS name matches the
+// target parameter and returns its DNS name in each of its availability zones.
+// Classic load balancers do not have per-zone DNS names, so
+// dns.ErrZonalRecordsNotSupported is returned if no network load balancer
+// matches.
+func (m *Provider) ZonalTargets(ctx context.Context, target string, zone configv1.DNSZone) ([]dns.ZonalTarget, error) {
+	var targets []dns.ZonalTarget
+	found := false
+	fn := func(resp *elbv2.DescribeLoadBalancersOutput, lastPage bool) (shouldContinue bool) {
+		for _, lb := range resp.LoadBalancers {
+			if aws.StringValue(lb.DNSName) != target {
+				continue
+			}
+			log.V(2).Info("found network load balancer", "name", aws.StringValue(lb.LoadBalancerName), "dns name", target, "type", aws.StringValue(lb.Type))
+			if aws.StringValue(lb.Type) == elbv2.LoadBalancerTypeEnumNetwork {
+				found = true
+				targets = zonalTargetsForLoadBalancer(lb)
+			}
+			return false
+		}
+		return true
+	}
+	if err := m.elbv2.DescribeLoadBalancersPagesWithContext(ctx, &elbv2.DescribeLoadBalancersInput{}, fn); err != nil {
+		return nil, fmt.Errorf("failed to describe network load balancers: %v", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("couldn't find network load balancer %s: %w", target, dns.ErrZonalRecordsNotSupported)
+	}
+	return targets, nil
+}
+
+// zonalTargetsForLoadBalancer returns the per-zone DNS names of the given
+// network load balancer, which are the load balancer's DNS name prefixed with
+// the name of each availability zone, sorted by zone name.
+func zonalTargetsForLoadBalancer(lb *elbv2.LoadBalancer) []dns.ZonalTarget {
+	dnsName := aws.StringValue(lb.DNSName)
+	var targets []dns.ZonalTarget
+	for _, az := range lb.AvailabilityZones {
+		zone := aws.StringValue(az.ZoneName)
+		if len(zone) == 0 {
+			continue
+		}
+		targets = append(targets, dns.ZonalTarget{Zone: zone, Target: zone + "." + dnsName})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Zone < targets[j].Zone
+	})
+	return targets
+}
+
+// EnsureZonalRecord creates or replaces the CNAME record for domain in zone so
+// that it points to the given zonal target.
+func (m *Provider) EnsureZonalRecord(ctx context.Context, domain, target string, ttl int64, zone configv1.DNSZone) error {
+	zoneID, err := m.getZoneID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to find hosted zone for zonal record: %v", err)
+	}
+	rrset := &route53.ResourceRecordSet{
+		Name:            aws.String(domain),
+		Type:            aws.String(route53.RRTypeCname),
+		TTL:             aws.Int64(ttl),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(target)}},
+	}
+	if err := m.changeResourceRecordSet(ctx, zoneID, string(upsertAction), rrset); err != nil {
+		return err
+	}
+	log.Info("upserted zonal record", "zone id", zoneID, "domain", domain, "target", target)
+	return nil
+}
+
+// DeleteZonalRecord deletes the CNAME record for domain in zone.  Route 53
+// requires the exact record set to delete it, so the current record set is
+// looked up first.
+func (m *Provider) DeleteZonalRecord(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	zoneID, err := m.getZoneID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to find hosted zone for zonal record: %v", err)
+	}
+	rrset, err := m.getResourceRecordSet(ctx, zoneID, domain, route53.RRTypeCname)
+	if err != nil {
+		return err
+	}
+	if rrset == nil {
+		log.Info("zonal record not found", "zone id", zoneID, "domain", domain)
+		return nil
+	}
+	if err := m.changeResourceRecordSet(ctx, zoneID, string(deleteAction), rrset); err != nil {
+		return err
+	}
+	log.Info("deleted zonal record", "zone id", zoneID, "domain", domain)
+	return nil
+}
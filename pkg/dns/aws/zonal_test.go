@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func Test_zonalTargetsForLoadBalancer(t *testing.T) {
+	lb := &elbv2.LoadBalancer{
+		DNSName: aws.String("router-default-123.elb.us-east-1.amazonaws.com"),
+		AvailabilityZones: []*elbv2.AvailabilityZone{
+			{ZoneName: aws.String("us-east-1b")},
+			{ZoneName: aws.String("us-east-1a")},
+			{},
+		},
+	}
+	expected := []dns.ZonalTarget{
+		{Zone: "us-east-1a", Target: "us-east-1a.router-default-123.elb.us-east-1.amazonaws.com"},
+		{Zone: "us-east-1b", Target: "us-east-1b.router-default-123.elb.us-east-1.amazonaws.com"},
+	}
+	if actual := zonalTargetsForLoadBalancer(lb); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func Test_sameDomain(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"apps.example.com.", "apps.example.com", true},
+		{"Apps.Example.com", "apps.example.com.", true},
+		{`\052.us-east-1a.apps.example.com.`, "*.us-east-1a.apps.example.com.", true},
+		{"apps.example.com.", "example.com.", false},
+	}
+	for _, tc := range testCases {
+		if actual := sameDomain(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("sameDomain(%q, %q): expected %t, got %t", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
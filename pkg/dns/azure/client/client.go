@@ -12,6 +12,9 @@ import (
 type DNSClient interface {
 	Put(ctx context.Context, zone Zone, arec ARecord, metadata map[string]*string) error
 	Delete(ctx context.Context, zone Zone, arec ARecord) error
+	// RecordSetID returns the Azure resource ID of the A record set with
+	// the given record's name.
+	RecordSetID(ctx context.Context, zone Zone, arec ARecord) (string, error)
 }
 
 type Config struct {
@@ -94,6 +97,17 @@ func (c *dnsClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
 	}
 }
 
+func (c *dnsClient) RecordSetID(ctx context.Context, zone Zone, arec ARecord) (string, error) {
+	switch zone.Provider {
+	case "Microsoft.Network/privateDnsZones":
+		return c.privateRecordSetClient.RecordSetID(ctx, zone, arec)
+	case "Microsoft.Network/dnszones":
+		return c.recordSetClient.RecordSetID(ctx, zone, arec)
+	default:
+		return "", errors.Errorf("unsupported Zone provider %s", zone.Provider)
+	}
+}
+
 type recordSetClient struct {
 	client dns.RecordSetsClient
 }
@@ -130,6 +144,11 @@ func (c *recordSetClient) Put(ctx context.Context, zone Zone, arec ARecord, meta
 func (c *recordSetClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
 	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
 	if err != nil {
+		// A canceled or expired context says nothing about whether
+		// the record exists.
+		if ctx.Err() != nil {
+			return errors.Wrapf(ctx.Err(), "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+		}
 		// TODO: How do we interpret this as a notfound error?
 		return nil
 	}
@@ -140,6 +159,17 @@ func (c *recordSetClient) Delete(ctx context.Context, zone Zone, arec ARecord) e
 	return nil
 }
 
+func (c *recordSetClient) RecordSetID(ctx context.Context, zone Zone, arec ARecord) (string, error) {
+	rs, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+	}
+	if rs.ID == nil {
+		return "", nil
+	}
+	return *rs.ID, nil
+}
+
 type privateRecordSetClient struct {
 	client privatedns.RecordSetsClient
 }
@@ -176,6 +206,11 @@ func (c *privateRecordSetClient) Put(ctx context.Context, zone Zone, arec ARecor
 func (c *privateRecordSetClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
 	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, privatedns.A, arec.Name)
 	if err != nil {
+		// A canceled or expired context says nothing about whether
+		// the record exists.
+		if ctx.Err() != nil {
+			return errors.Wrapf(ctx.Err(), "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+		}
 		// TODO: How do we interpret this as a notfound error?
 		return nil
 	}
@@ -185,3 +220,14 @@ func (c *privateRecordSetClient) Delete(ctx context.Context, zone Zone, arec ARe
 	}
 	return nil
 }
+
+func (c *privateRecordSetClient) RecordSetID(ctx context.Context, zone Zone, arec ARecord) (string, error) {
+	rs, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, privatedns.A, arec.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+	}
+	if rs.ID == nil {
+		return "", nil
+	}
+	return *rs.ID, nil
+}
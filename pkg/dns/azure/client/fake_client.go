@@ -22,6 +22,10 @@ func (c *FakeDNSClient) Delete(ctx context.Context, zone Zone, arec ARecord) err
 	return nil
 }
 
+func (c *FakeDNSClient) RecordSetID(ctx context.Context, zone Zone, arec ARecord) (string, error) {
+	return "", nil
+}
+
 func (c *FakeDNSClient) RecordedCall(rg, zone, rel string) (string, bool) {
 	call, ok := c.fakeARM[rg+zone+rel]
 	return call, ok
@@ -27,8 +27,9 @@ const (
 )
 
 var (
-	_   dns.Provider = &provider{}
-	log              = logf.Logger.WithName("dns")
+	_   dns.Provider       = &provider{}
+	_   dns.RecordIDLister = &provider{}
+	log                    = logf.Logger.WithName("dns")
 )
 
 // Config is the necessary input to configure the manager for azure.
@@ -93,7 +94,7 @@ func userAgent(operatorReleaseVersion string) string {
 	return fmt.Sprintf("%s/%s", "openshift.io ingress-operator", operatorReleaseVersion)
 }
 
-func (m *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (m *provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if record.Spec.RecordType != iov1.ARecordType {
 		return fmt.Errorf("only A record types are supported")
 	}
@@ -118,7 +119,7 @@ func (m *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	}
 
 	// TODO: handle >0 targets
-	err = m.client.Put(context.TODO(), *targetZone, ARecord, m.config.Tags)
+	err = m.client.Put(ctx, *targetZone, ARecord, m.config.Tags)
 
 	if err == nil {
 		log.Info("upserted DNS record", "record", record.Spec, "zone", zone)
@@ -127,7 +128,7 @@ func (m *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	return err
 }
 
-func (m *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (m *provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	targetZone, err := client.ParseZone(zone.ID)
 	if err != nil {
 		return errors.Wrap(err, "failed to parse zoneID")
@@ -140,7 +141,7 @@ func (m *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 
 	// TODO: handle >0 targets
 	err = m.client.Delete(
-		context.TODO(),
+		ctx,
 		*targetZone,
 		client.ARecord{
 			Address: record.Spec.Targets[0],
@@ -155,8 +156,26 @@ func (m *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	return err
 }
 
-func (m *provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return m.Ensure(record, zone)
+func (m *provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return m.Ensure(ctx, record, zone)
+}
+
+// RecordIDs returns the Azure resource ID of the A record set for the given
+// record in the given zone, as reported by the Azure API.
+func (m *provider) RecordIDs(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) ([]string, error) {
+	targetZone, err := client.ParseZone(zone.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse zoneID")
+	}
+	ARecordName, err := getARecordName(record.Spec.DNSName, targetZone.Name)
+	if err != nil {
+		return nil, err
+	}
+	id, err := m.client.RecordSetID(ctx, *targetZone, client.ARecord{Name: ARecordName})
+	if err != nil || len(id) == 0 {
+		return nil, err
+	}
+	return []string{id}, nil
 }
 
 // getARecordName extracts the ARecord subdomain name from the full domain string.
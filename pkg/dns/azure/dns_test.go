@@ -1,6 +1,7 @@
 package azure_test
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -45,7 +46,7 @@ func Test_Ensure(t *testing.T) {
 	dnsZone := configv1.DNSZone{
 		ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
 	}
-	err = mgr.Ensure(&record, dnsZone)
+	err = mgr.Ensure(context.Background(), &record, dnsZone)
 	if err != nil {
 		t.Fatal("failed to ensure dns")
 		return
@@ -86,7 +87,7 @@ func Test_Delete(t *testing.T) {
 	dnsZone := configv1.DNSZone{
 		ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
 	}
-	err = mgr.Delete(&record, dnsZone)
+	err = mgr.Delete(context.Background(), &record, dnsZone)
 	if err != nil {
 		t.Error("failed to ensure dns")
 		return
@@ -0,0 +1,112 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ErrCAANotSupported is returned by a CAAProvider that wraps a provider that
+// cannot manage CAA records.
+var ErrCAANotSupported = errors.New("the DNS provider does not support CAA records")
+
+// CAARecord is a single certification authority authorization resource record,
+// as specified in RFC 8659.
+type CAARecord struct {
+	// Flags is the flags octet.  Only the issuer critical flag (128) is
+	// defined.
+	Flags uint8
+	// Tag is the property tag, for example "issue", "issuewild", or
+	// "iodef".
+	Tag string
+	// Value is the property value, for example "letsencrypt.org".
+	Value string
+}
+
+// String returns the record data in presentation format, for example
+// `0 issue "letsencrypt.org"`.
+func (r CAARecord) String() string {
+	return fmt.Sprintf("%d %s %s", r.Flags, r.Tag, strconv.Quote(r.Value))
+}
+
+// ParseCAARecord parses record data in presentation format.
+func ParseCAARecord(s string) (CAARecord, error) {
+	fields := strings.SplitN(strings.TrimSpace(s), " ", 3)
+	if len(fields) != 3 {
+		return CAARecord{}, fmt.Errorf("invalid CAA record %q: expected flags, tag, and value", s)
+	}
+	flags, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil {
+		return CAARecord{}, fmt.Errorf("invalid CAA record %q: invalid flags: %w", s, err)
+	}
+	tag := fields[1]
+	if len(tag) == 0 || len(tag) > 15 {
+		return CAARecord{}, fmt.Errorf("invalid CAA record %q: tag must be between 1 and 15 characters", s)
+	}
+	for _, c := range tag {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return CAARecord{}, fmt.Errorf("invalid CAA record %q: tag must be alphanumeric", s)
+		}
+	}
+	value := strings.TrimSpace(fields[2])
+	if strings.HasPrefix(value, `"`) {
+		if value, err = strconv.Unquote(value); err != nil {
+			return CAARecord{}, fmt.Errorf("invalid CAA record %q: invalid value: %w", s, err)
+		}
+	}
+	return CAARecord{Flags: uint8(flags), Tag: strings.ToLower(tag), Value: value}, nil
+}
+
+// ParseCAARecords parses newline-separated records in presentation format.
+// Blank lines are ignored.
+func ParseCAARecords(s string) ([]CAARecord, error) {
+	var records []CAARecord
+	for _, line := range strings.Split(s, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, err := ParseCAARecord(line)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// FormatCAARecords returns the given records in presentation format, one per
+// line, in a canonical order.
+func FormatCAARecords(records []CAARecord) string {
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		lines = append(lines, record.String())
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// CAARecordsEqual returns true if the given record sets contain the same
+// records, irrespective of order.
+func CAARecordsEqual(a, b []CAARecord) bool {
+	return FormatCAARecords(a) == FormatCAARecords(b)
+}
+
+// CAAProvider is implemented by providers that can manage the CAA resource
+// record set for a domain.
+type CAAProvider interface {
+	// CAARecords returns the CAA records that are published for domain in
+	// zone.
+	CAARecords(ctx context.Context, domain string, zone configv1.DNSZone) ([]CAARecord, error)
+
+	// EnsureCAA creates or replaces the CAA resource record set for domain
+	// in zone.
+	EnsureCAA(ctx context.Context, domain string, records []CAARecord, ttl int64, zone configv1.DNSZone) error
+
+	// DeleteCAA deletes the CAA resource record set for domain in zone.
+	DeleteCAA(ctx context.Context, domain string, zone configv1.DNSZone) error
+}
@@ -0,0 +1,56 @@
+package dns
+
+import "testing"
+
+func TestParseCAARecord(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    CAARecord
+		expectError bool
+	}{
+		{input: `0 issue "letsencrypt.org"`, expected: CAARecord{Tag: "issue", Value: "letsencrypt.org"}},
+		{input: `128 ISSUEWILD "ca.example.com; account=123"`, expected: CAARecord{Flags: 128, Tag: "issuewild", Value: "ca.example.com; account=123"}},
+		{input: `0 iodef mailto:security@example.com`, expected: CAARecord{Tag: "iodef", Value: "mailto:security@example.com"}},
+		{input: `0 issue`, expectError: true},
+		{input: `256 issue "letsencrypt.org"`, expectError: true},
+		{input: `0 is-sue "letsencrypt.org"`, expectError: true},
+		{input: `0 issue "letsencrypt.org`, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := ParseCAARecord(tc.input)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatalf("expected an error, got %+v", actual)
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case !tc.expectError && actual != tc.expected:
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCAARecordsRoundTrip(t *testing.T) {
+	records := []CAARecord{
+		{Tag: "issuewild", Value: "letsencrypt.org"},
+		{Tag: "issue", Value: "letsencrypt.org"},
+		{Tag: "iodef", Value: "mailto:security@example.com"},
+	}
+	formatted := FormatCAARecords(records)
+	expected := "0 iodef \"mailto:security@example.com\"\n0 issue \"letsencrypt.org\"\n0 issuewild \"letsencrypt.org\""
+	if formatted != expected {
+		t.Fatalf("expected %q, got %q", expected, formatted)
+	}
+	parsed, err := ParseCAARecords(formatted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !CAARecordsEqual(parsed, records) {
+		t.Errorf("expected %v to equal %v", parsed, records)
+	}
+	if CAARecordsEqual(parsed[:2], records) {
+		t.Error("expected record sets of different lengths to differ")
+	}
+}
@@ -1,27 +1,46 @@
 package dns
 
 import (
+	"context"
+
 	iov1 "github.com/openshift/api/operatoringress/v1"
 
 	configv1 "github.com/openshift/api/config/v1"
 )
 
-// Provider knows how to manage DNS zones only as pertains to routing.
+// Provider knows how to manage DNS zones only as pertains to routing.  Each
+// method takes a context that the provider must pass on to any cloud API calls
+// so that the caller can bound how long the call takes and can cancel it.
 type Provider interface {
 	// Ensure will create or update record.
-	Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error
+	Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error
 
 	// Delete will delete record.
-	Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error
+	Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error
 
 	// Replace will replace the record
-	Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
+	Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error
+}
+
+// RecordIDLister is implemented by providers whose APIs assign identifiers to
+// the records that they publish.  Providers whose APIs identify records only by
+// name and type, such as Route 53 and Cloud DNS, do not implement it.
+type RecordIDLister interface {
+	// RecordIDs returns the provider-specific identifiers of the records
+	// that are published for record in zone.
+	RecordIDs(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) ([]string, error)
 }
 
 var _ Provider = &FakeProvider{}
 
 type FakeProvider struct{}
 
-func (_ *FakeProvider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error  { return nil }
-func (_ *FakeProvider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error  { return nil }
-func (_ *FakeProvider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error { return nil }
+func (_ *FakeProvider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return nil
+}
+func (_ *FakeProvider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return nil
+}
+func (_ *FakeProvider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return nil
+}
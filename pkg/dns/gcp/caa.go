@@ -0,0 +1,92 @@
+package gcp
+
+import (
+	"context"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	gdnsv1 "google.golang.org/api/dns/v1"
+)
+
+var _ dns.CAAProvider = &Provider{}
+
+// CAARecords returns the CAA records that are published for domain in zone.
+func (p *Provider) CAARecords(ctx context.Context, domain string, zone configv1.DNSZone) ([]dns.CAARecord, error) {
+	rrset, err := p.getCAAResourceRecordSet(ctx, domain, zone)
+	if err != nil || rrset == nil {
+		return nil, err
+	}
+	var records []dns.CAARecord
+	for _, rrdata := range rrset.Rrdatas {
+		record, err := dns.ParseCAARecord(rrdata)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// EnsureCAA creates or replaces the CAA resource record set for domain in
+// zone.  Any existing record set is deleted in the same change so that the
+// replacement is atomic.
+func (p *Provider) EnsureCAA(ctx context.Context, domain string, records []dns.CAARecord, ttl int64, zone configv1.DNSZone) error {
+	project, zoneID, err := p.parseZone(zone)
+	if err != nil {
+		return err
+	}
+	current, err := p.getCAAResourceRecordSet(ctx, domain, zone)
+	if err != nil {
+		return err
+	}
+	desired := &gdnsv1.ResourceRecordSet{Name: domain, Type: "CAA", Ttl: ttl}
+	for _, record := range records {
+		desired.Rrdatas = append(desired.Rrdatas, record.String())
+	}
+	change := &gdnsv1.Change{Additions: []*gdnsv1.ResourceRecordSet{desired}}
+	if current != nil {
+		change.Deletions = []*gdnsv1.ResourceRecordSet{current}
+	}
+	if _, err := p.dnsService.Changes.Create(project, zoneID, change).Context(ctx).Do(); err != nil {
+		return err
+	}
+	log.Info("upserted CAA records", "project", project, "zone", zoneID, "domain", domain, "records", dns.FormatCAARecords(records))
+	return nil
+}
+
+// DeleteCAA deletes the CAA resource record set for domain in zone.
+func (p *Provider) DeleteCAA(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	project, zoneID, err := p.parseZone(zone)
+	if err != nil {
+		return err
+	}
+	current, err := p.getCAAResourceRecordSet(ctx, domain, zone)
+	if err != nil || current == nil {
+		return err
+	}
+	change := &gdnsv1.Change{Deletions: []*gdnsv1.ResourceRecordSet{current}}
+	if _, err := p.dnsService.Changes.Create(project, zoneID, change).Context(ctx).Do(); err != nil {
+		return err
+	}
+	log.Info("deleted CAA records", "project", project, "zone", zoneID, "domain", domain)
+	return nil
+}
+
+// getCAAResourceRecordSet returns the CAA resource record set for domain in
+// zone, or nil if there is none.
+func (p *Provider) getCAAResourceRecordSet(ctx context.Context, domain string, zone configv1.DNSZone) (*gdnsv1.ResourceRecordSet, error) {
+	project, zoneID, err := p.parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.dnsService.ResourceRecordSets.List(project, zoneID).Name(domain).Type("CAA").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Rrsets) == 0 {
+		return nil, nil
+	}
+	return resp.Rrsets[0], nil
+}
@@ -74,7 +74,7 @@ func (p *Provider) parseZone(zone configv1.DNSZone) (string, string, error) {
 	return project, zoneID, nil
 }
 
-func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	change := &gdnsv1.Change{Additions: []*gdnsv1.ResourceRecordSet{resourceRecordSet(record)}}
 
 	project, zoneID, err := p.parseZone(zone)
@@ -83,7 +83,7 @@ func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	}
 
 	call := p.dnsService.Changes.Create(project, zoneID, change)
-	_, err = call.Do()
+	_, err = call.Context(ctx).Do()
 	// Since we don't yet handle updates, assume that existing records are correct.
 	if ae, ok := err.(*googleapi.Error); ok && ae.Code == http.StatusConflict {
 		return nil
@@ -91,9 +91,7 @@ func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	return err
 }
 
-func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	ctx := context.Background()
-
+func (p *Provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	project, zoneID, err := p.parseZone(zone)
 	if err != nil {
 		return err
@@ -104,7 +102,7 @@ func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
 			log.Info("found old DNS resource record set", "resourceRecordSet", resourceRecordSet)
 			change := &gdnsv1.Change{Deletions: []*gdnsv1.ResourceRecordSet{resourceRecordSet}}
 			call := p.dnsService.Changes.Create(project, zoneID, change)
-			_, err := call.Do()
+			_, err := call.Context(ctx).Do()
 			if ae, ok := err.(*googleapi.Error); ok && ae.Code == http.StatusNotFound {
 				return nil
 			}
@@ -114,20 +112,20 @@ func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
 	}); err != nil {
 		return err
 	}
-	if err := p.Ensure(record, zone); err != nil {
+	if err := p.Ensure(ctx, record, zone); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	change := &gdnsv1.Change{Deletions: []*gdnsv1.ResourceRecordSet{resourceRecordSet(record)}}
 	project, zoneID, err := p.parseZone(zone)
 	if err != nil {
 		return err
 	}
 	call := p.dnsService.Changes.Create(project, zoneID, change)
-	_, err = call.Do()
+	_, err = call.Context(ctx).Do()
 	if ae, ok := err.(*googleapi.Error); ok && ae.Code == http.StatusNotFound {
 		return nil
 	}
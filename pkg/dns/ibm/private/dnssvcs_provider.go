@@ -1,6 +1,7 @@
 package private
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -72,15 +73,15 @@ func NewProvider(config common.Config) (*Provider, error) {
 	return provider, nil
 }
 
-func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.createOrUpdateDNSRecord(record, zone)
+func (p *Provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.createOrUpdateDNSRecord(ctx, record, zone)
 }
 
-func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.createOrUpdateDNSRecord(record, zone)
+func (p *Provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.createOrUpdateDNSRecord(ctx, record, zone)
 }
 
-func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if err := common.ValidateInputDNSData(record, zone); err != nil {
 		return fmt.Errorf("delete: invalid dns input data: %w", err)
 	}
@@ -91,7 +92,7 @@ func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	// "." when it creates a wildcard DNS record.
 	dnsName := strings.TrimSuffix(record.Spec.DNSName, ".")
 
-	result, response, err := p.dnsService.ListResourceRecords(listOpt)
+	result, response, err := p.dnsService.ListResourceRecordsWithContext(ctx, listOpt)
 	if err != nil {
 		if response == nil || response.StatusCode != http.StatusNotFound {
 			return fmt.Errorf("delete: failed to list the dns record: %w", err)
@@ -134,7 +135,7 @@ func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 					continue
 				}
 				delOpt := p.dnsService.NewDeleteResourceRecordOptions(p.config.InstanceID, zone.ID, *resourceRecord.ID)
-				delResponse, err := p.dnsService.DeleteResourceRecord(delOpt)
+				delResponse, err := p.dnsService.DeleteResourceRecordWithContext(ctx, delOpt)
 				if err != nil {
 					if delResponse == nil || delResponse.StatusCode != http.StatusNotFound {
 						return fmt.Errorf("delete: failed to delete the dns record: %w", err)
@@ -166,7 +167,7 @@ func validateDNSServices(provider *Provider) error {
 		}
 
 		listOpt := provider.dnsService.NewListResourceRecordsOptions(provider.config.InstanceID, zoneID)
-		_, _, err = provider.dnsService.ListResourceRecords(listOpt)
+		_, _, err = provider.dnsService.ListResourceRecordsWithContext(context.TODO(), listOpt)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to list dns records: %w", err))
 		}
@@ -175,7 +176,7 @@ func validateDNSServices(provider *Provider) error {
 }
 
 // createOrUpdateDNSRecord has the common logic for the Ensure and Update methods.
-func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) createOrUpdateDNSRecord(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if err := common.ValidateInputDNSData(record, zone); err != nil {
 		return fmt.Errorf("createOrUpdateDNSRecord: invalid dns input data: %w", err)
 	}
@@ -191,7 +192,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 		record.Spec.RecordTTL = defaultDNSSVCSRecordTTL
 	}
 
-	listResult, response, err := p.dnsService.ListResourceRecords(listOpt)
+	listResult, response, err := p.dnsService.ListResourceRecordsWithContext(ctx, listOpt)
 	if err != nil {
 		if response == nil || response.StatusCode != http.StatusNotFound {
 			return fmt.Errorf("createOrUpdateDNSRecord: failed to list the dns record: %w", err)
@@ -230,7 +231,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 					return fmt.Errorf("createOrUpdateDNSRecord: resource data has record with unknown type: %v", *resourceRecord.Type)
 				}
 				updateOpt.SetTTL(record.Spec.RecordTTL)
-				_, _, err := p.dnsService.UpdateResourceRecord(updateOpt)
+				_, _, err := p.dnsService.UpdateResourceRecordWithContext(ctx, updateOpt)
 				if err != nil {
 					return fmt.Errorf("createOrUpdateDNSRecord: failed to update the dns record: %w", err)
 				}
@@ -261,7 +262,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 
 			}
 			createOpt.SetTTL(record.Spec.RecordTTL)
-			_, _, err := p.dnsService.CreateResourceRecord(createOpt)
+			_, _, err := p.dnsService.CreateResourceRecordWithContext(ctx, createOpt)
 			if err != nil {
 				return fmt.Errorf("createOrUpdateDNSRecord: failed to create the dns record: %w", err)
 			}
@@ -1,6 +1,7 @@
 package private
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -137,7 +138,7 @@ func Test_Delete(t *testing.T) {
 
 			dnsService.DeleteDnsRecordInputOutput = tc.deleteDnsRecordInputOutput
 
-			err = provider.Delete(&record, zone)
+			err = provider.Delete(context.Background(), &record, zone)
 
 			if len(tc.expectErrorContains) != 0 && !strings.Contains(err.Error(), tc.expectErrorContains) {
 				t.Errorf("expected message to include %q, got %q", tc.expectErrorContains, err.Error())
@@ -262,7 +263,7 @@ func Test_createOrUpdateDNSRecord(t *testing.T) {
 
 			dnsService.UpdateDnsRecordInputOutput = tc.updateDnsRecordInputOutput
 
-			err = provider.createOrUpdateDNSRecord(&record, zone)
+			err = provider.createOrUpdateDNSRecord(context.Background(), &record, zone)
 
 			if len(tc.expectErrorContains) != 0 && !strings.Contains(err.Error(), tc.expectErrorContains) {
 				t.Errorf("expected message to include %q, got %q", tc.expectErrorContains, err.Error())
@@ -1,6 +1,7 @@
 package public
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -77,22 +78,22 @@ func validateDNSServices(provider *Provider) error {
 	for _, dnsService := range provider.dnsServices {
 		opt := dnsService.NewListAllDnsRecordsOptions()
 		opt.PerPage = &maxItems
-		if _, _, err := dnsService.ListAllDnsRecords(opt); err != nil {
+		if _, _, err := dnsService.ListAllDnsRecordsWithContext(context.TODO(), opt); err != nil {
 			errs = append(errs, fmt.Errorf("failed to get dns records: %w", err))
 		}
 	}
 	return kerrors.NewAggregate(errs)
 }
 
-func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.createOrUpdateDNSRecord(record, zone)
+func (p *Provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.createOrUpdateDNSRecord(ctx, record, zone)
 }
 
-func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
-	return p.createOrUpdateDNSRecord(record, zone)
+func (p *Provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.createOrUpdateDNSRecord(ctx, record, zone)
 }
 
-func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if err := common.ValidateInputDNSData(record, zone); err != nil {
 		return fmt.Errorf("delete: invalid dns input data: %w", err)
 	}
@@ -109,7 +110,7 @@ func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	opt.SetName(dnsName)
 	for _, target := range record.Spec.Targets {
 		opt.SetContent(target)
-		result, response, err := dnsService.ListAllDnsRecords(opt)
+		result, response, err := dnsService.ListAllDnsRecordsWithContext(ctx, opt)
 		if err != nil {
 			if response == nil || response.StatusCode != http.StatusNotFound {
 				return fmt.Errorf("delete: failed to list the dns record: %w", err)
@@ -124,7 +125,7 @@ func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 				return fmt.Errorf("delete: record id is nil")
 			}
 			delOpt := dnsService.NewDeleteDnsRecordOptions(*resultData.ID)
-			_, delResponse, err := dnsService.DeleteDnsRecord(delOpt)
+			_, delResponse, err := dnsService.DeleteDnsRecordWithContext(ctx, delOpt)
 			if err != nil {
 				if delResponse == nil || delResponse.StatusCode != http.StatusNotFound {
 					return fmt.Errorf("delete: failed to delete the dns record: %w", err)
@@ -138,7 +139,7 @@ func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	return nil
 }
 
-func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) createOrUpdateDNSRecord(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if err := common.ValidateInputDNSData(record, zone); err != nil {
 		return fmt.Errorf("createOrUpdateDNSRecord: invalid dns input data: %w", err)
 	}
@@ -162,7 +163,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 	listOpt.SetName(dnsName)
 	for _, target := range record.Spec.Targets {
 		listOpt.SetContent(target)
-		result, response, err := dnsService.ListAllDnsRecords(listOpt)
+		result, response, err := dnsService.ListAllDnsRecordsWithContext(ctx, listOpt)
 		if err != nil {
 			if response != nil && response.StatusCode != http.StatusNotFound {
 				return fmt.Errorf("createOrUpdateDNSRecord: failed to list the dns record: %w", err)
@@ -178,7 +179,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 			createOpt.SetType(string(record.Spec.RecordType))
 			createOpt.SetContent(target)
 			createOpt.SetTTL(record.Spec.RecordTTL)
-			_, _, err := dnsService.CreateDnsRecord(createOpt)
+			_, _, err := dnsService.CreateDnsRecordWithContext(ctx, createOpt)
 			if err != nil {
 				return fmt.Errorf("createOrUpdateDNSRecord: failed to create the dns record: %w", err)
 			}
@@ -189,7 +190,7 @@ func (p *Provider) createOrUpdateDNSRecord(record *iov1.DNSRecord, zone configv1
 			updateOpt.SetType(string(record.Spec.RecordType))
 			updateOpt.SetContent(target)
 			updateOpt.SetTTL(record.Spec.RecordTTL)
-			_, _, err := dnsService.UpdateDnsRecord(updateOpt)
+			_, _, err := dnsService.UpdateDnsRecordWithContext(ctx, updateOpt)
 			if err != nil {
 				return fmt.Errorf("createOrUpdateDNSRecord: failed to update the dns record: %w", err)
 			}
@@ -1,6 +1,7 @@
 package public
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -131,7 +132,7 @@ func Test_Delete(t *testing.T) {
 
 			dnsService.DeleteDnsRecordInputOutput = tc.deleteDnsRecordInputOutput
 
-			err = provider.Delete(&record, zone)
+			err = provider.Delete(context.Background(), &record, zone)
 
 			if len(tc.expectErrorContains) != 0 && !strings.Contains(err.Error(), tc.expectErrorContains) {
 				t.Errorf("expected message to include %q, got %q", tc.expectErrorContains, err.Error())
@@ -249,7 +250,7 @@ func Test_createOrUpdateDNSRecord(t *testing.T) {
 
 			dnsService.UpdateDnsRecordInputOutput = tc.updateDnsRecordInputOutput
 
-			err = provider.createOrUpdateDNSRecord(&record, zone)
+			err = provider.createOrUpdateDNSRecord(context.Background(), &record, zone)
 
 			if len(tc.expectErrorContains) != 0 && !strings.Contains(err.Error(), tc.expectErrorContains) {
 				t.Errorf("expected message to include %q, got %q", tc.expectErrorContains, err.Error())
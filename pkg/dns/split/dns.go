@@ -1,6 +1,7 @@
 package split
 
 import (
+	"context"
 	"reflect"
 
 	iov1 "github.com/openshift/api/operatoringress/v1"
@@ -11,8 +12,11 @@ import (
 )
 
 var (
-	_   dns.Provider = &Provider{}
-	log              = logf.Logger.WithName("dns")
+	_   dns.Provider            = &Provider{}
+	_   dns.RecordIDLister      = &Provider{}
+	_   dns.CAAProvider         = &Provider{}
+	_   dns.ZonalRecordProvider = &Provider{}
+	log                         = logf.Logger.WithName("dns")
 )
 
 // Provider is a dns.Provider that wraps two other providers.  The first
@@ -34,25 +38,107 @@ func NewProvider(public, private dns.Provider, privateZone *configv1.DNSZone) *P
 }
 
 // Ensure calls the Ensure method of one of the wrapped DNS providers.
-func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if reflect.DeepEqual(zone, *p.privateZone) {
-		return p.private.Ensure(record, zone)
+		return p.private.Ensure(ctx, record, zone)
 	}
-	return p.public.Ensure(record, zone)
+	return p.public.Ensure(ctx, record, zone)
 }
 
 // Delete calls the Delete method of one of the wrapped DNS providers.
-func (p *Provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if reflect.DeepEqual(zone, *p.privateZone) {
-		return p.private.Delete(record, zone)
+		return p.private.Delete(ctx, record, zone)
 	}
-	return p.public.Delete(record, zone)
+	return p.public.Delete(ctx, record, zone)
 }
 
 // Replace calls the Replace method of one of the wrapped DNS providers.
-func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *Provider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if reflect.DeepEqual(zone, *p.privateZone) {
-		return p.private.Replace(record, zone)
+		return p.private.Replace(ctx, record, zone)
 	}
-	return p.public.Replace(record, zone)
+	return p.public.Replace(ctx, record, zone)
+}
+
+// RecordIDs calls the RecordIDs method of one of the wrapped DNS providers if
+// that provider implements dns.RecordIDLister, and returns no identifiers
+// otherwise.
+func (p *Provider) RecordIDs(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) ([]string, error) {
+	provider := p.public
+	if reflect.DeepEqual(zone, *p.privateZone) {
+		provider = p.private
+	}
+	if lister, ok := provider.(dns.RecordIDLister); ok {
+		return lister.RecordIDs(ctx, record, zone)
+	}
+	return nil, nil
+}
+
+// CAARecords calls the CAARecords method of one of the wrapped DNS providers if
+// that provider implements dns.CAAProvider, and returns
+// dns.ErrCAANotSupported otherwise.
+func (p *Provider) CAARecords(ctx context.Context, domain string, zone configv1.DNSZone) ([]dns.CAARecord, error) {
+	if provider, ok := p.providerFor(zone).(dns.CAAProvider); ok {
+		return provider.CAARecords(ctx, domain, zone)
+	}
+	return nil, dns.ErrCAANotSupported
+}
+
+// EnsureCAA calls the EnsureCAA method of one of the wrapped DNS providers if
+// that provider implements dns.CAAProvider, and returns
+// dns.ErrCAANotSupported otherwise.
+func (p *Provider) EnsureCAA(ctx context.Context, domain string, records []dns.CAARecord, ttl int64, zone configv1.DNSZone) error {
+	if provider, ok := p.providerFor(zone).(dns.CAAProvider); ok {
+		return provider.EnsureCAA(ctx, domain, records, ttl, zone)
+	}
+	return dns.ErrCAANotSupported
+}
+
+// DeleteCAA calls the DeleteCAA method of one of the wrapped DNS providers if
+// that provider implements dns.CAAProvider, and returns
+// dns.ErrCAANotSupported otherwise.
+func (p *Provider) DeleteCAA(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	if provider, ok := p.providerFor(zone).(dns.CAAProvider); ok {
+		return provider.DeleteCAA(ctx, domain, zone)
+	}
+	return dns.ErrCAANotSupported
+}
+
+// ZonalTargets calls the ZonalTargets method of one of the wrapped DNS
+// providers if that provider implements dns.ZonalRecordProvider, and returns
+// dns.ErrZonalRecordsNotSupported otherwise.
+func (p *Provider) ZonalTargets(ctx context.Context, target string, zone configv1.DNSZone) ([]dns.ZonalTarget, error) {
+	if provider, ok := p.providerFor(zone).(dns.ZonalRecordProvider); ok {
+		return provider.ZonalTargets(ctx, target, zone)
+	}
+	return nil, dns.ErrZonalRecordsNotSupported
+}
+
+// EnsureZonalRecord calls the EnsureZonalRecord method of one of the wrapped
+// DNS providers if that provider implements dns.ZonalRecordProvider, and
+// returns dns.ErrZonalRecordsNotSupported otherwise.
+func (p *Provider) EnsureZonalRecord(ctx context.Context, domain, target string, ttl int64, zone configv1.DNSZone) error {
+	if provider, ok := p.providerFor(zone).(dns.ZonalRecordProvider); ok {
+		return provider.EnsureZonalRecord(ctx, domain, target, ttl, zone)
+	}
+	return dns.ErrZonalRecordsNotSupported
+}
+
+// DeleteZonalRecord calls the DeleteZonalRecord method of one of the wrapped
+// DNS providers if that provider implements dns.ZonalRecordProvider, and
+// returns dns.ErrZonalRecordsNotSupported otherwise.
+func (p *Provider) DeleteZonalRecord(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	if provider, ok := p.providerFor(zone).(dns.ZonalRecordProvider); ok {
+		return provider.DeleteZonalRecord(ctx, domain, zone)
+	}
+	return dns.ErrZonalRecordsNotSupported
+}
+
+// providerFor returns the wrapped provider for the given zone.
+func (p *Provider) providerFor(zone configv1.DNSZone) dns.Provider {
+	if reflect.DeepEqual(zone, *p.privateZone) {
+		return p.private
+	}
+	return p.public
 }
@@ -1,6 +1,7 @@
 package split_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -88,11 +89,11 @@ func TestSplitDNSProvider(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			provider := splitdns.NewProvider(publicProvider, privateProvider, &tc.privateZone)
-			assert.NoError(t, provider.Ensure(&iov1.DNSRecord{}, tc.publishToZone))
+			assert.NoError(t, provider.Ensure(context.Background(), &iov1.DNSRecord{}, tc.publishToZone))
 			assert.Equal(t, tc.expect, getResult())
-			assert.NoError(t, provider.Replace(&iov1.DNSRecord{}, tc.publishToZone))
+			assert.NoError(t, provider.Replace(context.Background(), &iov1.DNSRecord{}, tc.publishToZone))
 			assert.Equal(t, tc.expect, getResult())
-			assert.NoError(t, provider.Delete(&iov1.DNSRecord{}, tc.publishToZone))
+			assert.NoError(t, provider.Delete(context.Background(), &iov1.DNSRecord{}, tc.publishToZone))
 			assert.Equal(t, tc.expect, getResult())
 			assert.Empty(t, ch)
 		})
@@ -107,15 +108,15 @@ type fakeProvider struct {
 	recorder chan string
 }
 
-func (p *fakeProvider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *fakeProvider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	p.recorder <- p.name
 	return nil
 }
-func (p *fakeProvider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *fakeProvider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	p.recorder <- p.name
 	return nil
 }
-func (p *fakeProvider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+func (p *fakeProvider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	p.recorder <- p.name
 	return nil
 }
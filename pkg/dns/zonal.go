@@ -0,0 +1,37 @@
+package dns
+
+import (
+	"context"
+	"errors"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ErrZonalRecordsNotSupported is returned by a ZonalRecordProvider that wraps a
+// provider that cannot publish zonal records, or when the record's target is
+// not a load balancer with per-zone DNS names.
+var ErrZonalRecordsNotSupported = errors.New("the DNS provider does not support zonal records")
+
+// ZonalTarget is the DNS name that resolves to a load balancer's addresses in a
+// single availability zone.
+type ZonalTarget struct {
+	// Zone is the name of the availability zone, for example "us-east-1a".
+	Zone string
+	// Target is the load balancer's DNS name for the availability zone.
+	Target string
+}
+
+// ZonalRecordProvider is implemented by providers that can publish records that
+// point to the per-zone DNS names of a load balancer.
+type ZonalRecordProvider interface {
+	// ZonalTargets returns the per-zone DNS names of the load balancer
+	// with the given DNS name, for a record in zone.
+	ZonalTargets(ctx context.Context, target string, zone configv1.DNSZone) ([]ZonalTarget, error)
+
+	// EnsureZonalRecord creates or replaces the record for domain in zone
+	// so that it points to the given zonal target.
+	EnsureZonalRecord(ctx context.Context, domain, target string, ttl int64, zone configv1.DNSZone) error
+
+	// DeleteZonalRecord deletes the record for domain in zone.
+	DeleteZonalRecord(ctx context.Context, domain string, zone configv1.DNSZone) error
+}
@@ -0,0 +1,492 @@
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CanaryCompareWithAnnotation is an annotation on an ingresscontroller
+	// that enables comparison probes for the ingresscontroller.  The value
+	// is the name of the reference ingresscontroller, in the operator's
+	// namespace, through which the same routes are served.  For each route
+	// that is specified using CanaryCompareRoutesAnnotation, the canary
+	// controller periodically sends identical requests through both
+	// ingresscontrollers and records any differences in a report configmap.
+	CanaryCompareWithAnnotation = "ingress.operator.openshift.io/canary-compare-with"
+	// CanaryCompareRoutesAnnotation is an annotation on an ingresscontroller
+	// that specifies a comma-separated list of routes, each in the form
+	// "namespace/name", to compare when CanaryCompareWithAnnotation is set.
+	CanaryCompareRoutesAnnotation = "ingress.operator.openshift.io/canary-compare-routes"
+	// CanaryCompareHeadersAnnotation is an annotation on an ingresscontroller
+	// that specifies a comma-separated list of response header names whose
+	// values must match between the two ingresscontrollers.
+	CanaryCompareHeadersAnnotation = "ingress.operator.openshift.io/canary-compare-headers"
+	// CanaryCompareMaxLatencyDeltaAnnotation is an annotation on an
+	// ingresscontroller that specifies the maximum difference in request
+	// latency, as a Go duration (for example, "250ms"), that is tolerated
+	// between the two ingresscontrollers.
+	CanaryCompareMaxLatencyDeltaAnnotation = "ingress.operator.openshift.io/canary-compare-max-latency-delta"
+
+	// defaultCompareMaxLatencyDelta is the maximum latency difference that
+	// is tolerated when CanaryCompareMaxLatencyDeltaAnnotation is not set.
+	defaultCompareMaxLatencyDelta = 500 * time.Millisecond
+
+	// comparisonReportKey is the key in the report configmap that holds
+	// the per-route comparison results as JSON.
+	comparisonReportKey = "report.json"
+	// comparisonMismatchesKey is the key in the report configmap that holds
+	// the number of routes for which a mismatch was detected.
+	comparisonMismatchesKey = "mismatchedRoutes"
+	// comparisonLastProbeTimeKey is the key in the report configmap that
+	// holds the time of the last comparison that changed the report, in RFC
+	// 3339 format.  The configmap is not updated when only this time would
+	// change.
+	comparisonLastProbeTimeKey = "lastProbeTime"
+
+	// comparisonLatencySamples is the number of requests that are sent
+	// for each route through each ingresscontroller.  The median latency of
+	// these requests is compared so that a single slow request does not
+	// cause a mismatch.
+	comparisonLatencySamples = 5
+)
+
+// comparisonSpec describes a comparison between a candidate ingresscontroller
+// and a reference ingresscontroller.
+type comparisonSpec struct {
+	// reference is the name of the reference ingresscontroller.
+	reference string
+	// routes is the list of routes to probe.
+	routes []types.NamespacedName
+	// headers is the list of canonicalized response header names to
+	// compare.
+	headers []string
+	// maxLatencyDelta is the largest tolerated latency difference.
+	maxLatencyDelta time.Duration
+}
+
+// probeOutcome is the outcome of probing a route through one
+// ingresscontroller.
+type probeOutcome struct {
+	// Address is the address to which the probe connected.
+	Address string `json:"address,omitempty"`
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Headers holds the values of the compared response headers.
+	Headers map[string]string `json:"headers,omitempty"`
+	// LatencyMilliseconds is the median total request time.  It varies
+	// from one probe to the next, so it is not published in the report;
+	// only a latency mismatch is.
+	LatencyMilliseconds int64 `json:"-"`
+	// Error describes why the probe failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// routeComparison is the result of comparing a single route.
+type routeComparison struct {
+	// Route is the route in "namespace/name" form.
+	Route string `json:"route"`
+	// URL is the URL that was requested.
+	URL string `json:"url,omitempty"`
+	// Reference is the outcome through the reference ingresscontroller.
+	Reference probeOutcome `json:"reference"`
+	// Candidate is the outcome through the candidate ingresscontroller.
+	Candidate probeOutcome `json:"candidate"`
+	// Mismatches lists the differences that were found.
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+// comparisonSpecForIngressController returns the comparison that is specified
+// by the given ingresscontroller's annotations, or nil if comparison probes are
+// not enabled for the ingresscontroller.  An error is returned if the
+// annotations are invalid.
+func comparisonSpecForIngressController(ic *operatorv1.IngressController) (*comparisonSpec, error) {
+	reference := strings.TrimSpace(ic.Annotations[CanaryCompareWithAnnotation])
+	if len(reference) == 0 {
+		return nil, nil
+	}
+	if reference == ic.Name {
+		return nil, fmt.Errorf("annotation %s must name a different ingresscontroller", CanaryCompareWithAnnotation)
+	}
+
+	spec := &comparisonSpec{
+		reference:       reference,
+		maxLatencyDelta: defaultCompareMaxLatencyDelta,
+	}
+
+	for _, value := range strings.Split(ic.Annotations[CanaryCompareRoutesAnnotation], ",") {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+		parts := strings.Split(value, "/")
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("annotation %s has invalid route %q: expected namespace/name", CanaryCompareRoutesAnnotation, value)
+		}
+		spec.routes = append(spec.routes, types.NamespacedName{Namespace: parts[0], Name: parts[1]})
+	}
+	if len(spec.routes) == 0 {
+		return nil, fmt.Errorf("annotation %s must specify at least one route", CanaryCompareRoutesAnnotation)
+	}
+
+	for _, value := range strings.Split(ic.Annotations[CanaryCompareHeadersAnnotation], ",") {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+		spec.headers = append(spec.headers, http.CanonicalHeaderKey(value))
+	}
+
+	if value, ok := ic.Annotations[CanaryCompareMaxLatencyDeltaAnnotation]; ok {
+		delta, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || delta < 0 {
+			return nil, fmt.Errorf("annotation %s has invalid duration %q", CanaryCompareMaxLatencyDeltaAnnotation, value)
+		}
+		spec.maxLatencyDelta = delta
+	}
+
+	return spec, nil
+}
+
+// startCanaryComparisonPolling periodically runs the comparison probes that
+// are configured on any ingresscontroller and publishes the results.
+func (r *reconciler) startCanaryComparisonPolling(stop <-chan struct{}) {
+	go wait.Until(func() {
+		ingresscontrollers := &operatorv1.IngressControllerList{}
+		if err := r.client.List(context.TODO(), ingresscontrollers, client.InNamespace(r.config.Namespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers for canary comparison")
+			return
+		}
+		for i := range ingresscontrollers.Items {
+			ic := &ingresscontrollers.Items[i]
+			spec, err := comparisonSpecForIngressController(ic)
+			if err != nil {
+				log.Error(err, "invalid canary comparison configuration", "ingresscontroller", ic.Name)
+				continue
+			}
+			if spec == nil {
+				ComparisonMismatchedRoutes.DeleteLabelValues(ic.Name)
+				if err := r.deleteComparisonReport(ic); err != nil {
+					log.Error(err, "failed to delete canary comparison report", "ingresscontroller", ic.Name)
+				}
+				continue
+			}
+			results, err := r.compareIngressControllers(ic, spec)
+			if err != nil {
+				log.Error(err, "failed to run canary comparison", "ingresscontroller", ic.Name, "reference", spec.reference)
+				continue
+			}
+			if err := r.ensureComparisonReport(ic, results); err != nil {
+				log.Error(err, "failed to publish canary comparison report", "ingresscontroller", ic.Name)
+			}
+		}
+	}, canaryCheckFrequency, stop)
+}
+
+// compareIngressControllers probes each route in the given comparison through
+// both the candidate and reference ingresscontrollers and returns the results.
+func (r *reconciler) compareIngressControllers(candidate *operatorv1.IngressController, spec *comparisonSpec) ([]routeComparison, error) {
+	reference := &operatorv1.IngressController{}
+	name := types.NamespacedName{Namespace: candidate.Namespace, Name: spec.reference}
+	if err := r.client.Get(context.TODO(), name, reference); err != nil {
+		return nil, fmt.Errorf("failed to get reference ingresscontroller %s: %w", spec.reference, err)
+	}
+
+	var results []routeComparison
+	for _, routeName := range spec.routes {
+		result := routeComparison{Route: routeName.String()}
+		// The route is usually in a user namespace, which the operator's
+		// cache does not cover, so read it from the API.
+		route := &routev1.Route{}
+		if err := r.apiReader.Get(context.TODO(), routeName, route); err != nil {
+			result.Mismatches = []string{fmt.Sprintf("failed to get route: %v", err)}
+			results = append(results, result)
+			continue
+		}
+		host := route.Spec.Host
+		if len(host) == 0 {
+			host = routeHostForIngressController(route, candidate.Name)
+		}
+		if len(host) == 0 {
+			result.Mismatches = []string{"route has no host"}
+			results = append(results, result)
+			continue
+		}
+		scheme, port := "https", "443"
+		if route.Spec.TLS == nil {
+			scheme, port = "http", "80"
+		}
+		result.URL = scheme + "://" + host + route.Spec.Path
+
+		result.Reference = r.probeThroughIngressController(reference, result.URL, port, spec.headers)
+		result.Candidate = r.probeThroughIngressController(candidate, result.URL, port, spec.headers)
+		result.Mismatches = compareProbeOutcomes(result.Reference, result.Candidate, spec.headers, spec.maxLatencyDelta)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// probeThroughIngressController sends comparisonLatencySamples requests for the
+// given URL to the given ingresscontroller and returns the outcome.  The status
+// code and headers are those of the first response, and the latency is the
+// median latency.  The outcome is an error if any request fails.
+func (r *reconciler) probeThroughIngressController(ic *operatorv1.IngressController, url, port string, headers []string) probeOutcome {
+	outcome := probeOutcome{}
+	address, err := r.ingressControllerProbeAddress(ic)
+	if err != nil {
+		outcome.Error = err.Error()
+		return outcome
+	}
+	outcome.Address = net.JoinHostPort(address, port)
+
+	var result *probeResult
+	latencies := make([]time.Duration, 0, comparisonLatencySamples)
+	for i := 0; i < comparisonLatencySamples; i++ {
+		sample, err := sendProbeRequest(url, outcome.Address)
+		if err != nil {
+			outcome.Error = err.Error()
+			return outcome
+		}
+		if result == nil {
+			result = sample
+		}
+		latencies = append(latencies, sample.latency)
+	}
+	outcome.StatusCode = result.statusCode
+	outcome.LatencyMilliseconds = medianLatency(latencies).Milliseconds()
+	for _, header := range headers {
+		if outcome.Headers == nil {
+			outcome.Headers = map[string]string{}
+		}
+		outcome.Headers[header] = strings.Join(result.header.Values(header), ",")
+	}
+
+	return outcome
+}
+
+// medianLatency returns the median of the given latencies, or zero if there are
+// none.
+func medianLatency(latencies []time.Duration) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// ingressControllerProbeAddress returns the address to which requests should
+// be sent in order to reach the given ingresscontroller.  If the
+// ingresscontroller is published using a load balancer that has been
+// provisioned, the load balancer's address is used, so that the probe exercises
+// the same path as external clients.  Otherwise, the address of the
+// ingresscontroller's internal service is used.
+func (r *reconciler) ingressControllerProbeAddress(ic *operatorv1.IngressController) (string, error) {
+	if eps := ic.Status.EndpointPublishingStrategy; eps != nil && eps.Type == operatorv1.LoadBalancerServiceStrategyType {
+		service := &corev1.Service{}
+		if err := r.client.Get(context.TODO(), operatorcontroller.LoadBalancerServiceName(ic), service); err != nil && !errors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get load balancer service for ingresscontroller %s: %w", ic.Name, err)
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if len(ingress.IP) != 0 {
+				return ingress.IP, nil
+			}
+			if len(ingress.Hostname) != 0 {
+				return ingress.Hostname, nil
+			}
+		}
+	}
+
+	service := &corev1.Service{}
+	if err := r.client.Get(context.TODO(), operatorcontroller.InternalIngressControllerServiceName(ic), service); err != nil {
+		return "", fmt.Errorf("failed to get internal service for ingresscontroller %s: %w", ic.Name, err)
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return "", fmt.Errorf("internal service for ingresscontroller %s has no cluster IP", ic.Name)
+	}
+
+	return service.Spec.ClusterIP, nil
+}
+
+// compareProbeOutcomes returns a list of human-readable descriptions of the
+// differences between the given probe outcomes.  The list is empty if the
+// outcomes match.  Failures through both ingresscontrollers are reported as a
+// mismatch because the comparison cannot verify that the route is served
+// identically.
+func compareProbeOutcomes(reference, candidate probeOutcome, headers []string, maxLatencyDelta time.Duration) []string {
+	var mismatches []string
+
+	switch {
+	case len(reference.Error) != 0 && len(candidate.Error) != 0:
+		return []string{fmt.Sprintf("requests through both ingresscontrollers failed: reference: %s; candidate: %s", reference.Error, candidate.Error)}
+	case len(reference.Error) != 0:
+		return []string{fmt.Sprintf("request through the reference ingresscontroller failed: %s", reference.Error)}
+	case len(candidate.Error) != 0:
+		return []string{fmt.Sprintf("request through the candidate ingresscontroller failed: %s", candidate.Error)}
+	}
+
+	if reference.StatusCode != candidate.StatusCode {
+		mismatches = append(mismatches, fmt.Sprintf("status code differs: reference %d, candidate %d", reference.StatusCode, candidate.StatusCode))
+	}
+
+	for _, header := range headers {
+		if a, b := reference.Headers[header], candidate.Headers[header]; a != b {
+			mismatches = append(mismatches, fmt.Sprintf("header %s differs: reference %q, candidate %q", header, a, b))
+		}
+	}
+
+	delta := time.Duration(candidate.LatencyMilliseconds-reference.LatencyMilliseconds) * time.Millisecond
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta > maxLatencyDelta {
+		// Report only that the tolerance is exceeded so that the
+		// report does not change with every probe's latency.
+		mismatches = append(mismatches, fmt.Sprintf("latency differs by more than %v", maxLatencyDelta))
+	}
+
+	return mismatches
+}
+
+// routeHostForIngressController returns the host name that the named
+// ingresscontroller has admitted for the given route, or the empty string if
+// the ingresscontroller has not admitted it.
+func routeHostForIngressController(route *routev1.Route, ingressControllerName string) string {
+	for _, ingress := range route.Status.Ingress {
+		if ingress.RouterName == ingressControllerName {
+			return ingress.Host
+		}
+	}
+
+	return ""
+}
+
+// ensureComparisonReport publishes the given comparison results to the
+// ingresscontroller's comparison report configmap and updates the mismatch
+// metric.
+func (r *reconciler) ensureComparisonReport(ic *operatorv1.IngressController, results []routeComparison) error {
+	desired, err := desiredComparisonReportConfigMap(ic, results, time.Now())
+	if err != nil {
+		return err
+	}
+	mismatched, _ := strconv.Atoi(desired.Data[comparisonMismatchesKey])
+	ComparisonMismatchedRoutes.WithLabelValues(ic.Name).Set(float64(mismatched))
+
+	current := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), operatorcontroller.CanaryComparisonReportConfigMapName(ic), current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create configmap %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		log.Info("created canary comparison report configmap", "namespace", desired.Namespace, "name", desired.Name)
+		return nil
+	}
+
+	if !comparisonReportChanged(current.Data, desired.Data) {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = desired.Data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update configmap %s/%s: %w", updated.Namespace, updated.Name, err)
+	}
+
+	return nil
+}
+
+// comparisonReportChanged returns a Boolean value indicating whether the given
+// desired report data differ from the current report data in anything other
+// than the probe time.
+func comparisonReportChanged(current, desired map[string]string) bool {
+	ignoreProbeTime := cmpopts.IgnoreMapEntries(func(k, _ string) bool {
+		return k == comparisonLastProbeTimeKey
+	})
+	return !cmp.Equal(current, desired, ignoreProbeTime)
+}
+
+// deleteComparisonReport deletes the given ingresscontroller's comparison
+// report configmap if it exists.
+func (r *reconciler) deleteComparisonReport(ic *operatorv1.IngressController) error {
+	name := operatorcontroller.CanaryComparisonReportConfigMapName(ic)
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), name, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get configmap %s: %w", name, err)
+	}
+	if err := r.client.Delete(context.TODO(), cm); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete configmap %s: %w", name, err)
+	}
+	log.Info("deleted canary comparison report configmap", "namespace", name.Namespace, "name", name.Name)
+	return nil
+}
+
+// desiredComparisonReportConfigMap returns the desired comparison report
+// configmap for the given ingresscontroller and results.
+func desiredComparisonReportConfigMap(ic *operatorv1.IngressController, results []routeComparison, now time.Time) (*corev1.ConfigMap, error) {
+	report, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal canary comparison report: %w", err)
+	}
+
+	mismatched := 0
+	for _, result := range results {
+		if len(result.Mismatches) != 0 {
+			mismatched++
+		}
+	}
+
+	name := operatorcontroller.CanaryComparisonReportConfigMapName(ic)
+	trueVar := true
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: operatorv1.GroupVersion.String(),
+				Kind:       "IngressController",
+				Name:       ic.Name,
+				UID:        ic.UID,
+				Controller: &trueVar,
+			}},
+		},
+		Data: map[string]string{
+			comparisonReportKey:        string(report),
+			comparisonMismatchesKey:    strconv.Itoa(mismatched),
+			comparisonLastProbeTimeKey: now.UTC().Format(time.RFC3339),
+		},
+	}
+
+	return cm, nil
+}
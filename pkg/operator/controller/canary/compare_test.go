@@ -0,0 +1,328 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_comparisonSpecForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expected    *comparisonSpec
+		expectError bool
+	}{
+		{
+			description: "no annotations",
+			annotations: nil,
+			expected:    nil,
+		},
+		{
+			description: "reference is the same ingresscontroller",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation:   "candidate",
+				CanaryCompareRoutesAnnotation: "ns/route",
+			},
+			expectError: true,
+		},
+		{
+			description: "no routes",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation: "default",
+			},
+			expectError: true,
+		},
+		{
+			description: "malformed route",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation:   "default",
+				CanaryCompareRoutesAnnotation: "route",
+			},
+			expectError: true,
+		},
+		{
+			description: "invalid latency delta",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation:            "default",
+				CanaryCompareRoutesAnnotation:          "ns/route",
+				CanaryCompareMaxLatencyDeltaAnnotation: "fast",
+			},
+			expectError: true,
+		},
+		{
+			description: "defaults",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation:   "default",
+				CanaryCompareRoutesAnnotation: "ns/route",
+			},
+			expected: &comparisonSpec{
+				reference:       "default",
+				routes:          []types.NamespacedName{{Namespace: "ns", Name: "route"}},
+				maxLatencyDelta: defaultCompareMaxLatencyDelta,
+			},
+		},
+		{
+			description: "all annotations",
+			annotations: map[string]string{
+				CanaryCompareWithAnnotation:            "default",
+				CanaryCompareRoutesAnnotation:          "ns1/a, ns2/b,",
+				CanaryCompareHeadersAnnotation:         "content-type, x-app-version",
+				CanaryCompareMaxLatencyDeltaAnnotation: "250ms",
+			},
+			expected: &comparisonSpec{
+				reference: "default",
+				routes: []types.NamespacedName{
+					{Namespace: "ns1", Name: "a"},
+					{Namespace: "ns2", Name: "b"},
+				},
+				headers:         []string{"Content-Type", "X-App-Version"},
+				maxLatencyDelta: 250 * time.Millisecond,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "candidate",
+					Annotations: tc.annotations,
+				},
+			}
+			spec, err := comparisonSpecForIngressController(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected error, got nil")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, spec, cmp.AllowUnexported(comparisonSpec{})); diff != "" {
+				t.Errorf("unexpected comparison spec (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_compareProbeOutcomes(t *testing.T) {
+	headers := []string{"Content-Type"}
+	ok := probeOutcome{
+		StatusCode:          http.StatusOK,
+		Headers:             map[string]string{"Content-Type": "text/plain"},
+		LatencyMilliseconds: 100,
+	}
+	testCases := []struct {
+		description        string
+		reference          probeOutcome
+		candidate          probeOutcome
+		expectedMismatches int
+	}{
+		{
+			description: "identical outcomes",
+			reference:   ok,
+			candidate:   ok,
+		},
+		{
+			description:        "both failed",
+			reference:          probeOutcome{Error: "timeout"},
+			candidate:          probeOutcome{Error: "timeout"},
+			expectedMismatches: 1,
+		},
+		{
+			description:        "only candidate failed",
+			reference:          ok,
+			candidate:          probeOutcome{Error: "connection refused"},
+			expectedMismatches: 1,
+		},
+		{
+			description: "status code and header differ",
+			reference:   ok,
+			candidate: probeOutcome{
+				StatusCode:          http.StatusServiceUnavailable,
+				Headers:             map[string]string{"Content-Type": "text/html"},
+				LatencyMilliseconds: 100,
+			},
+			expectedMismatches: 2,
+		},
+		{
+			description: "latency within tolerance",
+			reference:   ok,
+			candidate: probeOutcome{
+				StatusCode:          http.StatusOK,
+				Headers:             map[string]string{"Content-Type": "text/plain"},
+				LatencyMilliseconds: 300,
+			},
+		},
+		{
+			description: "latency exceeds tolerance",
+			reference:   ok,
+			candidate: probeOutcome{
+				StatusCode:          http.StatusOK,
+				Headers:             map[string]string{"Content-Type": "text/plain"},
+				LatencyMilliseconds: 900,
+			},
+			expectedMismatches: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mismatches := compareProbeOutcomes(tc.reference, tc.candidate, headers, 500*time.Millisecond)
+			if len(mismatches) != tc.expectedMismatches {
+				t.Errorf("expected %d mismatches, got %d: %v", tc.expectedMismatches, len(mismatches), mismatches)
+			}
+		})
+	}
+}
+
+func Test_medianLatency(t *testing.T) {
+	testCases := []struct {
+		latencies []time.Duration
+		expected  time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{100 * time.Millisecond}, 100 * time.Millisecond},
+		{[]time.Duration{100 * time.Millisecond, 2 * time.Second, 120 * time.Millisecond}, 120 * time.Millisecond},
+		{[]time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 200 * time.Millisecond, 5 * time.Second}, 250 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		if actual := medianLatency(tc.latencies); actual != tc.expected {
+			t.Errorf("expected median of %v to be %v, got %v", tc.latencies, tc.expected, actual)
+		}
+	}
+}
+
+func Test_comparisonReportChanged(t *testing.T) {
+	current := map[string]string{
+		comparisonReportKey:        "[]",
+		comparisonMismatchesKey:    "0",
+		comparisonLastProbeTimeKey: "2024-01-01T00:00:00Z",
+	}
+	sameResults := map[string]string{
+		comparisonReportKey:        "[]",
+		comparisonMismatchesKey:    "0",
+		comparisonLastProbeTimeKey: "2024-01-01T00:01:00Z",
+	}
+	if comparisonReportChanged(current, sameResults) {
+		t.Errorf("expected a report that differs only in the probe time to be unchanged")
+	}
+	newResults := map[string]string{
+		comparisonReportKey:        "[{}]",
+		comparisonMismatchesKey:    "1",
+		comparisonLastProbeTimeKey: "2024-01-01T00:01:00Z",
+	}
+	if !comparisonReportChanged(current, newResults) {
+		t.Errorf("expected a report with new results to be changed")
+	}
+}
+
+// Test_comparisonReportIgnoresLatency verifies that reports from probes that
+// differ only in latency are unchanged, so that the report configmap is not
+// rewritten after every probe.
+func Test_comparisonReportIgnoresLatency(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "candidate", Namespace: "openshift-ingress-operator"}}
+	results := func(latency int64) []routeComparison {
+		return []routeComparison{{
+			Route:     "app/web",
+			URL:       "https://web.apps.example.com",
+			Reference: probeOutcome{StatusCode: http.StatusOK, LatencyMilliseconds: 100},
+			Candidate: probeOutcome{StatusCode: http.StatusOK, LatencyMilliseconds: latency},
+		}}
+	}
+	first, err := desiredComparisonReportConfigMap(ic, results(120), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := desiredComparisonReportConfigMap(ic, results(180), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comparisonReportChanged(first.Data, second.Data) {
+		t.Errorf("expected reports that differ only in latency to be unchanged:\n%s\n%s", first.Data[comparisonReportKey], second.Data[comparisonReportKey])
+	}
+}
+
+// Test_compareIngressControllers_routeOutsideCache verifies that the compared
+// routes are read through the API reader, so that routes in namespaces that
+// the operator's cache does not cover can be compared.
+func Test_compareIngressControllers_routeOutsideCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	routev1.Install(scheme)
+
+	reference := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "openshift-ingress-operator"}}
+	candidate := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "sharded", Namespace: "openshift-ingress-operator"}}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app"},
+		Spec:       routev1.RouteSpec{Host: "web.apps.example.com"},
+	}
+
+	// The cached client knows only the operator's namespaces.
+	cachedClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(reference).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if key.Namespace == "app" {
+				return fmt.Errorf("unable to get: %s because of unknown namespace for the cache", key)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+	apiReader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(route).Build()
+	r := &reconciler{client: cachedClient, apiReader: apiReader}
+
+	spec := &comparisonSpec{
+		reference:       reference.Name,
+		routes:          []types.NamespacedName{{Namespace: "app", Name: "web"}},
+		maxLatencyDelta: defaultCompareMaxLatencyDelta,
+	}
+	results, err := r.compareIngressControllers(candidate, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if expected := "http://web.apps.example.com"; results[0].URL != expected {
+		t.Errorf("expected route to be probed at %q, got %q (mismatches: %v)", expected, results[0].URL, results[0].Mismatches)
+	}
+}
+
+// Test_sendProbeRequest verifies that sendProbeRequest connects to the given
+// dial address while preserving the requested host.
+func Test_sendProbeRequest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Received-Host", r.Host)
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, CanaryHealthcheckResponse)
+	}))
+	defer server.Close()
+
+	result, err := sendProbeRequest("https://app.example.invalid/path", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.statusCode != http.StatusTeapot {
+		t.Errorf("expected status code %d, got %d", http.StatusTeapot, result.statusCode)
+	}
+	if host := result.header.Get("X-Received-Host"); host != "app.example.invalid" {
+		t.Errorf("expected request for host %q, got %q", "app.example.invalid", host)
+	}
+	if result.body != CanaryHealthcheckResponse {
+		t.Errorf("expected body %q, got %q", CanaryHealthcheckResponse, result.body)
+	}
+}
@@ -49,7 +49,10 @@ const (
 	// the endpoints of the canary route. Canary route rotation is disabled by default
 	// to prevent router reloads from impacting ingress performance periodically.
 	// Canary route rotation is enabled when the canary route rotation annotation has
-	// a value of "true" (disabled otherwise).
+	// a value of "true" (disabled otherwise).  The canary controller measures how
+	// long each router pod takes to serve each rotation.  When canary route rotation
+	// is disabled, the canary route is still rotated, less frequently, for this
+	// measurement.
 	CanaryRouteRotationAnnotation = "ingress.operator.openshift.io/rotate-canary-route"
 
 	// CanaryHealthcheckCommand is a parameter to pass to the ingress-operator to call
@@ -60,8 +63,12 @@ const (
 )
 
 var (
-	log              = logf.Logger.WithName(canaryControllerName)
-	routeProbeRunner sync.Once
+	log                   = logf.Logger.WithName(canaryControllerName)
+	routeProbeRunner      sync.Once
+	comparisonProbeRunner sync.Once
+	proxyProbeRunner      sync.Once
+	tlsProbeRunner        sync.Once
+	failoverProbeRunner   sync.Once
 )
 
 // New creates the canary controller.
@@ -73,6 +80,7 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	reconciler := &reconciler{
 		config:                    config,
 		client:                    mgr.GetClient(),
+		apiReader:                 mgr.GetAPIReader(),
 		enableCanaryRouteRotation: false,
 	}
 	c, err := controller.New(canaryControllerName, mgr, controller.Options{Reconciler: reconciler})
@@ -192,6 +200,30 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		r.startCanaryRoutePolling(r.config.Stop)
 	})
 
+	// Start probing any routes that are configured for comparison
+	// between ingresscontrollers.
+	comparisonProbeRunner.Do(func() {
+		r.startCanaryComparisonPolling(r.config.Stop)
+	})
+
+	// Start probing HostNetwork and NodePortService ingresscontrollers
+	// for PROXY protocol mismatches.
+	proxyProbeRunner.Do(func() {
+		r.startProxyProtocolPolling(r.config.Stop)
+	})
+
+	// Start probing ingresscontrollers for deviations from their TLS
+	// profiles.
+	tlsProbeRunner.Do(func() {
+		r.startTLSConformancePolling(r.config.Stop)
+	})
+
+	// Start probing ingresscontrollers that have a DNS failover standby
+	// through their own load balancers.
+	failoverProbeRunner.Do(func() {
+		r.startFailoverProbePolling(r.config.Stop)
+	})
+
 	return result, nil
 }
 
@@ -208,6 +240,9 @@ type reconciler struct {
 	config Config
 
 	client client.Client
+	// apiReader is used to read objects, such as routes in user namespaces,
+	// that are outside the namespaces that the operator's cache covers.
+	apiReader client.Reader
 
 	// Use a mutex so enableCanaryRotation is
 	// go-routine safe.
@@ -223,8 +258,9 @@ func (r *reconciler) isCanaryRouteRotationEnabled() bool {
 
 func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
 	// Keep track of how many canary checks have passed
-	// so the route endpoint can be periodically cycled
-	// (when canary route rotation is enabled).
+	// so the route endpoint can be periodically cycled,
+	// frequently when canary route rotation is enabled and
+	// otherwise only to measure route propagation.
 	checkCount := 0
 
 	// Keep track of successive canary check failures
@@ -256,9 +292,13 @@ func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
 		// Check if canary route rotations are enabled every iteration.
 		rotationEnabled := r.isCanaryRouteRotationEnabled()
 
-		// Periodically rotate the canary route endpoint if
-		// rotationEnabled is true.
-		if rotationEnabled && checkCount > canaryCheckCycleCount {
+		// Periodically rotate the canary route endpoint, less
+		// frequently if rotationEnabled is false.
+		cycleCount := canaryCheckCycleCount
+		if !rotationEnabled {
+			cycleCount = routePropagationMeasurementCycleCount
+		}
+		if checkCount > cycleCount {
 			haveService, service, err := r.currentCanaryService()
 			if err != nil {
 				log.Error(err, "failed to get canary service")
@@ -273,6 +313,9 @@ func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
 				return
 			}
 			checkCount = 0
+			// Measure how long the routers take to serve the
+			// change in the background.
+			go r.measureRoutePropagation(route, time.Now())
 			// Give the router time to reload by returning here.
 			return
 		}
@@ -295,13 +338,11 @@ func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
 		if err := r.setCanaryPassingStatusCondition(); err != nil {
 			log.Error(err, "error updating canary status condition")
 		}
+		// Verify that the route's requests and responses honor the
+		// ingresscontroller's header policies.
+		r.checkCanaryHeaderPolicies(route)
 		successiveFail = 0
-		// Only increment checkCount if periodic canary route
-		// endpoint rotation is enabled to prevent unbounded
-		// integer growth.
-		if rotationEnabled {
-			checkCount++
-		}
+		checkCount++
 	}, canaryCheckFrequency, stop)
 
 	return nil
@@ -355,9 +396,16 @@ func (r *reconciler) setCanaryDoesNotExistStatusCondition() error {
 // The assumption here is that cond is a condition that does not overlap with any of the status
 // conditions set by the ingress controller in pkg/operator/controller/ingress/status.go.
 func (r *reconciler) setCanaryStatusCondition(cond operatorv1.OperatorCondition) error {
+	return r.setIngressControllerStatusCondition(manifests.DefaultIngressControllerName, cond)
+}
+
+// setIngressControllerStatusCondition applies the given condition to the named
+// ingress controller.  The same assumption applies as for
+// setCanaryStatusCondition.
+func (r *reconciler) setIngressControllerStatusCondition(name string, cond operatorv1.OperatorCondition) error {
 	ic := &operatorv1.IngressController{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      manifests.DefaultIngressControllerName,
+			Name:      name,
 			Namespace: r.config.Namespace,
 		},
 	}
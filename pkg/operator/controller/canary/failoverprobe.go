@@ -0,0 +1,134 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// failoverProbeHostPrefix is the label that is prepended to an
+// ingresscontroller's domain to form the host name of a load balancer probe if
+// the ingresscontroller has not admitted the canary route.
+const failoverProbeHostPrefix = "failover-probe"
+
+// startFailoverProbePolling periodically probes each ingresscontroller that
+// designates a DNS failover standby through its own load balancer and updates
+// the ingresscontroller's LoadBalancerProbesSucceeding status condition, which
+// the DNS controller uses to decide whether to fail over.  The probes dial the
+// load balancer's address rather than resolving the ingresscontroller's domain,
+// which points at the standby while the ingresscontroller is failed over.
+func (r *reconciler) startFailoverProbePolling(stop <-chan struct{}) {
+	// Keep track of successive probe failures for each
+	// ingresscontroller for status reporting.
+	successiveFail := map[string]int{}
+
+	go wait.Until(func() {
+		ingresscontrollers := &operatorv1.IngressControllerList{}
+		if err := r.client.List(context.TODO(), ingresscontrollers, client.InNamespace(r.config.Namespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers for load balancer probes")
+			return
+		}
+		_, route, err := r.currentCanaryRoute()
+		if err != nil {
+			log.Error(err, "failed to get current canary route for load balancer probes")
+		}
+
+		probed := map[string]struct{}{}
+		for i := range ingresscontrollers.Items {
+			ic := &ingresscontrollers.Items[i]
+			if !failoverProbeApplies(ic) {
+				continue
+			}
+			probed[ic.Name] = struct{}{}
+			address, err := r.ingressControllerProbeAddress(ic)
+			if err == nil {
+				address = net.JoinHostPort(address, "443")
+				err = probeIngressControllerLoadBalancer(ic, route, address)
+			}
+			if err != nil {
+				log.Error(err, "error probing ingresscontroller load balancer", "ingresscontroller", ic.Name, "address", address)
+				successiveFail[ic.Name]++
+				// As with the canary route, report a failure only
+				// after successive failing probes.
+				if successiveFail[ic.Name] < canaryCheckFailureCount {
+					continue
+				}
+				cond := operatorv1.OperatorCondition{
+					Type:    ingresscontroller.IngressControllerLoadBalancerProbeSuccessConditionType,
+					Status:  operatorv1.ConditionFalse,
+					Reason:  "LoadBalancerProbeFailed",
+					Message: fmt.Sprintf("Probes through the load balancer are failing: %v", err),
+				}
+				if err := r.setIngressControllerStatusCondition(ic.Name, cond); err != nil {
+					log.Error(err, "error updating load balancer probe status condition", "ingresscontroller", ic.Name)
+				}
+				continue
+			}
+			successiveFail[ic.Name] = 0
+			cond := operatorv1.OperatorCondition{
+				Type:    ingresscontroller.IngressControllerLoadBalancerProbeSuccessConditionType,
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "LoadBalancerProbeSuccess",
+				Message: fmt.Sprintf("Probes through the load balancer at %s are passing", address),
+			}
+			if err := r.setIngressControllerStatusCondition(ic.Name, cond); err != nil {
+				log.Error(err, "error updating load balancer probe status condition", "ingresscontroller", ic.Name)
+			}
+		}
+		for name := range successiveFail {
+			if _, ok := probed[name]; !ok {
+				delete(successiveFail, name)
+			}
+		}
+	}, canaryCheckFrequency, stop)
+}
+
+// failoverProbeApplies returns a Boolean value indicating whether the given
+// ingresscontroller should be probed through its load balancer: it must
+// designate a DNS failover standby, must not be marked for deletion, and must
+// report its domain and endpoint publishing strategy.
+func failoverProbeApplies(ic *operatorv1.IngressController) bool {
+	if _, ok := ic.Annotations[ingresscontroller.DNSFailoverStandbyAnnotation]; !ok {
+		return false
+	}
+	if ic.DeletionTimestamp != nil {
+		return false
+	}
+	return len(ic.Status.Domain) != 0 && ic.Status.EndpointPublishingStrategy != nil
+}
+
+// probeIngressControllerLoadBalancer sends a probe request to the given
+// ingresscontroller at the given address and returns an error if the probe
+// fails.  If the ingresscontroller has admitted the given canary route, the
+// probe requests the canary route and requires the canary application's
+// response.  Otherwise, the probe requests a host in the ingresscontroller's
+// domain that no route uses, and any HTTP response from the routers shows that
+// the load balancer and routers are serving.
+func probeIngressControllerLoadBalancer(ic *operatorv1.IngressController, route *routev1.Route, address string) error {
+	if route != nil {
+		if host := routeHostForIngressController(route, ic.Name); len(host) != 0 {
+			result, err := sendProbeRequestWithHeaders("https://"+host, address, nil)
+			if err != nil {
+				return err
+			}
+			if result.statusCode != http.StatusOK || !strings.Contains(result.body, CanaryHealthcheckResponse) {
+				return fmt.Errorf("canary route %s returned status code %d through %s", host, result.statusCode, address)
+			}
+			return nil
+		}
+	}
+
+	_, err := sendProbeRequestWithHeaders("https://"+failoverProbeHostPrefix+"."+ic.Status.Domain, address, nil)
+	return err
+}
@@ -0,0 +1,135 @@
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_failoverProbeApplies(t *testing.T) {
+	now := metav1.Now()
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		deleted     bool
+		expected    bool
+	}{
+		{
+			description: "no standby",
+			expected:    false,
+		},
+		{
+			description: "standby",
+			annotations: map[string]string{ingresscontroller.DNSFailoverStandbyAnnotation: "standby"},
+			expected:    true,
+		},
+		{
+			description: "standby, marked for deletion",
+			annotations: map[string]string{ingresscontroller.DNSFailoverStandbyAnnotation: "standby"},
+			deleted:     true,
+			expected:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "primary", Annotations: tc.annotations},
+				Status: operatorv1.IngressControllerStatus{
+					Domain:                     "apps.example.com",
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType},
+				},
+			}
+			if tc.deleted {
+				ic.DeletionTimestamp = &now
+			}
+			if actual := failoverProbeApplies(ic); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// Test_probeIngressControllerLoadBalancer verifies that the probe dials the
+// given address, requests the canary route if the ingresscontroller admitted
+// it, and otherwise accepts any response from the routers.
+func Test_probeIngressControllerLoadBalancer(t *testing.T) {
+	var requestedHost string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedHost = r.Host
+		if r.Host != "canary.apps.example.com" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, CanaryHealthcheckResponse)
+	}))
+	defer server.Close()
+	closed := httptest.NewTLSServer(http.NotFoundHandler())
+	closed.Close()
+
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "primary"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+	admitted := &routev1.Route{Status: routev1.RouteStatus{Ingress: []routev1.RouteIngress{{RouterName: "primary", Host: "canary.apps.example.com"}}}}
+	misrouted := &routev1.Route{Status: routev1.RouteStatus{Ingress: []routev1.RouteIngress{{RouterName: "primary", Host: "other.apps.example.com"}}}}
+	notAdmitted := &routev1.Route{Status: routev1.RouteStatus{Ingress: []routev1.RouteIngress{{RouterName: "default", Host: "canary.apps.example.com"}}}}
+
+	testCases := []struct {
+		description  string
+		route        *routev1.Route
+		address      string
+		expectHost   string
+		expectFailed bool
+	}{
+		{
+			description: "canary route admitted",
+			route:       admitted,
+			address:     server.Listener.Addr().String(),
+			expectHost:  "canary.apps.example.com",
+		},
+		{
+			description:  "canary route not served",
+			route:        misrouted,
+			address:      server.Listener.Addr().String(),
+			expectHost:   "other.apps.example.com",
+			expectFailed: true,
+		},
+		{
+			description: "canary route not admitted",
+			route:       notAdmitted,
+			address:     server.Listener.Addr().String(),
+			expectHost:  "failover-probe.apps.example.com",
+		},
+		{
+			description: "no canary route",
+			address:     server.Listener.Addr().String(),
+			expectHost:  "failover-probe.apps.example.com",
+		},
+		{
+			description:  "load balancer unreachable",
+			route:        admitted,
+			address:      closed.Listener.Addr().String(),
+			expectFailed: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			requestedHost = ""
+			err := probeIngressControllerLoadBalancer(ic, tc.route, tc.address)
+			if failed := err != nil; failed != tc.expectFailed {
+				t.Errorf("expected failure %t, got %v", tc.expectFailed, err)
+			}
+			if requestedHost != tc.expectHost {
+				t.Errorf("expected request for host %q, got %q", tc.expectHost, requestedHost)
+			}
+		})
+	}
+}
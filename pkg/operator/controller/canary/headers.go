@@ -0,0 +1,376 @@
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CanaryRequestEchoPath is the path for which the canary application
+	// responds with a RequestEcho describing the request it received.
+	CanaryRequestEchoPath = "/request-echo"
+	// CanaryEchoResponseHeadersHeader is a request header that specifies a
+	// comma-separated list of response header names that the canary
+	// application sets in its response to a request for
+	// CanaryRequestEchoPath.  This makes it possible to verify that the
+	// router deletes response headers.
+	CanaryEchoResponseHeadersHeader = "X-Canary-Echo-Response-Headers"
+	// CanaryEchoResponseHeaderValue is the value of each response header
+	// that is set because of CanaryEchoResponseHeadersHeader.
+	CanaryEchoResponseHeaderValue = "canary"
+
+	// CanaryExpectClientIPPreservedAnnotation is an annotation on the
+	// default ingresscontroller that specifies, as a Boolean value,
+	// whether the canary controller should expect the canary application
+	// to see the client's IP address in the X-Forwarded-For header.  If the
+	// annotation is not set, client IP preservation is reported but not
+	// asserted.
+	CanaryExpectClientIPPreservedAnnotation = "ingress.operator.openshift.io/canary-expect-client-ip-preserved"
+
+	// canaryForwardedForSentinel is the X-Forwarded-For value that the
+	// canary sends in order to verify the forwarded header policy.  It is
+	// an address from TEST-NET-1, which no real client has.
+	canaryForwardedForSentinel = "192.0.2.1"
+	// canarySentHeaderValue is the value that the canary sends for
+	// headers that the router is expected to delete or to adjust the case
+	// of.
+	canarySentHeaderValue = "canary"
+)
+
+// RequestEcho is the response body that the canary application returns for a
+// request for CanaryRequestEchoPath.
+type RequestEcho struct {
+	// Response is the canary healthcheck response.
+	Response string `json:"response"`
+	// RemoteAddr is the address from which the canary application
+	// received the request.
+	RemoteAddr string `json:"remoteAddr"`
+	// Headers are the request headers in the order in which they were
+	// received, with names in the case in which they were received.
+	Headers []EchoedHeader `json:"headers"`
+}
+
+// EchoedHeader is a single request header that the canary application received.
+type EchoedHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// values returns the values of all echoed headers with the given name,
+// compared case-insensitively.
+func (e *RequestEcho) values(name string) []string {
+	var values []string
+	for _, header := range e.Headers {
+		if strings.EqualFold(header.Name, name) {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+// hasExactName returns a Boolean value indicating whether a header with exactly
+// the given name, including case, was echoed.
+func (e *RequestEcho) hasExactName(name string) bool {
+	for _, header := range e.Headers {
+		if header.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// headerCheck describes the headers that the canary sends to verify an
+// ingresscontroller's header policies.
+type headerCheck struct {
+	// request holds the request headers to send.  Keys are sent exactly
+	// as they appear in the map, without canonicalization.
+	request http.Header
+	// responseHeadersToEcho lists the response headers that the canary
+	// application is asked to set.
+	responseHeadersToEcho []string
+}
+
+// unadjustableHeaders are headers that the canary cannot send with arbitrary
+// values in order to check header name case adjustments.
+var unadjustableHeaders = map[string]bool{
+	"Host":              true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// headerActions returns the given ingresscontroller's request and response
+// header actions.
+func headerActions(ic *operatorv1.IngressController) ([]operatorv1.IngressControllerHTTPHeader, []operatorv1.IngressControllerHTTPHeader) {
+	if ic.Spec.HTTPHeaders == nil {
+		return nil, nil
+	}
+	return ic.Spec.HTTPHeaders.Actions.Request, ic.Spec.HTTPHeaders.Actions.Response
+}
+
+// hasRequestAction returns a Boolean value indicating whether the given
+// ingresscontroller has a request header action for the given header name.
+func hasRequestAction(ic *operatorv1.IngressController, name string) bool {
+	requestActions, _ := headerActions(ic)
+	for _, action := range requestActions {
+		if strings.EqualFold(action.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaticHeaderValue returns a Boolean value indicating whether the given
+// header action value is a literal value rather than one that HAProxy computes
+// per request.
+func isStaticHeaderValue(value string) bool {
+	return !strings.Contains(value, "%")
+}
+
+// desiredHeaderCheck returns the headers that the canary should send to verify
+// the given ingresscontroller's header policies.
+func desiredHeaderCheck(ic *operatorv1.IngressController) *headerCheck {
+	check := &headerCheck{request: http.Header{}}
+	check.request.Set("X-Forwarded-For", canaryForwardedForSentinel)
+
+	requestActions, responseActions := headerActions(ic)
+	for _, action := range requestActions {
+		if action.Action.Type == operatorv1.Delete {
+			check.request.Set(action.Name, canarySentHeaderValue)
+		}
+	}
+	for _, action := range responseActions {
+		if action.Action.Type == operatorv1.Delete {
+			check.responseHeadersToEcho = append(check.responseHeadersToEcho, action.Name)
+		}
+	}
+	if len(check.responseHeadersToEcho) != 0 {
+		check.request.Set(CanaryEchoResponseHeadersHeader, strings.Join(check.responseHeadersToEcho, ","))
+	}
+
+	if ic.Spec.HTTPHeaders != nil {
+		for _, adjustment := range ic.Spec.HTTPHeaders.HeaderNameCaseAdjustments {
+			name := string(adjustment)
+			if unadjustableHeaders[http.CanonicalHeaderKey(name)] || hasRequestAction(ic, name) {
+				continue
+			}
+			// Send the name in lower case, bypassing
+			// canonicalization, so that the adjustment is
+			// observable.
+			check.request[strings.ToLower(name)] = []string{canarySentHeaderValue}
+		}
+	}
+
+	return check
+}
+
+// forwardedForValues splits the given X-Forwarded-For header values into the
+// individual addresses that they list.
+func forwardedForValues(values []string) []string {
+	var addresses []string
+	for _, value := range values {
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); len(address) != 0 {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// headerPolicyViolations returns a list of human-readable descriptions of the
+// ways in which the request that the canary application received, and the
+// response headers that the canary received, do not match the given
+// ingresscontroller's header policies.  The second return value describes
+// whether the client's IP address was preserved, if it could be determined.
+func headerPolicyViolations(ic *operatorv1.IngressController, echo *RequestEcho, responseHeader http.Header, clientIP string) ([]string, string) {
+	var violations []string
+
+	policy := operatorv1.AppendHTTPHeaderPolicy
+	if ic.Spec.HTTPHeaders != nil && len(ic.Spec.HTTPHeaders.ForwardedHeaderPolicy) != 0 {
+		policy = ic.Spec.HTTPHeaders.ForwardedHeaderPolicy
+	}
+	forwardedFor := forwardedForValues(echo.values("X-Forwarded-For"))
+	if !hasRequestAction(ic, "X-Forwarded-For") {
+		switch policy {
+		case operatorv1.AppendHTTPHeaderPolicy:
+			if len(forwardedFor) < 2 || forwardedFor[0] != canaryForwardedForSentinel {
+				violations = append(violations, fmt.Sprintf("forwarded header policy %s: expected X-Forwarded-For to start with %s and have the client address appended, got %q", policy, canaryForwardedForSentinel, strings.Join(forwardedFor, ", ")))
+			}
+		case operatorv1.ReplaceHTTPHeaderPolicy:
+			if len(forwardedFor) != 1 || forwardedFor[0] == canaryForwardedForSentinel {
+				violations = append(violations, fmt.Sprintf("forwarded header policy %s: expected X-Forwarded-For to be replaced with the client address, got %q", policy, strings.Join(forwardedFor, ", ")))
+			}
+		case operatorv1.IfNoneHTTPHeaderPolicy, operatorv1.NeverHTTPHeaderPolicy:
+			if len(forwardedFor) != 1 || forwardedFor[0] != canaryForwardedForSentinel {
+				violations = append(violations, fmt.Sprintf("forwarded header policy %s: expected X-Forwarded-For to be passed through as %s, got %q", policy, canaryForwardedForSentinel, strings.Join(forwardedFor, ", ")))
+			}
+		}
+	}
+
+	requestActions, responseActions := headerActions(ic)
+	for _, action := range requestActions {
+		values := echo.values(action.Name)
+		switch action.Action.Type {
+		case operatorv1.Set:
+			if action.Action.Set == nil || !isStaticHeaderValue(action.Action.Set.Value) {
+				continue
+			}
+			if len(values) != 1 || values[0] != action.Action.Set.Value {
+				violations = append(violations, fmt.Sprintf("request header action: expected header %s to be set to %q, got %q", action.Name, action.Action.Set.Value, values))
+			}
+		case operatorv1.Delete:
+			if len(values) != 0 {
+				violations = append(violations, fmt.Sprintf("request header action: expected header %s to be deleted, got %q", action.Name, values))
+			}
+		}
+	}
+	for _, action := range responseActions {
+		values := responseHeader.Values(action.Name)
+		switch action.Action.Type {
+		case operatorv1.Set:
+			if action.Action.Set == nil || !isStaticHeaderValue(action.Action.Set.Value) {
+				continue
+			}
+			if len(values) != 1 || values[0] != action.Action.Set.Value {
+				violations = append(violations, fmt.Sprintf("response header action: expected header %s to be set to %q, got %q", action.Name, action.Action.Set.Value, values))
+			}
+		case operatorv1.Delete:
+			if len(values) != 0 {
+				violations = append(violations, fmt.Sprintf("response header action: expected header %s to be deleted, got %q", action.Name, values))
+			}
+		}
+	}
+
+	if ic.Spec.HTTPHeaders != nil {
+		if name := ic.Spec.HTTPHeaders.UniqueId.Name; len(name) != 0 && !hasRequestAction(ic, name) {
+			if values := echo.values(name); len(values) == 0 || len(values[0]) == 0 {
+				violations = append(violations, fmt.Sprintf("unique ID: expected header %s to be set", name))
+			}
+		}
+		for _, adjustment := range ic.Spec.HTTPHeaders.HeaderNameCaseAdjustments {
+			name := string(adjustment)
+			if unadjustableHeaders[http.CanonicalHeaderKey(name)] || hasRequestAction(ic, name) {
+				continue
+			}
+			if !echo.hasExactName(name) {
+				violations = append(violations, fmt.Sprintf("header name case adjustment: expected header name %s, got %q", name, echoedNames(echo, name)))
+			}
+		}
+	}
+
+	var clientIPStatus string
+	if len(clientIP) != 0 && (policy == operatorv1.AppendHTTPHeaderPolicy || policy == operatorv1.ReplaceHTTPHeaderPolicy) && len(forwardedFor) != 0 {
+		last := forwardedFor[len(forwardedFor)-1]
+		preserved := net.ParseIP(last).Equal(net.ParseIP(clientIP))
+		if preserved {
+			clientIPStatus = fmt.Sprintf("client IP %s is preserved", clientIP)
+		} else {
+			clientIPStatus = fmt.Sprintf("client IP %s is not preserved; the canary application saw %s", clientIP, last)
+		}
+		if expect, err := strconv.ParseBool(ic.Annotations[CanaryExpectClientIPPreservedAnnotation]); err == nil && expect != preserved {
+			violations = append(violations, fmt.Sprintf("client IP preservation: expected preserved=%t, but %s", expect, clientIPStatus))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations, clientIPStatus
+}
+
+// echoedNames returns the names of the echoed headers that match the given name
+// case-insensitively.
+func echoedNames(echo *RequestEcho, name string) []string {
+	var names []string
+	for _, header := range echo.Headers {
+		if strings.EqualFold(header.Name, name) {
+			names = append(names, header.Name)
+		}
+	}
+	return names
+}
+
+// headerPolicyCondition returns the canary header policy status condition for
+// the given violations and client IP status.
+func headerPolicyCondition(violations []string, clientIPStatus string) operatorv1.OperatorCondition {
+	cond := operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerCanaryHeaderPoliciesConditionType,
+	}
+	if len(violations) != 0 {
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "HeaderPolicyViolations"
+		cond.Message = "Canary requests do not match the configured header policies: " + strings.Join(violations, "; ")
+	} else {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "HeaderPoliciesHonored"
+		cond.Message = "Canary requests match the configured header policies"
+	}
+	if len(clientIPStatus) != 0 {
+		cond.Message += " (" + clientIPStatus + ")"
+	}
+	return cond
+}
+
+// checkCanaryHeaderPolicies sends a request through the canary route to the
+// canary application's request echo endpoint, verifies the echoed request and
+// the response against the default ingresscontroller's header policies, and
+// updates the ingresscontroller's header policy status condition.
+func (r *reconciler) checkCanaryHeaderPolicies(route *routev1.Route) {
+	ic := &operatorv1.IngressController{}
+	name := types.NamespacedName{Namespace: r.config.Namespace, Name: manifests.DefaultIngressControllerName}
+	if err := r.client.Get(context.TODO(), name, ic); err != nil {
+		log.Error(err, "failed to get ingresscontroller for canary header policy check", "ingresscontroller", name)
+		return
+	}
+
+	cond, err := probeHeaderPolicies(ic, route)
+	if err != nil {
+		log.Error(err, "error performing canary header policy check")
+		cond = operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerCanaryHeaderPoliciesConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "HeaderCheckFailed",
+			Message: fmt.Sprintf("Failed to check header policies using the canary route: %v", err),
+		}
+	}
+	if err := r.setCanaryStatusCondition(cond); err != nil {
+		log.Error(err, "error updating canary status condition")
+	}
+}
+
+// probeHeaderPolicies sends the header policy check request for the given
+// ingresscontroller through the given route and returns the resulting status
+// condition.
+func probeHeaderPolicies(ic *operatorv1.IngressController, route *routev1.Route) (operatorv1.OperatorCondition, error) {
+	routeHost := getRouteHost(route)
+	if len(routeHost) == 0 {
+		return operatorv1.OperatorCondition{}, fmt.Errorf("route host is empty, cannot check header policies")
+	}
+	check := desiredHeaderCheck(ic)
+	result, err := sendProbeRequestWithHeaders("https://"+routeHost+CanaryRequestEchoPath, "", check.request)
+	if err != nil {
+		return operatorv1.OperatorCondition{}, err
+	}
+	if result.statusCode != http.StatusOK {
+		return operatorv1.OperatorCondition{}, fmt.Errorf("unexpected status code: %d", result.statusCode)
+	}
+	echo := &RequestEcho{}
+	if err := json.Unmarshal([]byte(result.body), echo); err != nil {
+		return operatorv1.OperatorCondition{}, fmt.Errorf("failed to parse canary request echo: %w", err)
+	}
+	violations, clientIPStatus := headerPolicyViolations(ic, echo, result.header, result.localIP)
+	return headerPolicyCondition(violations, clientIPStatus), nil
+}
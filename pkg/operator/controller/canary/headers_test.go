@@ -0,0 +1,197 @@
+package canary
+
+import (
+	"net/http"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_desiredHeaderCheck(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		Spec: operatorv1.IngressControllerSpec{
+			HTTPHeaders: &operatorv1.IngressControllerHTTPHeaders{
+				HeaderNameCaseAdjustments: []operatorv1.IngressControllerHTTPHeaderNameCaseAdjustment{"X-Foo", "Host"},
+				Actions: operatorv1.IngressControllerHTTPHeaderActions{
+					Request: []operatorv1.IngressControllerHTTPHeader{{
+						Name:   "X-Delete-Me",
+						Action: operatorv1.IngressControllerHTTPHeaderActionUnion{Type: operatorv1.Delete},
+					}},
+					Response: []operatorv1.IngressControllerHTTPHeader{{
+						Name:   "X-Powered-By",
+						Action: operatorv1.IngressControllerHTTPHeaderActionUnion{Type: operatorv1.Delete},
+					}},
+				},
+			},
+		},
+	}
+	check := desiredHeaderCheck(ic)
+	if v := check.request.Get("X-Forwarded-For"); v != canaryForwardedForSentinel {
+		t.Errorf("expected X-Forwarded-For %q, got %q", canaryForwardedForSentinel, v)
+	}
+	if v := check.request.Get("X-Delete-Me"); v != canarySentHeaderValue {
+		t.Errorf("expected X-Delete-Me to be sent, got %q", v)
+	}
+	if v := check.request.Get(CanaryEchoResponseHeadersHeader); v != "X-Powered-By" {
+		t.Errorf("expected %s to request X-Powered-By, got %q", CanaryEchoResponseHeadersHeader, v)
+	}
+	if _, ok := check.request["x-foo"]; !ok {
+		t.Errorf("expected x-foo to be sent in lower case, got %v", check.request)
+	}
+	if _, ok := check.request["host"]; ok {
+		t.Errorf("expected host not to be sent, got %v", check.request)
+	}
+}
+
+func Test_headerPolicyViolations(t *testing.T) {
+	const clientIP = "10.128.0.5"
+	withPolicy := func(policy operatorv1.IngressControllerHTTPHeaderPolicy) *operatorv1.IngressController {
+		return &operatorv1.IngressController{
+			Spec: operatorv1.IngressControllerSpec{
+				HTTPHeaders: &operatorv1.IngressControllerHTTPHeaders{
+					ForwardedHeaderPolicy: policy,
+				},
+			},
+		}
+	}
+	echoWith := func(headers ...EchoedHeader) *RequestEcho {
+		return &RequestEcho{Headers: headers}
+	}
+	testCases := []struct {
+		description      string
+		ic               *operatorv1.IngressController
+		echo             *RequestEcho
+		response         http.Header
+		expectViolations int
+	}{
+		{
+			description: "append policy honored",
+			ic:          withPolicy(operatorv1.AppendHTTPHeaderPolicy),
+			echo:        echoWith(EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel + ", " + clientIP}),
+		},
+		{
+			description:      "append policy replaced instead",
+			ic:               withPolicy(operatorv1.AppendHTTPHeaderPolicy),
+			echo:             echoWith(EchoedHeader{"X-Forwarded-For", clientIP}),
+			expectViolations: 1,
+		},
+		{
+			// The router image does not support trusted proxies yet, so
+			// the forwarded header policy applies to all connections.
+			description: "append policy with trusted proxies",
+			ic: &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{ingresscontroller.ForwardedHeaderTrustedProxiesAnnotation: "192.0.2.0/24"},
+				},
+			},
+			echo: echoWith(EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel + ", " + clientIP}),
+		},
+		{
+			description: "replace policy honored",
+			ic:          withPolicy(operatorv1.ReplaceHTTPHeaderPolicy),
+			echo:        echoWith(EchoedHeader{"X-Forwarded-For", clientIP}),
+		},
+		{
+			description: "never policy honored",
+			ic:          withPolicy(operatorv1.NeverHTTPHeaderPolicy),
+			echo:        echoWith(EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel}),
+		},
+		{
+			description:      "never policy with appended address",
+			ic:               withPolicy(operatorv1.NeverHTTPHeaderPolicy),
+			echo:             echoWith(EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel}, EchoedHeader{"X-Forwarded-For", clientIP}),
+			expectViolations: 1,
+		},
+		{
+			description: "header actions, unique ID, and case adjustment honored",
+			ic: &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					HTTPHeaders: &operatorv1.IngressControllerHTTPHeaders{
+						UniqueId:                  operatorv1.IngressControllerHTTPUniqueIdHeaderPolicy{Name: "X-Request-Id"},
+						HeaderNameCaseAdjustments: []operatorv1.IngressControllerHTTPHeaderNameCaseAdjustment{"X-FOO"},
+						Actions: operatorv1.IngressControllerHTTPHeaderActions{
+							Request: []operatorv1.IngressControllerHTTPHeader{{
+								Name: "X-Set-Me",
+								Action: operatorv1.IngressControllerHTTPHeaderActionUnion{
+									Type: operatorv1.Set,
+									Set:  &operatorv1.IngressControllerSetHTTPHeader{Value: "value"},
+								},
+							}, {
+								Name:   "X-Delete-Me",
+								Action: operatorv1.IngressControllerHTTPHeaderActionUnion{Type: operatorv1.Delete},
+							}},
+							Response: []operatorv1.IngressControllerHTTPHeader{{
+								Name: "X-Frame-Options",
+								Action: operatorv1.IngressControllerHTTPHeaderActionUnion{
+									Type: operatorv1.Set,
+									Set:  &operatorv1.IngressControllerSetHTTPHeader{Value: "DENY"},
+								},
+							}},
+						},
+					},
+				},
+			},
+			echo: echoWith(
+				EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel + "," + clientIP},
+				EchoedHeader{"X-Set-Me", "value"},
+				EchoedHeader{"X-Request-Id", "abc"},
+				EchoedHeader{"X-FOO", canarySentHeaderValue},
+			),
+			response: http.Header{"X-Frame-Options": []string{"DENY"}},
+		},
+		{
+			description: "header actions, unique ID, and case adjustment violated",
+			ic: &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					HTTPHeaders: &operatorv1.IngressControllerHTTPHeaders{
+						UniqueId:                  operatorv1.IngressControllerHTTPUniqueIdHeaderPolicy{Name: "X-Request-Id"},
+						HeaderNameCaseAdjustments: []operatorv1.IngressControllerHTTPHeaderNameCaseAdjustment{"X-FOO"},
+						Actions: operatorv1.IngressControllerHTTPHeaderActions{
+							Request: []operatorv1.IngressControllerHTTPHeader{{
+								Name:   "X-Delete-Me",
+								Action: operatorv1.IngressControllerHTTPHeaderActionUnion{Type: operatorv1.Delete},
+							}},
+							Response: []operatorv1.IngressControllerHTTPHeader{{
+								Name:   "X-Powered-By",
+								Action: operatorv1.IngressControllerHTTPHeaderActionUnion{Type: operatorv1.Delete},
+							}},
+						},
+					},
+				},
+			},
+			echo: echoWith(
+				EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel + "," + clientIP},
+				EchoedHeader{"X-Delete-Me", canarySentHeaderValue},
+				EchoedHeader{"x-foo", canarySentHeaderValue},
+			),
+			response:         http.Header{"X-Powered-By": []string{CanaryEchoResponseHeaderValue}},
+			expectViolations: 4,
+		},
+		{
+			description: "client IP not preserved when preservation is expected",
+			ic: func() *operatorv1.IngressController {
+				ic := withPolicy(operatorv1.AppendHTTPHeaderPolicy)
+				ic.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{CanaryExpectClientIPPreservedAnnotation: "true"}}
+				return ic
+			}(),
+			echo:             echoWith(EchoedHeader{"X-Forwarded-For", canaryForwardedForSentinel + ", 10.0.0.1"}),
+			expectViolations: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			response := tc.response
+			if response == nil {
+				response = http.Header{}
+			}
+			violations, _ := headerPolicyViolations(tc.ic, tc.echo, response, clientIP)
+			if len(violations) != tc.expectViolations {
+				t.Errorf("expected %d violations, got %d: %v", tc.expectViolations, len(violations), violations)
+			}
+		})
+	}
+}
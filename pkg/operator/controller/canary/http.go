@@ -1,12 +1,14 @@
 package canary
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"strings"
 	"time"
@@ -20,6 +22,22 @@ const (
 	echoServerPortAckHeader = "x-request-port"
 )
 
+// probeResult holds the parts of a probe response that the canary checks
+// inspect.
+type probeResult struct {
+	// statusCode is the HTTP status code of the response.
+	statusCode int
+	// header is the set of response headers.
+	header http.Header
+	// body is the response body.
+	body string
+	// latency is the total time taken by the request.
+	latency time.Duration
+	// localIP is the IP address from which the request was sent, or the
+	// empty string if the request was sent through a proxy.
+	localIP string
+}
+
 // probeRouteEndpoint probes the given route's host
 // and returns an error when applicable.
 func probeRouteEndpoint(route *routev1.Route) error {
@@ -28,42 +46,142 @@ func probeRouteEndpoint(route *routev1.Route) error {
 		return fmt.Errorf("route host is empty, cannot test route")
 	}
 
-	// Create HTTP request
 	// Use https now that the canary route uses edge termination.
 	// Some clusters that expose the default ingress controller
 	// via an external load balancer drop all traffic on port 80,
 	// in which case redirecting insecure traffic is not possible.
 	// See https://bugzilla.redhat.com/show_bug.cgi?id=1934773.
-	request, err := http.NewRequest("GET", "https://"+routeHost, nil)
+	result, err := sendProbeRequest("https://"+routeHost, "")
 	if err != nil {
-		return fmt.Errorf("error creating canary HTTP request %v: %v", request, err)
+		return err
+	}
+	body := result.body
+
+	// Verify body contents
+	if len(body) == 0 {
+		return fmt.Errorf("expected canary response body to not be empty")
+	}
+
+	if !strings.Contains(body, CanaryHealthcheckResponse) {
+		return fmt.Errorf("expected canary request body to contain %q", CanaryHealthcheckResponse)
+	}
+
+	// Verify that the request was received on the correct port
+	recPort := result.header.Get(echoServerPortAckHeader)
+	if len(recPort) == 0 {
+		return fmt.Errorf("expected %q header in canary response to have a nonempty value", echoServerPortAckHeader)
+	}
+	routePortStr := route.Spec.Port.TargetPort.String()
+	if routePortStr != recPort {
+		// router wedged, register in metrics counter
+		CanaryEndpointWrongPortEcho.Inc()
+		return fmt.Errorf("canary request received on port %s, but route specifies %v", recPort, routePortStr)
+	}
+
+	// Check status code
+	switch status := result.statusCode; status {
+	case http.StatusOK:
+		// Register total time in metrics (use milliseconds)
+		CanaryRequestTime.WithLabelValues(routeHost).Observe(float64(result.latency.Milliseconds()))
+	case http.StatusRequestTimeout:
+		return fmt.Errorf("status code %d: request timed out", status)
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("status code %d: Canary route not available via router", status)
+	case http.StatusBadGateway:
+		return fmt.Errorf("status code %d: bad gateway", status)
+	case http.StatusInternalServerError:
+		return fmt.Errorf("status code %d: server error", status)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("status code %d: too many requests", status)
+	default:
+		return fmt.Errorf("unexpected status code: %d", status)
+	}
+
+	return nil
+}
+
+// sendProbeRequest sends a GET request for the given URL and returns the
+// response.  If dialAddress is non-empty, the connection is made to that
+// address (in "host:port" form) instead of the address that the URL's host
+// resolves to, while the URL's host is still used for the Host header and
+// for SNI.  This makes it possible to send identical requests through
+// different ingresscontrollers.
+func sendProbeRequest(url, dialAddress string) (*probeResult, error) {
+	return sendProbeRequestWithHeaders(url, dialAddress, nil)
+}
+
+// sendProbeRequestWithHeaders is like sendProbeRequest but also sends the given
+// request headers.  Header names are sent exactly as they appear in the map.
+func sendProbeRequestWithHeaders(url, dialAddress string, header http.Header) (*probeResult, error) {
+	// Create HTTP request
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating canary HTTP request %v: %v", request, err)
+	}
+	host := request.URL.Hostname()
+	for name, values := range header {
+		request.Header[name] = values
 	}
 
 	// Create HTTP result
 	// for request stats tracking.
 	result := &httpstat.Result{}
 
-	// Get request context
+	// Get request context, and record the local address of the
+	// connection so that client IP preservation can be checked.
+	var localIP string
 	ctx := httpstat.WithHTTPStat(request.Context(), result)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if addr, ok := info.Conn.LocalAddr().(*net.TCPAddr); ok {
+				localIP = addr.IP.String()
+			}
+		},
+	})
 	request = request.WithContext(ctx)
 
-	// Send the HTTP request
-	timeout, _ := time.ParseDuration("10s")
-	client := &http.Client{
-		Timeout: timeout,
+	transport := &http.Transport{
+		// Use the cluster-wide proxy if it is available in the
+		// pod's environment.
+		Proxy: http.ProxyFromEnvironment,
 		// The canary route uses edge termination and the
 		// default router certificate may be self signed, so
 		// skip certificate verification here. See
 		// https://bugzilla.redhat.com/show_bug.cgi?id=1932401.
 		// TODO: Add the router's certificate to the HTTP client
 		// so we can enable TLS verification.
-		Transport: &http.Transport{
-			// Use the cluster-wide proxy if it is available in the
-			// pod's environment.
-			Proxy:             http.ProxyFromEnvironment,
-			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
-			DisableKeepAlives: true, // BZ#2037447
-		},
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true, // BZ#2037447
+	}
+	if len(dialAddress) != 0 {
+		// A proxy would make its own connection to the URL's
+		// host, so bypass it when dialing a specific address.
+		transport.Proxy = nil
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, dialAddress)
+		}
+	}
+
+	// Send the HTTP request
+	timeout, _ := time.ParseDuration("10s")
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	if len(dialAddress) != 0 {
+		// A redirect could point to a different host, which would
+		// still be dialed at dialAddress, so return the redirect
+		// response itself instead of following it.
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	proxied := false
+	if transport.Proxy != nil {
+		if proxyURL, err := transport.Proxy(request); err == nil && proxyURL != nil {
+			proxied = true
+		}
 	}
 	response, err := client.Do(request)
 
@@ -72,15 +190,15 @@ func probeRouteEndpoint(route *routev1.Route) error {
 		dnsErr := &net.DNSError{}
 		if errors.As(err, &dnsErr) {
 			// Handle DNS error
-			CanaryRouteDNSError.WithLabelValues(routeHost, dnsErr.Server).Inc()
-			return fmt.Errorf("error sending canary HTTP request: DNS error: %v", err)
+			CanaryRouteDNSError.WithLabelValues(host, dnsErr.Server).Inc()
+			return nil, fmt.Errorf("error sending canary HTTP request: DNS error: %v", err)
 		}
 		// Check if err is a timeout error
 		if os.IsTimeout(err) {
 			// Handle timeout error
-			return fmt.Errorf("error sending canary HTTP Request: Timeout: %v", err)
+			return nil, fmt.Errorf("error sending canary HTTP Request: Timeout: %v", err)
 		}
-		return fmt.Errorf("error sending canary HTTP request to %q: %v", routeHost, err)
+		return nil, fmt.Errorf("error sending canary HTTP request to %q: %v", host, err)
 	}
 
 	// Close response body even if read fails
@@ -89,53 +207,23 @@ func probeRouteEndpoint(route *routev1.Route) error {
 	// Read response body
 	bodyBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return fmt.Errorf("error reading canary response body: %v", err)
+		return nil, fmt.Errorf("error reading canary response body: %v", err)
 	}
-	body := string(bodyBytes)
 	t := time.Now()
 	// Mark request as finished
 	result.End(t)
-	totalTime := result.Total(t)
 
-	// Verify body contents
-	if len(body) == 0 {
-		return fmt.Errorf("expected canary response body to not be empty")
+	// The proxy's address, not ours, is the client address that the
+	// router sees.
+	if proxied {
+		localIP = ""
 	}
 
-	if !strings.Contains(body, CanaryHealthcheckResponse) {
-		return fmt.Errorf("expected canary request body to contain %q", CanaryHealthcheckResponse)
-	}
-
-	// Verify that the request was received on the correct port
-	recPort := response.Header.Get(echoServerPortAckHeader)
-	if len(recPort) == 0 {
-		return fmt.Errorf("expected %q header in canary response to have a nonempty value", echoServerPortAckHeader)
-	}
-	routePortStr := route.Spec.Port.TargetPort.String()
-	if routePortStr != recPort {
-		// router wedged, register in metrics counter
-		CanaryEndpointWrongPortEcho.Inc()
-		return fmt.Errorf("canary request received on port %s, but route specifies %v", recPort, routePortStr)
-	}
-
-	// Check status code
-	switch status := response.StatusCode; status {
-	case http.StatusOK:
-		// Register total time in metrics (use milliseconds)
-		CanaryRequestTime.WithLabelValues(routeHost).Observe(float64(totalTime.Milliseconds()))
-	case http.StatusRequestTimeout:
-		return fmt.Errorf("status code %d: request timed out", status)
-	case http.StatusServiceUnavailable:
-		return fmt.Errorf("status code %d: Canary route not available via router", status)
-	case http.StatusBadGateway:
-		return fmt.Errorf("status code %d: bad gateway", status)
-	case http.StatusInternalServerError:
-		return fmt.Errorf("status code %d: server error", status)
-	case http.StatusTooManyRequests:
-		return fmt.Errorf("status code %d: too many requests", status)
-	default:
-		return fmt.Errorf("unexpected status code: %d", status)
-	}
-
-	return nil
+	return &probeResult{
+		statusCode: response.StatusCode,
+		header:     response.Header,
+		body:       string(bodyBytes),
+		latency:    result.Total(t),
+		localIP:    localIP,
+	}, nil
 }
@@ -30,6 +30,19 @@ var (
 			Help: "A counter tracking canary route DNS lookup errors",
 		}, []string{"host", "dnsServer"})
 
+	CanaryRoutePropagationTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ingress_canary_route_propagation_seconds",
+			Help:    "Time in seconds from a change to the canary route until a router pod serves the change",
+			Buckets: []float64{1, 2, 5, 10, 15, 30, 60, 120},
+		}, []string{"ingresscontroller"})
+
+	ComparisonMismatchedRoutes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ingress_canary_comparison_mismatched_routes",
+			Help: "The number of routes for which the last comparison probe found a difference between an ingresscontroller and its reference ingresscontroller",
+		}, []string{"ingresscontroller"})
+
 	// Populate prometheus collector.
 	// Individual metrics are stored as public variables
 	// so that metrics can be globally controlled.
@@ -38,6 +51,8 @@ var (
 		CanaryEndpointWrongPortEcho,
 		CanaryRouteReachable,
 		CanaryRouteDNSError,
+		CanaryRoutePropagationTime,
+		ComparisonMismatchedRoutes,
 	}
 )
 
@@ -0,0 +1,248 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CanaryRoutePropagationBoundAnnotation is an annotation on an
+	// ingresscontroller that specifies how long, as a duration string, each
+	// of the ingresscontroller's router pods may take to serve a change to
+	// the canary route before the canary controller reports that route
+	// propagation is slow.  Route propagation is measured for each
+	// ingresscontroller that admits the canary route whenever the canary
+	// route is rotated, which happens at least every
+	// routePropagationMeasurementCycleCount canary checks.
+	CanaryRoutePropagationBoundAnnotation = "ingress.operator.openshift.io/canary-route-propagation-bound"
+
+	// defaultRoutePropagationBound is the propagation bound that is used if
+	// CanaryRoutePropagationBoundAnnotation is not set.
+	defaultRoutePropagationBound = 30 * time.Second
+	// routePropagationPollInterval is how often each router pod is probed
+	// while waiting for it to serve a change to the canary route.
+	routePropagationPollInterval = 1 * time.Second
+	// routePropagationMeasurementCycleCount is how many successful canary
+	// checks should be observed before rotating the canary endpoint in
+	// order to measure route propagation when canary route rotation is not
+	// enabled.
+	routePropagationMeasurementCycleCount = 30
+	// routePropagationTimeout is how long to wait for a router pod to serve
+	// a change to the canary route before giving up on the measurement.
+	routePropagationTimeout = 2 * time.Minute
+)
+
+// routePropagationBound returns the route propagation bound for the given
+// ingresscontroller.
+func routePropagationBound(ic *operatorv1.IngressController) (time.Duration, error) {
+	val, ok := ic.Annotations[CanaryRoutePropagationBoundAnnotation]
+	if !ok {
+		return defaultRoutePropagationBound, nil
+	}
+	bound, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for annotation %s: %w", CanaryRoutePropagationBoundAnnotation, err)
+	}
+	if bound <= 0 {
+		return 0, fmt.Errorf("invalid value for annotation %s: %q is not a positive duration", CanaryRoutePropagationBoundAnnotation, val)
+	}
+	return bound, nil
+}
+
+// measureRoutePropagation measures how long the router pods of each
+// ingresscontroller that has admitted the given canary route, which was changed
+// at the given time, take to serve the change.  Each ingresscontroller is
+// measured concurrently so that a late start does not inflate its latencies.
+func (r *reconciler) measureRoutePropagation(route *routev1.Route, changed time.Time) {
+	if route.Spec.Port == nil {
+		log.Info("canary route has no port; skipping route propagation measurement")
+		return
+	}
+	expectedPort := route.Spec.Port.TargetPort.String()
+
+	ingresscontrollers := &operatorv1.IngressControllerList{}
+	if err := r.client.List(context.TODO(), ingresscontrollers, client.InNamespace(r.config.Namespace)); err != nil {
+		log.Error(err, "failed to list ingresscontrollers for route propagation measurement")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range ingresscontrollers.Items {
+		ic := &ingresscontrollers.Items[i]
+		routeHost := routeHostForIngressController(route, ic.Name)
+		if len(routeHost) == 0 || ic.DeletionTimestamp != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.measureIngressControllerRoutePropagation(ic, routeHost, expectedPort, changed)
+		}()
+	}
+	wg.Wait()
+}
+
+// measureIngressControllerRoutePropagation measures how long each of the given
+// ingresscontroller's router pods takes to serve the canary route, which was
+// changed at the given time to use the given port, records each measurement in
+// the CanaryRoutePropagationTime metric, and updates the ingresscontroller's
+// status condition for route propagation.
+func (r *reconciler) measureIngressControllerRoutePropagation(ic *operatorv1.IngressController, routeHost, expectedPort string, changed time.Time) {
+	bound, err := routePropagationBound(ic)
+	if err != nil {
+		log.Error(err, "failed to get route propagation bound; using the default", "ingresscontroller", ic.Name, "default", defaultRoutePropagationBound)
+		bound = defaultRoutePropagationBound
+	}
+
+	pods, err := r.currentRouterPods(ic)
+	if err != nil {
+		log.Error(err, "failed to list router pods for route propagation measurement", "ingresscontroller", ic.Name)
+		return
+	}
+	if len(pods) == 0 {
+		log.Info("no ready router pods; skipping route propagation measurement", "ingresscontroller", ic.Name)
+		return
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies = map[string]time.Duration{}
+	)
+	for i := range pods {
+		pod := pods[i]
+		address := routerPodProbeAddress(&pod)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, ok := waitForRoutePort(routeHost, address, expectedPort, changed)
+			if !ok {
+				log.Info("router pod did not serve the updated canary route in time", "ingresscontroller", ic.Name, "pod", pod.Name, "timeout", routePropagationTimeout)
+				return
+			}
+			CanaryRoutePropagationTime.WithLabelValues(ic.Name).Observe(latency.Seconds())
+			mu.Lock()
+			latencies[pod.Name] = latency
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var unserved []string
+	for _, pod := range pods {
+		if _, ok := latencies[pod.Name]; !ok {
+			unserved = append(unserved, pod.Name)
+		}
+	}
+	log.Info("measured canary route propagation", "ingresscontroller", ic.Name, "latencies", latencies, "unserved", unserved)
+
+	cond := routePropagationCondition(bound, latencies, unserved)
+	if err := r.setIngressControllerStatusCondition(ic.Name, cond); err != nil {
+		log.Error(err, "error updating route propagation status condition", "ingresscontroller", ic.Name)
+	}
+}
+
+// currentRouterPods returns the given ingresscontroller's router pods that are
+// running and have an IP address.
+func (r *reconciler) currentRouterPods(ic *operatorv1.IngressController) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(operatorcontroller.IngressControllerDeploymentPodSelector(ic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build pod selector: %w", err)
+	}
+	podList := &corev1.PodList{}
+	if err := r.client.List(context.TODO(), podList, client.InNamespace(operatorcontroller.DefaultOperandNamespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %q: %w", operatorcontroller.DefaultOperandNamespace, err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || len(pod.Status.PodIP) == 0 {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// routerPodProbeAddress returns the address, in "host:port" form, on which the
+// given router pod accepts HTTPS connections.
+func routerPodProbeAddress(pod *corev1.Pod) string {
+	port := int32(443)
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == ingresscontroller.HTTPSPortName {
+				port = containerPort.ContainerPort
+			}
+		}
+	}
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port)))
+}
+
+// waitForRoutePort probes the canary route's host through the router at the
+// given address until the canary application reports that it received the
+// request on the expected port, and returns the time elapsed since the route
+// was changed.  The second return value is false if the router did not serve
+// the expected port within routePropagationTimeout.
+func waitForRoutePort(routeHost, address, expectedPort string, changed time.Time) (time.Duration, bool) {
+	deadline := changed.Add(routePropagationTimeout)
+	for {
+		result, err := sendProbeRequest("https://"+routeHost, address)
+		now := time.Now()
+		if err == nil && result.header.Get(echoServerPortAckHeader) == expectedPort {
+			return now.Sub(changed), true
+		}
+		if now.Add(routePropagationPollInterval).After(deadline) {
+			return 0, false
+		}
+		time.Sleep(routePropagationPollInterval)
+	}
+}
+
+// routePropagationCondition returns the canary route propagation status
+// condition for the given bound, per-pod propagation latencies, and pods that
+// did not serve the change at all.
+func routePropagationCondition(bound time.Duration, latencies map[string]time.Duration, unserved []string) operatorv1.OperatorCondition {
+	var slow []string
+	for pod, latency := range latencies {
+		if latency > bound {
+			slow = append(slow, fmt.Sprintf("%s (%v)", pod, latency.Round(time.Millisecond)))
+		}
+	}
+	sort.Strings(slow)
+	unserved = append([]string{}, unserved...)
+	sort.Strings(unserved)
+	for _, pod := range unserved {
+		slow = append(slow, fmt.Sprintf("%s (not served within %v)", pod, routePropagationTimeout))
+	}
+
+	if len(slow) != 0 {
+		return operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerCanaryRoutePropagationConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "RoutePropagationExceededBound",
+			Message: fmt.Sprintf("Router pods took longer than %v to serve a change to the canary route: %s", bound, strings.Join(slow, ", ")),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerCanaryRoutePropagationConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "RoutePropagationWithinBound",
+		Message: fmt.Sprintf("All router pods served the last change to the canary route within %v", bound),
+	}
+}
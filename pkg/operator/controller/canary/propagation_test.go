@@ -0,0 +1,222 @@
+package canary
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_routePropagationBound(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expected    time.Duration
+		expectError bool
+	}{
+		{
+			description: "no annotation",
+			expected:    defaultRoutePropagationBound,
+		},
+		{
+			description: "valid duration",
+			annotations: map[string]string{CanaryRoutePropagationBoundAnnotation: "10s"},
+			expected:    10 * time.Second,
+		},
+		{
+			description: "invalid duration",
+			annotations: map[string]string{CanaryRoutePropagationBoundAnnotation: "soon"},
+			expectError: true,
+		},
+		{
+			description: "negative duration",
+			annotations: map[string]string{CanaryRoutePropagationBoundAnnotation: "-5s"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+			}
+			bound, err := routePropagationBound(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected error, got nil")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if bound != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, bound)
+			}
+		})
+	}
+}
+
+func Test_routePropagationCondition(t *testing.T) {
+	testCases := []struct {
+		description    string
+		latencies      map[string]time.Duration
+		unserved       []string
+		expectedStatus operatorv1.ConditionStatus
+	}{
+		{
+			description:    "all pods within bound",
+			latencies:      map[string]time.Duration{"router-a": 2 * time.Second, "router-b": 5 * time.Second},
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+		{
+			description:    "one pod exceeds bound",
+			latencies:      map[string]time.Duration{"router-a": 2 * time.Second, "router-b": 12 * time.Second},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			description:    "one pod never served the change",
+			latencies:      map[string]time.Duration{"router-a": 2 * time.Second},
+			unserved:       []string{"router-b"},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cond := routePropagationCondition(10*time.Second, tc.latencies, tc.unserved)
+			if cond.Status != tc.expectedStatus {
+				t.Errorf("expected status %s, got %s: %s", tc.expectedStatus, cond.Status, cond.Message)
+			}
+		})
+	}
+}
+
+func Test_routerPodProbeAddress(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Ports: []corev1.ContainerPort{
+					{Name: "http", ContainerPort: 8080},
+					{Name: "https", ContainerPort: 8443},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{PodIP: "fd00::1"},
+	}
+	if address := routerPodProbeAddress(pod); address != "[fd00::1]:8443" {
+		t.Errorf("expected %q, got %q", "[fd00::1]:8443", address)
+	}
+
+	pod.Spec.Containers[0].Ports = nil
+	pod.Status.PodIP = "10.0.0.1"
+	if address := routerPodProbeAddress(pod); address != "10.0.0.1:443" {
+		t.Errorf("expected %q, got %q", "10.0.0.1:443", address)
+	}
+}
+
+// Test_waitForRoutePort verifies that waitForRoutePort returns once the router
+// at the given address serves the expected port.
+func Test_waitForRoutePort(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(echoServerPortAckHeader, "8888")
+	}))
+	defer server.Close()
+
+	changed := time.Now()
+	latency, ok := waitForRoutePort("canary.example.invalid", server.Listener.Addr().String(), "8888", changed)
+	if !ok {
+		t.Fatal("expected the route port to be served")
+	}
+	if latency <= 0 || latency > routePropagationTimeout {
+		t.Errorf("unexpected latency %v", latency)
+	}
+}
+
+// Test_measureRoutePropagation verifies that route propagation is measured for
+// each ingresscontroller that has admitted the canary route, and only for
+// those ingresscontrollers.
+func Test_measureRoutePropagation(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(echoServerPortAckHeader, "8888")
+	}))
+	defer server.Close()
+	_, serverPort, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(serverPort)
+
+	namespace := "openshift-ingress-operator"
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, name := range []string{"default", "sharded", "unadmitted"} {
+		ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "router-" + name,
+				Namespace: operatorcontroller.DefaultOperandNamespace,
+				Labels:    operatorcontroller.IngressControllerDeploymentPodSelector(ic).MatchLabels,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Ports: []corev1.ContainerPort{{Name: ingresscontroller.HTTPSPortName, ContainerPort: int32(port)}},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "127.0.0.1"},
+		}
+		builder = builder.WithObjects(ic, pod).WithStatusSubresource(ic)
+	}
+	cl := builder.Build()
+	r := &reconciler{config: Config{Namespace: namespace}, client: cl}
+
+	route := &routev1.Route{
+		Spec: routev1.RouteSpec{
+			Port: &routev1.RoutePort{TargetPort: intstr.FromInt(8888)},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{
+				{RouterName: "default", Host: "canary.apps.example.com"},
+				{RouterName: "sharded", Host: "canary.shard.example.com"},
+			},
+		},
+	}
+	r.measureRoutePropagation(route, time.Now())
+
+	for name, expectCondition := range map[string]bool{"default": true, "sharded": true, "unadmitted": false} {
+		ic := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, ic); err != nil {
+			t.Fatal(err)
+		}
+		var cond *operatorv1.OperatorCondition
+		for i := range ic.Status.Conditions {
+			if ic.Status.Conditions[i].Type == ingresscontroller.IngressControllerCanaryRoutePropagationConditionType {
+				cond = &ic.Status.Conditions[i]
+			}
+		}
+		switch {
+		case expectCondition && cond == nil:
+			t.Errorf("expected ingresscontroller %s to have a route propagation condition", name)
+		case expectCondition && cond.Status != operatorv1.ConditionTrue:
+			t.Errorf("expected ingresscontroller %s route propagation condition to be True, got %s: %s", name, cond.Status, cond.Message)
+		case !expectCondition && cond != nil:
+			t.Errorf("expected ingresscontroller %s to have no route propagation condition, got %v", name, *cond)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package canary
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// proxyProtocolProbeHostPrefix is the label that is prepended to an
+	// ingresscontroller's domain to form the host name that is used to
+	// probe the ingresscontroller's published endpoint.  The host name
+	// need not correspond to any route; any HTTP response from a router
+	// shows that the connection was framed correctly.
+	proxyProtocolProbeHostPrefix = "proxy-protocol-probe"
+	// proxyProtocolProbeTimeout is the timeout for each PROXY protocol
+	// probe.
+	proxyProtocolProbeTimeout = 10 * time.Second
+	// proxyProtocolHeader is the PROXY protocol version 1 header that the
+	// probes send.  The "UNKNOWN" form tells the receiver to use the
+	// connection's own addresses.
+	proxyProtocolHeader = "PROXY UNKNOWN\r\n"
+)
+
+// proxyProbeOutcome classifies the result of sending a single HTTP request
+// with or without a PROXY protocol header.
+type proxyProbeOutcome string
+
+const (
+	// proxyProbeResponded means that the router returned an HTTP response,
+	// which shows that the PROXY protocol framing was as expected.
+	proxyProbeResponded proxyProbeOutcome = "Responded"
+	// proxyProbeBadRequest means that the router returned a 400 response,
+	// which is how HAProxy responds when it receives a PROXY protocol
+	// header that it does not expect.
+	proxyProbeBadRequest proxyProbeOutcome = "BadRequest"
+	// proxyProbeNoResponse means that the connection was established but
+	// closed without a response, which is how HAProxy responds when it
+	// expects a PROXY protocol header and does not receive one.
+	proxyProbeNoResponse proxyProbeOutcome = "NoResponse"
+	// proxyProbeUnreachable means that no connection could be
+	// established, so nothing can be inferred.
+	proxyProbeUnreachable proxyProbeOutcome = "Unreachable"
+)
+
+// proxyProtocolProbes holds the outcomes of the four PROXY protocol probes for
+// one port of an ingresscontroller.
+type proxyProtocolProbes struct {
+	// internalPlain and internalProxy are the outcomes through the
+	// ingresscontroller's internal service, which reaches the routers
+	// directly.
+	internalPlain proxyProbeOutcome
+	internalProxy proxyProbeOutcome
+	// publishedPlain and publishedProxy are the outcomes through the
+	// ingresscontroller's published endpoint, which external clients use.
+	publishedPlain proxyProbeOutcome
+	publishedProxy proxyProbeOutcome
+}
+
+// proxyProtocolProbeApplies returns a Boolean value indicating whether the given
+// ingresscontroller uses an endpoint publishing strategy for which the
+// administrator may put an external load balancer in front of the routers and
+// must configure PROXY protocol to match.
+func proxyProtocolProbeApplies(ic *operatorv1.IngressController) bool {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || len(ic.Status.Domain) == 0 {
+		return false
+	}
+	switch eps.Type {
+	case operatorv1.HostNetworkStrategyType, operatorv1.NodePortServiceStrategyType:
+		return true
+	}
+	return false
+}
+
+// startProxyProtocolPolling periodically probes the HTTP and HTTPS ports of
+// each HostNetwork and NodePortService ingresscontroller with and without a
+// PROXY protocol header and updates the ingresscontroller's PROXY protocol
+// status condition.  The condition is removed from ingresscontrollers with
+// other endpoint publishing strategies.
+func (r *reconciler) startProxyProtocolPolling(stop <-chan struct{}) {
+	go wait.Until(func() {
+		ingresscontrollers := &operatorv1.IngressControllerList{}
+		if err := r.client.List(context.TODO(), ingresscontrollers, client.InNamespace(r.config.Namespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers for PROXY protocol probes")
+			return
+		}
+		for i := range ingresscontrollers.Items {
+			ic := &ingresscontrollers.Items[i]
+			if !proxyProtocolProbeApplies(ic) {
+				if err := r.removeIngressControllerStatusCondition(ic, ingresscontroller.IngressControllerProxyProtocolConsistentConditionType); err != nil {
+					log.Error(err, "error removing PROXY protocol status condition", "ingresscontroller", ic.Name)
+				}
+				continue
+			}
+			httpProbes, httpsProbes, err := r.probeProxyProtocol(ic)
+			if err != nil {
+				log.Error(err, "failed to probe PROXY protocol", "ingresscontroller", ic.Name)
+				continue
+			}
+			cond := combinedProxyProtocolCondition(httpProbes, httpsProbes)
+			if cond.Status == operatorv1.ConditionFalse {
+				log.Info("detected PROXY protocol mismatch", "ingresscontroller", ic.Name, "reason", cond.Reason)
+			}
+			if err := r.setIngressControllerStatusCondition(ic.Name, cond); err != nil {
+				log.Error(err, "error updating PROXY protocol status condition", "ingresscontroller", ic.Name)
+			}
+		}
+	}, canaryCheckFrequency, stop)
+}
+
+// probeProxyProtocol sends requests with and without a PROXY protocol header
+// through the given ingresscontroller's internal service and published
+// endpoint, to the HTTP port and to the HTTPS port, and returns the outcomes
+// for each port.  A load balancer can be configured to send PROXY protocol on
+// one port but not the other, so both ports are probed.
+func (r *reconciler) probeProxyProtocol(ic *operatorv1.IngressController) (*proxyProtocolProbes, *proxyProtocolProbes, error) {
+	service := &corev1.Service{}
+	if err := r.client.Get(context.TODO(), operatorcontroller.InternalIngressControllerServiceName(ic), service); err != nil {
+		return nil, nil, fmt.Errorf("failed to get internal service for ingresscontroller %s: %w", ic.Name, err)
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return nil, nil, fmt.Errorf("internal service for ingresscontroller %s has no cluster IP", ic.Name)
+	}
+	host := proxyProtocolProbeHostPrefix + "." + ic.Status.Domain
+	probePort := func(port string, useTLS bool) *proxyProtocolProbes {
+		internalAddress := net.JoinHostPort(service.Spec.ClusterIP, port)
+		publishedAddress := net.JoinHostPort(host, port)
+		return &proxyProtocolProbes{
+			internalPlain:  sendProxyProtocolProbe(internalAddress, host, false, useTLS),
+			internalProxy:  sendProxyProtocolProbe(internalAddress, host, true, useTLS),
+			publishedPlain: sendProxyProtocolProbe(publishedAddress, host, false, useTLS),
+			publishedProxy: sendProxyProtocolProbe(publishedAddress, host, true, useTLS),
+		}
+	}
+
+	return probePort("80", false), probePort("443", true), nil
+}
+
+// sendProxyProtocolProbe connects to the given address, optionally sends a
+// PROXY protocol header, optionally performs a TLS handshake, sends an HTTP
+// request for the given host, and classifies the result.  A TLS handshake that
+// fails is classified as no response, which is how HAProxy responds on its
+// HTTPS port both when it expects a PROXY protocol header and does not receive
+// one and when it receives one that it does not expect.
+func sendProxyProtocolProbe(address, host string, sendProxyHeader, useTLS bool) proxyProbeOutcome {
+	var conn net.Conn
+	conn, err := net.DialTimeout("tcp", address, proxyProtocolProbeTimeout)
+	if err != nil {
+		return proxyProbeUnreachable
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(proxyProtocolProbeTimeout)); err != nil {
+		return proxyProbeUnreachable
+	}
+
+	if sendProxyHeader {
+		if _, err := io.WriteString(conn, proxyProtocolHeader); err != nil {
+			return proxyProbeNoResponse
+		}
+	}
+	if useTLS {
+		// The probe only checks the framing of the connection, not
+		// the router's certificate.
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+		if err := tlsConn.Handshake(); err != nil {
+			return proxyProbeNoResponse
+		}
+		conn = tlsConn
+	}
+	request := fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nUser-Agent: ingress-operator-proxy-protocol-probe\r\nConnection: close\r\n\r\n", host)
+	if _, err := io.WriteString(conn, request); err != nil {
+		return proxyProbeNoResponse
+	}
+
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return proxyProbeNoResponse
+	}
+	response.Body.Close()
+	if response.StatusCode == http.StatusBadRequest {
+		return proxyProbeBadRequest
+	}
+	return proxyProbeResponded
+}
+
+// proxyProtocolCondition returns the PROXY protocol status condition for the
+// given probe outcomes.  The internal probes determine whether the routers
+// expect PROXY protocol, and the published probes determine whether whatever
+// is in front of the routers agrees.
+func proxyProtocolCondition(probes *proxyProtocolProbes) operatorv1.OperatorCondition {
+	cond := operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerProxyProtocolConsistentConditionType,
+	}
+	outcomes := fmt.Sprintf("internal without PROXY: %s, internal with PROXY: %s, published without PROXY: %s, published with PROXY: %s", probes.internalPlain, probes.internalProxy, probes.publishedPlain, probes.publishedProxy)
+
+	var routersExpectProxy bool
+	switch {
+	case probes.internalPlain == proxyProbeResponded && probes.internalProxy != proxyProbeResponded:
+		routersExpectProxy = false
+	case probes.internalProxy == proxyProbeResponded && probes.internalPlain != proxyProbeResponded:
+		routersExpectProxy = true
+	default:
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "RouterProbesInconclusive"
+		cond.Message = fmt.Sprintf("Unable to determine whether the routers expect PROXY protocol (%s)", outcomes)
+		return cond
+	}
+
+	switch {
+	case probes.publishedPlain == proxyProbeResponded:
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "ProxyProtocolConsistent"
+		if routersExpectProxy {
+			cond.Message = "The routers expect PROXY protocol and the load balancer in front of the published endpoint sends it"
+		} else {
+			cond.Message = "The routers do not expect PROXY protocol and the published endpoint does not send it"
+		}
+	case routersExpectProxy && probes.publishedPlain == proxyProbeNoResponse && probes.publishedProxy == proxyProbeResponded:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "LoadBalancerNotSendingProxyProtocol"
+		cond.Message = "The load balancer in front of the published endpoint does not send PROXY protocol, but the ingresscontroller's endpoint publishing strategy specifies the PROXY protocol; enable PROXY protocol on the load balancer or set the ingresscontroller's protocol to TCP"
+	case !routersExpectProxy && probes.publishedPlain == proxyProbeBadRequest:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "IngressControllerNotAcceptingProxyProtocol"
+		cond.Message = "The load balancer in front of the published endpoint sends PROXY protocol, but the ingresscontroller's endpoint publishing strategy does not specify the PROXY protocol; set the ingresscontroller's protocol to PROXY or disable PROXY protocol on the load balancer"
+	default:
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "PublishedEndpointProbesInconclusive"
+		cond.Message = fmt.Sprintf("Unable to determine whether the published endpoint matches the routers' PROXY protocol setting (%s)", outcomes)
+	}
+	return cond
+}
+
+// combinedProxyProtocolCondition returns the PROXY protocol status condition for
+// the given probe outcomes for the HTTP and HTTPS ports.  A mismatch on either
+// port makes the condition false, and otherwise an inconclusive result on
+// either port makes it unknown.
+func combinedProxyProtocolCondition(httpProbes, httpsProbes *proxyProtocolProbes) operatorv1.OperatorCondition {
+	httpCond := proxyProtocolCondition(httpProbes)
+	httpsCond := proxyProtocolCondition(httpsProbes)
+	for _, status := range []operatorv1.ConditionStatus{operatorv1.ConditionFalse, operatorv1.ConditionUnknown} {
+		for _, port := range []struct {
+			name string
+			cond operatorv1.OperatorCondition
+		}{{"HTTP", httpCond}, {"HTTPS", httpsCond}} {
+			if port.cond.Status == status {
+				cond := port.cond
+				cond.Message = fmt.Sprintf("%s port: %s", port.name, cond.Message)
+				return cond
+			}
+		}
+	}
+	return httpCond
+}
+
+// removeIngressControllerStatusCondition removes the status condition of the
+// given type from the given ingresscontroller if it has one.
+func (r *reconciler) removeIngressControllerStatusCondition(ic *operatorv1.IngressController, conditionType string) error {
+	var conditions []operatorv1.OperatorCondition
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type != conditionType {
+			conditions = append(conditions, cond)
+		}
+	}
+	if len(conditions) == len(ic.Status.Conditions) {
+		return nil
+	}
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = conditions
+	if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %v", ic.Name, err)
+	}
+	return nil
+}
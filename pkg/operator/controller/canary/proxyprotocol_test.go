@@ -0,0 +1,268 @@
+package canary
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_proxyProtocolCondition(t *testing.T) {
+	testCases := []struct {
+		description    string
+		probes         proxyProtocolProbes
+		expectedStatus operatorv1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			description:    "neither side uses PROXY protocol",
+			probes:         proxyProtocolProbes{proxyProbeResponded, proxyProbeBadRequest, proxyProbeResponded, proxyProbeBadRequest},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: "ProxyProtocolConsistent",
+		},
+		{
+			description:    "both sides use PROXY protocol",
+			probes:         proxyProtocolProbes{proxyProbeNoResponse, proxyProbeResponded, proxyProbeResponded, proxyProbeBadRequest},
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: "ProxyProtocolConsistent",
+		},
+		{
+			description:    "routers expect PROXY protocol but the load balancer does not send it",
+			probes:         proxyProtocolProbes{proxyProbeNoResponse, proxyProbeResponded, proxyProbeNoResponse, proxyProbeResponded},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: "LoadBalancerNotSendingProxyProtocol",
+		},
+		{
+			description:    "the load balancer sends PROXY protocol but routers do not expect it",
+			probes:         proxyProtocolProbes{proxyProbeResponded, proxyProbeBadRequest, proxyProbeBadRequest, proxyProbeBadRequest},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: "IngressControllerNotAcceptingProxyProtocol",
+		},
+		{
+			description:    "published endpoint is unreachable",
+			probes:         proxyProtocolProbes{proxyProbeResponded, proxyProbeBadRequest, proxyProbeUnreachable, proxyProbeUnreachable},
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: "PublishedEndpointProbesInconclusive",
+		},
+		{
+			description:    "internal service is unreachable",
+			probes:         proxyProtocolProbes{proxyProbeUnreachable, proxyProbeUnreachable, proxyProbeResponded, proxyProbeBadRequest},
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: "RouterProbesInconclusive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cond := proxyProtocolCondition(&tc.probes)
+			if cond.Status != tc.expectedStatus || cond.Reason != tc.expectedReason {
+				t.Errorf("expected status %s and reason %s, got status %s and reason %s: %s", tc.expectedStatus, tc.expectedReason, cond.Status, cond.Reason, cond.Message)
+			}
+		})
+	}
+}
+
+func Test_combinedProxyProtocolCondition(t *testing.T) {
+	consistent := proxyProtocolProbes{proxyProbeResponded, proxyProbeBadRequest, proxyProbeResponded, proxyProbeBadRequest}
+	mismatched := proxyProtocolProbes{proxyProbeNoResponse, proxyProbeResponded, proxyProbeNoResponse, proxyProbeResponded}
+	inconclusive := proxyProtocolProbes{proxyProbeResponded, proxyProbeBadRequest, proxyProbeUnreachable, proxyProbeUnreachable}
+	testCases := []struct {
+		description    string
+		http, https    proxyProtocolProbes
+		expectedStatus operatorv1.ConditionStatus
+		expectedPrefix string
+	}{
+		{"both ports consistent", consistent, consistent, operatorv1.ConditionTrue, ""},
+		{"HTTPS port mismatched", consistent, mismatched, operatorv1.ConditionFalse, "HTTPS port: "},
+		{"HTTP port mismatched and HTTPS port inconclusive", mismatched, inconclusive, operatorv1.ConditionFalse, "HTTP port: "},
+		{"HTTPS port inconclusive", consistent, inconclusive, operatorv1.ConditionUnknown, "HTTPS port: "},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cond := combinedProxyProtocolCondition(&tc.http, &tc.https)
+			if cond.Status != tc.expectedStatus || !strings.HasPrefix(cond.Message, tc.expectedPrefix) {
+				t.Errorf("expected status %s and message prefix %q, got status %s and message %q", tc.expectedStatus, tc.expectedPrefix, cond.Status, cond.Message)
+			}
+		})
+	}
+}
+
+// Test_removeIngressControllerStatusCondition verifies that the PROXY protocol
+// status condition is removed from an ingresscontroller whose endpoint
+// publishing strategy the probes do not cover.
+func Test_removeIngressControllerStatusCondition(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"},
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.example.com",
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+			},
+			Conditions: []operatorv1.OperatorCondition{
+				{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionTrue},
+				{Type: ingresscontroller.IngressControllerProxyProtocolConsistentConditionType, Status: operatorv1.ConditionTrue},
+			},
+		},
+	}
+	if proxyProtocolProbeApplies(ic) {
+		t.Fatal("expected PROXY protocol probes not to apply to a LoadBalancerService ingresscontroller")
+	}
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ic).WithStatusSubresource(ic).Build()
+	r := &reconciler{client: cl}
+	if err := r.removeIngressControllerStatusCondition(ic, ingresscontroller.IngressControllerProxyProtocolConsistentConditionType); err != nil {
+		t.Fatal(err)
+	}
+	updated := &operatorv1.IngressController{}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Status.Conditions) != 1 || updated.Status.Conditions[0].Type != operatorv1.OperatorStatusTypeAvailable {
+		t.Errorf("expected only the Available condition to remain, got %v", updated.Status.Conditions)
+	}
+}
+
+// fakeRouter listens on a local port and responds to connections the way
+// HAProxy does depending on whether it expects a PROXY protocol header.  It
+// returns the listener's address.
+func fakeRouter(t *testing.T, expectProxy bool) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				hasProxy := strings.HasPrefix(line, "PROXY ")
+				switch {
+				case expectProxy && !hasProxy:
+					return
+				case !expectProxy && hasProxy:
+					conn.Write([]byte("HTTP/1.1 400 Bad request\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+				default:
+					conn.Write([]byte("HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// fakeTLSRouter listens on a local port and terminates TLS the way HAProxy's
+// HTTPS port does depending on whether it expects a PROXY protocol header: it
+// closes the connection if the framing is not what it expects.  It returns the
+// listener's address.
+func fakeTLSRouter(t *testing.T, expectProxy bool) string {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	config := &tls.Config{Certificates: server.TLS.Certificates}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				prefix, err := reader.Peek(len("PROXY "))
+				if err != nil {
+					return
+				}
+				hasProxy := string(prefix) == "PROXY "
+				if hasProxy != expectProxy {
+					return
+				}
+				if hasProxy {
+					if _, err := reader.ReadString('\n'); err != nil {
+						return
+					}
+				}
+				tlsConn := tls.Server(&bufferedConn{Conn: conn, reader: reader}, config)
+				request, err := http.ReadRequest(bufio.NewReader(tlsConn))
+				if err != nil {
+					return
+				}
+				request.Body.Close()
+				tlsConn.Write([]byte("HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// bufferedConn is a net.Conn that reads through a bufio.Reader that may have
+// buffered data from the connection.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func Test_sendProxyProtocolProbe(t *testing.T) {
+	plainRouter := fakeRouter(t, false)
+	proxyRouter := fakeRouter(t, true)
+	plainTLSRouter := fakeTLSRouter(t, false)
+	proxyTLSRouter := fakeTLSRouter(t, true)
+
+	testCases := []struct {
+		description     string
+		address         string
+		sendProxyHeader bool
+		useTLS          bool
+		expected        proxyProbeOutcome
+	}{
+		{"plain request to a router without PROXY protocol", plainRouter, false, false, proxyProbeResponded},
+		{"PROXY request to a router without PROXY protocol", plainRouter, true, false, proxyProbeBadRequest},
+		{"plain request to a router with PROXY protocol", proxyRouter, false, false, proxyProbeNoResponse},
+		{"PROXY request to a router with PROXY protocol", proxyRouter, true, false, proxyProbeResponded},
+		{"plain TLS request to a router without PROXY protocol", plainTLSRouter, false, true, proxyProbeResponded},
+		{"PROXY TLS request to a router without PROXY protocol", plainTLSRouter, true, true, proxyProbeNoResponse},
+		{"plain TLS request to a router with PROXY protocol", proxyTLSRouter, false, true, proxyProbeNoResponse},
+		{"PROXY TLS request to a router with PROXY protocol", proxyTLSRouter, true, true, proxyProbeResponded},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if outcome := sendProxyProtocolProbe(tc.address, "example.com", tc.sendProxyHeader, tc.useTLS); outcome != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, outcome)
+			}
+		})
+	}
+}
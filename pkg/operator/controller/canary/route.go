@@ -95,12 +95,25 @@ func (r *reconciler) deleteCanaryRoute(route *routev1.Route) (bool, error) {
 	return true, nil
 }
 
+// canaryRouteAdjustCaseAnnotation is the route annotation that enables the
+// ingresscontroller's header name case adjustments for the canary route so that
+// the canary can verify them.
+const canaryRouteAdjustCaseAnnotation = "haproxy.router.openshift.io/h1-adjust-case"
+
 // canaryRouteChanged returns true if current and expected differ by Spec.Port,
-// Spec.To, or Spec.TLS.
+// Spec.To, Spec.TLS, or the h1-adjust-case annotation.
 func canaryRouteChanged(current, expected *routev1.Route) (bool, *routev1.Route) {
 	changed := false
 	updated := current.DeepCopy()
 
+	if expectedVal, ok := expected.Annotations[canaryRouteAdjustCaseAnnotation]; ok && current.Annotations[canaryRouteAdjustCaseAnnotation] != expectedVal {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[canaryRouteAdjustCaseAnnotation] = expectedVal
+		changed = true
+	}
+
 	if current.Spec.Host != expected.Spec.Host {
 		updated.Spec.Host = expected.Spec.Host
 		changed = true
@@ -37,7 +37,8 @@ func Test_desiredCanaryRoute(t *testing.T) {
 	assert.Equal(t, route.Spec.Subdomain, "canary-openshift-ingress-canary", "unexpected route spec.subdomain")
 
 	expectedAnnotations := map[string]string{
-		"haproxy.router.openshift.io/balance": "roundrobin",
+		"haproxy.router.openshift.io/balance":        "roundrobin",
+		"haproxy.router.openshift.io/h1-adjust-case": "true",
 	}
 	assert.Equal(t, route.Annotations, expectedAnnotations, "unexpected route annotations")
 
@@ -116,6 +117,20 @@ func Test_canaryRouteChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if the h1-adjust-case annotation changes",
+			mutate: func(route *routev1.Route) {
+				route.Annotations[canaryRouteAdjustCaseAnnotation] = "false"
+			},
+			expect: true,
+		},
+		{
+			description: "if an unrelated annotation is added",
+			mutate: func(route *routev1.Route) {
+				route.Annotations["example.com/foo"] = "bar"
+			},
+			expect: false,
+		},
 	}
 
 	daemonsetRef := metav1.OwnerReference{
@@ -0,0 +1,520 @@
+package canary
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// tlsConformanceProbeHostPrefix is the label that is prepended to an
+	// ingresscontroller's domain to form the server name that the TLS
+	// conformance probes send.  The name need not correspond to any
+	// route; the routers negotiate TLS for unknown names using the default
+	// certificate and the ingresscontroller's TLS profile.
+	tlsConformanceProbeHostPrefix = "tls-conformance-probe"
+	// tlsConformanceProbeInterval is how often the TLS conformance probes
+	// run.  Each run performs one handshake for each TLS version and one
+	// for each known cipher, so the probes run less often than the canary
+	// check.
+	tlsConformanceProbeInterval = 10 * time.Minute
+	// tlsConformanceProbeTimeout is the timeout for each TLS handshake
+	// probe.
+	tlsConformanceProbeTimeout = 10 * time.Second
+)
+
+// tlsProbeCipher describes a cipher that the TLS conformance probes test.
+type tlsProbeCipher struct {
+	// name is the OpenSSL name of the cipher, which is how TLS profiles
+	// specify ciphers.
+	name string
+	// id is the IANA identifier of the cipher.
+	id uint16
+	// tls13 indicates that the cipher is a TLS 1.3 ciphersuite.
+	tls13 bool
+	// auth is the type of certificate key that the cipher requires, or
+	// empty if the cipher does not depend on the certificate.
+	auth string
+}
+
+// tlsProbeCiphers is the list of ciphers that the TLS conformance probes test.
+// It comprises every cipher in the predefined TLS profiles.
+var tlsProbeCiphers = []tlsProbeCipher{
+	{name: "TLS_AES_128_GCM_SHA256", id: 0x1301, tls13: true},
+	{name: "TLS_AES_256_GCM_SHA384", id: 0x1302, tls13: true},
+	{name: "TLS_CHACHA20_POLY1305_SHA256", id: 0x1303, tls13: true},
+	{name: "ECDHE-ECDSA-AES128-GCM-SHA256", id: 0xc02b, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES128-GCM-SHA256", id: 0xc02f, auth: "RSA"},
+	{name: "ECDHE-ECDSA-AES256-GCM-SHA384", id: 0xc02c, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES256-GCM-SHA384", id: 0xc030, auth: "RSA"},
+	{name: "ECDHE-ECDSA-CHACHA20-POLY1305", id: 0xcca9, auth: "ECDSA"},
+	{name: "ECDHE-RSA-CHACHA20-POLY1305", id: 0xcca8, auth: "RSA"},
+	{name: "DHE-RSA-AES128-GCM-SHA256", id: 0x009e, auth: "RSA"},
+	{name: "DHE-RSA-AES256-GCM-SHA384", id: 0x009f, auth: "RSA"},
+	{name: "DHE-RSA-CHACHA20-POLY1305", id: 0xccaa, auth: "RSA"},
+	{name: "ECDHE-ECDSA-AES128-SHA256", id: 0xc023, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES128-SHA256", id: 0xc027, auth: "RSA"},
+	{name: "ECDHE-ECDSA-AES128-SHA", id: 0xc009, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES128-SHA", id: 0xc013, auth: "RSA"},
+	{name: "ECDHE-ECDSA-AES256-SHA384", id: 0xc024, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES256-SHA384", id: 0xc028, auth: "RSA"},
+	{name: "ECDHE-ECDSA-AES256-SHA", id: 0xc00a, auth: "ECDSA"},
+	{name: "ECDHE-RSA-AES256-SHA", id: 0xc014, auth: "RSA"},
+	{name: "DHE-RSA-AES128-SHA256", id: 0x0067, auth: "RSA"},
+	{name: "DHE-RSA-AES256-SHA256", id: 0x006b, auth: "RSA"},
+	{name: "AES128-GCM-SHA256", id: 0x009c, auth: "RSA"},
+	{name: "AES256-GCM-SHA384", id: 0x009d, auth: "RSA"},
+	{name: "AES128-SHA256", id: 0x003c, auth: "RSA"},
+	{name: "AES256-SHA256", id: 0x003d, auth: "RSA"},
+	{name: "AES128-SHA", id: 0x002f, auth: "RSA"},
+	{name: "AES256-SHA", id: 0x0035, auth: "RSA"},
+	{name: "DES-CBC3-SHA", id: 0x000a, auth: "RSA"},
+}
+
+// tlsProbeVersion describes a TLS version that the TLS conformance probes
+// test.
+type tlsProbeVersion struct {
+	// profileName is how TLS profiles specify the version.
+	profileName configv1.TLSProtocolVersion
+	// id is the version's protocol identifier.
+	id uint16
+}
+
+// tlsProbeVersions is the list of TLS versions that the TLS conformance probes
+// test, in ascending order.
+var tlsProbeVersions = []tlsProbeVersion{
+	{profileName: configv1.VersionTLS10, id: 0x0301},
+	{profileName: configv1.VersionTLS11, id: 0x0302},
+	{profileName: configv1.VersionTLS12, id: 0x0303},
+	{profileName: configv1.VersionTLS13, id: 0x0304},
+}
+
+// tlsHandshakeOutcome classifies the result of a single TLS handshake probe.
+type tlsHandshakeOutcome string
+
+const (
+	// tlsHandshakeAccepted means that the router selected the offered
+	// version and cipher.
+	tlsHandshakeAccepted tlsHandshakeOutcome = "Accepted"
+	// tlsHandshakeRejected means that the router sent an alert, closed
+	// the connection, or selected something other than what was offered.
+	tlsHandshakeRejected tlsHandshakeOutcome = "Rejected"
+	// tlsHandshakeUnreachable means that no connection could be
+	// established, so nothing can be inferred.
+	tlsHandshakeUnreachable tlsHandshakeOutcome = "Unreachable"
+)
+
+// tlsConformanceResults holds the outcomes of the TLS conformance probes for an
+// ingresscontroller.
+type tlsConformanceResults struct {
+	// versions maps each TLS version to the outcome of a handshake that
+	// offers only that version and every cipher that is valid for it.
+	versions map[configv1.TLSProtocolVersion]tlsHandshakeOutcome
+	// ciphers maps the OpenSSL name of each cipher to the outcome of a
+	// handshake that offers only that cipher, using TLS 1.3 for TLS 1.3
+	// ciphersuites and TLS 1.2 otherwise.  Ciphers that are not tested
+	// because the profile does not permit the version are omitted.
+	ciphers map[string]tlsHandshakeOutcome
+}
+
+// startTLSConformancePolling periodically performs TLS handshakes against each
+// ingresscontroller with every TLS version and cipher and updates the
+// ingresscontroller's TLS profile conformance status condition and metric.
+func (r *reconciler) startTLSConformancePolling(stop <-chan struct{}) {
+	go wait.Until(func() {
+		ingresscontrollers := &operatorv1.IngressControllerList{}
+		if err := r.client.List(context.TODO(), ingresscontrollers, client.InNamespace(r.config.Namespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers for TLS conformance probes")
+			return
+		}
+		infraConfig := &configv1.Infrastructure{}
+		if err := r.client.Get(context.TODO(), operatorcontroller.InfrastructureClusterConfigName(), infraConfig); err != nil {
+			log.Error(err, "failed to get infrastructure config for TLS conformance probes")
+			return
+		}
+		for i := range ingresscontrollers.Items {
+			ic := &ingresscontrollers.Items[i]
+			if !tlsConformanceProbeApplies(ic) {
+				continue
+			}
+			results, err := r.probeTLSConformance(ic, infraConfig.Status.PlatformStatus)
+			if err != nil {
+				log.Error(err, "failed to probe TLS conformance", "ingresscontroller", ic.Name)
+				continue
+			}
+			cond, deviations := tlsConformanceCondition(ic.Status.TLSProfile, results)
+			if cond.Status != operatorv1.ConditionUnknown {
+				ingresscontroller.SetTLSProfileDeviationsMetric(ic, len(deviations))
+			}
+			if cond.Status == operatorv1.ConditionFalse {
+				log.Info("detected TLS profile deviations", "ingresscontroller", ic.Name, "deviations", deviations)
+			}
+			if err := r.setIngressControllerStatusCondition(ic.Name, cond); err != nil {
+				log.Error(err, "error updating TLS conformance status condition", "ingresscontroller", ic.Name)
+			}
+		}
+	}, tlsConformanceProbeInterval, stop)
+}
+
+// tlsConformanceProbeApplies returns a Boolean value indicating whether the
+// given ingresscontroller can be probed: it must not be marked for deletion,
+// it must report the TLS profile that it
+// has configured, and it must not be rolling out a change, which could make the
+// routers' behavior temporarily inconsistent.
+func tlsConformanceProbeApplies(ic *operatorv1.IngressController) bool {
+	if ic.DeletionTimestamp != nil {
+		return false
+	}
+	if ic.Status.TLSProfile == nil || len(ic.Status.Domain) == 0 || ic.Status.EndpointPublishingStrategy == nil {
+		return false
+	}
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type == operatorv1.OperatorStatusTypeProgressing && cond.Status == operatorv1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
+// probeTLSConformance performs TLS handshakes against the given
+// ingresscontroller's routers through its internal service and returns the
+// outcomes.
+func (r *reconciler) probeTLSConformance(ic *operatorv1.IngressController, platform *configv1.PlatformStatus) (*tlsConformanceResults, error) {
+	service := &corev1.Service{}
+	if err := r.client.Get(context.TODO(), operatorcontroller.InternalIngressControllerServiceName(ic), service); err != nil {
+		return nil, fmt.Errorf("failed to get internal service for ingresscontroller %s: %w", ic.Name, err)
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return nil, fmt.Errorf("internal service for ingresscontroller %s has no cluster IP", ic.Name)
+	}
+	proxyProtocol, err := ingresscontroller.IsProxyProtocolNeeded(ic, platform)
+	if err != nil {
+		return nil, err
+	}
+	address := net.JoinHostPort(service.Spec.ClusterIP, "443")
+	serverName := tlsConformanceProbeHostPrefix + "." + ic.Status.Domain
+	return probeTLS(address, serverName, proxyProtocol, tlsProfileVersion(ic.Status.TLSProfile)), nil
+}
+
+// probeTLS performs the TLS conformance handshakes against the given address.
+// Individual ciphers are only tested with versions that the given minimum
+// version permits, as otherwise every handshake would be rejected regardless
+// of the cipher.
+func probeTLS(address, serverName string, proxyProtocol bool, minVersion uint16) *tlsConformanceResults {
+	results := &tlsConformanceResults{
+		versions: map[configv1.TLSProtocolVersion]tlsHandshakeOutcome{},
+		ciphers:  map[string]tlsHandshakeOutcome{},
+	}
+	for _, version := range tlsProbeVersions {
+		var ids []uint16
+		for _, cipher := range tlsProbeCiphers {
+			if cipher.tls13 == (version.id == 0x0304) {
+				ids = append(ids, cipher.id)
+			}
+		}
+		results.versions[version.profileName] = sendTLSHandshakeProbe(address, serverName, proxyProtocol, version.id, ids)
+	}
+	for _, cipher := range tlsProbeCiphers {
+		version := uint16(0x0303)
+		if cipher.tls13 {
+			version = 0x0304
+		}
+		if version < minVersion {
+			continue
+		}
+		results.ciphers[cipher.name] = sendTLSHandshakeProbe(address, serverName, proxyProtocol, version, []uint16{cipher.id})
+	}
+	return results
+}
+
+// tlsProfileVersion returns the protocol identifier of the given profile's
+// minimum TLS version.
+func tlsProfileVersion(profile *configv1.TLSProfileSpec) uint16 {
+	for _, version := range tlsProbeVersions {
+		if version.profileName == profile.MinTLSVersion {
+			return version.id
+		}
+	}
+	return tlsProbeVersions[0].id
+}
+
+// sendTLSHandshakeProbe connects to the given address, optionally sends a PROXY
+// protocol header, sends a ClientHello that offers only the given TLS version
+// and ciphers, and classifies the server's response.  The handshake is not
+// completed; the server's choice of version and cipher in its ServerHello is
+// all that the probe needs.  A hand-built ClientHello is used because
+// crypto/tls cannot offer individual TLS 1.3 ciphersuites or ciphers that it
+// does not implement.
+func sendTLSHandshakeProbe(address, serverName string, proxyProtocol bool, version uint16, ciphers []uint16) tlsHandshakeOutcome {
+	hello, err := buildClientHello(serverName, version, ciphers)
+	if err != nil {
+		return tlsHandshakeUnreachable
+	}
+	conn, err := net.DialTimeout("tcp", address, tlsConformanceProbeTimeout)
+	if err != nil {
+		return tlsHandshakeUnreachable
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(tlsConformanceProbeTimeout)); err != nil {
+		return tlsHandshakeUnreachable
+	}
+	if proxyProtocol {
+		hello = append([]byte(proxyProtocolHeader), hello...)
+	}
+	if _, err := conn.Write(hello); err != nil {
+		return tlsHandshakeRejected
+	}
+
+	selectedVersion, selectedCipher, err := readServerHello(conn)
+	if err != nil {
+		return tlsHandshakeRejected
+	}
+	if selectedVersion != version {
+		return tlsHandshakeRejected
+	}
+	for _, cipher := range ciphers {
+		if cipher == selectedCipher {
+			return tlsHandshakeAccepted
+		}
+	}
+	return tlsHandshakeRejected
+}
+
+// buildClientHello returns a TLS record containing a ClientHello that offers
+// only the given version and ciphers.  The version is pinned using the
+// supported_versions extension, which servers that implement TLS 1.3 honor for
+// every version.
+func buildClientHello(serverName string, version uint16, ciphers []uint16) ([]byte, error) {
+	random := make([]byte, 32)
+	sessionID := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(sessionID); err != nil {
+		return nil, err
+	}
+
+	var extensions []byte
+	addExtension := func(extensionType uint16, data []byte) {
+		extensions = binary.BigEndian.AppendUint16(extensions, extensionType)
+		extensions = binary.BigEndian.AppendUint16(extensions, uint16(len(data)))
+		extensions = append(extensions, data...)
+	}
+	// server_name
+	sni := []byte{0}
+	sni = binary.BigEndian.AppendUint16(sni, uint16(len(serverName)))
+	sni = append(sni, serverName...)
+	addExtension(0x0000, append(binary.BigEndian.AppendUint16(nil, uint16(len(sni))), sni...))
+	// supported_groups: x25519, secp256r1, secp384r1
+	addExtension(0x000a, []byte{0x00, 0x06, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18})
+	// ec_point_formats: uncompressed
+	addExtension(0x000b, []byte{0x01, 0x00})
+	// signature_algorithms
+	signatureAlgorithms := []uint16{0x0403, 0x0503, 0x0804, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0203, 0x0201}
+	sigalgs := binary.BigEndian.AppendUint16(nil, uint16(2*len(signatureAlgorithms)))
+	for _, alg := range signatureAlgorithms {
+		sigalgs = binary.BigEndian.AppendUint16(sigalgs, alg)
+	}
+	addExtension(0x000d, sigalgs)
+	// extended_master_secret
+	addExtension(0x0017, nil)
+	// supported_versions
+	addExtension(0x002b, binary.BigEndian.AppendUint16([]byte{0x02}, version))
+	if version >= 0x0304 {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		share := binary.BigEndian.AppendUint16(nil, 0x001d)
+		share = binary.BigEndian.AppendUint16(share, uint16(len(key.PublicKey().Bytes())))
+		share = append(share, key.PublicKey().Bytes()...)
+		// key_share
+		addExtension(0x0033, append(binary.BigEndian.AppendUint16(nil, uint16(len(share))), share...))
+	}
+	// renegotiation_info
+	addExtension(0xff01, []byte{0x00})
+
+	legacyVersion := version
+	if legacyVersion > 0x0303 {
+		legacyVersion = 0x0303
+	}
+	body := binary.BigEndian.AppendUint16(nil, legacyVersion)
+	body = append(body, random...)
+	body = append(body, byte(len(sessionID)))
+	body = append(body, sessionID...)
+	body = binary.BigEndian.AppendUint16(body, uint16(2*len(ciphers)))
+	for _, cipher := range ciphers {
+		body = binary.BigEndian.AppendUint16(body, cipher)
+	}
+	body = append(body, 0x01, 0x00) // null compression
+	body = binary.BigEndian.AppendUint16(body, uint16(len(extensions)))
+	body = append(body, extensions...)
+
+	handshake := []byte{0x01, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	handshake = append(handshake, body...)
+
+	record := []byte{0x16, 0x03, 0x01}
+	record = binary.BigEndian.AppendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...), nil
+}
+
+// readServerHello reads the first TLS record from r and, if it is a
+// ServerHello, returns the version and cipher that the server selected.  An
+// alert or anything else is returned as an error.
+func readServerHello(r io.Reader) (uint16, uint16, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, err
+	}
+	length := binary.BigEndian.Uint16(header[3:5])
+	fragment := make([]byte, length)
+	if _, err := io.ReadFull(r, fragment); err != nil {
+		return 0, 0, err
+	}
+	switch header[0] {
+	case 0x15:
+		if len(fragment) == 2 {
+			return 0, 0, fmt.Errorf("received TLS alert %d", fragment[1])
+		}
+		return 0, 0, fmt.Errorf("received TLS alert")
+	case 0x16:
+	default:
+		return 0, 0, fmt.Errorf("unexpected TLS record type %d", header[0])
+	}
+
+	// Handshake message header, legacy version, and random.
+	if len(fragment) < 4+2+32+1 || fragment[0] != 0x02 {
+		return 0, 0, fmt.Errorf("expected ServerHello")
+	}
+	version := binary.BigEndian.Uint16(fragment[4:6])
+	p := fragment[4+2+32:]
+	sessionIDLength := int(p[0])
+	p = p[1:]
+	if len(p) < sessionIDLength+3 {
+		return 0, 0, fmt.Errorf("truncated ServerHello")
+	}
+	p = p[sessionIDLength:]
+	cipher := binary.BigEndian.Uint16(p[0:2])
+	p = p[3:]
+	if len(p) >= 2 {
+		extensionsLength := int(binary.BigEndian.Uint16(p[0:2]))
+		p = p[2:]
+		if len(p) < extensionsLength {
+			return 0, 0, fmt.Errorf("truncated ServerHello extensions")
+		}
+		p = p[:extensionsLength]
+		for len(p) >= 4 {
+			extensionType := binary.BigEndian.Uint16(p[0:2])
+			extensionLength := int(binary.BigEndian.Uint16(p[2:4]))
+			if len(p) < 4+extensionLength {
+				return 0, 0, fmt.Errorf("truncated ServerHello extension")
+			}
+			if extensionType == 0x002b && extensionLength == 2 {
+				version = binary.BigEndian.Uint16(p[4:6])
+			}
+			p = p[4+extensionLength:]
+		}
+	}
+	return version, cipher, nil
+}
+
+// tlsConformanceCondition returns the TLS profile conformance status condition
+// for the given profile and probe results, along with a description of each
+// deviation.  A cipher in the profile that the routers reject is only a
+// deviation if the routers accept some other cipher in the profile that
+// requires the same type of certificate key; otherwise the default
+// certificate's key type explains the rejection.
+func tlsConformanceCondition(profile *configv1.TLSProfileSpec, results *tlsConformanceResults) (operatorv1.OperatorCondition, []string) {
+	cond := operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerTLSProfileConformantConditionType,
+	}
+	minVersion := tlsProfileVersion(profile)
+	inProfile := map[string]bool{}
+	for _, cipher := range profile.Ciphers {
+		inProfile[cipher] = true
+	}
+
+	var deviations []string
+	unreachable := 0
+	for _, version := range tlsProbeVersions {
+		outcome := results.versions[version.profileName]
+		switch {
+		case outcome == tlsHandshakeUnreachable:
+			unreachable++
+		case outcome == tlsHandshakeAccepted && version.id < minVersion:
+			deviations = append(deviations, fmt.Sprintf("%s is accepted but the minimum version is %s", version.profileName, profile.MinTLSVersion))
+		case outcome == tlsHandshakeRejected && version.id >= minVersion && tlsProfileHasCipherForVersion(profile, version.id):
+			deviations = append(deviations, fmt.Sprintf("%s is rejected but the minimum version is %s", version.profileName, profile.MinTLSVersion))
+		}
+	}
+
+	acceptedAuth := map[string]bool{}
+	for _, cipher := range tlsProbeCiphers {
+		if results.ciphers[cipher.name] == tlsHandshakeAccepted && inProfile[cipher.name] {
+			acceptedAuth[cipher.auth] = true
+		}
+	}
+	for _, cipher := range tlsProbeCiphers {
+		outcome, tested := results.ciphers[cipher.name]
+		switch {
+		case !tested:
+		case outcome == tlsHandshakeUnreachable:
+			unreachable++
+		case outcome == tlsHandshakeAccepted && !inProfile[cipher.name]:
+			deviations = append(deviations, fmt.Sprintf("cipher %s is accepted but is not in the profile", cipher.name))
+		case outcome == tlsHandshakeRejected && inProfile[cipher.name] && (cipher.tls13 || acceptedAuth[cipher.auth]):
+			deviations = append(deviations, fmt.Sprintf("cipher %s is in the profile but is rejected", cipher.name))
+		}
+	}
+	sort.Strings(deviations)
+
+	switch {
+	case len(deviations) != 0:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "DeviationsDetected"
+		cond.Message = fmt.Sprintf("The routers' TLS configuration does not match the TLS profile: %s", strings.Join(deviations, "; "))
+	case unreachable != 0:
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "ProbesInconclusive"
+		cond.Message = fmt.Sprintf("%d TLS handshake probes could not connect to the routers", unreachable)
+	default:
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "Conformant"
+		cond.Message = "The routers negotiate TLS versions and ciphers as specified by the TLS profile"
+	}
+	return cond, deviations
+}
+
+// tlsProfileHasCipherForVersion returns a Boolean value indicating whether the
+// given profile specifies any cipher that can be used with the given version.
+// HAProxy configures TLS 1.3 ciphersuites separately from other ciphers, so a
+// profile that specifies only one kind effectively disables the versions that
+// require the other.
+func tlsProfileHasCipherForVersion(profile *configv1.TLSProfileSpec, version uint16) bool {
+	for _, name := range profile.Ciphers {
+		for _, cipher := range tlsProbeCiphers {
+			if cipher.name == name && cipher.tls13 == (version == 0x0304) {
+				return true
+			}
+		}
+	}
+	return false
+}
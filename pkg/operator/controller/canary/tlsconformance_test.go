@@ -0,0 +1,181 @@
+package canary
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// newTestTLSListener returns a listener that performs TLS handshakes with an
+// RSA certificate using the given configuration.
+func newTestTLSListener(t *testing.T, config *tls.Config) net.Listener {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "*.apps.example.com"},
+		DNSNames:     []string{"*.apps.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Certificates = []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return listener
+}
+
+func Test_probeTLS(t *testing.T) {
+	listener := newTestTLSListener(t, &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	})
+	defer listener.Close()
+
+	results := probeTLS(listener.Addr().String(), "tls-conformance-probe.apps.example.com", false, tls.VersionTLS12)
+	expectedVersions := map[configv1.TLSProtocolVersion]tlsHandshakeOutcome{
+		configv1.VersionTLS10: tlsHandshakeRejected,
+		configv1.VersionTLS11: tlsHandshakeRejected,
+		configv1.VersionTLS12: tlsHandshakeAccepted,
+		configv1.VersionTLS13: tlsHandshakeAccepted,
+	}
+	for version, expected := range expectedVersions {
+		if actual := results.versions[version]; actual != expected {
+			t.Errorf("expected %s to be %s, got %s", version, expected, actual)
+		}
+	}
+	expectedCiphers := map[string]tlsHandshakeOutcome{
+		"ECDHE-RSA-AES128-GCM-SHA256": tlsHandshakeAccepted,
+		"ECDHE-RSA-AES256-GCM-SHA384": tlsHandshakeRejected,
+		"DHE-RSA-AES128-GCM-SHA256":   tlsHandshakeRejected,
+		"TLS_AES_256_GCM_SHA384":      tlsHandshakeAccepted,
+	}
+	for cipher, expected := range expectedCiphers {
+		if actual := results.ciphers[cipher]; actual != expected {
+			t.Errorf("expected cipher %s to be %s, got %s", cipher, expected, actual)
+		}
+	}
+
+	unreachable := probeTLS("127.0.0.1:1", "tls-conformance-probe.apps.example.com", false, tls.VersionTLS12)
+	if actual := unreachable.versions[configv1.VersionTLS12]; actual != tlsHandshakeUnreachable {
+		t.Errorf("expected a closed port to be unreachable, got %s", actual)
+	}
+}
+
+func Test_tlsConformanceCondition(t *testing.T) {
+	profile := &configv1.TLSProfileSpec{
+		MinTLSVersion: configv1.VersionTLS12,
+		Ciphers: []string{
+			"TLS_AES_128_GCM_SHA256",
+			"TLS_AES_256_GCM_SHA384",
+			"TLS_CHACHA20_POLY1305_SHA256",
+			"ECDHE-ECDSA-AES128-GCM-SHA256",
+			"ECDHE-RSA-AES128-GCM-SHA256",
+		},
+	}
+	results := func(overrides map[string]tlsHandshakeOutcome, versionOverrides map[configv1.TLSProtocolVersion]tlsHandshakeOutcome) *tlsConformanceResults {
+		r := &tlsConformanceResults{
+			versions: map[configv1.TLSProtocolVersion]tlsHandshakeOutcome{
+				configv1.VersionTLS10: tlsHandshakeRejected,
+				configv1.VersionTLS11: tlsHandshakeRejected,
+				configv1.VersionTLS12: tlsHandshakeAccepted,
+				configv1.VersionTLS13: tlsHandshakeAccepted,
+			},
+			ciphers: map[string]tlsHandshakeOutcome{},
+		}
+		for _, cipher := range tlsProbeCiphers {
+			r.ciphers[cipher.name] = tlsHandshakeRejected
+		}
+		// The certificate is RSA, so the ECDSA cipher is rejected.
+		for _, name := range []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256", "ECDHE-RSA-AES128-GCM-SHA256"} {
+			r.ciphers[name] = tlsHandshakeAccepted
+		}
+		for k, v := range overrides {
+			r.ciphers[k] = v
+		}
+		for k, v := range versionOverrides {
+			r.versions[k] = v
+		}
+		return r
+	}
+
+	testCases := []struct {
+		description        string
+		results            *tlsConformanceResults
+		expectedStatus     operatorv1.ConditionStatus
+		expectedReason     string
+		expectedDeviations int
+	}{
+		{
+			description:    "conformant",
+			results:        results(nil, nil),
+			expectedStatus: operatorv1.ConditionTrue,
+			expectedReason: "Conformant",
+		},
+		{
+			description:        "old version accepted",
+			results:            results(nil, map[configv1.TLSProtocolVersion]tlsHandshakeOutcome{configv1.VersionTLS10: tlsHandshakeAccepted}),
+			expectedStatus:     operatorv1.ConditionFalse,
+			expectedReason:     "DeviationsDetected",
+			expectedDeviations: 1,
+		},
+		{
+			description:        "cipher outside the profile accepted",
+			results:            results(map[string]tlsHandshakeOutcome{"DES-CBC3-SHA": tlsHandshakeAccepted}, nil),
+			expectedStatus:     operatorv1.ConditionFalse,
+			expectedReason:     "DeviationsDetected",
+			expectedDeviations: 1,
+		},
+		{
+			description:        "TLS 1.3 ciphersuite in the profile rejected",
+			results:            results(map[string]tlsHandshakeOutcome{"TLS_CHACHA20_POLY1305_SHA256": tlsHandshakeRejected}, nil),
+			expectedStatus:     operatorv1.ConditionFalse,
+			expectedReason:     "DeviationsDetected",
+			expectedDeviations: 1,
+		},
+		{
+			description:    "routers unreachable",
+			results:        results(nil, map[configv1.TLSProtocolVersion]tlsHandshakeOutcome{configv1.VersionTLS12: tlsHandshakeUnreachable}),
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: "ProbesInconclusive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cond, deviations := tlsConformanceCondition(profile, tc.results)
+			if cond.Status != tc.expectedStatus || cond.Reason != tc.expectedReason || len(deviations) != tc.expectedDeviations {
+				t.Errorf("expected status %s, reason %s, and %d deviations, got status %s, reason %s, and deviations %v", tc.expectedStatus, tc.expectedReason, tc.expectedDeviations, cond.Status, cond.Reason, deviations)
+			}
+		})
+	}
+}
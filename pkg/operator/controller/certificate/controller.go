@@ -76,6 +76,9 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 			// The ingress could have been deleted and we're processing a stale queue
 			// item, so ignore and skip.
 			log.Info("ingresscontroller not found; reconciliation will be skipped", "request", request)
+			if err := r.deleteDefaultCertificatePins(request.Name); err != nil {
+				errs = append(errs, err)
+			}
 		} else {
 			errs = append(errs, fmt.Errorf("failed to get ingresscontroller: %v", err))
 		}
@@ -102,8 +105,19 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 				UID:        deployment.UID,
 				Controller: &trueVar,
 			}
-			if _, err := r.ensureDefaultCertificateForIngress(ca, deployment.Namespace, deploymentRef, ingress); err != nil {
+			if haveCert, err := r.ensureDefaultCertificateForIngress(ca, deployment.Namespace, deploymentRef, ingress); err != nil {
 				errs = append(errs, fmt.Errorf("failed to ensure default cert for %s: %v", ingress.Name, err))
+			} else if haveCert {
+				// Nothing else triggers a reconcile when the
+				// default certificate is due to be rotated, so
+				// requeue for that time.
+				if _, current, err := r.currentRouterDefaultCertificate(ingress, deployment.Namespace); err != nil {
+					errs = append(errs, err)
+				} else if current != nil {
+					if due := defaultCertificateRotationDueIn(current, time.Now()); due > 0 && (result.RequeueAfter == 0 || due < result.RequeueAfter) {
+						result.RequeueAfter = due
+					}
+				}
 			}
 		}
 	}
@@ -2,7 +2,9 @@ package certificate
 
 import (
 	"context"
+	"crypto/rsa"
 	"fmt"
+	"time"
 
 	"github.com/openshift/library-go/pkg/crypto"
 
@@ -12,21 +14,21 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-// ensureDefaultCertificateForIngress creates or deletes an operator-generated
-// default certificate for a given IngressController as appropriate.  Returns true
-// if it the secret exists, or false if it does not, as well as any errors.
+// ensureDefaultCertificateForIngress creates, rotates, or deletes an
+// operator-generated default certificate for a given IngressController as
+// appropriate.  The operator keeps a pre-generated next key for the
+// certificate, publishes the pins of the current and next keys, and always
+// rotates the certificate onto the next key so that clients that pin the
+// published pin set are not broken by the rotation.  Returns true if it the
+// secret exists, or false if it does not, as well as any errors.
 func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret, namespace string, deploymentRef metav1.OwnerReference, ci *operatorv1.IngressController) (bool, error) {
 	ca, err := crypto.GetCAFromBytes(caSecret.Data["tls.crt"], caSecret.Data["tls.key"])
 	if err != nil {
 		return false, fmt.Errorf("failed to get CA from secret %s/%s: %v", caSecret.Namespace, caSecret.Name, err)
 	}
-	wantCert, desired, err := desiredRouterDefaultCertificateSecret(ca, namespace, deploymentRef, ci)
-	if err != nil {
-		return false, err
-	}
+	wantCert := wantRouterDefaultCertificate(ci, namespace)
 	if !wantCert {
 		// If the operator generated certificate is not being used, ensure that the ingress controller's
 		// Spec.DefaultCertificate secret exists before deleting the operator generated secret.
@@ -35,6 +37,12 @@ func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret,
 		if err != nil {
 			return false, fmt.Errorf("failed to lookup user specified default certificate: %v", err)
 		}
+		if err := r.deleteDefaultCertificatePins(ci.Name); err != nil {
+			return false, err
+		}
+		if err := r.deleteNextDefaultCertificateKey(ci, namespace); err != nil {
+			return false, err
+		}
 	}
 
 	haveCert, current, err := r.currentRouterDefaultCertificate(ci, namespace)
@@ -52,25 +60,62 @@ func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret,
 			return false, nil
 		}
 	case wantCert && !haveCert:
+		next, err := r.ensureNextDefaultCertificateKey(ci, namespace, deploymentRef, nil)
+		if err != nil {
+			return false, err
+		}
+		_, desired, err := desiredRouterDefaultCertificateSecret(ca, next, namespace, deploymentRef, ci)
+		if err != nil {
+			return false, err
+		}
 		if created, err := r.createRouterDefaultCertificate(desired); err != nil {
 			return false, fmt.Errorf("failed to create default certificate: %v", err)
 		} else if created {
 			r.recorder.Eventf(ci, "Normal", "CreatedDefaultCertificate", "Created default wildcard certificate %q", desired.Name)
-			return true, nil
+			return true, r.ensureNextDefaultCertificatePins(ci, namespace, deploymentRef, desired)
 		}
 	case wantCert && haveCert:
 		// TODO Update if CA certificate changed.
-		return true, nil
+		if defaultCertificateNeedsRotation(current, time.Now()) {
+			next, err := r.ensureNextDefaultCertificateKey(ci, namespace, deploymentRef, current)
+			if err != nil {
+				return true, err
+			}
+			_, desired, err := desiredRouterDefaultCertificateSecret(ca, next, namespace, deploymentRef, ci)
+			if err != nil {
+				return true, err
+			}
+			updated := current.DeepCopy()
+			updated.Data = desired.Data
+			if err := r.client.Update(context.TODO(), updated); err != nil {
+				return true, fmt.Errorf("failed to rotate default certificate: %v", err)
+			}
+			r.recorder.Eventf(ci, "Normal", "RotatedDefaultCertificate", "Rotated default wildcard certificate %q onto its pre-announced next key", current.Name)
+			current = updated
+		}
+		return true, r.ensureNextDefaultCertificatePins(ci, namespace, deploymentRef, current)
 	}
 	return false, nil
 }
 
-// desiredRouterDefaultCertificateSecret returns the desired default certificate
-// secret.
-func desiredRouterDefaultCertificateSecret(ca *crypto.CA, namespace string, deploymentRef metav1.OwnerReference, ci *operatorv1.IngressController) (bool, *corev1.Secret, error) {
+// ensureNextDefaultCertificatePins ensures that a next key distinct from the
+// given current default certificate's key exists and that the pins of both
+// are published.
+func (r *reconciler) ensureNextDefaultCertificatePins(ci *operatorv1.IngressController, namespace string, deploymentRef metav1.OwnerReference, current *corev1.Secret) error {
+	next, err := r.ensureNextDefaultCertificateKey(ci, namespace, deploymentRef, current)
+	if err != nil {
+		return err
+	}
+	return r.ensureDefaultCertificatePins(ci, current, next)
+}
+
+// wantRouterDefaultCertificate returns a Boolean value indicating whether the
+// operator should generate a default certificate for the given
+// ingresscontroller.
+func wantRouterDefaultCertificate(ci *operatorv1.IngressController, namespace string) bool {
 	// Without an ingress domain, we cannot generate a default certificate.
 	if len(ci.Status.Domain) == 0 {
-		return false, nil, nil
+		return false
 	}
 
 	name := controller.RouterOperatorGeneratedDefaultCertificateSecretName(ci, namespace)
@@ -79,11 +124,22 @@ func desiredRouterDefaultCertificateSecret(ca *crypto.CA, namespace string, depl
 	// operator does not need to generate a certificate, unless the specified
 	// secret name redundantly corresponds to the operator generated secret.
 	if ci.Spec.DefaultCertificate != nil && ci.Spec.DefaultCertificate.Name != name.Name {
+		return false
+	}
+
+	return true
+}
+
+// desiredRouterDefaultCertificateSecret returns the desired default certificate
+// secret, with a certificate for the given key.
+func desiredRouterDefaultCertificateSecret(ca *crypto.CA, key *rsa.PrivateKey, namespace string, deploymentRef metav1.OwnerReference, ci *operatorv1.IngressController) (bool, *corev1.Secret, error) {
+	if !wantRouterDefaultCertificate(ci, namespace) {
 		return false, nil, nil
 	}
 
-	hostnames := sets.NewString(fmt.Sprintf("*.%s", ci.Status.Domain))
-	cert, err := ca.MakeServerCert(hostnames, 0)
+	name := controller.RouterOperatorGeneratedDefaultCertificateSecretName(ci, namespace)
+
+	cert, err := makeServerCertificate(ca, key, ci.Status.Domain, time.Now())
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to make certificate: %v", err)
 	}
@@ -58,6 +58,11 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 		t.Fatalf("failed to create CA")
 	}
 
+	nextKey, err := generateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
 	testCases := []struct {
 		description string
 		ic          *operatorv1.IngressController
@@ -122,7 +127,7 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			wantCert, _, err := desiredRouterDefaultCertificateSecret(ca, "test-namespace", metav1.OwnerReference{Name: "test-ref"}, tc.ic)
+			wantCert, _, err := desiredRouterDefaultCertificateSecret(ca, nextKey, "test-namespace", metav1.OwnerReference{Name: "test-ref"}, tc.ic)
 			switch {
 			case err != nil:
 				t.Fatalf("unexpected error: %v", err)
@@ -0,0 +1,332 @@
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	librarycrypto "github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// defaultCertificateLifetime is the lifetime of an operator-generated
+	// default certificate.
+	defaultCertificateLifetime = 2 * 365 * 24 * time.Hour
+	// defaultCertificateRotateBefore is how long before an
+	// operator-generated default certificate expires the operator rotates
+	// it onto the pre-generated next key.
+	defaultCertificateRotateBefore = 30 * 24 * time.Hour
+
+	// nextKeySecretKey is the key in the next key secret that holds the
+	// PEM-encoded private key.
+	nextKeySecretKey = "tls.key"
+
+	// currentPinKey is the key in the pins configmap that holds the SPKI
+	// SHA-256 pin of the current default certificate's key.
+	currentPinKey = "current-spki-sha256"
+	// nextPinKey is the key in the pins configmap that holds the SPKI
+	// SHA-256 pin of the key onto which the default certificate will next
+	// be rotated.
+	nextPinKey = "next-spki-sha256"
+	// pinSetKey is the key in the pins configmap that holds the pin set
+	// that clients should accept, one pin per line, current pin first.
+	pinSetKey = "spki-sha256-pins"
+	// currentNotAfterKey is the key in the pins configmap that holds the
+	// expiration time of the current default certificate, in RFC 3339
+	// format.  The certificate is rotated onto the next key before then.
+	currentNotAfterKey = "current-not-after"
+)
+
+// spkiSHA256Pin returns the base64-encoded SHA-256 digest of the DER-encoded
+// SubjectPublicKeyInfo of the given public key, which is the form used for
+// public key pinning.
+func spkiSHA256Pin(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// generateKey generates a key pair for a default certificate.
+func generateKey() (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// parseKey parses the given PEM-encoded RSA private key.
+func parseKey(keyBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM-encoded key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key: %w", err)
+	}
+	return key, nil
+}
+
+// parseLeafCertificate parses the first certificate in the given PEM-encoded
+// certificate chain.
+func parseLeafCertificate(certBytes []byte) (*x509.Certificate, error) {
+	certs, err := librarycrypto.CertsFromPEM(certBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return certs[0], nil
+}
+
+// makeServerCertificate returns a wildcard server certificate for the given
+// domain that the given CA signs for the given key.  This is equivalent to the
+// CA's MakeServerCert method except that the key is supplied by the caller, so
+// that the operator can rotate onto a key whose pin it has already published.
+func makeServerCertificate(ca *librarycrypto.CA, key *rsa.PrivateKey, domain string, now time.Time) (*librarycrypto.TLSCertificateConfig, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	subjectKeyID := sha1.Sum(der)
+	serial, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	hostname := fmt.Sprintf("*.%s", domain)
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: hostname},
+		DNSNames:              []string{hostname},
+		SignatureAlgorithm:    x509.SHA256WithRSA,
+		NotBefore:             now.Add(-1 * time.Second),
+		NotAfter:              now.Add(defaultCertificateLifetime),
+		SerialNumber:          serial,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		AuthorityKeyId:        ca.Config.Certs[0].SubjectKeyId,
+		SubjectKeyId:          subjectKeyID[:],
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.Config.Certs[0], &key.PublicKey, ca.Config.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return &librarycrypto.TLSCertificateConfig{
+		Certs: append([]*x509.Certificate{cert}, ca.Config.Certs...),
+		Key:   key,
+	}, nil
+}
+
+// defaultCertificateNeedsRotation returns a Boolean value indicating whether
+// the given default certificate secret should be rotated onto the next key at
+// the given time.
+func defaultCertificateNeedsRotation(secret *corev1.Secret, now time.Time) bool {
+	return defaultCertificateRotationDueIn(secret, now) <= 0
+}
+
+// defaultCertificateRotationDueIn returns how long after the given time the
+// given default certificate secret is due to be rotated onto the next key, or
+// zero if it is already due or cannot be parsed.
+func defaultCertificateRotationDueIn(secret *corev1.Secret, now time.Time) time.Duration {
+	cert, err := parseLeafCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		return 0
+	}
+	if due := cert.NotAfter.Add(-defaultCertificateRotateBefore).Sub(now); due > 0 {
+		return due
+	}
+	return 0
+}
+
+// ensureNextDefaultCertificateKey ensures that the next key secret for the
+// given ingresscontroller exists and holds a key that differs from the key of
+// the given current default certificate, if any, and returns the next key.
+func (r *reconciler) ensureNextDefaultCertificateKey(ci *operatorv1.IngressController, namespace string, deploymentRef metav1.OwnerReference, current *corev1.Secret) (*rsa.PrivateKey, error) {
+	name := controller.RouterOperatorGeneratedDefaultCertificateNextKeySecretName(ci, namespace)
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		key, err := generateKey()
+		if err != nil {
+			return nil, err
+		}
+		desired := desiredNextKeySecret(name.Namespace, name.Name, deploymentRef, key)
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return nil, fmt.Errorf("failed to create secret %s: %w", name, err)
+		}
+		log.Info("created next default certificate key", "namespace", name.Namespace, "name", name.Name)
+		return key, nil
+	}
+
+	key, err := parseKey(secret.Data[nextKeySecretKey])
+	if err == nil && (current == nil || !keyMatchesCertificate(key, current)) {
+		return key, nil
+	}
+
+	// The next key is missing, malformed, or has already been promoted
+	// to the current certificate, so pre-generate a new one.
+	if key, err = generateKey(); err != nil {
+		return nil, err
+	}
+	updated := secret.DeepCopy()
+	updated.Data = desiredNextKeySecret(name.Namespace, name.Name, deploymentRef, key).Data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return nil, fmt.Errorf("failed to update secret %s: %w", name, err)
+	}
+	log.Info("generated new next default certificate key", "namespace", name.Namespace, "name", name.Name)
+	return key, nil
+}
+
+// keyMatchesCertificate returns a Boolean value indicating whether the given
+// key is the key of the certificate in the given default certificate secret.
+func keyMatchesCertificate(key *rsa.PrivateKey, secret *corev1.Secret) bool {
+	cert, err := parseLeafCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(cert.PublicKey, &key.PublicKey)
+}
+
+// desiredNextKeySecret returns the desired next key secret.
+func desiredNextKeySecret(namespace, name string, deploymentRef metav1.OwnerReference, key *rsa.PrivateKey) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			nextKeySecretKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			}),
+		},
+	}
+	secret.SetOwnerReferences([]metav1.OwnerReference{deploymentRef})
+	return secret
+}
+
+// desiredDefaultCertificatePinsConfigMap returns the desired pins configmap for
+// the given ingresscontroller, current default certificate secret, and next
+// key.
+func desiredDefaultCertificatePinsConfigMap(ci *operatorv1.IngressController, current *corev1.Secret, next *rsa.PrivateKey) (*corev1.ConfigMap, error) {
+	cert, err := parseLeafCertificate(current.Data["tls.crt"])
+	if err != nil {
+		return nil, err
+	}
+	currentPin, err := spkiSHA256Pin(cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	nextPin, err := spkiSHA256Pin(&next.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	name := controller.DefaultCertificatePinsConfigMapName(ci.Name)
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Data: map[string]string{
+			currentPinKey:      currentPin,
+			nextPinKey:         nextPin,
+			pinSetKey:          currentPin + "\n" + nextPin + "\n",
+			currentNotAfterKey: cert.NotAfter.UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// ensureDefaultCertificatePins ensures that the pins configmap for the given
+// ingresscontroller publishes the pins of the given current default
+// certificate and next key.
+func (r *reconciler) ensureDefaultCertificatePins(ci *operatorv1.IngressController, current *corev1.Secret, next *rsa.PrivateKey) error {
+	desired, err := desiredDefaultCertificatePinsConfigMap(ci, current, next)
+	if err != nil {
+		return err
+	}
+	existing := &corev1.ConfigMap{}
+	name := controller.DefaultCertificatePinsConfigMapName(ci.Name)
+	if err := r.client.Get(context.TODO(), name, existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+		r.recorder.Eventf(ci, "Normal", "PublishedDefaultCertificatePins", "Published default certificate pins in %q", name)
+		return nil
+	}
+	if reflect.DeepEqual(existing.Data, desired.Data) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Data = desired.Data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update configmap %s: %w", name, err)
+	}
+	r.recorder.Eventf(ci, "Normal", "UpdatedDefaultCertificatePins", "Updated default certificate pins in %q", name)
+	return nil
+}
+
+// deleteDefaultCertificatePins deletes the pins configmap for the named
+// ingresscontroller, if it exists.
+func (r *reconciler) deleteDefaultCertificatePins(ingressControllerName string) error {
+	name := controller.DefaultCertificatePinsConfigMapName(ingressControllerName)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+	}
+	if err := r.client.Delete(context.TODO(), cm); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete configmap %s: %w", name, err)
+	}
+	return nil
+}
+
+// deleteNextDefaultCertificateKey deletes the next key secret for the given
+// ingresscontroller, if it exists.
+func (r *reconciler) deleteNextDefaultCertificateKey(ci *operatorv1.IngressController, namespace string) error {
+	name := controller.RouterOperatorGeneratedDefaultCertificateNextKeySecretName(ci, namespace)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+	}
+	if err := r.client.Delete(context.TODO(), secret); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete secret %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package certificate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_makeServerCertificate(t *testing.T) {
+	caCert, caKey, err := generateRouterCA()
+	if err != nil {
+		t.Fatalf("failed to generate CA: %v", err)
+	}
+	ca, err := crypto.GetCAFromBytes(caCert, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	nextKey, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tlsConfig, err := makeServerCertificate(ca, nextKey, "apps.example.com", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	leaf := tlsConfig.Certs[0]
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != "*.apps.example.com" {
+		t.Errorf("unexpected DNS names: %v", leaf.DNSNames)
+	}
+	if err := leaf.CheckSignatureFrom(ca.Config.Certs[0]); err != nil {
+		t.Errorf("certificate is not signed by the CA: %v", err)
+	}
+	leafPin, err := spkiSHA256Pin(leaf.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPin, err := spkiSHA256Pin(&nextKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leafPin != keyPin {
+		t.Errorf("expected certificate pin %q to match the given key's pin %q", leafPin, keyPin)
+	}
+	if !leaf.NotAfter.After(now.Add(defaultCertificateRotateBefore)) {
+		t.Errorf("expected a certificate that does not need immediate rotation, got NotAfter %v", leaf.NotAfter)
+	}
+}
+
+func Test_defaultCertificateNeedsRotation(t *testing.T) {
+	ca, err := crypto.GetCAFromBytes([]byte(cert), []byte(key))
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	certKey, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+	_, secret, err := desiredRouterDefaultCertificateSecret(ca, certKey, "openshift-ingress", metav1.OwnerReference{}, ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if defaultCertificateNeedsRotation(secret, now) {
+		t.Error("expected a new certificate not to need rotation")
+	}
+	if !defaultCertificateNeedsRotation(secret, now.Add(defaultCertificateLifetime-defaultCertificateRotateBefore+time.Hour)) {
+		t.Error("expected a certificate that expires soon to need rotation")
+	}
+	if !defaultCertificateNeedsRotation(&corev1.Secret{}, now) {
+		t.Error("expected a secret without a certificate to need rotation")
+	}
+}
+
+func Test_defaultCertificateRotationDueIn(t *testing.T) {
+	ca, err := crypto.GetCAFromBytes([]byte(cert), []byte(key))
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	certKey, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+	_, secret, err := desiredRouterDefaultCertificateSecret(ca, certKey, "openshift-ingress", metav1.OwnerReference{}, ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := parseLeafCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotateAt := leaf.NotAfter.Add(-defaultCertificateRotateBefore)
+
+	if due := defaultCertificateRotationDueIn(secret, rotateAt.Add(-time.Hour)); due != time.Hour {
+		t.Errorf("expected rotation to be due in 1h, got %v", due)
+	}
+	if due := defaultCertificateRotationDueIn(secret, rotateAt.Add(time.Hour)); due != 0 {
+		t.Errorf("expected rotation to be due now, got %v", due)
+	}
+	if due := defaultCertificateRotationDueIn(&corev1.Secret{}, rotateAt); due != 0 {
+		t.Errorf("expected rotation of a secret without a certificate to be due now, got %v", due)
+	}
+}
+
+// Test_ensureDefaultCertificateForIngress verifies that the operator publishes
+// the pins of the current and next keys and that rotation moves the
+// certificate onto the previously published next key.
+func Test_ensureDefaultCertificateForIngress(t *testing.T) {
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "router-ca"},
+		Data: map[string][]byte{
+			"tls.crt": []byte(cert),
+			"tls.key": []byte(key),
+		},
+	}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+	const namespace = "openshift-ingress"
+	r := &reconciler{
+		client:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	getPins := func() *corev1.ConfigMap {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := r.client.Get(context.TODO(), controller.DefaultCertificatePinsConfigMapName(ic.Name), cm); err != nil {
+			t.Fatalf("failed to get pins configmap: %v", err)
+		}
+		return cm
+	}
+	getCertPin := func() string {
+		t.Helper()
+		secret := &corev1.Secret{}
+		if err := r.client.Get(context.TODO(), controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, namespace), secret); err != nil {
+			t.Fatalf("failed to get default certificate: %v", err)
+		}
+		leaf, err := parseLeafCertificate(secret.Data["tls.crt"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pin, err := spkiSHA256Pin(leaf.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pin
+	}
+
+	if _, err := r.ensureDefaultCertificateForIngress(caSecret, namespace, metav1.OwnerReference{}, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pins := getPins()
+	if pin := getCertPin(); pin != pins.Data[currentPinKey] {
+		t.Fatalf("expected the current pin %q to match the certificate's pin %q", pins.Data[currentPinKey], pin)
+	}
+	if pins.Data[currentPinKey] == pins.Data[nextPinKey] {
+		t.Fatal("expected the next pin to differ from the current pin")
+	}
+	if expected := pins.Data[currentPinKey] + "\n" + pins.Data[nextPinKey] + "\n"; pins.Data[pinSetKey] != expected {
+		t.Errorf("expected pin set %q, got %q", expected, pins.Data[pinSetKey])
+	}
+
+	// Force rotation by replacing the certificate with one that expires
+	// soon, and verify that the new certificate uses the announced key.
+	oldNextPin := pins.Data[nextPinKey]
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, namespace), secret); err != nil {
+		t.Fatal(err)
+	}
+	ca, err := crypto.GetCAFromBytes([]byte(cert), []byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentKey, err := parseKey(secret.Data["tls.key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring, err := makeServerCertificate(ca, currentKey, ic.Status.Domain, time.Now().Add(-defaultCertificateLifetime+time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["tls.crt"], secret.Data["tls.key"], err = expiring.GetPEMBytes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.client.Update(context.TODO(), secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.ensureDefaultCertificateForIngress(caSecret, namespace, metav1.OwnerReference{}, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pin := getCertPin(); pin != oldNextPin {
+		t.Fatalf("expected the rotated certificate's pin %q to be the previously announced next pin %q", pin, oldNextPin)
+	}
+	pins = getPins()
+	if pins.Data[currentPinKey] != oldNextPin {
+		t.Errorf("expected current pin %q, got %q", oldNextPin, pins.Data[currentPinKey])
+	}
+	if pins.Data[nextPinKey] == oldNextPin {
+		t.Error("expected a new next key to be generated after rotation")
+	}
+}
@@ -0,0 +1,143 @@
+package clientcaconfigmap
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// clientCABundleKey is the key of the CA bundle in a user-managed client CA
+// configmap.
+const clientCABundleKey = "ca-bundle.pem"
+
+// clientTLSScopesSourceConfigMapNames returns the names of the user-managed
+// client CA configmaps that the given ingresscontroller's client TLS scopes
+// reference.  An invalid annotation references no configmaps.
+func clientTLSScopesSourceConfigMapNames(ic *operatorv1.IngressController) []string {
+	scopes, err := ingresscontroller.ClientTLSScopesForIngressController(ic)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if !seen[scope.ClientCA] {
+			seen[scope.ClientCA] = true
+			names = append(names, scope.ClientCA)
+		}
+	}
+	return names
+}
+
+// ensureClientTLSScopesConfigMap ensures that the client TLS scopes configmap
+// in the openshift-ingress namespace has the router's client TLS scopes
+// configuration and a copy of the CA bundle from each scope's client CA
+// configmap in the openshift-config namespace if the ingresscontroller
+// specifies client TLS scopes, and that the configmap does not exist
+// otherwise.  The configmap is not created or updated until every scope's
+// client CA configmap exists, so that the router never applies a scope without
+// its client CA.
+func (r *reconciler) ensureClientTLSScopesConfigMap(ctx context.Context, ic *operatorv1.IngressController) error {
+	destName := operatorcontroller.ClientTLSScopesConfigMapName(ic)
+	have, current, err := r.currentClientCAConfigMap(ctx, destName)
+	if err != nil {
+		return err
+	}
+
+	var scopes []ingresscontroller.ClientTLSScope
+	if ic.DeletionTimestamp == nil {
+		scopes, err = ingresscontroller.ClientTLSScopesForIngressController(ic)
+		if err != nil {
+			// The ingress controller rejects the ingresscontroller,
+			// so leave the current configmap, if any, in place until
+			// the annotation is fixed.
+			log.Info("ignoring invalid client TLS scopes", "ingresscontroller", ic.Name, "error", err)
+			return nil
+		}
+	}
+
+	if len(scopes) == 0 {
+		if !have {
+			return nil
+		}
+		if err := r.client.Delete(ctx, current); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete configmap: %w", err)
+			}
+		} else {
+			log.Info("deleted configmap", "namespace", current.Namespace, "name", current.Name)
+		}
+		return nil
+	}
+
+	sources := map[string]*corev1.ConfigMap{}
+	for _, name := range clientTLSScopesSourceConfigMapNames(ic) {
+		sourceName := types.NamespacedName{Namespace: r.config.SourceNamespace, Name: name}
+		haveSource, source, err := r.currentClientCAConfigMap(ctx, sourceName)
+		if err != nil {
+			return err
+		}
+		if !haveSource {
+			return fmt.Errorf("client CA configmap %s for client TLS scopes does not exist", sourceName)
+		}
+		if len(source.Data[clientCABundleKey]) == 0 {
+			return fmt.Errorf("client CA configmap %s for client TLS scopes has no %q key", sourceName, clientCABundleKey)
+		}
+		sources[name] = source
+	}
+
+	desired, err := desiredClientTLSScopesConfigMap(scopes, sources, destName)
+	if err != nil {
+		return err
+	}
+
+	if !have {
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create configmap: %w", err)
+		}
+		log.Info("created configmap", "namespace", desired.Namespace, "name", desired.Name)
+		return nil
+	}
+	if reflect.DeepEqual(current.Data, desired.Data) {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = desired.Data
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update configmap: %w", err)
+	}
+	log.Info("updated configmap", "namespace", updated.Namespace, "name", updated.Name)
+	return nil
+}
+
+// desiredClientTLSScopesConfigMap returns the desired client TLS scopes
+// configmap for the given scopes and their client CA configmaps, which are
+// keyed by name.
+func desiredClientTLSScopesConfigMap(scopes []ingresscontroller.ClientTLSScope, sources map[string]*corev1.ConfigMap, name types.NamespacedName) (*corev1.ConfigMap, error) {
+	config, err := ingresscontroller.RenderClientTLSScopesConfig(scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render client TLS scopes configuration: %w", err)
+	}
+	data := map[string]string{ingresscontroller.ClientTLSScopesConfigKey: config}
+	for _, scope := range scopes {
+		data[ingresscontroller.ClientTLSScopeCABundleKey(scope.Name)] = sources[scope.ClientCA].Data[clientCABundleKey]
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Data: data,
+	}, nil
+}
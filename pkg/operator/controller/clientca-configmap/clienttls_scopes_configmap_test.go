@@ -0,0 +1,151 @@
+package clientcaconfigmap
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_ensureClientTLSScopesConfigMap verifies that
+// ensureClientTLSScopesConfigMap creates the client TLS scopes configmap with
+// each scope's client CA bundle once every client CA configmap exists, updates
+// it when a client CA changes, and deletes it when the scopes are removed.
+func Test_ensureClientTLSScopesConfigMap(t *testing.T) {
+	const scopes = `[{"name":"payments","hostnames":["pay.apps.example.com"],"clientCA":"payments-ca"},{"name":"partners","routeSelector":{"matchLabels":{"mtls":"partners"}},"clientCertificatePolicy":"Optional","clientCA":"partners-ca"}]`
+	ic := func(annotation string) *operatorv1.IngressController {
+		ic := &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress-operator",
+				Name:      "test",
+			},
+		}
+		if len(annotation) != 0 {
+			ic.Annotations = map[string]string{ingresscontroller.ClientTLSScopesAnnotation: annotation}
+		}
+		return ic
+	}
+	cm := func(namespace, name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Data:       data,
+		}
+	}
+	tests := []struct {
+		name            string
+		ic              *operatorv1.IngressController
+		existingObjects []runtime.Object
+		expectError     bool
+		expectCreate    bool
+		expectUpdate    bool
+		expectDelete    bool
+		expectData      map[string]string
+	}{
+		{
+			name: "do nothing if no scopes are specified",
+			ic:   ic(""),
+		},
+		{
+			name: "return an error and do nothing else if a client CA configmap is absent",
+			ic:   ic(scopes),
+			existingObjects: []runtime.Object{
+				cm("openshift-config", "payments-ca", map[string]string{"ca-bundle.pem": "payments"}),
+			},
+			expectError: true,
+		},
+		{
+			name: "create the configmap if every client CA configmap is present",
+			ic:   ic(scopes),
+			existingObjects: []runtime.Object{
+				cm("openshift-config", "payments-ca", map[string]string{"ca-bundle.pem": "payments"}),
+				cm("openshift-config", "partners-ca", map[string]string{"ca-bundle.pem": "partners"}),
+			},
+			expectCreate: true,
+			expectData: map[string]string{
+				"payments-ca-bundle.pem": "payments",
+				"partners-ca-bundle.pem": "partners",
+			},
+		},
+		{
+			name: "update the configmap if a client CA changes",
+			ic:   ic(scopes),
+			existingObjects: []runtime.Object{
+				cm("openshift-config", "payments-ca", map[string]string{"ca-bundle.pem": "payments-rotated"}),
+				cm("openshift-config", "partners-ca", map[string]string{"ca-bundle.pem": "partners"}),
+				cm("openshift-ingress", "router-client-tls-scopes-test", map[string]string{"payments-ca-bundle.pem": "payments"}),
+			},
+			expectUpdate: true,
+			expectData: map[string]string{
+				"payments-ca-bundle.pem": "payments-rotated",
+				"partners-ca-bundle.pem": "partners",
+			},
+		},
+		{
+			name: "delete the configmap if the scopes are removed",
+			ic:   ic(""),
+			existingObjects: []runtime.Object{
+				cm("openshift-ingress", "router-client-tls-scopes-test", map[string]string{"payments-ca-bundle.pem": "payments"}),
+			},
+			expectDelete: true,
+		},
+		{
+			name: "keep the configmap if the scopes are invalid",
+			ic:   ic(`[{"name":"payments"}]`),
+			existingObjects: []runtime.Object{
+				cm("openshift-ingress", "router-client-tls-scopes-test", map[string]string{"payments-ca-bundle.pem": "payments"}),
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(tc.existingObjects...).
+				Build()
+			cl := &fakeClientRecorder{fakeClient, t, []client.Object{}, []client.Object{}, []client.Object{}}
+			reconciler := &reconciler{
+				client: cl,
+				config: Config{
+					SourceNamespace: "openshift-config",
+					TargetNamespace: "openshift-ingress",
+				},
+			}
+			err := reconciler.ensureClientTLSScopesConfigMap(context.Background(), tc.ic)
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectError, err)
+			}
+			if (len(cl.added) != 0) != tc.expectCreate || (len(cl.updated) != 0) != tc.expectUpdate || (len(cl.deleted) != 0) != tc.expectDelete {
+				t.Fatalf("expected create %t, update %t, and delete %t, got %d creates, %d updates, and %d deletes", tc.expectCreate, tc.expectUpdate, tc.expectDelete, len(cl.added), len(cl.updated), len(cl.deleted))
+			}
+			if tc.expectData == nil {
+				return
+			}
+			current := &corev1.ConfigMap{}
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "openshift-ingress", Name: "router-client-tls-scopes-test"}, current); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.expectData {
+				if current.Data[k] != v {
+					t.Errorf("expected key %q to have value %q, got %q", k, v, current.Data[k])
+				}
+			}
+			if len(current.Data[ingresscontroller.ClientTLSScopesConfigKey]) == 0 {
+				t.Errorf("expected key %q to have the client TLS scopes configuration", ingresscontroller.ClientTLSScopesConfigKey)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
 
 	corev1 "k8s.io/api/core/v1"
@@ -50,10 +51,10 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 
 	hasConfigMap := func(o client.Object) bool {
 		ic := o.(*operatorv1.IngressController)
-		return len(ic.Spec.ClientTLS.ClientCA.Name) != 0
+		return len(ic.Spec.ClientTLS.ClientCA.Name) != 0 || hasClientTLSScopes(ic)
 	}
 
-	// If the ingresscontroller's configmap reference changes, reconcile the
+	// If the ingresscontroller's configmap references change, reconcile the
 	// ingresscontroller.
 	if err := c.Watch(
 		source.Kind(operatorCache, &operatorv1.IngressController{}),
@@ -70,7 +71,9 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 				newIC := e.ObjectNew.(*operatorv1.IngressController)
 				oldName := oldIC.Spec.ClientTLS.ClientCA.Name
 				newName := newIC.Spec.ClientTLS.ClientCA.Name
-				return oldName != newName ||
+				oldScopes := oldIC.Annotations[ingresscontroller.ClientTLSScopesAnnotation]
+				newScopes := newIC.Annotations[ingresscontroller.ClientTLSScopesAnnotation]
+				return oldName != newName || oldScopes != newScopes ||
 					oldIC.DeletionTimestamp != newIC.DeletionTimestamp
 			},
 			GenericFunc: func(e event.GenericEvent) bool {
@@ -81,8 +84,9 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 		return nil, err
 	}
 
-	// Index ingresscontrollers by spec.clientTLS.clientCA.name so that we
-	// can look up the ingresscontroller when the configmap is changed.
+	// Index ingresscontrollers by spec.clientTLS.clientCA.name and by the
+	// client CA configmaps of their client TLS scopes so that we can look
+	// up the ingresscontroller when the configmap is changed.
 	const clientCAUserConfigmapIndexFieldName = "clientCAUserConfigmapName"
 	if err := mgr.GetFieldIndexer().IndexField(
 		context.Background(),
@@ -90,10 +94,14 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 		clientCAUserConfigmapIndexFieldName,
 		client.IndexerFunc(func(o client.Object) []string {
 			ic := o.(*operatorv1.IngressController)
-			if len(ic.Spec.ClientTLS.ClientCA.Name) == 0 {
+			names := clientTLSScopesSourceConfigMapNames(ic)
+			if len(ic.Spec.ClientTLS.ClientCA.Name) != 0 {
+				names = append(names, ic.Spec.ClientTLS.ClientCA.Name)
+			}
+			if len(names) == 0 {
 				return []string{}
 			}
-			return []string{ic.Spec.ClientTLS.ClientCA.Name}
+			return names
 		}),
 	); err != nil {
 		return nil, fmt.Errorf("failed to create index for user-managed client CA configmaps: %w", err)
@@ -109,7 +117,10 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 		clientCAOperatorConfigmapIndexFieldName,
 		client.IndexerFunc(func(o client.Object) []string {
 			ic := o.(*operatorv1.IngressController)
-			return []string{operatorcontroller.ClientCAConfigMapName(ic).Name}
+			return []string{
+				operatorcontroller.ClientCAConfigMapName(ic).Name,
+				operatorcontroller.ClientTLSScopesConfigMapName(ic).Name,
+			}
 		}),
 	); err != nil {
 		return nil, fmt.Errorf("failed to create index for operator-managed client CA configmaps: %w", err)
@@ -165,6 +176,13 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	return c, nil
 }
 
+// hasClientTLSScopes returns a Boolean value indicating whether the given
+// ingresscontroller specifies client TLS scopes.
+func hasClientTLSScopes(ic *operatorv1.IngressController) bool {
+	_, ok := ic.Annotations[ingresscontroller.ClientTLSScopesAnnotation]
+	return ok
+}
+
 // Config holds all the things necessary for the controller to run.
 type Config struct {
 	SourceNamespace string
@@ -192,7 +210,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	}
 
 	const finalizer = "ingresscontroller.operator.openshift.io/finalizer-clientca-configmap"
-	if len(ic.Spec.ClientTLS.ClientCA.Name) != 0 && ic.DeletionTimestamp == nil && !slice.ContainsString(ic.Finalizers, finalizer) {
+	if (len(ic.Spec.ClientTLS.ClientCA.Name) != 0 || hasClientTLSScopes(ic)) && ic.DeletionTimestamp == nil && !slice.ContainsString(ic.Finalizers, finalizer) {
 		// Ensure the ingresscontroller has a finalizer so we get a
 		// chance to delete the configmap when the ingresscontroller is
 		// deleted.
@@ -216,6 +234,10 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return reconcile.Result{}, fmt.Errorf("failed to ensure client CA configmap for ingresscontroller %q: %w", ic.Name, err)
 	}
 
+	if err := r.ensureClientTLSScopesConfigMap(ctx, ic); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to ensure client TLS scopes configmap for ingresscontroller %q: %w", ic.Name, err)
+	}
+
 	if ic.DeletionTimestamp != nil && slice.ContainsString(ic.Finalizers, finalizer) {
 		ic.Finalizers = slice.RemoveString(ic.Finalizers, finalizer)
 		if err := r.client.Update(ctx, ic); err != nil {
@@ -0,0 +1,309 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DNSRecordCAAPublishedConditionType is the type of the DNSRecord
+	// zone condition that indicates whether the CAA records that are
+	// requested by the DNSRecord's CAA annotation are published to the
+	// zone.
+	DNSRecordCAAPublishedConditionType = "CAAPublished"
+
+	// PublishedCAAZonesAnnotation is the key of an annotation that the DNS
+	// controller adds to DNSRecord CRs to record the zones to which it has
+	// published CAA records, so that it only updates or deletes CAA
+	// records that it owns.  The value is a JSON array of DNS zones.
+	PublishedCAAZonesAnnotation = "ingress.operator.openshift.io/published-caa-zones"
+)
+
+// caaAnnotationChangedPredicate matches updates to DNSRecords that change the
+// CAA annotation.  Annotations do not change the DNSRecord's generation, so
+// these updates are otherwise filtered out.
+var caaAnnotationChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return e.ObjectOld.GetAnnotations()[dnsrecord.CAARecordsAnnotation] != e.ObjectNew.GetAnnotations()[dnsrecord.CAARecordsAnnotation]
+	},
+}
+
+// caaDomainForRecord returns the domain at which CAA records for the given
+// DNSRecord are published.  CAA records apply to the domain at which they are
+// published and all of its subdomains, so the records for a wildcard DNSRecord
+// are published at the parent domain of the wildcard.
+func caaDomainForRecord(record *iov1.DNSRecord) string {
+	return strings.TrimPrefix(record.Spec.DNSName, "*.")
+}
+
+// publishedCAAZones returns the zones from the given DNSRecord's published CAA
+// zones annotation.  If the annotation is absent or cannot be parsed, no zones
+// are returned.
+func publishedCAAZones(record *iov1.DNSRecord) []configv1.DNSZone {
+	value, ok := record.Annotations[PublishedCAAZonesAnnotation]
+	if !ok {
+		return nil
+	}
+	var zones []configv1.DNSZone
+	if err := json.Unmarshal([]byte(value), &zones); err != nil {
+		log.Error(err, "failed to parse annotation; ignoring it", "dnsrecord", record.Name, "annotation", PublishedCAAZonesAnnotation)
+		return nil
+	}
+	return zones
+}
+
+// caaPublishedToZone returns a Boolean value indicating whether the given
+// DNSRecord's published CAA zones annotation indicates that the operator
+// published CAA records to the given zone.
+func caaPublishedToZone(record *iov1.DNSRecord, zone *configv1.DNSZone) bool {
+	for _, published := range publishedCAAZones(record) {
+		if reflect.DeepEqual(&published, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// currentCAAConditionReason returns the reason of the given DNSRecord's current
+// CAA zone condition for the given zone, or the empty string if the DNSRecord
+// has no such condition.
+func currentCAAConditionReason(record *iov1.DNSRecord, zone *configv1.DNSZone) string {
+	for i := range record.Status.Zones {
+		if !reflect.DeepEqual(&record.Status.Zones[i].DNSZone, zone) {
+			continue
+		}
+		for _, cond := range record.Status.Zones[i].Conditions {
+			if cond.Type == DNSRecordCAAPublishedConditionType {
+				return cond.Reason
+			}
+		}
+	}
+	return ""
+}
+
+// updatePublishedCAAZonesAnnotation sets the given DNSRecord's published CAA
+// zones annotation to the given zones, or removes it if there are none, and
+// patches the DNSRecord if the annotation has changed.
+func (r *reconciler) updatePublishedCAAZonesAnnotation(ctx context.Context, record *iov1.DNSRecord, zones []configv1.DNSZone) error {
+	current, haveCurrent := record.Annotations[PublishedCAAZonesAnnotation]
+	updated := record.DeepCopy()
+	if len(zones) == 0 {
+		if !haveCurrent {
+			return nil
+		}
+		delete(updated.Annotations, PublishedCAAZonesAnnotation)
+	} else {
+		value, err := json.Marshal(zones)
+		if err != nil {
+			return fmt.Errorf("failed to marshal published CAA zones: %w", err)
+		}
+		if haveCurrent && current == string(value) {
+			return nil
+		}
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[PublishedCAAZonesAnnotation] = string(value)
+	}
+	if err := r.client.Patch(ctx, updated, client.MergeFrom(record)); err != nil {
+		return fmt.Errorf("failed to annotate dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("annotated dnsrecord with published CAA zones", "dnsrecord", record.Name, "value", updated.Annotations[PublishedCAAZonesAnnotation])
+	return nil
+}
+
+// publishCAAToZone publishes the CAA records that are requested by the given
+// DNSRecord's CAA annotation to the given zone, or deletes previously published
+// CAA records if the annotation has been removed.  CAA records that exist in
+// the zone and that the operator did not publish are not modified; instead,
+// the conflict is reported.  publishCAAToZone returns the status for the zone,
+// which is nil if the DNSRecord has never requested CAA records, a Boolean
+// value indicating whether the operator owns CAA records in the zone, and a
+// Boolean value indicating whether the request should be requeued.
+func (r *reconciler) publishCAAToZone(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord) (*iov1.DNSZoneStatus, bool, bool) {
+	value, requested := record.Annotations[dnsrecord.CAARecordsAnnotation]
+	previouslyPublished := caaPublishedToZone(record, &zone)
+	if !requested && !previouslyPublished {
+		return nil, false, false
+	}
+
+	condition, published, requeue := r.ensureCAAInZone(ctx, zone, record, value, requested, previouslyPublished)
+	condition.Type = DNSRecordCAAPublishedConditionType
+	condition.LastTransitionTime = metav1.Now()
+	return &iov1.DNSZoneStatus{
+		DNSZone:    zone,
+		Conditions: []iov1.DNSZoneCondition{condition},
+	}, published, requeue
+}
+
+// ensureCAAInZone ensures the CAA records for the given DNSRecord in the given
+// zone and returns the resulting condition, a Boolean value indicating whether
+// the operator owns CAA records in the zone, and a Boolean value indicating
+// whether the request should be requeued.  CAA records that already match the
+// desired records but that the operator did not publish remain unowned, so
+// that they are not deleted when the DNSRecord no longer requests them.
+func (r *reconciler) ensureCAAInZone(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord, value string, requested, previouslyPublished bool) (iov1.DNSZoneCondition, bool, bool) {
+	domain := caaDomainForRecord(record)
+
+	if record.Spec.DNSManagementPolicy == iov1.UnmanagedDNS {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionUnknown),
+			Reason:  "UnmanagedDNS",
+			Message: "CAA records are currently not being managed by the operator",
+		}, previouslyPublished, false
+	}
+
+	provider, ok := r.dnsProvider.(dns.CAAProvider)
+	if !ok {
+		return caaNotSupportedCondition(), previouslyPublished, false
+	}
+
+	if !requested {
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.DeleteCAA(ctx, domain, zone)
+		})
+		if err != nil {
+			log.Error(err, "failed to delete CAA records from zone", "domain", domain, "dnszone", zone)
+			return caaProviderErrorCondition("delete", err), true, true
+		}
+		log.Info("deleted CAA records from zone", "domain", domain, "dnszone", zone)
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  "NotRequested",
+			Message: "CAA records are not requested and have been removed",
+		}, false, false
+	}
+
+	desired, err := dns.ParseCAARecords(value)
+	if err != nil || len(desired) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no records specified")
+		}
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  "InvalidCAARecords",
+			Message: fmt.Sprintf("The %s annotation is invalid: %v", dnsrecord.CAARecordsAnnotation, err),
+		}, previouslyPublished, false
+	}
+
+	var current []dns.CAARecord
+	err = callDNSProvider(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = provider.CAARecords(ctx, domain, zone)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, dns.ErrCAANotSupported) {
+			return caaNotSupportedCondition(), previouslyPublished, false
+		}
+		log.Error(err, "failed to get CAA records from zone", "domain", domain, "dnszone", zone)
+		return caaProviderErrorCondition("get", err), previouslyPublished, true
+	}
+
+	published := previouslyPublished
+	if !dns.CAARecordsEqual(current, desired) {
+		if len(current) != 0 && !previouslyPublished {
+			// Requeue so that the condition clears once the conflict is
+			// resolved, but only record the event when the conflict is
+			// first detected.
+			if currentCAAConditionReason(record, &zone) != "CAARecordConflict" {
+				r.recorder.Eventf(record, "Warning", "CAARecordConflict", "The DNS zone %s already has CAA records for %s that the operator did not publish: %s", zone.ID, domain, formatCAARecordsForMessage(current))
+			}
+			return iov1.DNSZoneCondition{
+				Status:  string(operatorv1.ConditionFalse),
+				Reason:  "CAARecordConflict",
+				Message: fmt.Sprintf("The zone already has CAA records for %s that the operator did not publish: %s.  Delete these records, or add the desired records (%s) to them, to resolve the conflict", domain, formatCAARecordsForMessage(current), formatCAARecordsForMessage(desired)),
+			}, false, true
+		}
+		// The zone has no CAA records for the domain, or has records
+		// that the operator published, so the operator owns the
+		// records from here on, even if publishing them fails
+		// partway.
+		published = true
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.EnsureCAA(ctx, domain, desired, record.Spec.RecordTTL, zone)
+		})
+		if err != nil {
+			log.Error(err, "failed to publish CAA records to zone", "domain", domain, "dnszone", zone)
+			return caaProviderErrorCondition("ensure", err), published, true
+		}
+		log.Info("published CAA records to zone", "domain", domain, "records", dns.FormatCAARecords(desired), "dnszone", zone)
+	}
+
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionTrue),
+		Reason:  "ProviderSuccess",
+		Message: fmt.Sprintf("The DNS provider succeeded in ensuring the CAA records for %s", domain),
+	}, published, false
+}
+
+// deleteCAAFromZones deletes the CAA records that the operator published for
+// the given DNSRecord from every zone.
+func (r *reconciler) deleteCAAFromZones(ctx context.Context, record *iov1.DNSRecord) []error {
+	provider, ok := r.dnsProvider.(dns.CAAProvider)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	domain := caaDomainForRecord(record)
+	for _, zone := range publishedCAAZones(record) {
+		zone := zone
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.DeleteCAA(ctx, domain, zone)
+		})
+		if err != nil && !errors.Is(err, dns.ErrCAANotSupported) {
+			errs = append(errs, err)
+		} else {
+			log.Info("deleted CAA records from DNS provider", "domain", domain, "zone", zone)
+		}
+	}
+	return errs
+}
+
+func caaNotSupportedCondition() iov1.DNSZoneCondition {
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionUnknown),
+		Reason:  "CAANotSupported",
+		Message: "The DNS provider for this platform does not support managing CAA records",
+	}
+}
+
+func caaProviderErrorCondition(verb string, err error) iov1.DNSZoneCondition {
+	if isDNSProviderTimeout(err) {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  providerTimeoutReason,
+			Message: fmt.Sprintf("The DNS provider did not %s the CAA records within %v; the operation will be retried: %v", verb, dnsProviderTimeout, err),
+		}
+	}
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionFalse),
+		Reason:  "ProviderError",
+		Message: fmt.Sprintf("The DNS provider failed to %s the CAA records: %v", verb, err),
+	}
+}
+
+// formatCAARecordsForMessage returns the given records in presentation format
+// on a single line, for use in condition messages and events.
+func formatCAARecordsForMessage(records []dns.CAARecord) string {
+	return strings.ReplaceAll(dns.FormatCAARecords(records), "\n", ", ")
+}
@@ -0,0 +1,276 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeCAAProvider is a dns.Provider that stores CAA records in memory.
+type fakeCAAProvider struct {
+	dns.FakeProvider
+	records map[string][]dns.CAARecord
+}
+
+func (p *fakeCAAProvider) CAARecords(ctx context.Context, domain string, zone configv1.DNSZone) ([]dns.CAARecord, error) {
+	return p.records[domain], nil
+}
+
+func (p *fakeCAAProvider) EnsureCAA(ctx context.Context, domain string, records []dns.CAARecord, ttl int64, zone configv1.DNSZone) error {
+	p.records[domain] = records
+	return nil
+}
+
+func (p *fakeCAAProvider) DeleteCAA(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	delete(p.records, domain)
+	return nil
+}
+
+func Test_publishCAAToZone(t *testing.T) {
+	zone := configv1.DNSZone{ID: "public"}
+	letsEncrypt := []dns.CAARecord{{Tag: "issue", Value: "letsencrypt.org"}, {Tag: "issuewild", Value: "letsencrypt.org"}}
+	otherCA := []dns.CAARecord{{Tag: "issue", Value: "ca.example.com"}}
+
+	testCases := []struct {
+		description     string
+		provider        dns.Provider
+		annotation      *string
+		policy          iov1.DNSManagementPolicy
+		published       bool
+		existing        []dns.CAARecord
+		expectNoStatus  bool
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedRecords []dns.CAARecord
+		expectPublished bool
+		expectRequeue   bool
+	}{
+		{
+			description:    "no CAA records requested",
+			expectNoStatus: true,
+		},
+		{
+			description:     "CAA records requested",
+			annotation:      stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  "ProviderSuccess",
+			expectedRecords: letsEncrypt,
+			expectPublished: true,
+		},
+		{
+			description:     "CAA records already exist",
+			annotation:      stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			existing:        letsEncrypt,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  "ProviderSuccess",
+			expectedRecords: letsEncrypt,
+		},
+		{
+			description:     "conflicting CAA records",
+			annotation:      stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			existing:        otherCA,
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  "CAARecordConflict",
+			expectedRecords: otherCA,
+			expectRequeue:   true,
+		},
+		{
+			description:     "previously published CAA records are updated",
+			annotation:      stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			published:       true,
+			existing:        otherCA,
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  "ProviderSuccess",
+			expectedRecords: letsEncrypt,
+			expectPublished: true,
+		},
+		{
+			description:    "previously published CAA records are deleted",
+			published:      true,
+			existing:       letsEncrypt,
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: "NotRequested",
+		},
+		{
+			description:     "unpublished CAA records are left alone",
+			existing:        otherCA,
+			expectNoStatus:  true,
+			expectedRecords: otherCA,
+		},
+		{
+			description:    "invalid annotation",
+			annotation:     stringPtr("issue letsencrypt.org"),
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: "InvalidCAARecords",
+		},
+		{
+			description:     "invalid annotation keeps previously published CAA records owned",
+			annotation:      stringPtr("issue letsencrypt.org"),
+			published:       true,
+			existing:        letsEncrypt,
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  "InvalidCAARecords",
+			expectedRecords: letsEncrypt,
+			expectPublished: true,
+		},
+		{
+			description:    "unmanaged DNS",
+			annotation:     stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			policy:         iov1.UnmanagedDNS,
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: "UnmanagedDNS",
+		},
+		{
+			description:    "provider does not support CAA",
+			provider:       &dns.FakeProvider{},
+			annotation:     stringPtr(dns.FormatCAARecords(letsEncrypt)),
+			expectedStatus: operatorv1.ConditionUnknown,
+			expectedReason: "CAANotSupported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			fake := &fakeCAAProvider{records: map[string][]dns.CAARecord{}}
+			if tc.existing != nil {
+				fake.records["apps.example.com."] = tc.existing
+			}
+			r := &reconciler{dnsProvider: fake, recorder: record.NewFakeRecorder(10)}
+			if tc.provider != nil {
+				r.dnsProvider = tc.provider
+			}
+			policy := tc.policy
+			if len(policy) == 0 {
+				policy = iov1.ManagedDNS
+			}
+			dnsRecord := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             "*.apps.example.com.",
+					RecordType:          iov1.CNAMERecordType,
+					RecordTTL:           30,
+					DNSManagementPolicy: policy,
+				},
+			}
+			if tc.annotation != nil {
+				dnsRecord.Annotations[dnsrecord.CAARecordsAnnotation] = *tc.annotation
+			}
+			if tc.published {
+				dnsRecord.Annotations[PublishedCAAZonesAnnotation] = `[{"id":"public"}]`
+			}
+
+			status, published, requeue := r.publishCAAToZone(context.Background(), zone, dnsRecord)
+			if requeue != tc.expectRequeue {
+				t.Errorf("expected requeue to be %t, got %t", tc.expectRequeue, requeue)
+			}
+			if published != tc.expectPublished {
+				t.Errorf("expected published to be %t, got %t", tc.expectPublished, published)
+			}
+			if tc.expectNoStatus {
+				if status != nil {
+					t.Errorf("expected no status, got %+v", status)
+				}
+			} else {
+				if status == nil || len(status.Conditions) != 1 {
+					t.Fatalf("expected a status with one condition, got %+v", status)
+				}
+				cond := status.Conditions[0]
+				if cond.Type != DNSRecordCAAPublishedConditionType || cond.Status != string(tc.expectedStatus) || cond.Reason != tc.expectedReason {
+					t.Errorf("expected status %s and reason %s, got status %s and reason %s: %s", tc.expectedStatus, tc.expectedReason, cond.Status, cond.Reason, cond.Message)
+				}
+			}
+			if actual := fake.records["apps.example.com."]; !dns.CAARecordsEqual(actual, tc.expectedRecords) {
+				t.Errorf("expected CAA records %v, got %v", tc.expectedRecords, actual)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// Test_updatePublishedCAAZonesAnnotation verifies that the published CAA zones
+// annotation is patched onto the DNSRecord and that caaPublishedToZone reads
+// it back.
+func Test_updatePublishedCAAZonesAnnotation(t *testing.T) {
+	zone := configv1.DNSZone{ID: "public"}
+	dnsRecord := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default-wildcard"},
+	}
+	scheme := runtime.NewScheme()
+	iov1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(dnsRecord).Build()
+	r := &reconciler{client: cl}
+
+	get := func() *iov1.DNSRecord {
+		t.Helper()
+		current := &iov1.DNSRecord{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: dnsRecord.Namespace, Name: dnsRecord.Name}, current); err != nil {
+			t.Fatal(err)
+		}
+		return current
+	}
+
+	if err := r.updatePublishedCAAZonesAnnotation(context.Background(), get(), []configv1.DNSZone{zone}); err != nil {
+		t.Fatal(err)
+	}
+	if current := get(); !caaPublishedToZone(current, &zone) {
+		t.Errorf("expected CAA records to be published to zone %v, got annotations %v", zone, current.Annotations)
+	}
+	if err := r.updatePublishedCAAZonesAnnotation(context.Background(), get(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if current := get(); caaPublishedToZone(current, &zone) {
+		t.Errorf("expected CAA records not to be published to zone %v, got annotations %v", zone, current.Annotations)
+	}
+}
+
+// Test_publishCAAToZone_conflictEvent verifies that a CAA record conflict is
+// reported with an event only when it is first detected and not on every
+// requeue.
+func Test_publishCAAToZone_conflictEvent(t *testing.T) {
+	zone := configv1.DNSZone{ID: "public"}
+	fake := &fakeCAAProvider{records: map[string][]dns.CAARecord{
+		"apps.example.com.": {{Tag: "issue", Value: "ca.example.com"}},
+	}}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{dnsProvider: fake, recorder: recorder}
+	dnsRecord := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			dnsrecord.CAARecordsAnnotation: dns.FormatCAARecords([]dns.CAARecord{{Tag: "issue", Value: "letsencrypt.org"}}),
+		}},
+		Spec: iov1.DNSRecordSpec{
+			DNSName:             "*.apps.example.com.",
+			RecordType:          iov1.CNAMERecordType,
+			RecordTTL:           30,
+			DNSManagementPolicy: iov1.ManagedDNS,
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		status, _, requeue := r.publishCAAToZone(context.Background(), zone, dnsRecord)
+		if !requeue {
+			t.Fatalf("expected a conflict to be requeued")
+		}
+		if status == nil || status.Conditions[0].Reason != "CAARecordConflict" {
+			t.Fatalf("expected a CAARecordConflict condition, got %+v", status)
+		}
+		dnsRecord.Status.Zones = []iov1.DNSZoneStatus{*status}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected 1 event, got %d", len(recorder.Events))
+	}
+}
@@ -64,8 +64,18 @@ const (
 	kubeCloudConfigName = "kube-cloud-config"
 	// cloudCABundleKey is the key in the kube cloud config ConfigMap where the custom CA bundle is located
 	cloudCABundleKey = "ca-bundle.pem"
+
+	// providerTimeoutReason is the reason for a DNSRecord zone's
+	// "Published" condition when a DNS provider call exceeded
+	// dnsProviderTimeout.
+	providerTimeoutReason = "ProviderTimeout"
 )
 
+// dnsProviderTimeout is the deadline for each call to the DNS provider.  A
+// provider that hangs would otherwise block reconciliation of DNSRecords
+// indefinitely.  It is a variable to enable unit testing.
+var dnsProviderTimeout = 2 * time.Minute
+
 var log = logf.Logger.WithName(controllerName)
 
 func New(mgr manager.Manager, config Config) (runtimecontroller.Controller, error) {
@@ -80,7 +90,15 @@ func New(mgr manager.Manager, config Config) (runtimecontroller.Controller, erro
 	if err != nil {
 		return nil, err
 	}
-	if err := c.Watch(source.Kind(operatorCache, &iov1.DNSRecord{}), &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{}); err != nil {
+	if err := c.Watch(source.Kind(operatorCache, &iov1.DNSRecord{}), &handler.EnqueueRequestForObject{}, predicate.Or(predicate.GenerationChangedPredicate{}, caaAnnotationChangedPredicate, zonalAnnotationChangedPredicate, failoverAnnotationChangedPredicate)); err != nil {
+		return nil, err
+	}
+	// Watch ingresscontrollers and their wildcard DNSRecords in order to
+	// fail DNSRecords over to standby ingresscontrollers and back.
+	if err := c.Watch(source.Kind(operatorCache, &operatorv1.IngressController{}), handler.EnqueueRequestsFromMapFunc(reconciler.ToFailoverDNSRecords), ingressControllerHealthChangedPredicate); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind(operatorCache, &iov1.DNSRecord{}), handler.EnqueueRequestsFromMapFunc(reconciler.ToFailoverDNSRecords), predicate.GenerationChangedPredicate{}); err != nil {
 		return nil, err
 	}
 	if err := c.Watch(source.Kind(operatorCache, &configv1.DNS{}), handler.EnqueueRequestsFromMapFunc(reconciler.ToDNSRecords)); err != nil {
@@ -160,7 +178,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	// If the DNS record was deleted, clean up and return.
 	if record.DeletionTimestamp != nil {
-		if err := r.delete(record); err != nil {
+		if err := r.delete(ctx, record); err != nil {
 			log.Error(err, "failed to delete dnsrecord; will retry", "dnsrecord", record)
 			return reconcile.Result{RequeueAfter: 15 * time.Second}, nil
 		}
@@ -175,6 +193,16 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return reconcile.Result{}, nil
 	}
 
+	// If the DNSRecord has a failover policy, determine whether it should
+	// be failed over to the standby ingresscontroller or back, and publish
+	// the resulting targets.
+	record, failoverRequeueAfter, err := r.reconcileDNSFailover(ctx, record)
+	if err != nil {
+		log.Error(err, "failed to reconcile DNS failover; will retry", "dnsrecord", request.NamespacedName)
+		failoverRequeueAfter = 30 * time.Second
+	}
+	effectiveRecord := failedOverRecord(record)
+
 	var zones []configv1.DNSZone
 	if dnsConfig.Spec.PrivateZone != nil {
 		zones = append(zones, *dnsConfig.Spec.PrivateZone)
@@ -182,7 +210,30 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	if dnsConfig.Spec.PublicZone != nil {
 		zones = append(zones, *dnsConfig.Spec.PublicZone)
 	}
-	requeue, statuses := r.publishRecordToZones(zones, record)
+	requeue, statuses, published := r.publishRecordToZones(ctx, zones, effectiveRecord)
+
+	// CAA records are only meaningful in the public zone, where
+	// certificate authorities look them up.
+	var caaZones []configv1.DNSZone
+	if dnsConfig.Spec.PublicZone != nil {
+		caaStatus, caaPublished, caaRequeue := r.publishCAAToZone(ctx, *dnsConfig.Spec.PublicZone, record)
+		if caaStatus != nil {
+			statuses = mergeStatuses(zones, statuses, []iov1.DNSZoneStatus{*caaStatus})
+			requeue = requeue || caaRequeue
+		}
+		if caaPublished {
+			caaZones = append(caaZones, *dnsConfig.Spec.PublicZone)
+		}
+	}
+	if err := r.updatePublishedCAAZonesAnnotation(ctx, record, caaZones); err != nil {
+		log.Error(err, "failed to record published CAA zones; will retry", "dnsrecord", request.NamespacedName)
+		requeue = true
+	}
+
+	// Zonal records are published alongside the record in every zone.
+	zonalStatuses, zonalRequeue := r.publishZonalRecordsToZones(ctx, zones, record)
+	statuses = mergeStatuses(zones, statuses, zonalStatuses)
+	requeue = requeue || zonalRequeue
 
 	// Requeue if publishing records failed.
 	result := reconcile.Result{}
@@ -190,7 +241,15 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		result.RequeueAfter = 30 * time.Second
 	}
 
-	if !dnsZoneStatusSlicesEqual(statuses, record.Status.Zones) {
+	if err := r.updatePublishedRecordsAnnotation(ctx, record, published); err != nil {
+		log.Error(err, "failed to record published records; will retry", "dnsrecord", request.NamespacedName)
+		result.RequeueAfter = 10 * time.Second
+	}
+	if failoverRequeueAfter > 0 && (result.RequeueAfter == 0 || failoverRequeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = failoverRequeueAfter
+	}
+
+	if !dnsZoneStatusSlicesEqual(statuses, record.Status.Zones) || record.Status.ObservedGeneration != record.Generation {
 		var current iov1.DNSRecord
 		if err := r.client.Get(ctx, request.NamespacedName, &current); err != nil {
 			log.Error(err, "failed to get dnsrecord; will retry", "dnsrecord", request.NamespacedName)
@@ -271,19 +330,26 @@ func (r *reconciler) createDNSProviderIfNeeded(dnsConfig *configv1.DNS, record *
 // replacePublishedRecord replaces a previously published record with the given record,
 // and the result is returned as a condition. Upon errors during publishing,
 // an error object is returned.
-func (r *reconciler) replacePublishedRecord(zone configv1.DNSZone, record *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
+func (r *reconciler) replacePublishedRecord(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
 	condition := iov1.DNSZoneCondition{
 		Status:             string(operatorv1.ConditionUnknown),
 		Type:               iov1.DNSRecordPublishedConditionType,
 		LastTransitionTime: metav1.Now(),
 	}
 
-	err := r.dnsProvider.Replace(record, zone)
+	err := callDNSProvider(ctx, func(ctx context.Context) error {
+		return r.dnsProvider.Replace(ctx, record, zone)
+	})
 	if err != nil {
 		log.Error(err, "failed to replace DNS record in zone", "record", record.Spec, "dnszone", zone)
 		condition.Status = string(operatorv1.ConditionFalse)
-		condition.Reason = "ProviderError"
-		condition.Message = fmt.Sprintf("The DNS provider failed to replace the record: %v", err)
+		if isDNSProviderTimeout(err) {
+			condition.Reason = providerTimeoutReason
+			condition.Message = fmt.Sprintf("The DNS provider did not replace the record within %v; the operation will be retried: %v", dnsProviderTimeout, err)
+		} else {
+			condition.Reason = "ProviderError"
+			condition.Message = fmt.Sprintf("The DNS provider failed to replace the record: %v", err)
+		}
 	} else {
 		log.Info("replaced DNS record in zone", "record", record.Spec, "dnszone", zone)
 		condition.Status = string(operatorv1.ConditionTrue)
@@ -297,19 +363,26 @@ func (r *reconciler) replacePublishedRecord(zone configv1.DNSZone, record *iov1.
 // publishRecord ensures the given record is published to the provided zone
 // and the result is returned as a condition. Upon errors during publishing
 // an error object is returned.
-func (r *reconciler) publishRecord(zone configv1.DNSZone, record *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
+func (r *reconciler) publishRecord(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
 	condition := iov1.DNSZoneCondition{
 		Status:             string(operatorv1.ConditionUnknown),
 		Type:               iov1.DNSRecordPublishedConditionType,
 		LastTransitionTime: metav1.Now(),
 	}
 
-	err := r.dnsProvider.Ensure(record, zone)
+	err := callDNSProvider(ctx, func(ctx context.Context) error {
+		return r.dnsProvider.Ensure(ctx, record, zone)
+	})
 	if err != nil {
 		log.Error(err, "failed to publish DNS record to zone", "record", record.Spec, "dnszone", zone)
 		condition.Status = string(operatorv1.ConditionFalse)
-		condition.Reason = "ProviderError"
-		condition.Message = fmt.Sprintf("The DNS provider failed to ensure the record: %v", err)
+		if isDNSProviderTimeout(err) {
+			condition.Reason = providerTimeoutReason
+			condition.Message = fmt.Sprintf("The DNS provider did not ensure the record within %v; the operation will be retried: %v", dnsProviderTimeout, err)
+		} else {
+			condition.Reason = "ProviderError"
+			condition.Message = fmt.Sprintf("The DNS provider failed to ensure the record: %v", err)
+		}
 	} else {
 		log.Info("published DNS record to zone", "record", record.Spec, "dnszone", zone)
 		condition.Status = string(operatorv1.ConditionTrue)
@@ -320,19 +393,64 @@ func (r *reconciler) publishRecord(zone configv1.DNSZone, record *iov1.DNSRecord
 	return condition, err
 }
 
+// dnsProviderTimeoutError is returned by callDNSProvider if the DNS provider
+// did not complete the call before the call's deadline.
+type dnsProviderTimeoutError struct {
+	err error
+}
+
+func (e *dnsProviderTimeoutError) Error() string {
+	return fmt.Sprintf("DNS provider call timed out after %v: %v", dnsProviderTimeout, e.err)
+}
+
+func (e *dnsProviderTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// callDNSProvider invokes the given function, which is expected to call the DNS
+// provider, with a context derived from ctx that expires after
+// dnsProviderTimeout.  If the call fails because that deadline was exceeded,
+// the error is returned as a *dnsProviderTimeoutError.
+func callDNSProvider(ctx context.Context, call func(ctx context.Context) error) error {
+	callCtx, cancel := context.WithTimeout(ctx, dnsProviderTimeout)
+	defer cancel()
+
+	err := call(callCtx)
+	if err != nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &dnsProviderTimeoutError{err: err}
+	}
+	return err
+}
+
+// isDNSProviderTimeout returns a Boolean value indicating whether the given
+// error is the result of a DNS provider call that exceeded its deadline.
+func isDNSProviderTimeout(err error) bool {
+	_, ok := err.(*dnsProviderTimeoutError)
+	return ok
+}
+
 // publishRecordToZones attempts to publish records and returns a bool
-// indicating if we need to requeue due to errors and list of latest DNS Zone status.
-func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1.DNSRecord) (bool, []iov1.DNSZoneStatus) {
+// indicating if we need to requeue due to errors, list of latest DNS Zone
+// status, and the list of records that were successfully published.
+func (r *reconciler) publishRecordToZones(ctx context.Context, zones []configv1.DNSZone, record *iov1.DNSRecord) (bool, []iov1.DNSZoneStatus, []PublishedRecord) {
 	var statuses []iov1.DNSZoneStatus
+	var published []PublishedRecord
 	var requeue bool
 	dnsPolicy := record.Spec.DNSManagementPolicy
 	for i := range zones {
-		isRecordPublished := recordIsAlreadyPublishedToZone(record, &zones[i])
-
-		// Only publish the record if the DNSRecord has been modified
-		// (which would mean the target could have changed) or its
-		// status does not indicate that it has already been published.
-		if record.Generation == record.Status.ObservedGeneration && isRecordPublished {
+		isRecordPublished, isPublishedRecordCurrent := recordIsAlreadyPublishedToZone(record, &zones[i])
+
+		// Only publish the record if what was last published to the
+		// zone differs from the DNSRecord's spec or its status does
+		// not indicate that it has already been published.  Nothing is
+		// published for an unmanaged record, so the published-records
+		// annotation says nothing about whether its status is current;
+		// as before the annotation was introduced, its status is
+		// updated only if the DNSRecord has been modified.
+		if dnsPolicy == iov1.UnmanagedDNS {
+			isPublishedRecordCurrent = record.Generation == record.Status.ObservedGeneration
+		}
+		if isRecordPublished && isPublishedRecordCurrent {
 			log.Info("skipping zone to which the DNS record is already published", "record", record.Spec, "dnszone", zones[i])
 			continue
 		}
@@ -349,15 +467,17 @@ func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1
 				LastTransitionTime: metav1.Now(),
 			}
 		} else if isRecordPublished {
-			condition, err = r.replacePublishedRecord(zones[i], record)
+			condition, err = r.replacePublishedRecord(ctx, zones[i], record)
 		} else {
-			condition, err = r.publishRecord(zones[i], record)
+			condition, err = r.publishRecord(ctx, zones[i], record)
 		}
 
 		// Check if replacing or publishing record resulted in an error.
 		// If it did, re-enqueue the request for processing.
 		if err != nil {
 			requeue = true
+		} else if dnsPolicy != iov1.UnmanagedDNS {
+			published = append(published, r.newPublishedRecord(ctx, record, zones[i]))
 		}
 
 		statuses = append(statuses, iov1.DNSZoneStatus{
@@ -366,13 +486,21 @@ func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1
 		})
 	}
 
-	return requeue, mergeStatuses(zones, record.Status.DeepCopy().Zones, statuses)
+	return requeue, mergeStatuses(zones, record.Status.DeepCopy().Zones, statuses), published
 }
 
-// recordIsAlreadyPublishedToZone returns a Boolean value indicating whether the
-// given DNSRecord is already published to the given zone, as determined from
-// the DNSRecord's status conditions.
-func recordIsAlreadyPublishedToZone(record *iov1.DNSRecord, zoneToPublish *configv1.DNSZone) bool {
+// recordIsAlreadyPublishedToZone returns two Boolean values.  The first
+// indicates whether the given DNSRecord is already published to the given
+// zone, as determined from the DNSRecord's status conditions.  The second
+// indicates whether the published record is current, meaning that no change
+// needs to be made in the zone.  If the DNSRecord's published-records
+// annotation has an entry for the zone, the record is current if that entry
+// matches the DNSRecord's spec.  Otherwise, for example for records that were
+// published by an earlier version of the operator, the record is current if
+// the DNSRecord's generation has been observed.
+func recordIsAlreadyPublishedToZone(record *iov1.DNSRecord, zoneToPublish *configv1.DNSZone) (bool, bool) {
+	isPublished := false
+zones:
 	for _, zoneInStatus := range record.Status.Zones {
 		if !reflect.DeepEqual(&zoneInStatus.DNSZone, zoneToPublish) {
 			continue
@@ -380,24 +508,37 @@ func recordIsAlreadyPublishedToZone(record *iov1.DNSRecord, zoneToPublish *confi
 
 		for _, condition := range zoneInStatus.Conditions {
 			if condition.Type == iov1.DNSRecordPublishedConditionType {
-				return condition.Status == string(operatorv1.ConditionTrue)
+				isPublished = condition.Status == string(operatorv1.ConditionTrue)
+				break zones
 			}
 		}
 	}
+	if !isPublished {
+		return false, false
+	}
 
-	return false
+	if published := publishedRecordForZone(record, zoneToPublish); published != nil {
+		return true, publishedRecordMatchesSpec(published, &record.Spec)
+	}
+
+	return true, record.Generation == record.Status.ObservedGeneration
 }
 
-func (r *reconciler) delete(record *iov1.DNSRecord) error {
-	var errs []error
+func (r *reconciler) delete(ctx context.Context, record *iov1.DNSRecord) error {
+	errs := r.deleteCAAFromZones(ctx, record)
+	errs = append(errs, r.deleteZonalRecordsFromZones(ctx, record)...)
 	for i := range record.Status.Zones {
 		zone := record.Status.Zones[i].DNSZone
 		// If the record is currently not published in a zone,
 		// skip deleting it for that zone.
-		if !recordIsAlreadyPublishedToZone(record, &zone) {
+		if isPublished, _ := recordIsAlreadyPublishedToZone(record, &zone); !isPublished {
 			continue
 		}
-		err := r.dnsProvider.Delete(record, zone)
+		// If the record is failed over, the published record has
+		// the standby's targets.
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return r.dnsProvider.Delete(ctx, failedOverRecord(record), zone)
+		})
 		if err != nil {
 			errs = append(errs, err)
 		} else {
@@ -408,10 +549,15 @@ func (r *reconciler) delete(record *iov1.DNSRecord) error {
 		updated := record.DeepCopy()
 		if slice.ContainsString(updated.Finalizers, manifests.DNSRecordFinalizer) {
 			updated.Finalizers = slice.RemoveString(updated.Finalizers, manifests.DNSRecordFinalizer)
-			if err := r.client.Update(context.TODO(), updated); err != nil {
+			if err := r.client.Update(ctx, updated); err != nil {
 				errs = append(errs, fmt.Errorf("failed to remove finalizer from dnsrecord %s: %v", record.Name, err))
 			}
 		}
+		if _, ok := record.Annotations[DNSFailoverStateAnnotation]; ok {
+			if icName, ok := record.Labels[manifests.OwningIngressControllerLabel]; ok {
+				DeleteDNSFailoverMetrics(icName)
+			}
+		}
 	}
 	return utilerrors.NewAggregate(errs)
 }
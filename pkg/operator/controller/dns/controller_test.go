@@ -1,7 +1,9 @@
 package dns
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -119,7 +121,7 @@ func Test_publishRecordToZones(t *testing.T) {
 				dnsProvider: &dns.FakeProvider{},
 			}
 
-			_, actual := r.publishRecordToZones(test.zones, record)
+			_, actual, _ := r.publishRecordToZones(context.Background(), test.zones, record)
 			opts := cmpopts.IgnoreFields(iov1.DNSZoneCondition{}, "Reason", "Message", "LastTransitionTime")
 			if !cmp.Equal(actual, test.expect, opts) {
 				t.Fatalf("found diff between actual and expected:\n%s", cmp.Diff(actual, test.expect, opts))
@@ -128,6 +130,136 @@ func Test_publishRecordToZones(t *testing.T) {
 	}
 }
 
+// Test_publishRecordToZonesUnmanaged verifies that publishRecordToZones updates
+// the status of a published record that has become unmanaged only if the
+// DNSRecord has been modified, regardless of the published-records annotation.
+func Test_publishRecordToZonesUnmanaged(t *testing.T) {
+	zone := configv1.DNSZone{ID: "zone"}
+	spec := iov1.DNSRecordSpec{
+		DNSName:             "subdomain.dnszone.io.",
+		RecordType:          iov1.ARecordType,
+		DNSManagementPolicy: iov1.UnmanagedDNS,
+		Targets:             []string{"55.11.22.33"},
+	}
+	publishedAnnotation := `[{"dnsZone":{"id":"zone"},"dnsName":"subdomain.dnszone.io.","targets":["55.11.22.33"],"recordType":"A","recordTTL":0,"publishTime":null}]`
+	tests := []struct {
+		name               string
+		generation         int64
+		observedGeneration int64
+		annotations        map[string]string
+		expectStatus       string
+	}{
+		{
+			name:               "unmodified record",
+			generation:         1,
+			observedGeneration: 1,
+			expectStatus:       "True",
+		},
+		{
+			name:               "unmodified record with a matching published-records annotation",
+			generation:         1,
+			observedGeneration: 1,
+			annotations:        map[string]string{PublishedRecordsAnnotation: publishedAnnotation},
+			expectStatus:       "True",
+		},
+		{
+			name:               "modified record",
+			generation:         2,
+			observedGeneration: 1,
+			expectStatus:       "Unknown",
+		},
+		{
+			name:               "modified record with a matching published-records annotation",
+			generation:         2,
+			observedGeneration: 1,
+			annotations:        map[string]string{PublishedRecordsAnnotation: publishedAnnotation},
+			expectStatus:       "Unknown",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation:  test.generation,
+					Annotations: test.annotations,
+				},
+				Spec: spec,
+				Status: iov1.DNSRecordStatus{
+					ObservedGeneration: test.observedGeneration,
+					Zones: []iov1.DNSZoneStatus{{
+						DNSZone:    zone,
+						Conditions: []iov1.DNSZoneCondition{{Type: "Published", Status: "True"}},
+					}},
+				},
+			}
+			r := &reconciler{dnsProvider: &dns.FakeProvider{}}
+			_, statuses, published := r.publishRecordToZones(context.Background(), []configv1.DNSZone{zone}, record)
+			if len(published) != 0 {
+				t.Errorf("expected no published records, got %v", published)
+			}
+			if len(statuses) != 1 || len(statuses[0].Conditions) != 1 || statuses[0].Conditions[0].Status != test.expectStatus {
+				t.Errorf("expected Published condition with status %s, got %+v", test.expectStatus, statuses)
+			}
+		})
+	}
+}
+
+// hangingProvider is a dns.Provider whose methods block until the context
+// that is passed to them is done.
+type hangingProvider struct{}
+
+func (_ *hangingProvider) Ensure(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (_ *hangingProvider) Delete(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (_ *hangingProvider) Replace(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Test_publishRecordToZonesProviderTimeout verifies that publishRecordToZones
+// bounds each DNS provider call with dnsProviderTimeout and reports a timeout
+// as a retryable "Published" condition with the "ProviderTimeout" reason.
+func Test_publishRecordToZonesProviderTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { dnsProviderTimeout = timeout }(dnsProviderTimeout)
+	dnsProviderTimeout = 10 * time.Millisecond
+
+	zone := configv1.DNSZone{ID: "zone"}
+	record := &iov1.DNSRecord{
+		Spec: iov1.DNSRecordSpec{
+			DNSName:             "subdomain.dnszone.io.",
+			RecordType:          iov1.ARecordType,
+			DNSManagementPolicy: iov1.ManagedDNS,
+			Targets:             []string{"55.11.22.33"},
+		},
+	}
+	r := &reconciler{dnsProvider: &hangingProvider{}}
+
+	requeue, statuses, published := r.publishRecordToZones(context.Background(), []configv1.DNSZone{zone}, record)
+	if !requeue {
+		t.Error("expected requeue")
+	}
+	if len(published) != 0 {
+		t.Errorf("expected no published records, got %v", published)
+	}
+	expected := []iov1.DNSZoneStatus{{
+		DNSZone: zone,
+		Conditions: []iov1.DNSZoneCondition{{
+			Type:   iov1.DNSRecordPublishedConditionType,
+			Status: string(operatorv1.ConditionFalse),
+			Reason: providerTimeoutReason,
+		}},
+	}}
+	opts := cmpopts.IgnoreFields(iov1.DNSZoneCondition{}, "Message", "LastTransitionTime")
+	if !cmp.Equal(statuses, expected, opts) {
+		t.Fatalf("found diff between actual and expected:\n%s", cmp.Diff(statuses, expected, opts))
+	}
+}
+
 // TestPublishRecordToZonesMergesStatus verifies that publishRecordToZones
 // correctly merges status updates.
 func TestPublishRecordToZonesMergesStatus(t *testing.T) {
@@ -218,7 +350,7 @@ func TestPublishRecordToZonesMergesStatus(t *testing.T) {
 			r := &reconciler{dnsProvider: &dns.FakeProvider{}}
 			zone := []configv1.DNSZone{{ID: "zone2"}}
 			oldStatuses := record.Status.DeepCopy().Zones
-			_, newStatuses := r.publishRecordToZones(zone, record)
+			_, newStatuses, _ := r.publishRecordToZones(context.Background(), zone, record)
 			if !dnsZoneStatusSlicesEqual(oldStatuses, tc.oldZoneStatuses) {
 				t.Fatalf("publishRecordToZones mutated the record's status conditions\nold: %#v\nnew: %#v", oldStatuses, tc.oldZoneStatuses)
 			}
@@ -634,10 +766,13 @@ func Test_recordIsAlreadyPublishedToZone(t *testing.T) {
 		zoneWithTag = configv1.DNSZone{Tags: map[string]string{"foo": "bar"}}
 	)
 	testCases := []struct {
-		description  string
-		zone         *configv1.DNSZone
-		zoneStatuses []iov1.DNSZoneStatus
-		expect       bool
+		description   string
+		zone          *configv1.DNSZone
+		zoneStatuses  []iov1.DNSZoneStatus
+		annotations   map[string]string
+		generation    int64
+		expect        bool
+		expectCurrent bool
 	}{
 		{
 			description:  "status.zones is empty",
@@ -743,7 +878,8 @@ func Test_recordIsAlreadyPublishedToZone(t *testing.T) {
 					},
 				},
 			},
-			expect: true,
+			expect:        true,
+			expectCurrent: true,
 		},
 		{
 			description: "status.zones has an entry with matching tag and Published=True",
@@ -768,19 +904,112 @@ func Test_recordIsAlreadyPublishedToZone(t *testing.T) {
 					},
 				},
 			},
-			expect: true,
+			expect:        true,
+			expectCurrent: true,
+		},
+		{
+			description: "Published=True, no published records annotation, and generation changed",
+			zone:        &zoneWithId,
+			zoneStatuses: []iov1.DNSZoneStatus{
+				{
+					DNSZone: zoneWithId,
+					Conditions: []iov1.DNSZoneCondition{
+						{
+							Type:   "Published",
+							Status: "True",
+						},
+					},
+				},
+			},
+			generation:    2,
+			expect:        true,
+			expectCurrent: false,
+		},
+		{
+			description: "Published=True, generation changed, but published records annotation matches spec",
+			zone:        &zoneWithId,
+			zoneStatuses: []iov1.DNSZoneStatus{
+				{
+					DNSZone: zoneWithId,
+					Conditions: []iov1.DNSZoneCondition{
+						{
+							Type:   "Published",
+							Status: "True",
+						},
+					},
+				},
+			},
+			annotations: map[string]string{
+				PublishedRecordsAnnotation: `[{"dnsZone":{"id":"foo"},"dnsName":"*.apps.example.com.","targets":["lb.example.com"],"recordType":"CNAME","recordTTL":30,"publishTime":null}]`,
+			},
+			generation:    2,
+			expect:        true,
+			expectCurrent: true,
+		},
+		{
+			description: "Published=True but published records annotation has a different target",
+			zone:        &zoneWithId,
+			zoneStatuses: []iov1.DNSZoneStatus{
+				{
+					DNSZone: zoneWithId,
+					Conditions: []iov1.DNSZoneCondition{
+						{
+							Type:   "Published",
+							Status: "True",
+						},
+					},
+				},
+			},
+			annotations: map[string]string{
+				PublishedRecordsAnnotation: `[{"dnsZone":{"id":"foo"},"dnsName":"*.apps.example.com.","targets":["old-lb.example.com"],"recordType":"CNAME","recordTTL":30,"publishTime":null}]`,
+			},
+			expect:        true,
+			expectCurrent: false,
+		},
+		{
+			description: "Published=False and published records annotation matches spec",
+			zone:        &zoneWithId,
+			zoneStatuses: []iov1.DNSZoneStatus{
+				{
+					DNSZone: zoneWithId,
+					Conditions: []iov1.DNSZoneCondition{
+						{
+							Type:   "Published",
+							Status: "False",
+						},
+					},
+				},
+			},
+			annotations: map[string]string{
+				PublishedRecordsAnnotation: `[{"dnsZone":{"id":"foo"},"dnsName":"*.apps.example.com.","targets":["lb.example.com"],"recordType":"CNAME","recordTTL":30,"publishTime":null}]`,
+			},
+			expect:        false,
+			expectCurrent: false,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
 			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tc.annotations,
+					Generation:  tc.generation,
+				},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:    "*.apps.example.com.",
+					Targets:    []string{"lb.example.com"},
+					RecordType: iov1.CNAMERecordType,
+					RecordTTL:  30,
+				},
 				Status: iov1.DNSRecordStatus{Zones: tc.zoneStatuses},
 			}
-			actual := recordIsAlreadyPublishedToZone(record, tc.zone)
+			actual, actualCurrent := recordIsAlreadyPublishedToZone(record, tc.zone)
 			if actual != tc.expect {
 				t.Errorf("expected %t, got %t", tc.expect, actual)
 			}
+			if actualCurrent != tc.expectCurrent {
+				t.Errorf("expected current to be %t, got %t", tc.expectCurrent, actualCurrent)
+			}
 		})
 	}
 }
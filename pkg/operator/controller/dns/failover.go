@@ -0,0 +1,410 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DNSFailoverStateAnnotation is the key of an annotation that the DNS
+	// controller adds to DNSRecord CRs that have a failover policy to
+	// record whether the DNSRecord is failed over to a standby
+	// ingresscontroller.  The value is a JSON DNSFailoverState value.
+	DNSFailoverStateAnnotation = "ingress.operator.openshift.io/dns-failover-state"
+
+	// loadBalancerProbesSucceedingConditionType is the type of the
+	// ingresscontroller status condition that the canary controller sets
+	// on each ingresscontroller that has a DNS failover standby.  The
+	// canary controller probes the ingresscontroller through its own load
+	// balancer, so the condition does not follow the DNSRecord to the
+	// standby.  It has the same value as
+	// IngressControllerLoadBalancerProbeSuccessConditionType in the ingress
+	// controller package, which this package does not import.
+	loadBalancerProbesSucceedingConditionType = "LoadBalancerProbesSucceeding"
+
+	// maxFailbackDelayFactor limits how much repeated failovers extend the
+	// failback delay.
+	maxFailbackDelayFactor = 8
+)
+
+// DNSFailoverState describes the failover state of a DNSRecord.
+type DNSFailoverState struct {
+	// Active indicates whether the DNSRecord is failed over.
+	Active bool `json:"active"`
+	// Standby is the name of the standby ingresscontroller.
+	Standby string `json:"standby"`
+	// Targets are the targets of the standby's wildcard DNSRecord that
+	// were published for the DNSRecord while it is failed over.
+	Targets []string `json:"targets,omitempty"`
+	// RecordType is the type of the standby's wildcard DNSRecord.
+	RecordType iov1.DNSRecordType `json:"recordType,omitempty"`
+	// LastTransitionTime is the time of the last failover or failback.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// ConsecutiveFailovers is the number of failovers that each followed
+	// the previous failback within the failback delay.  Each consecutive
+	// failover doubles the failback delay, up to maxFailbackDelayFactor
+	// times the configured delay, so that an ingresscontroller that
+	// repeatedly recovers only briefly does not flap.
+	ConsecutiveFailovers int `json:"consecutiveFailovers,omitempty"`
+}
+
+// failoverAnnotationChangedPredicate matches updates to DNSRecords that change
+// the failover policy annotation.  Annotations do not change the DNSRecord's
+// generation, so these updates are otherwise filtered out.
+var failoverAnnotationChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return e.ObjectOld.GetAnnotations()[dnsrecord.FailoverPolicyAnnotation] != e.ObjectNew.GetAnnotations()[dnsrecord.FailoverPolicyAnnotation]
+	},
+}
+
+// ingressControllerHealthChangedPredicate matches updates to
+// ingresscontrollers that change the status conditions that determine DNS
+// failover.
+var ingressControllerHealthChangedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return true },
+	DeleteFunc: func(e event.DeleteEvent) bool { return true },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldIC, ok := e.ObjectOld.(*operatorv1.IngressController)
+		if !ok {
+			return false
+		}
+		newIC, ok := e.ObjectNew.(*operatorv1.IngressController)
+		if !ok {
+			return false
+		}
+		for _, conditionType := range []string{operatorv1.OperatorStatusTypeAvailable, operatorv1.OperatorStatusTypeDegraded, loadBalancerProbesSucceedingConditionType} {
+			if !reflect.DeepEqual(getIngressControllerCondition(oldIC, conditionType), getIngressControllerCondition(newIC, conditionType)) {
+				return true
+			}
+		}
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+// dnsFailoverState returns the state from the given DNSRecord's failover state
+// annotation, or nil if the annotation is absent or cannot be parsed.
+func dnsFailoverState(record *iov1.DNSRecord) *DNSFailoverState {
+	value, ok := record.Annotations[DNSFailoverStateAnnotation]
+	if !ok {
+		return nil
+	}
+	var state DNSFailoverState
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		log.Error(err, "failed to parse annotation; ignoring it", "dnsrecord", record.Name, "annotation", DNSFailoverStateAnnotation)
+		return nil
+	}
+	return &state
+}
+
+// failedOverRecord returns the record that the DNS controller publishes for
+// the given DNSRecord.  If the DNSRecord is failed over, this is a copy of the
+// DNSRecord with the standby's targets and record type.  Otherwise, it is the
+// DNSRecord itself.
+func failedOverRecord(record *iov1.DNSRecord) *iov1.DNSRecord {
+	state := dnsFailoverState(record)
+	if state == nil || !state.Active || len(state.Targets) == 0 {
+		return record
+	}
+	effective := record.DeepCopy()
+	effective.Spec.Targets = append([]string{}, state.Targets...)
+	if len(state.RecordType) != 0 {
+		effective.Spec.RecordType = state.RecordType
+	}
+	return effective
+}
+
+// getIngressControllerCondition returns the ingresscontroller's status
+// condition of the given type, or nil if it has none.
+func getIngressControllerCondition(ic *operatorv1.IngressController, conditionType string) *operatorv1.OperatorCondition {
+	for i := range ic.Status.Conditions {
+		if ic.Status.Conditions[i].Type == conditionType {
+			return &ic.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// ingressControllerHealth returns a Boolean value indicating whether the given
+// ingresscontroller is healthy, and the time since which it has been healthy or
+// unhealthy.  An ingresscontroller is unhealthy if it is degraded or the canary
+// controller's probes through its load balancer are failing.
+func ingressControllerHealth(ic *operatorv1.IngressController) (bool, time.Time) {
+	var failing, passing []time.Time
+	if cond := getIngressControllerCondition(ic, operatorv1.OperatorStatusTypeDegraded); cond != nil {
+		if cond.Status == operatorv1.ConditionTrue {
+			failing = append(failing, cond.LastTransitionTime.Time)
+		} else {
+			passing = append(passing, cond.LastTransitionTime.Time)
+		}
+	}
+	if cond := getIngressControllerCondition(ic, loadBalancerProbesSucceedingConditionType); cond != nil {
+		if cond.Status == operatorv1.ConditionFalse {
+			failing = append(failing, cond.LastTransitionTime.Time)
+		} else {
+			passing = append(passing, cond.LastTransitionTime.Time)
+		}
+	}
+	// Each failing condition has been failing since its last transition,
+	// so the ingresscontroller has been unhealthy since the earliest of
+	// these transitions.  It has been healthy since the latest transition
+	// of the passing conditions.
+	if len(failing) != 0 {
+		sort.Slice(failing, func(i, j int) bool { return failing[i].Before(failing[j]) })
+		return false, failing[0]
+	}
+	var since time.Time
+	for _, t := range passing {
+		if t.After(since) {
+			since = t
+		}
+	}
+	return true, since
+}
+
+// ingressControllerCanServe returns a Boolean value indicating whether the
+// given ingresscontroller is available and not degraded, which is required for
+// it to take over as a standby.
+func ingressControllerCanServe(ic *operatorv1.IngressController) bool {
+	available := getIngressControllerCondition(ic, operatorv1.OperatorStatusTypeAvailable)
+	degraded := getIngressControllerCondition(ic, operatorv1.OperatorStatusTypeDegraded)
+	return available != nil && available.Status == operatorv1.ConditionTrue &&
+		(degraded == nil || degraded.Status != operatorv1.ConditionTrue)
+}
+
+// failbackDelay returns how long the primary ingresscontroller must be healthy
+// before the DNSRecord fails back, given the policy and the number of
+// consecutive failovers.
+func failbackDelay(policy *dnsrecord.FailoverPolicy, consecutiveFailovers int) time.Duration {
+	factor := 1
+	for i := 1; i < consecutiveFailovers && factor < maxFailbackDelayFactor; i++ {
+		factor *= 2
+	}
+	return policy.FailbackDelay.Duration * time.Duration(factor)
+}
+
+// reconcileDNSFailover fails the given DNSRecord over to the standby that its
+// failover policy designates, or back from the standby, as the health of the
+// owning and standby ingresscontrollers requires.  It records the result in the
+// DNSRecord's failover state annotation and returns the possibly updated
+// DNSRecord, as well as a duration after which the DNSRecord should be
+// reconciled again to re-evaluate failover, or zero if no re-evaluation is
+// needed.
+func (r *reconciler) reconcileDNSFailover(ctx context.Context, record *iov1.DNSRecord) (*iov1.DNSRecord, time.Duration, error) {
+	state := dnsFailoverState(record)
+	policy, err := dnsrecord.FailoverPolicyForDNSRecord(record)
+	if err != nil {
+		log.Error(err, "ignoring invalid failover policy", "dnsrecord", record.Name)
+	}
+	icName := record.Labels[manifests.OwningIngressControllerLabel]
+	if policy == nil || record.Spec.DNSManagementPolicy == iov1.UnmanagedDNS || len(icName) == 0 {
+		if state != nil && state.Active {
+			return r.failBack(ctx, record, nil, state, "DNS failover is no longer configured")
+		}
+		if len(icName) != 0 {
+			SetDNSFailoverActiveMetric(icName, false)
+		}
+		return record, 0, nil
+	}
+
+	primary := &operatorv1.IngressController{}
+	if err := r.cache.Get(ctx, types.NamespacedName{Namespace: record.Namespace, Name: icName}, primary); err != nil {
+		if errors.IsNotFound(err) {
+			return record, 0, nil
+		}
+		return record, 0, fmt.Errorf("failed to get ingresscontroller %s: %w", icName, err)
+	}
+
+	standby := &operatorv1.IngressController{}
+	var standbyRecord *iov1.DNSRecord
+	standbyUnavailable := ""
+	if err := r.cache.Get(ctx, types.NamespacedName{Namespace: record.Namespace, Name: policy.Standby}, standby); err != nil {
+		if !errors.IsNotFound(err) {
+			return record, 0, fmt.Errorf("failed to get standby ingresscontroller %s: %w", policy.Standby, err)
+		}
+		standbyUnavailable = fmt.Sprintf("standby ingresscontroller %s does not exist", policy.Standby)
+	} else if !ingressControllerCanServe(standby) {
+		standbyUnavailable = fmt.Sprintf("standby ingresscontroller %s is unavailable or degraded", policy.Standby)
+	} else {
+		standbyRecord = &iov1.DNSRecord{}
+		if err := r.cache.Get(ctx, controller.WildcardDNSRecordName(standby), standbyRecord); err != nil {
+			if !errors.IsNotFound(err) {
+				return record, 0, fmt.Errorf("failed to get wildcard dnsrecord for standby ingresscontroller %s: %w", policy.Standby, err)
+			}
+			standbyUnavailable = fmt.Sprintf("standby ingresscontroller %s has no wildcard dnsrecord", policy.Standby)
+		} else if len(standbyRecord.Spec.Targets) == 0 {
+			standbyUnavailable = fmt.Sprintf("standby ingresscontroller %s has no load balancer targets", policy.Standby)
+		}
+	}
+
+	healthy, since := ingressControllerHealth(primary)
+	now := clock.Now()
+
+	if state == nil || !state.Active {
+		SetDNSFailoverActiveMetric(primary.Name, false)
+		if healthy {
+			return record, 0, nil
+		}
+		if len(standbyUnavailable) != 0 {
+			log.Info("not failing over because the standby cannot serve", "dnsrecord", record.Name, "ingresscontroller", primary.Name, "reason", standbyUnavailable)
+			return record, 0, nil
+		}
+		if elapsed := now.Sub(since); elapsed < policy.Threshold.Duration {
+			return record, policy.Threshold.Duration - elapsed, nil
+		}
+		consecutive := 1
+		if state != nil && now.Sub(state.LastTransitionTime.Time) < failbackDelay(policy, state.ConsecutiveFailovers) {
+			consecutive = state.ConsecutiveFailovers + 1
+		}
+		updated := &DNSFailoverState{
+			Active:               true,
+			Standby:              policy.Standby,
+			Targets:              append([]string{}, standbyRecord.Spec.Targets...),
+			RecordType:           standbyRecord.Spec.RecordType,
+			LastTransitionTime:   metav1.NewTime(now),
+			ConsecutiveFailovers: consecutive,
+		}
+		record, err := r.updateDNSFailoverState(ctx, record, updated)
+		if err != nil {
+			return record, 0, err
+		}
+		r.recorder.Eventf(primary, "Warning", "DNSFailover", "Ingresscontroller has been unhealthy since %s; pointed wildcard DNS record %s at standby ingresscontroller %s (targets %v)", since.UTC().Format(time.RFC3339), record.Spec.DNSName, policy.Standby, updated.Targets)
+		log.Info("failed over dnsrecord to standby", "dnsrecord", record.Name, "ingresscontroller", primary.Name, "standby", policy.Standby, "targets", updated.Targets)
+		DNSFailoverSwitches.WithLabelValues(primary.Name, "failover").Inc()
+		SetDNSFailoverActiveMetric(primary.Name, true)
+		return record, 0, nil
+	}
+
+	switch {
+	case state.Standby != policy.Standby:
+		return r.failBack(ctx, record, primary, state, fmt.Sprintf("the standby changed from %s to %s", state.Standby, policy.Standby))
+	case len(standbyUnavailable) != 0:
+		return r.failBack(ctx, record, primary, state, standbyUnavailable)
+	case !healthy:
+		// Follow the standby's load balancer if it changes.
+		if !reflect.DeepEqual(state.Targets, standbyRecord.Spec.Targets) || state.RecordType != standbyRecord.Spec.RecordType {
+			updated := *state
+			updated.Targets = append([]string{}, standbyRecord.Spec.Targets...)
+			updated.RecordType = standbyRecord.Spec.RecordType
+			record, err := r.updateDNSFailoverState(ctx, record, &updated)
+			return record, 0, err
+		}
+		SetDNSFailoverActiveMetric(primary.Name, true)
+		return record, 0, nil
+	}
+	// The primary must have been healthy for the failback delay, and
+	// only the time since the failover counts.
+	if since.Before(state.LastTransitionTime.Time) {
+		since = state.LastTransitionTime.Time
+	}
+	delay := failbackDelay(policy, state.ConsecutiveFailovers)
+	if elapsed := now.Sub(since); elapsed < delay {
+		SetDNSFailoverActiveMetric(primary.Name, true)
+		return record, delay - elapsed, nil
+	}
+	return r.failBack(ctx, record, primary, state, fmt.Sprintf("ingresscontroller has been healthy since %s", since.UTC().Format(time.RFC3339)))
+}
+
+// failBack points the given DNSRecord back at its own targets, records an event
+// on the given ingresscontroller, or on the DNSRecord if the ingresscontroller
+// is nil, and returns the updated DNSRecord.
+func (r *reconciler) failBack(ctx context.Context, record *iov1.DNSRecord, ic *operatorv1.IngressController, state *DNSFailoverState, reason string) (*iov1.DNSRecord, time.Duration, error) {
+	updated := &DNSFailoverState{
+		Standby:              state.Standby,
+		LastTransitionTime:   metav1.NewTime(clock.Now()),
+		ConsecutiveFailovers: state.ConsecutiveFailovers,
+	}
+	record, err := r.updateDNSFailoverState(ctx, record, updated)
+	if err != nil {
+		return record, 0, err
+	}
+	var object runtime.Object = record
+	icName := record.Labels[manifests.OwningIngressControllerLabel]
+	if ic != nil {
+		object, icName = ic, ic.Name
+	}
+	r.recorder.Eventf(object, "Normal", "DNSFailback", "Pointed wildcard DNS record %s back at the ingresscontroller's load balancer (targets %v) from standby ingresscontroller %s: %s", record.Spec.DNSName, record.Spec.Targets, state.Standby, reason)
+	log.Info("failed back dnsrecord from standby", "dnsrecord", record.Name, "standby", state.Standby, "reason", reason)
+	if len(icName) != 0 {
+		DNSFailoverSwitches.WithLabelValues(icName, "failback").Inc()
+		SetDNSFailoverActiveMetric(icName, false)
+	}
+	return record, 0, nil
+}
+
+// updateDNSFailoverState patches the given DNSRecord's failover state
+// annotation and returns the updated DNSRecord.
+func (r *reconciler) updateDNSFailoverState(ctx context.Context, record *iov1.DNSRecord, state *DNSFailoverState) (*iov1.DNSRecord, error) {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return record, fmt.Errorf("failed to marshal failover state: %w", err)
+	}
+	updated := record.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[DNSFailoverStateAnnotation] = string(value)
+	if err := r.client.Patch(ctx, updated, client.MergeFrom(record)); err != nil {
+		return record, fmt.Errorf("failed to annotate dnsrecord %s/%s with failover state: %w", record.Namespace, record.Name, err)
+	}
+	return updated, nil
+}
+
+// ToFailoverDNSRecords maps an ingresscontroller, or the wildcard DNSRecord of
+// an ingresscontroller, to the DNSRecords whose failover policies involve that
+// ingresscontroller as the owner or the standby.
+func (r *reconciler) ToFailoverDNSRecords(ctx context.Context, o client.Object) []reconcile.Request {
+	icName := o.GetName()
+	if _, ok := o.(*iov1.DNSRecord); ok {
+		icName = o.GetLabels()[manifests.OwningIngressControllerLabel]
+		if len(icName) == 0 {
+			return nil
+		}
+	}
+	records := &iov1.DNSRecordList{}
+	if err := r.cache.List(ctx, records, client.InNamespace(o.GetNamespace())); err != nil {
+		log.Error(err, "failed to list dnsrecords", "namespace", o.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range records.Items {
+		record := &records.Items[i]
+		if record.Name == o.GetName() && record.Namespace == o.GetNamespace() {
+			continue
+		}
+		policy, err := dnsrecord.FailoverPolicyForDNSRecord(record)
+		if err != nil || policy == nil {
+			continue
+		}
+		if record.Labels[manifests.OwningIngressControllerLabel] != icName && policy.Standby != icName {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: record.Namespace, Name: record.Name},
+		})
+	}
+	return requests
+}
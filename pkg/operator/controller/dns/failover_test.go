@@ -0,0 +1,259 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
+
+func Test_ingressControllerHealth(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	condition := func(conditionType string, status operatorv1.ConditionStatus, t time.Time) operatorv1.OperatorCondition {
+		return operatorv1.OperatorCondition{Type: conditionType, Status: status, LastTransitionTime: metav1.NewTime(t)}
+	}
+	testCases := []struct {
+		description   string
+		conditions    []operatorv1.OperatorCondition
+		expectHealthy bool
+		expectSince   time.Time
+	}{
+		{
+			description:   "no conditions",
+			expectHealthy: true,
+		},
+		{
+			description: "not degraded, load balancer probes succeeding",
+			conditions: []operatorv1.OperatorCondition{
+				condition(operatorv1.OperatorStatusTypeDegraded, operatorv1.ConditionFalse, t1),
+				condition(loadBalancerProbesSucceedingConditionType, operatorv1.ConditionTrue, t2),
+			},
+			expectHealthy: true,
+			expectSince:   t2,
+		},
+		{
+			description: "degraded",
+			conditions: []operatorv1.OperatorCondition{
+				condition(operatorv1.OperatorStatusTypeDegraded, operatorv1.ConditionTrue, t2),
+				condition(loadBalancerProbesSucceedingConditionType, operatorv1.ConditionTrue, t1),
+			},
+			expectSince: t2,
+		},
+		{
+			description: "degraded and load balancer probes failing",
+			conditions: []operatorv1.OperatorCondition{
+				condition(operatorv1.OperatorStatusTypeDegraded, operatorv1.ConditionTrue, t2),
+				condition(loadBalancerProbesSucceedingConditionType, operatorv1.ConditionFalse, t1),
+			},
+			expectSince: t1,
+		},
+		{
+			description: "load balancer probe status unknown",
+			conditions: []operatorv1.OperatorCondition{
+				condition(loadBalancerProbesSucceedingConditionType, operatorv1.ConditionUnknown, t1),
+			},
+			expectHealthy: true,
+			expectSince:   t1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{Status: operatorv1.IngressControllerStatus{Conditions: tc.conditions}}
+			healthy, since := ingressControllerHealth(ic)
+			if healthy != tc.expectHealthy || !since.Equal(tc.expectSince) {
+				t.Errorf("expected (%t, %v), got (%t, %v)", tc.expectHealthy, tc.expectSince, healthy, since)
+			}
+		})
+	}
+}
+
+func Test_failbackDelay(t *testing.T) {
+	policy := &dnsrecord.FailoverPolicy{FailbackDelay: metav1.Duration{Duration: 10 * time.Minute}}
+	for consecutive, expected := range map[int]time.Duration{
+		0:  10 * time.Minute,
+		1:  10 * time.Minute,
+		2:  20 * time.Minute,
+		3:  40 * time.Minute,
+		4:  80 * time.Minute,
+		10: 80 * time.Minute,
+	} {
+		if actual := failbackDelay(policy, consecutive); actual != expected {
+			t.Errorf("expected failback delay %v after %d consecutive failovers, got %v", expected, consecutive, actual)
+		}
+	}
+}
+
+// Test_reconcileDNSFailover verifies that reconcileDNSFailover fails a
+// DNSRecord over to the standby after the threshold, follows changes to the
+// standby's targets, fails back after the failback delay, and extends the
+// failback delay after a repeated failover.
+func Test_reconcileDNSFailover(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := utilclocktesting.NewFakeClock(t0)
+	clock = fakeClock
+	defer func() {
+		clock = utilclock.RealClock{}
+	}()
+
+	const namespace = "openshift-ingress-operator"
+	ingressController := func(name string, conditions ...operatorv1.OperatorCondition) *operatorv1.IngressController {
+		return &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     operatorv1.IngressControllerStatus{Conditions: conditions},
+		}
+	}
+	degraded := func(status operatorv1.ConditionStatus, t time.Time) operatorv1.OperatorCondition {
+		return operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeDegraded, Status: status, LastTransitionTime: metav1.NewTime(t)}
+	}
+	available := operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionTrue}
+	wildcardRecord := func(icName string, targets ...string) *iov1.DNSRecord {
+		return &iov1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      icName + "-wildcard",
+				Labels:    map[string]string{manifests.OwningIngressControllerLabel: icName},
+			},
+			Spec: iov1.DNSRecordSpec{
+				DNSName:             "*.apps.example.com.",
+				Targets:             targets,
+				RecordType:          iov1.CNAMERecordType,
+				RecordTTL:           30,
+				DNSManagementPolicy: iov1.ManagedDNS,
+			},
+		}
+	}
+
+	primaryRecord := wildcardRecord("default", "primary-lb.example.com")
+	primaryRecord.Annotations = map[string]string{
+		dnsrecord.FailoverPolicyAnnotation: `{"standby":"standby","threshold":"5m","failbackDelay":"15m"}`,
+	}
+	primary := ingressController("default", available, degraded(operatorv1.ConditionTrue, t0))
+	standby := ingressController("standby", available, degraded(operatorv1.ConditionFalse, t0))
+	standbyRecord := wildcardRecord("standby", "standby-lb.example.com")
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	iov1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(primaryRecord, primary, standby, standbyRecord).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		client:   cl,
+		cache:    fakeCache{Reader: cl},
+		recorder: recorder,
+	}
+
+	update := func(o client.Object) {
+		t.Helper()
+		if err := cl.Update(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	type expectation struct {
+		targets      []string
+		requeueAfter time.Duration
+		event        string
+	}
+	step := func(description string, at time.Duration, expected expectation) {
+		t.Helper()
+		fakeClock.SetTime(t0.Add(at))
+		current := &iov1.DNSRecord{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "default-wildcard"}, current); err != nil {
+			t.Fatal(err)
+		}
+		updated, requeueAfter, err := r.reconcileDNSFailover(context.Background(), current)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if targets := failedOverRecord(updated).Spec.Targets; !reflect.DeepEqual(targets, expected.targets) {
+			t.Errorf("%s: expected targets %v, got %v", description, expected.targets, targets)
+		}
+		if requeueAfter != expected.requeueAfter {
+			t.Errorf("%s: expected requeue after %v, got %v", description, expected.requeueAfter, requeueAfter)
+		}
+		select {
+		case event := <-recorder.Events:
+			if len(expected.event) == 0 || !strings.Contains(event, expected.event) {
+				t.Errorf("%s: expected event %q, got %q", description, expected.event, event)
+			}
+		default:
+			if len(expected.event) != 0 {
+				t.Errorf("%s: expected event %q, got none", description, expected.event)
+			}
+		}
+	}
+
+	primaryTargets := []string{"primary-lb.example.com"}
+
+	step("primary unhealthy for less than the threshold", time.Minute, expectation{
+		targets:      primaryTargets,
+		requeueAfter: 4 * time.Minute,
+	})
+	step("primary unhealthy for the threshold", 5*time.Minute, expectation{
+		targets: []string{"standby-lb.example.com"},
+		event:   "DNSFailover",
+	})
+
+	standbyRecord.Spec.Targets = []string{"standby-lb-2.example.com"}
+	update(standbyRecord)
+	step("standby targets changed", 6*time.Minute, expectation{
+		targets: []string{"standby-lb-2.example.com"},
+	})
+
+	primary.Status.Conditions = []operatorv1.OperatorCondition{available, degraded(operatorv1.ConditionFalse, t0.Add(10*time.Minute))}
+	update(primary)
+	step("primary healthy for less than the failback delay", 20*time.Minute, expectation{
+		targets:      []string{"standby-lb-2.example.com"},
+		requeueAfter: 5 * time.Minute,
+	})
+	step("primary healthy for the failback delay", 25*time.Minute, expectation{
+		targets: primaryTargets,
+		event:   "DNSFailback",
+	})
+
+	primary.Status.Conditions = []operatorv1.OperatorCondition{available, degraded(operatorv1.ConditionTrue, t0.Add(26*time.Minute))}
+	update(primary)
+	step("primary unhealthy again", 31*time.Minute, expectation{
+		targets: []string{"standby-lb-2.example.com"},
+		event:   "DNSFailover",
+	})
+
+	primary.Status.Conditions = []operatorv1.OperatorCondition{available, degraded(operatorv1.ConditionFalse, t0.Add(32*time.Minute))}
+	update(primary)
+	step("failback delay doubled after a repeated failover", 50*time.Minute, expectation{
+		targets:      []string{"standby-lb-2.example.com"},
+		requeueAfter: 12 * time.Minute,
+	})
+
+	standby.Status.Conditions = []operatorv1.OperatorCondition{available, degraded(operatorv1.ConditionTrue, t0.Add(51*time.Minute))}
+	update(standby)
+	step("standby degraded", 51*time.Minute, expectation{
+		targets: primaryTargets,
+		event:   "DNSFailback",
+	})
+	step("primary unhealthy and standby degraded", 60*time.Minute, expectation{
+		targets: primaryTargets,
+	})
+}
@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	DNSFailoverActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ingress_controller_dns_failover_active",
+			Help: "A gauge set to 1 if the ingresscontroller's wildcard DNS record is failed over to its standby ingresscontroller and 0 otherwise",
+		}, []string{"name"})
+
+	DNSFailoverSwitches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingress_controller_dns_failover_switches_total",
+			Help: "A counter of the switches of the ingresscontroller's wildcard DNS record to its standby ingresscontroller (direction=\"failover\") and back (direction=\"failback\")",
+		}, []string{"name", "direction"})
+
+	// metricsList is the list of the DNS controller's metrics.
+	metricsList = []prometheus.Collector{
+		DNSFailoverActive,
+		DNSFailoverSwitches,
+	}
+)
+
+// SetDNSFailoverActiveMetric sets the DNS failover metric for the given
+// ingresscontroller to indicate whether its wildcard DNS record is failed over.
+func SetDNSFailoverActiveMetric(name string, active bool) {
+	if active {
+		DNSFailoverActive.WithLabelValues(name).Set(1)
+	} else {
+		DNSFailoverActive.WithLabelValues(name).Set(0)
+	}
+}
+
+// DeleteDNSFailoverMetrics deletes the DNS failover metrics for the given
+// ingresscontroller.
+func DeleteDNSFailoverMetrics(name string) {
+	DNSFailoverActive.DeleteLabelValues(name)
+	DNSFailoverSwitches.DeletePartialMatch(prometheus.Labels{"name": name})
+}
+
+// RegisterMetrics calls prometheus.Register on each metric in metricsList, and
+// returns on errors.
+func RegisterMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
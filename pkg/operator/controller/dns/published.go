@@ -0,0 +1,164 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	configv1 "github.com/openshift/api/config/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PublishedRecordsAnnotation is the key of an annotation that the DNS
+// controller adds to DNSRecord CRs to record, for each zone, what it last
+// successfully published to that zone.  The value is a JSON array of
+// PublishedRecord values.
+const PublishedRecordsAnnotation = "ingress.operator.openshift.io/published-records"
+
+// PublishedRecord describes a record as it was last successfully published to
+// a zone.
+type PublishedRecord struct {
+	// DNSZone is the zone to which the record was published.
+	DNSZone configv1.DNSZone `json:"dnsZone"`
+	// DNSName is the name of the published record.
+	DNSName string `json:"dnsName"`
+	// Targets are the published record targets.
+	Targets []string `json:"targets"`
+	// RecordType is the type of the published record.
+	RecordType iov1.DNSRecordType `json:"recordType"`
+	// RecordTTL is the TTL of the published record, in seconds.
+	RecordTTL int64 `json:"recordTTL"`
+	// ProviderRecordIDs are the provider-specific identifiers of the
+	// published record, if the provider can report them.
+	ProviderRecordIDs []string `json:"providerRecordIDs,omitempty"`
+	// PublishTime is the time at which the record was published.
+	PublishTime metav1.Time `json:"publishTime"`
+}
+
+// publishedRecords returns the records from the given DNSRecord's
+// published-records annotation.  If the annotation is absent or cannot be
+// parsed, no records are returned.
+func publishedRecords(record *iov1.DNSRecord) []PublishedRecord {
+	value, ok := record.Annotations[PublishedRecordsAnnotation]
+	if !ok {
+		return nil
+	}
+	var published []PublishedRecord
+	if err := json.Unmarshal([]byte(value), &published); err != nil {
+		log.Error(err, "failed to parse annotation; ignoring it", "dnsrecord", record.Name, "annotation", PublishedRecordsAnnotation)
+		return nil
+	}
+	return published
+}
+
+// publishedRecordForZone returns the record that was last published to the
+// given zone according to the given DNSRecord's published-records annotation,
+// or nil if the annotation has no record for the zone.
+func publishedRecordForZone(record *iov1.DNSRecord, zone *configv1.DNSZone) *PublishedRecord {
+	for _, published := range publishedRecords(record) {
+		if reflect.DeepEqual(&published.DNSZone, zone) {
+			return &published
+		}
+	}
+	return nil
+}
+
+// publishedRecordMatchesSpec returns a Boolean value indicating whether the
+// given published record has the same name, targets, type, and TTL as the
+// given DNSRecord spec.  The order of targets is not significant.
+func publishedRecordMatchesSpec(published *PublishedRecord, spec *iov1.DNSRecordSpec) bool {
+	if published.DNSName != spec.DNSName || published.RecordType != spec.RecordType || published.RecordTTL != spec.RecordTTL {
+		return false
+	}
+	targetCmpOpts := []cmp.Option{
+		cmpopts.EquateEmpty(),
+		cmpopts.SortSlices(func(a, b string) bool { return a < b }),
+	}
+	return cmp.Equal(published.Targets, spec.Targets, targetCmpOpts...)
+}
+
+// newPublishedRecord returns a PublishedRecord describing the given record as
+// it has just been published to the given zone.  If the DNS provider can
+// report provider-specific record identifiers, they are included.
+func (r *reconciler) newPublishedRecord(ctx context.Context, record *iov1.DNSRecord, zone configv1.DNSZone) PublishedRecord {
+	published := PublishedRecord{
+		DNSZone:     zone,
+		DNSName:     record.Spec.DNSName,
+		Targets:     append([]string{}, record.Spec.Targets...),
+		RecordType:  record.Spec.RecordType,
+		RecordTTL:   record.Spec.RecordTTL,
+		PublishTime: metav1.NewTime(clock.Now()),
+	}
+	sort.Strings(published.Targets)
+	if lister, ok := r.dnsProvider.(dns.RecordIDLister); ok {
+		var ids []string
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			var err error
+			ids, err = lister.RecordIDs(ctx, record, zone)
+			return err
+		})
+		if err != nil {
+			log.Error(err, "failed to get provider record IDs", "record", record.Spec, "dnszone", zone)
+		}
+		published.ProviderRecordIDs = ids
+	}
+	return published
+}
+
+// mergePublishedRecords updates or extends the given slice of published
+// records with the given updates, matching records by zone, and returns the
+// resulting slice.
+func mergePublishedRecords(current, updates []PublishedRecord) []PublishedRecord {
+	result := append([]PublishedRecord{}, current...)
+	for _, update := range updates {
+		found := false
+		for i := range result {
+			if reflect.DeepEqual(result[i].DNSZone, update.DNSZone) {
+				result[i] = update
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, update)
+		}
+	}
+	return result
+}
+
+// updatePublishedRecordsAnnotation merges the given updates into the given
+// DNSRecord's published-records annotation and patches the DNSRecord if the
+// annotation has changed.
+func (r *reconciler) updatePublishedRecordsAnnotation(ctx context.Context, record *iov1.DNSRecord, updates []PublishedRecord) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	value, err := json.Marshal(mergePublishedRecords(publishedRecords(record), updates))
+	if err != nil {
+		return fmt.Errorf("failed to marshal published records: %w", err)
+	}
+	if record.Annotations[PublishedRecordsAnnotation] == string(value) {
+		return nil
+	}
+
+	updated := record.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[PublishedRecordsAnnotation] = string(value)
+	if err := r.client.Patch(ctx, updated, client.MergeFrom(record)); err != nil {
+		return fmt.Errorf("failed to annotate dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("annotated dnsrecord with published records", "dnsrecord", record.Name, "value", string(value))
+	return nil
+}
@@ -0,0 +1,99 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	configv1 "github.com/openshift/api/config/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+)
+
+func Test_publishedRecordMatchesSpec(t *testing.T) {
+	spec := iov1.DNSRecordSpec{
+		DNSName:    "*.apps.example.com.",
+		Targets:    []string{"192.0.2.1", "192.0.2.2"},
+		RecordType: iov1.ARecordType,
+		RecordTTL:  30,
+	}
+	testCases := []struct {
+		description string
+		published   PublishedRecord
+		expect      bool
+	}{
+		{
+			description: "same record with targets in a different order",
+			published: PublishedRecord{
+				DNSName:    "*.apps.example.com.",
+				Targets:    []string{"192.0.2.2", "192.0.2.1"},
+				RecordType: iov1.ARecordType,
+				RecordTTL:  30,
+			},
+			expect: true,
+		},
+		{
+			description: "different target",
+			published: PublishedRecord{
+				DNSName:    "*.apps.example.com.",
+				Targets:    []string{"192.0.2.1", "192.0.2.3"},
+				RecordType: iov1.ARecordType,
+				RecordTTL:  30,
+			},
+			expect: false,
+		},
+		{
+			description: "different TTL",
+			published: PublishedRecord{
+				DNSName:    "*.apps.example.com.",
+				Targets:    []string{"192.0.2.1", "192.0.2.2"},
+				RecordType: iov1.ARecordType,
+				RecordTTL:  60,
+			},
+			expect: false,
+		},
+		{
+			description: "different record type",
+			published: PublishedRecord{
+				DNSName:    "*.apps.example.com.",
+				Targets:    []string{"192.0.2.1", "192.0.2.2"},
+				RecordType: iov1.CNAMERecordType,
+				RecordTTL:  30,
+			},
+			expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if actual := publishedRecordMatchesSpec(&tc.published, &spec); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func Test_mergePublishedRecords(t *testing.T) {
+	publicZone := configv1.DNSZone{ID: "public"}
+	privateZone := configv1.DNSZone{Tags: map[string]string{"Name": "private"}}
+	current := []PublishedRecord{
+		{DNSZone: publicZone, Targets: []string{"old"}},
+		{DNSZone: privateZone, Targets: []string{"old"}},
+	}
+	updates := []PublishedRecord{
+		{DNSZone: privateZone, Targets: []string{"new"}},
+		{DNSZone: configv1.DNSZone{ID: "other"}, Targets: []string{"new"}},
+	}
+	expected := []PublishedRecord{
+		{DNSZone: publicZone, Targets: []string{"old"}},
+		{DNSZone: privateZone, Targets: []string{"new"}},
+		{DNSZone: configv1.DNSZone{ID: "other"}, Targets: []string{"new"}},
+	}
+
+	actual := mergePublishedRecords(current, updates)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+	if current[1].Targets[0] != "old" {
+		t.Errorf("mergePublishedRecords mutated its input")
+	}
+}
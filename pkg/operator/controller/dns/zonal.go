@@ -0,0 +1,352 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// DNSRecordZonalRecordsPublishedConditionType is the type of the
+	// DNSRecord zone condition that indicates whether the zonal records
+	// that are requested by the DNSRecord's zonal records annotation are
+	// published to the zone.  The condition's message lists the published
+	// record for each availability zone.
+	DNSRecordZonalRecordsPublishedConditionType = "ZonalRecordsPublished"
+
+	// PublishedZonalRecordsAnnotation is the key of an annotation that the
+	// DNS controller adds to DNSRecord CRs to record the zonal records that
+	// it has published, so that it can delete them when they are no longer
+	// wanted.  The value is a JSON array of PublishedZonalRecord values.
+	PublishedZonalRecordsAnnotation = "ingress.operator.openshift.io/published-zonal-records"
+)
+
+// PublishedZonalRecord describes a zonal record that the DNS controller has
+// published to a DNS zone.
+type PublishedZonalRecord struct {
+	// DNSZone is the DNS zone to which the record was published.
+	DNSZone configv1.DNSZone `json:"dnsZone"`
+	// AvailabilityZone is the load balancer's availability zone.
+	AvailabilityZone string `json:"availabilityZone"`
+	// DNSName is the name of the published record.
+	DNSName string `json:"dnsName"`
+	// Target is the load balancer's DNS name for the availability zone.
+	Target string `json:"target"`
+}
+
+// zonalAnnotationChangedPredicate matches updates to DNSRecords that change the
+// zonal records annotation.  Annotations do not change the DNSRecord's
+// generation, so these updates are otherwise filtered out.
+var zonalAnnotationChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return e.ObjectOld.GetAnnotations()[dnsrecord.ZonalRecordsAnnotation] != e.ObjectNew.GetAnnotations()[dnsrecord.ZonalRecordsAnnotation]
+	},
+}
+
+// zonalRecordDomain returns the name of the zonal record for the given
+// availability zone.  For a wildcard DNSRecord, the availability zone is
+// inserted below the wildcard label, so that the zonal record for
+// "*.apps.example.com." and "us-east-1a" is "*.us-east-1a.apps.example.com.".
+func zonalRecordDomain(record *iov1.DNSRecord, availabilityZone string) string {
+	if strings.HasPrefix(record.Spec.DNSName, "*.") {
+		return "*." + availabilityZone + "." + strings.TrimPrefix(record.Spec.DNSName, "*.")
+	}
+	return availabilityZone + "." + record.Spec.DNSName
+}
+
+// publishedZonalRecords returns the records from the given DNSRecord's
+// published zonal records annotation.  If the annotation is absent or cannot be
+// parsed, no records are returned.
+func publishedZonalRecords(record *iov1.DNSRecord) []PublishedZonalRecord {
+	value, ok := record.Annotations[PublishedZonalRecordsAnnotation]
+	if !ok {
+		return nil
+	}
+	var published []PublishedZonalRecord
+	if err := json.Unmarshal([]byte(value), &published); err != nil {
+		log.Error(err, "failed to parse annotation; ignoring it", "dnsrecord", record.Name, "annotation", PublishedZonalRecordsAnnotation)
+		return nil
+	}
+	return published
+}
+
+// publishedZonalRecordsForZone returns the zonal records that the given
+// DNSRecord's annotation indicates were published to the given zone.
+func publishedZonalRecordsForZone(record *iov1.DNSRecord, zone *configv1.DNSZone) []PublishedZonalRecord {
+	var result []PublishedZonalRecord
+	for _, published := range publishedZonalRecords(record) {
+		if reflect.DeepEqual(&published.DNSZone, zone) {
+			result = append(result, published)
+		}
+	}
+	return result
+}
+
+// publishZonalRecordsToZones publishes the zonal records that are requested by
+// the given DNSRecord's zonal records annotation to the given zones, deletes
+// previously published zonal records that are no longer wanted, and records
+// the published records on the DNSRecord.  publishZonalRecordsToZones returns
+// the statuses for the zones in which zonal records are or were requested, and
+// a Boolean value indicating whether the request should be requeued.
+func (r *reconciler) publishZonalRecordsToZones(ctx context.Context, zones []configv1.DNSZone, record *iov1.DNSRecord) ([]iov1.DNSZoneStatus, bool) {
+	var (
+		statuses  []iov1.DNSZoneStatus
+		published []PublishedZonalRecord
+		requeue   bool
+	)
+	for _, zone := range zones {
+		status, zonePublished, zoneRequeue := r.publishZonalRecordsToZone(ctx, zone, record)
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+		published = append(published, zonePublished...)
+		requeue = requeue || zoneRequeue
+	}
+	if err := r.updatePublishedZonalRecordsAnnotation(ctx, record, published); err != nil {
+		log.Error(err, "failed to record published zonal records; will retry", "dnsrecord", record.Name)
+		requeue = true
+	}
+	return statuses, requeue
+}
+
+// publishZonalRecordsToZone publishes the zonal records that are requested by
+// the given DNSRecord to the given zone, or deletes previously published zonal
+// records that are no longer wanted.  publishZonalRecordsToZone returns the
+// status for the zone, which is nil if the DNSRecord has neither requested nor
+// published zonal records, the zonal records that are published to the zone
+// afterwards, and a Boolean value indicating whether the request should be
+// requeued.
+func (r *reconciler) publishZonalRecordsToZone(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord) (*iov1.DNSZoneStatus, []PublishedZonalRecord, bool) {
+	requested := record.Annotations[dnsrecord.ZonalRecordsAnnotation] == "true"
+	previous := publishedZonalRecordsForZone(record, &zone)
+	if !requested && len(previous) == 0 {
+		return nil, nil, false
+	}
+
+	condition, published, requeue := r.ensureZonalRecordsInZone(ctx, zone, record, requested, previous)
+	condition.Type = DNSRecordZonalRecordsPublishedConditionType
+	condition.LastTransitionTime = metav1.Now()
+	return &iov1.DNSZoneStatus{
+		DNSZone:    zone,
+		Conditions: []iov1.DNSZoneCondition{condition},
+	}, published, requeue
+}
+
+func (r *reconciler) ensureZonalRecordsInZone(ctx context.Context, zone configv1.DNSZone, record *iov1.DNSRecord, requested bool, previous []PublishedZonalRecord) (iov1.DNSZoneCondition, []PublishedZonalRecord, bool) {
+	if record.Spec.DNSManagementPolicy == iov1.UnmanagedDNS {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionUnknown),
+			Reason:  "UnmanagedDNS",
+			Message: "Zonal records are currently not being managed by the operator",
+		}, previous, false
+	}
+
+	provider, ok := r.dnsProvider.(dns.ZonalRecordProvider)
+	if !ok {
+		return zonalRecordsNotSupportedCondition(nil), previous, false
+	}
+
+	var desired []PublishedZonalRecord
+	if requested {
+		if record.Spec.RecordType != iov1.CNAMERecordType || len(record.Spec.Targets) == 0 {
+			return zonalRecordsNotSupportedCondition(fmt.Errorf("the record's target is not a load balancer hostname")), previous, false
+		}
+		var targets []dns.ZonalTarget
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			var err error
+			targets, err = provider.ZonalTargets(ctx, record.Spec.Targets[0], zone)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, dns.ErrZonalRecordsNotSupported) {
+				return zonalRecordsNotSupportedCondition(err), previous, false
+			}
+			log.Error(err, "failed to get zonal targets", "target", record.Spec.Targets[0], "dnszone", zone)
+			return zonalProviderErrorCondition("look up", err), previous, true
+		}
+		for _, target := range targets {
+			desired = append(desired, PublishedZonalRecord{
+				DNSZone:          zone,
+				AvailabilityZone: target.Zone,
+				DNSName:          zonalRecordDomain(record, target.Zone),
+				Target:           target.Target,
+			})
+		}
+	}
+
+	var (
+		published []PublishedZonalRecord
+		errs      []error
+	)
+	wanted := map[string]bool{}
+	for _, d := range desired {
+		wanted[d.DNSName] = true
+	}
+	for _, p := range previous {
+		if wanted[p.DNSName] {
+			continue
+		}
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.DeleteZonalRecord(ctx, p.DNSName, zone)
+		})
+		if err != nil {
+			log.Error(err, "failed to delete zonal record from zone", "domain", p.DNSName, "dnszone", zone)
+			errs = append(errs, err)
+			published = append(published, p)
+			continue
+		}
+		log.Info("deleted zonal record from zone", "domain", p.DNSName, "dnszone", zone)
+	}
+	for _, d := range desired {
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.EnsureZonalRecord(ctx, d.DNSName, d.Target, record.Spec.RecordTTL, zone)
+		})
+		if err != nil {
+			log.Error(err, "failed to publish zonal record to zone", "domain", d.DNSName, "target", d.Target, "dnszone", zone)
+			errs = append(errs, err)
+			for _, p := range previous {
+				if p.DNSName == d.DNSName {
+					published = append(published, p)
+				}
+			}
+			continue
+		}
+		log.Info("published zonal record to zone", "domain", d.DNSName, "target", d.Target, "dnszone", zone)
+		published = append(published, d)
+	}
+
+	if len(errs) != 0 {
+		verb := "ensure"
+		if !requested {
+			verb = "delete"
+		}
+		return zonalProviderErrorCondition(verb, utilerrors.NewAggregate(errs)), published, true
+	}
+	if !requested {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  "NotRequested",
+			Message: "Zonal records are not requested and have been removed",
+		}, published, false
+	}
+	if len(desired) == 0 {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  "NoAvailabilityZones",
+			Message: fmt.Sprintf("The load balancer %s has no availability zones", record.Spec.Targets[0]),
+		}, published, true
+	}
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionTrue),
+		Reason:  "ProviderSuccess",
+		Message: fmt.Sprintf("The DNS provider succeeded in ensuring the zonal records: %s", formatZonalRecordsForMessage(desired)),
+	}, published, false
+}
+
+// updatePublishedZonalRecordsAnnotation sets the given DNSRecord's published
+// zonal records annotation to the given records, or removes it if there are
+// none, and patches the DNSRecord if the annotation has changed.
+func (r *reconciler) updatePublishedZonalRecordsAnnotation(ctx context.Context, record *iov1.DNSRecord, published []PublishedZonalRecord) error {
+	current, haveCurrent := record.Annotations[PublishedZonalRecordsAnnotation]
+	updated := record.DeepCopy()
+	if len(published) == 0 {
+		if !haveCurrent {
+			return nil
+		}
+		delete(updated.Annotations, PublishedZonalRecordsAnnotation)
+	} else {
+		value, err := json.Marshal(published)
+		if err != nil {
+			return fmt.Errorf("failed to marshal published zonal records: %w", err)
+		}
+		if haveCurrent && current == string(value) {
+			return nil
+		}
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[PublishedZonalRecordsAnnotation] = string(value)
+	}
+	if err := r.client.Patch(ctx, updated, client.MergeFrom(record)); err != nil {
+		return fmt.Errorf("failed to annotate dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("annotated dnsrecord with published zonal records", "dnsrecord", record.Name, "value", updated.Annotations[PublishedZonalRecordsAnnotation])
+	return nil
+}
+
+// deleteZonalRecordsFromZones deletes the zonal records that the operator
+// published for the given DNSRecord from every zone.
+func (r *reconciler) deleteZonalRecordsFromZones(ctx context.Context, record *iov1.DNSRecord) []error {
+	provider, ok := r.dnsProvider.(dns.ZonalRecordProvider)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, p := range publishedZonalRecords(record) {
+		err := callDNSProvider(ctx, func(ctx context.Context) error {
+			return provider.DeleteZonalRecord(ctx, p.DNSName, p.DNSZone)
+		})
+		if err != nil && !errors.Is(err, dns.ErrZonalRecordsNotSupported) {
+			errs = append(errs, err)
+		} else {
+			log.Info("deleted zonal record from DNS provider", "domain", p.DNSName, "zone", p.DNSZone)
+		}
+	}
+	return errs
+}
+
+func zonalRecordsNotSupportedCondition(err error) iov1.DNSZoneCondition {
+	message := "The DNS provider for this platform does not support zonal records"
+	if err != nil {
+		message = fmt.Sprintf("Zonal records cannot be published for this record: %v", err)
+	}
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionUnknown),
+		Reason:  "ZonalRecordsNotSupported",
+		Message: message,
+	}
+}
+
+func zonalProviderErrorCondition(verb string, err error) iov1.DNSZoneCondition {
+	if isDNSProviderTimeout(err) {
+		return iov1.DNSZoneCondition{
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  providerTimeoutReason,
+			Message: fmt.Sprintf("The DNS provider did not %s the zonal records within %v; the operation will be retried: %v", verb, dnsProviderTimeout, err),
+		}
+	}
+	return iov1.DNSZoneCondition{
+		Status:  string(operatorv1.ConditionFalse),
+		Reason:  "ProviderError",
+		Message: fmt.Sprintf("The DNS provider failed to %s the zonal records: %v", verb, err),
+	}
+}
+
+// formatZonalRecordsForMessage returns the given records on a single line, for
+// use in condition messages.
+func formatZonalRecordsForMessage(records []PublishedZonalRecord) string {
+	formatted := make([]string, 0, len(records))
+	for _, record := range records {
+		formatted = append(formatted, fmt.Sprintf("%s: %s -> %s", record.AvailabilityZone, record.DNSName, record.Target))
+	}
+	return strings.Join(formatted, ", ")
+}
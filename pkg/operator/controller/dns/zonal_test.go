@@ -0,0 +1,223 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeZonalProvider is a dns.Provider that stores zonal records in memory.
+type fakeZonalProvider struct {
+	dns.FakeProvider
+	targets []dns.ZonalTarget
+	err     error
+	records map[string]string
+}
+
+func (p *fakeZonalProvider) ZonalTargets(ctx context.Context, target string, zone configv1.DNSZone) ([]dns.ZonalTarget, error) {
+	return p.targets, p.err
+}
+
+func (p *fakeZonalProvider) EnsureZonalRecord(ctx context.Context, domain, target string, ttl int64, zone configv1.DNSZone) error {
+	p.records[domain] = target
+	return nil
+}
+
+func (p *fakeZonalProvider) DeleteZonalRecord(ctx context.Context, domain string, zone configv1.DNSZone) error {
+	delete(p.records, domain)
+	return nil
+}
+
+func Test_zonalRecordDomain(t *testing.T) {
+	testCases := []struct {
+		dnsName  string
+		expected string
+	}{
+		{"*.apps.example.com.", "*.us-east-1a.apps.example.com."},
+		{"api.example.com.", "us-east-1a.api.example.com."},
+	}
+	for _, tc := range testCases {
+		r := &iov1.DNSRecord{Spec: iov1.DNSRecordSpec{DNSName: tc.dnsName}}
+		if actual := zonalRecordDomain(r, "us-east-1a"); actual != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.dnsName, tc.expected, actual)
+		}
+	}
+}
+
+func Test_publishZonalRecordsToZone(t *testing.T) {
+	zone := configv1.DNSZone{ID: "public"}
+	const lb = "router-default-123.elb.us-east-1.amazonaws.com"
+	zoneA := dns.ZonalTarget{Zone: "us-east-1a", Target: "us-east-1a." + lb}
+	zoneB := dns.ZonalTarget{Zone: "us-east-1b", Target: "us-east-1b." + lb}
+	published := func(targets ...dns.ZonalTarget) []PublishedZonalRecord {
+		var result []PublishedZonalRecord
+		for _, target := range targets {
+			result = append(result, PublishedZonalRecord{
+				DNSZone:          zone,
+				AvailabilityZone: target.Zone,
+				DNSName:          "*." + target.Zone + ".apps.example.com.",
+				Target:           target.Target,
+			})
+		}
+		return result
+	}
+	recordsFor := func(targets ...dns.ZonalTarget) map[string]string {
+		result := map[string]string{}
+		for _, p := range published(targets...) {
+			result[p.DNSName] = p.Target
+		}
+		return result
+	}
+
+	testCases := []struct {
+		description       string
+		provider          dns.Provider
+		requested         bool
+		policy            iov1.DNSManagementPolicy
+		previous          []PublishedZonalRecord
+		targets           []dns.ZonalTarget
+		targetsErr        error
+		existing          map[string]string
+		expectNoStatus    bool
+		expectedStatus    operatorv1.ConditionStatus
+		expectedReason    string
+		expectedPublished []PublishedZonalRecord
+		expectedRecords   map[string]string
+		expectRequeue     bool
+	}{
+		{
+			description:     "zonal records not requested",
+			targets:         []dns.ZonalTarget{zoneA, zoneB},
+			expectNoStatus:  true,
+			expectedRecords: map[string]string{},
+		},
+		{
+			description:       "zonal records requested",
+			requested:         true,
+			targets:           []dns.ZonalTarget{zoneA, zoneB},
+			expectedStatus:    operatorv1.ConditionTrue,
+			expectedReason:    "ProviderSuccess",
+			expectedPublished: published(zoneA, zoneB),
+			expectedRecords:   recordsFor(zoneA, zoneB),
+		},
+		{
+			description:       "zonal record for a removed availability zone is deleted",
+			requested:         true,
+			previous:          published(zoneA, zoneB),
+			targets:           []dns.ZonalTarget{zoneA},
+			existing:          recordsFor(zoneA, zoneB),
+			expectedStatus:    operatorv1.ConditionTrue,
+			expectedReason:    "ProviderSuccess",
+			expectedPublished: published(zoneA),
+			expectedRecords:   recordsFor(zoneA),
+		},
+		{
+			description:     "previously published zonal records are deleted",
+			previous:        published(zoneA, zoneB),
+			targets:         []dns.ZonalTarget{zoneA, zoneB},
+			existing:        recordsFor(zoneA, zoneB),
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  "NotRequested",
+			expectedRecords: map[string]string{},
+		},
+		{
+			description:     "target is not a network load balancer",
+			requested:       true,
+			targetsErr:      dns.ErrZonalRecordsNotSupported,
+			expectedStatus:  operatorv1.ConditionUnknown,
+			expectedReason:  "ZonalRecordsNotSupported",
+			expectedRecords: map[string]string{},
+		},
+		{
+			description:       "unmanaged DNS",
+			requested:         true,
+			policy:            iov1.UnmanagedDNS,
+			previous:          published(zoneA),
+			targets:           []dns.ZonalTarget{zoneA},
+			existing:          recordsFor(zoneA),
+			expectedStatus:    operatorv1.ConditionUnknown,
+			expectedReason:    "UnmanagedDNS",
+			expectedPublished: published(zoneA),
+			expectedRecords:   recordsFor(zoneA),
+		},
+		{
+			description:     "provider does not support zonal records",
+			provider:        &dns.FakeProvider{},
+			requested:       true,
+			expectedStatus:  operatorv1.ConditionUnknown,
+			expectedReason:  "ZonalRecordsNotSupported",
+			expectedRecords: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			fake := &fakeZonalProvider{targets: tc.targets, err: tc.targetsErr, records: map[string]string{}}
+			for k, v := range tc.existing {
+				fake.records[k] = v
+			}
+			r := &reconciler{dnsProvider: fake, recorder: record.NewFakeRecorder(10)}
+			if tc.provider != nil {
+				r.dnsProvider = tc.provider
+			}
+			policy := tc.policy
+			if len(policy) == 0 {
+				policy = iov1.ManagedDNS
+			}
+			dnsRecord := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             "*.apps.example.com.",
+					Targets:             []string{lb},
+					RecordType:          iov1.CNAMERecordType,
+					RecordTTL:           30,
+					DNSManagementPolicy: policy,
+				},
+			}
+			if tc.requested {
+				dnsRecord.Annotations[dnsrecord.ZonalRecordsAnnotation] = "true"
+			}
+			if tc.previous != nil {
+				value, err := json.Marshal(tc.previous)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dnsRecord.Annotations[PublishedZonalRecordsAnnotation] = string(value)
+			}
+
+			status, actualPublished, requeue := r.publishZonalRecordsToZone(context.Background(), zone, dnsRecord)
+			if requeue != tc.expectRequeue {
+				t.Errorf("expected requeue to be %t, got %t", tc.expectRequeue, requeue)
+			}
+			if tc.expectNoStatus {
+				if status != nil {
+					t.Errorf("expected no status, got %+v", status)
+				}
+			} else {
+				if status == nil || len(status.Conditions) != 1 {
+					t.Fatalf("expected a status with one condition, got %+v", status)
+				}
+				cond := status.Conditions[0]
+				if cond.Type != DNSRecordZonalRecordsPublishedConditionType || cond.Status != string(tc.expectedStatus) || cond.Reason != tc.expectedReason {
+					t.Errorf("expected status %s and reason %s, got status %s and reason %s: %s", tc.expectedStatus, tc.expectedReason, cond.Status, cond.Reason, cond.Message)
+				}
+			}
+			if !reflect.DeepEqual(actualPublished, tc.expectedPublished) {
+				t.Errorf("expected published records %+v, got %+v", tc.expectedPublished, actualPublished)
+			}
+			if !reflect.DeepEqual(fake.records, tc.expectedRecords) {
+				t.Errorf("expected zonal records %v, got %v", tc.expectedRecords, fake.records)
+			}
+		})
+	}
+}
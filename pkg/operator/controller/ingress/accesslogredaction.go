@@ -0,0 +1,266 @@
+package ingress
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// RouterAccessLogRedactQueryParametersAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of query
+	// parameter names whose values are replaced with a placeholder in the
+	// request line and URI that the router writes to its access logs.
+	RouterAccessLogRedactQueryParametersAnnotation = "ingress.operator.openshift.io/access-log-redact-query-parameters"
+	// RouterAccessLogRedactHeadersAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of captured
+	// request or response header names whose values are replaced with a
+	// placeholder in the router's access logs.  Each header must be listed
+	// in the ingresscontroller's spec.logging.access.httpCaptureHeaders.
+	RouterAccessLogRedactHeadersAnnotation = "ingress.operator.openshift.io/access-log-redact-headers"
+	// RouterAccessLogRedactCookiesAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of cookie
+	// names whose values must not appear in the router's access logs.
+	// Each cookie must be matched by the ingresscontroller's
+	// spec.logging.access.httpCaptureCookies.
+	RouterAccessLogRedactCookiesAnnotation = "ingress.operator.openshift.io/access-log-redact-cookies"
+
+	// accessLogRedactedValue is the placeholder that replaces redacted
+	// values in access logs.
+	accessLogRedactedValue = "REDACTED"
+
+	// haproxyDefaultHTTPLogFormat is the format that HAProxy uses for
+	// "option httplog", which is the router's access log format when
+	// spec.logging.access.httpLogFormat is empty.
+	haproxyDefaultHTTPLogFormat = `%ci:%cp [%tr] %ft %b/%s %TR/%Tw/%Tc/%Tr/%Ta %ST %B %CC %CS %tsc %ac/%fc/%bc/%sc/%rc %sq/%bq %hr %hs %{+Q}r`
+)
+
+// accessLogRedactionNameRegexp matches the query parameter, header, and cookie
+// names that may be redacted.  The names are rendered into regular expressions
+// and HAProxy configuration, so the permitted characters are deliberately
+// restricted to ones that need no escaping in either.
+var accessLogRedactionNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// accessLogRedaction describes the values that are redacted from an
+// ingresscontroller's access logs.
+type accessLogRedaction struct {
+	queryParameters []string
+	headers         []string
+	cookies         []string
+}
+
+// accessLogRedactionPolicy returns the access log redaction policy that the
+// given ingresscontroller specifies, or nil if it specifies none.  An error is
+// returned if any of the annotations has a malformed name.
+func accessLogRedactionPolicy(ic *operatorv1.IngressController) (*accessLogRedaction, error) {
+	var (
+		policy accessLogRedaction
+		err    error
+	)
+	if policy.queryParameters, err = parseAccessLogRedactionNames(ic, RouterAccessLogRedactQueryParametersAnnotation); err != nil {
+		return nil, err
+	}
+	if policy.headers, err = parseAccessLogRedactionNames(ic, RouterAccessLogRedactHeadersAnnotation); err != nil {
+		return nil, err
+	}
+	if policy.cookies, err = parseAccessLogRedactionNames(ic, RouterAccessLogRedactCookiesAnnotation); err != nil {
+		return nil, err
+	}
+	if len(policy.queryParameters) == 0 && len(policy.headers) == 0 && len(policy.cookies) == 0 {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+// parseAccessLogRedactionNames parses the comma-separated list of names in the
+// given annotation on the given ingresscontroller.
+func parseAccessLogRedactionNames(ic *operatorv1.IngressController, annotation string) ([]string, error) {
+	value, ok := ic.Annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if !accessLogRedactionNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid name %q in annotation %s: must match %s", name, annotation, accessLogRedactionNameRegexp)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// redactsCapturedCookie returns a Boolean value indicating whether the given
+// policy redacts a cookie that the given cookie capture may capture.
+func (p *accessLogRedaction) redactsCapturedCookie(capture operatorv1.IngressControllerCaptureHTTPCookie) bool {
+	for _, name := range p.cookies {
+		if cookieCaptureMatches(capture, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieCaptureMatches returns a Boolean value indicating whether the given
+// cookie capture may capture the named cookie.
+func cookieCaptureMatches(capture operatorv1.IngressControllerCaptureHTTPCookie, name string) bool {
+	switch capture.MatchType {
+	case operatorv1.CookieMatchTypeExact:
+		return capture.Name == name
+	case operatorv1.CookieMatchTypePrefix:
+		return strings.HasPrefix(name, capture.NamePrefix)
+	}
+	return false
+}
+
+// validateAccessLogRedaction returns an error if the given ingresscontroller
+// specifies an access log redaction policy that is malformed or that refers to
+// headers or cookies that the ingresscontroller does not capture.
+func validateAccessLogRedaction(ic *operatorv1.IngressController) error {
+	policy, err := accessLogRedactionPolicy(ic)
+	if err != nil || policy == nil {
+		return err
+	}
+	accessLogging := accessLoggingForIngressController(ic)
+	if accessLogging == nil {
+		return fmt.Errorf("access log redaction requires spec.logging.access to be configured")
+	}
+	for _, name := range policy.headers {
+		if indexOfCapturedHeader(accessLogging.HTTPCaptureHeaders.Request, name) < 0 && indexOfCapturedHeader(accessLogging.HTTPCaptureHeaders.Response, name) < 0 {
+			return fmt.Errorf("header %q in annotation %s is not listed in spec.logging.access.httpCaptureHeaders", name, RouterAccessLogRedactHeadersAnnotation)
+		}
+	}
+	for _, name := range policy.cookies {
+		if len(accessLogging.HTTPCaptureCookies) == 0 || !cookieCaptureMatches(accessLogging.HTTPCaptureCookies[0], name) {
+			return fmt.Errorf("cookie %q in annotation %s is not matched by spec.logging.access.httpCaptureCookies", name, RouterAccessLogRedactCookiesAnnotation)
+		}
+	}
+	return nil
+}
+
+// indexOfCapturedHeader returns the index of the named header in the given
+// list of captured headers, or -1 if it is not captured.  Header names are
+// compared case-insensitively.
+func indexOfCapturedHeader(headers []operatorv1.IngressControllerCaptureHTTPHeader, name string) int {
+	for i := range headers {
+		if strings.EqualFold(headers[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// logFormatVariableRegexp matches a log-format variable: a percent sign,
+// optional flags in braces, and either a sample expression in brackets or a
+// variable name.  A doubled percent sign is matched as well so that it can be
+// preserved.
+var logFormatVariableRegexp = regexp.MustCompile(`%%|%(\{[^}]*\})?(\[[^\]]*\]|[A-Za-z]+)`)
+
+// redactedHTTPLogFormat returns the given HAProxy log format, or HAProxy's
+// default HTTP log format if the given one is empty, with each variable that
+// would log a redacted value replaced by an equivalent expression that masks
+// the value.  Redaction is therefore applied by HAProxy, and masked values
+// never leave the router pod.
+//
+// The request line (%r), URI (%HU), and query string (%HQ) are rendered from
+// the captured request URI with the values of redacted query parameters
+// replaced.  Captured headers (%hr, %hrl, %hs, %hsl) are rendered one by one so
+// that redacted headers can be masked and redacted cookies can be masked in
+// captured Cookie and Set-Cookie headers.  Captured cookies (%CC, %CS) are not
+// logged if the captured cookie is redacted, and the router is not configured
+// to capture the cookie in that case.
+func redactedHTTPLogFormat(format string, accessLogging *operatorv1.AccessLogging, policy *accessLogRedaction) string {
+	if len(format) == 0 {
+		format = haproxyDefaultHTTPLogFormat
+	}
+	redactCookie := len(accessLogging.HTTPCaptureCookies) > 0 && policy.redactsCapturedCookie(accessLogging.HTTPCaptureCookies[0])
+
+	uri := "capture.req.uri"
+	if len(policy.queryParameters) != 0 {
+		uri += fmt.Sprintf(`,regsub('([?&](%s)=)[^&#]*','\1%s',g)`, strings.Join(policy.queryParameters, "|"), accessLogRedactedValue)
+	}
+
+	return logFormatVariableRegexp.ReplaceAllStringFunc(format, func(variable string) string {
+		match := logFormatVariableRegexp.FindStringSubmatch(variable)
+		flags, name := match[1], match[2]
+		quoted := strings.Contains(flags, "+Q")
+		switch name {
+		case "r":
+			if len(policy.queryParameters) == 0 {
+				return variable
+			}
+			line := fmt.Sprintf("%%HM %%[%s] %%HV", uri)
+			if quoted {
+				return `"` + line + `"`
+			}
+			return line
+		case "HU":
+			if len(policy.queryParameters) == 0 {
+				return variable
+			}
+			return fmt.Sprintf("%%%s[%s]", flags, uri)
+		case "HQ":
+			if len(policy.queryParameters) == 0 {
+				return variable
+			}
+			return fmt.Sprintf("%%%s[%s,regsub('^[^?]*','')]", flags, uri)
+		case "hr", "hrl":
+			return redactedCapturedHeaders(variable, flags, name == "hrl", "capture.req.hdr", accessLogging.HTTPCaptureHeaders.Request, policy)
+		case "hs", "hsl":
+			return redactedCapturedHeaders(variable, flags, name == "hsl", "capture.res.hdr", accessLogging.HTTPCaptureHeaders.Response, policy)
+		case "CC", "CS":
+			if redactCookie {
+				return "-"
+			}
+		}
+		return variable
+	})
+}
+
+// redactedCapturedHeaders returns a log-format expression that is equivalent
+// to the given captured header variable except that redacted headers are
+// masked, and the values of redacted cookies in captured Cookie and Set-Cookie
+// headers are replaced.  If none of the captured headers is redacted, the
+// variable is returned unchanged.
+func redactedCapturedHeaders(variable, flags string, list bool, fetch string, headers []operatorv1.IngressControllerCaptureHTTPHeader, policy *accessLogRedaction) string {
+	redacted := false
+	for _, header := range headers {
+		if policy.redactedHeaderExpression(header.Name) != "" {
+			redacted = true
+			break
+		}
+	}
+	if !redacted {
+		return variable
+	}
+	var fields []string
+	for i, header := range headers {
+		expression := fmt.Sprintf("%s(%d)", fetch, i) + policy.redactedHeaderExpression(header.Name)
+		fields = append(fields, fmt.Sprintf("%%%s[%s]", flags, expression))
+	}
+	if list {
+		return strings.Join(fields, " ")
+	}
+	return "{" + strings.Join(fields, "|") + "}"
+}
+
+// redactedHeaderExpression returns the converters that mask the given captured
+// header's value according to the policy, or the empty string if the policy
+// does not redact the header.  A redacted header is masked entirely.  A Cookie
+// or Set-Cookie header has the values of redacted cookies replaced.
+func (p *accessLogRedaction) redactedHeaderExpression(name string) string {
+	for _, redacted := range p.headers {
+		if strings.EqualFold(name, redacted) {
+			return fmt.Sprintf(",regsub('.+','%s')", accessLogRedactedValue)
+		}
+	}
+	if len(p.cookies) != 0 && (strings.EqualFold(name, "Cookie") || strings.EqualFold(name, "Set-Cookie")) {
+		return fmt.Sprintf(`,regsub('((^|; *)(%s)=)[^;]*','\1%s',g)`, strings.Join(p.cookies, "|"), accessLogRedactedValue)
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_redactedHTTPLogFormat(t *testing.T) {
+	accessLogging := &operatorv1.AccessLogging{
+		HTTPCaptureHeaders: operatorv1.IngressControllerCaptureHTTPHeaders{
+			Request: []operatorv1.IngressControllerCaptureHTTPHeader{
+				{Name: "Host", MaxLength: 100},
+				{Name: "Authorization", MaxLength: 100},
+				{Name: "Cookie", MaxLength: 100},
+			},
+			Response: []operatorv1.IngressControllerCaptureHTTPHeader{
+				{Name: "Content-Type", MaxLength: 100},
+				{Name: "Set-Cookie", MaxLength: 100},
+			},
+		},
+		HTTPCaptureCookies: []operatorv1.IngressControllerCaptureHTTPCookie{{
+			IngressControllerCaptureHTTPCookieUnion: operatorv1.IngressControllerCaptureHTTPCookieUnion{
+				MatchType: operatorv1.CookieMatchTypeExact,
+				Name:      "session",
+			},
+		}},
+	}
+	testCases := []struct {
+		description string
+		format      string
+		policy      *accessLogRedaction
+		expected    string
+	}{
+		{
+			description: "query parameters in the default format",
+			policy:      &accessLogRedaction{queryParameters: []string{"token", "access_token"}},
+			expected:    `%ci:%cp [%tr] %ft %b/%s %TR/%Tw/%Tc/%Tr/%Ta %ST %B %CC %CS %tsc %ac/%fc/%bc/%sc/%rc %sq/%bq %hr %hs "%HM %[capture.req.uri,regsub('([?&](token|access_token)=)[^&#]*','\1REDACTED',g)] %HV"`,
+		},
+		{
+			description: "query parameters in a custom format",
+			format:      `%rt %HU %HQ %r %%r`,
+			policy:      &accessLogRedaction{queryParameters: []string{"token"}},
+			expected:    `%rt %[capture.req.uri,regsub('([?&](token)=)[^&#]*','\1REDACTED',g)] %[capture.req.uri,regsub('([?&](token)=)[^&#]*','\1REDACTED',g),regsub('^[^?]*','')] %HM %[capture.req.uri,regsub('([?&](token)=)[^&#]*','\1REDACTED',g)] %HV %%r`,
+		},
+		{
+			description: "request header",
+			format:      `%hr %{+Q}hrl %hs`,
+			policy:      &accessLogRedaction{headers: []string{"authorization"}},
+			expected:    `{%[capture.req.hdr(0)]|%[capture.req.hdr(1),regsub('.+','REDACTED')]|%[capture.req.hdr(2)]} %{+Q}[capture.req.hdr(0)] %{+Q}[capture.req.hdr(1),regsub('.+','REDACTED')] %{+Q}[capture.req.hdr(2)] %hs`,
+		},
+		{
+			description: "cookie",
+			format:      `%CC %CS %ST`,
+			policy:      &accessLogRedaction{cookies: []string{"session"}},
+			expected:    `- - %ST`,
+		},
+		{
+			description: "cookie in captured cookie headers",
+			format:      `%hr %hs`,
+			policy:      &accessLogRedaction{cookies: []string{"session", "token"}},
+			expected:    `{%[capture.req.hdr(0)]|%[capture.req.hdr(1)]|%[capture.req.hdr(2),regsub('((^|; *)(session|token)=)[^;]*','\1REDACTED',g)]} {%[capture.res.hdr(0)]|%[capture.res.hdr(1),regsub('((^|; *)(session|token)=)[^;]*','\1REDACTED',g)]}`,
+		},
+		{
+			description: "cookie that is not captured",
+			format:      `%CC %CS %ST`,
+			policy:      &accessLogRedaction{cookies: []string{"other"}},
+			expected:    `%CC %CS %ST`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if actual := redactedHTTPLogFormat(tc.format, accessLogging, tc.policy); actual != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_validateAccessLogRedaction(t *testing.T) {
+	ic := func(annotations map[string]string, logging bool) *operatorv1.IngressController {
+		ic := &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+		}
+		if logging {
+			ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+				Access: &operatorv1.AccessLogging{
+					Destination: operatorv1.LoggingDestination{
+						Type: operatorv1.ContainerLoggingDestinationType,
+					},
+					HTTPCaptureHeaders: operatorv1.IngressControllerCaptureHTTPHeaders{
+						Request: []operatorv1.IngressControllerCaptureHTTPHeader{{Name: "Authorization", MaxLength: 100}},
+					},
+					HTTPCaptureCookies: []operatorv1.IngressControllerCaptureHTTPCookie{{
+						IngressControllerCaptureHTTPCookieUnion: operatorv1.IngressControllerCaptureHTTPCookieUnion{
+							MatchType:  operatorv1.CookieMatchTypePrefix,
+							NamePrefix: "sess",
+						},
+					}},
+				},
+			}
+		}
+		return ic
+	}
+	testCases := []struct {
+		description string
+		ic          *operatorv1.IngressController
+		expectError bool
+	}{
+		{"no annotations", ic(nil, false), false},
+		{"query parameters", ic(map[string]string{RouterAccessLogRedactQueryParametersAnnotation: "token, code"}, true), false},
+		{"malformed query parameter", ic(map[string]string{RouterAccessLogRedactQueryParametersAnnotation: "to(ken"}, true), true},
+		{"access logging not configured", ic(map[string]string{RouterAccessLogRedactQueryParametersAnnotation: "token"}, false), true},
+		{"captured header", ic(map[string]string{RouterAccessLogRedactHeadersAnnotation: "authorization"}, true), false},
+		{"header that is not captured", ic(map[string]string{RouterAccessLogRedactHeadersAnnotation: "X-Api-Key"}, true), true},
+		{"captured cookie", ic(map[string]string{RouterAccessLogRedactCookiesAnnotation: "session"}, true), false},
+		{"cookie that is not captured", ic(map[string]string{RouterAccessLogRedactCookiesAnnotation: "token"}, true), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateAccessLogRedaction(tc.ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Error("expected an error")
+			case !tc.expectError && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+)
+
+const (
+	// RouterBindAddressCIDRAnnotation is an annotation on a HostNetwork
+	// ingresscontroller that specifies a CIDR.  Each router pod is to bind
+	// its HTTP and HTTPS ports to the node's address within this CIDR
+	// instead of to all addresses.  The router image does not support
+	// binding a specific address yet, so the annotation is validated and
+	// reported in the HostNetworkBindAddressApplied status condition but
+	// does not change the router deployment.
+	RouterBindAddressCIDRAnnotation = "ingress.operator.openshift.io/hostnetwork-bind-address-cidr"
+	// RouterBindAddressNodeAnnotationAnnotation is an annotation on a
+	// HostNetwork ingresscontroller that specifies the key of a node
+	// annotation.  Each router pod is to bind its HTTP and HTTPS ports to
+	// the address that is the value of this annotation on the pod's node.
+	// This annotation and RouterBindAddressCIDRAnnotation are mutually
+	// exclusive, and the same router support caveat applies.
+	RouterBindAddressNodeAnnotationAnnotation = "ingress.operator.openshift.io/hostnetwork-bind-address-node-annotation"
+)
+
+// bindAddressPolicy returns the CIDR or node annotation key that the given
+// ingresscontroller specifies for selecting the address to which its router
+// pods bind.  If neither is specified, both return values are empty.  An error
+// is returned if both are specified or if the CIDR is malformed.
+func bindAddressPolicy(ic *operatorv1.IngressController) (*net.IPNet, string, error) {
+	cidrValue, haveCIDR := ic.Annotations[RouterBindAddressCIDRAnnotation]
+	nodeAnnotation, haveNodeAnnotation := ic.Annotations[RouterBindAddressNodeAnnotationAnnotation]
+	switch {
+	case haveCIDR && haveNodeAnnotation:
+		return nil, "", fmt.Errorf("annotations %s and %s are mutually exclusive", RouterBindAddressCIDRAnnotation, RouterBindAddressNodeAnnotationAnnotation)
+	case haveCIDR:
+		_, cidr, err := net.ParseCIDR(strings.TrimSpace(cidrValue))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid value for annotation %s: %w", RouterBindAddressCIDRAnnotation, err)
+		}
+		return cidr, "", nil
+	case haveNodeAnnotation:
+		nodeAnnotation = strings.TrimSpace(nodeAnnotation)
+		if len(nodeAnnotation) == 0 {
+			return nil, "", fmt.Errorf("invalid value for annotation %s: value must not be empty", RouterBindAddressNodeAnnotationAnnotation)
+		}
+		return nil, nodeAnnotation, nil
+	}
+	return nil, "", nil
+}
+
+// hostNetworkPorts returns the HTTP and HTTPS ports of the given HostNetwork
+// ingresscontroller.
+func hostNetworkPorts(ic *operatorv1.IngressController) []int32 {
+	ports := []int32{routerDefaultHostNetworkHTTPPort, routerDefaultHostNetworkHTTPSPort}
+	if config := ic.Status.EndpointPublishingStrategy.HostNetwork; config != nil {
+		ports = []int32{config.HTTPPort, config.HTTPSPort}
+	}
+	return ports
+}
+
+// validateBindAddressPolicy returns an error if the desired ingresscontroller
+// has an invalid bind address policy, or if its policy cannot keep its router
+// pods from conflicting with those of another admitted HostNetwork
+// ingresscontroller that uses the same HTTP or HTTPS port:
+//
+//   - Router pods with distinct CIDRs may be scheduled to the same node, so
+//     overlapping CIDRs that are not equal could cause both to bind the same
+//     address and port.
+//
+//   - A node annotation policy cannot be resolved before scheduling, so the
+//     scheduler cannot tell the pods' addresses apart and would never place
+//     them on the same node, which defeats the purpose of the policy.  A node
+//     annotation policy is therefore only allowed for an ingresscontroller
+//     whose ports no other HostNetwork ingresscontroller uses; a CIDR policy
+//     must be used to share ports.
+func validateBindAddressPolicy(desired *operatorv1.IngressController, existing []operatorv1.IngressController) error {
+	cidr, nodeAnnotation, err := bindAddressPolicy(desired)
+	if err != nil {
+		return err
+	}
+	if cidr == nil && len(nodeAnnotation) == 0 {
+		return nil
+	}
+	if desired.Status.EndpointPublishingStrategy == nil || desired.Status.EndpointPublishingStrategy.Type != operatorv1.HostNetworkStrategyType {
+		return fmt.Errorf("a bind address policy requires the %s endpoint publishing strategy", operatorv1.HostNetworkStrategyType)
+	}
+
+	desiredPorts := hostNetworkPorts(desired)
+	for i := range existing {
+		current := existing[i]
+		if current.UID == desired.UID || !ingresscontroller.IsAdmitted(&current) {
+			continue
+		}
+		if current.Status.EndpointPublishingStrategy == nil || current.Status.EndpointPublishingStrategy.Type != operatorv1.HostNetworkStrategyType {
+			continue
+		}
+		sharedPort := int32(0)
+		for _, port := range hostNetworkPorts(&current) {
+			if port == desiredPorts[0] || port == desiredPorts[1] {
+				sharedPort = port
+			}
+		}
+		if sharedPort == 0 {
+			continue
+		}
+		if cidr == nil {
+			return fmt.Errorf("the %s annotation cannot be used because ingresscontroller %s also uses port %d; use the %s annotation to share ports", RouterBindAddressNodeAnnotationAnnotation, current.Name, sharedPort, RouterBindAddressCIDRAnnotation)
+		}
+		currentCIDR, _, err := bindAddressPolicy(&current)
+		if err != nil || currentCIDR == nil || currentCIDR.String() == cidr.String() {
+			continue
+		}
+		if !cidr.Contains(currentCIDR.IP) && !currentCIDR.Contains(cidr.IP) {
+			continue
+		}
+		return fmt.Errorf("bind address CIDR %s overlaps bind address CIDR %s of ingresscontroller %s, which also uses port %d", cidr, currentCIDR, current.Name, sharedPort)
+	}
+	return nil
+}
+
+// computeBindAddressCondition computes the ingresscontroller's bind address
+// status condition, which reports the configured bind address policy.  If no
+// policy is configured, nil is returned.
+//
+// The router image does not yet support binding a specific address, so the
+// router deployment is not changed and the condition reports that the policy
+// is not applied.
+func computeBindAddressCondition(ic *operatorv1.IngressController) *operatorv1.OperatorCondition {
+	cidr, nodeAnnotation, err := bindAddressPolicy(ic)
+	if err != nil || (cidr == nil && len(nodeAnnotation) == 0) {
+		return nil
+	}
+	policy := fmt.Sprintf("the node address in %s", cidr)
+	if cidr == nil {
+		policy = fmt.Sprintf("the address in node annotation %s", nodeAnnotation)
+	}
+	return &operatorv1.OperatorCondition{
+		Type:    IngressControllerBindAddressConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "RouterSupportPending",
+		Message: fmt.Sprintf("The routers are configured to bind %s, but the router image does not support binding a specific address yet, so the routers bind all addresses.", policy),
+	}
+}
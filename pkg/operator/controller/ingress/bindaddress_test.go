@@ -0,0 +1,183 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_validateBindAddressPolicy(t *testing.T) {
+	hostNetworkIC := func(name, cidr string, httpPort, httpsPort int32) operatorv1.IngressController {
+		ic := operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				UID:  types.UID(name),
+			},
+			Status: operatorv1.IngressControllerStatus{
+				Conditions: []operatorv1.OperatorCondition{{
+					Type:   IngressControllerAdmittedConditionType,
+					Status: operatorv1.ConditionTrue,
+				}},
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.HostNetworkStrategyType,
+					HostNetwork: &operatorv1.HostNetworkStrategy{
+						HTTPPort:  httpPort,
+						HTTPSPort: httpsPort,
+						StatsPort: 1936,
+					},
+				},
+			},
+		}
+		if len(cidr) != 0 {
+			ic.Annotations = map[string]string{RouterBindAddressCIDRAnnotation: cidr}
+		}
+		return ic
+	}
+	nodeAnnotationIC := func(name string, httpPort, httpsPort int32) operatorv1.IngressController {
+		ic := hostNetworkIC(name, "", httpPort, httpsPort)
+		ic.Annotations = map[string]string{RouterBindAddressNodeAnnotationAnnotation: "example.com/ingress-address"}
+		return ic
+	}
+	testCases := []struct {
+		description string
+		desired     operatorv1.IngressController
+		existing    []operatorv1.IngressController
+		expectError bool
+	}{
+		{
+			description: "no bind address policy",
+			desired:     hostNetworkIC("a", "", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "", 80, 443)},
+		},
+		{
+			description: "disjoint CIDRs with the same ports",
+			desired:     hostNetworkIC("a", "192.0.2.0/24", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "198.51.100.0/24", 80, 443)},
+		},
+		{
+			description: "equal CIDRs with the same ports",
+			desired:     hostNetworkIC("a", "192.0.2.0/24", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "192.0.2.0/24", 80, 443)},
+		},
+		{
+			description: "overlapping CIDRs with the same ports",
+			desired:     hostNetworkIC("a", "192.0.2.0/24", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "192.0.2.0/25", 80, 443)},
+			expectError: true,
+		},
+		{
+			description: "overlapping CIDRs with different ports",
+			desired:     hostNetworkIC("a", "192.0.2.0/24", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "192.0.2.0/25", 8080, 8443)},
+		},
+		{
+			description: "node annotation with the same ports as another ingresscontroller",
+			desired:     nodeAnnotationIC("a", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "", 80, 443)},
+			expectError: true,
+		},
+		{
+			description: "node annotation with the same ports as a node annotation ingresscontroller",
+			desired:     nodeAnnotationIC("a", 80, 443),
+			existing:    []operatorv1.IngressController{nodeAnnotationIC("b", 80, 443)},
+			expectError: true,
+		},
+		{
+			description: "CIDR with the same ports as a node annotation ingresscontroller",
+			desired:     hostNetworkIC("a", "192.0.2.0/24", 80, 443),
+			existing:    []operatorv1.IngressController{nodeAnnotationIC("b", 80, 443)},
+		},
+		{
+			description: "node annotation with different ports",
+			desired:     nodeAnnotationIC("a", 80, 443),
+			existing:    []operatorv1.IngressController{hostNetworkIC("b", "", 8080, 8443)},
+		},
+		{
+			description: "bind address policy without HostNetwork",
+			desired: func() operatorv1.IngressController {
+				ic := hostNetworkIC("a", "192.0.2.0/24", 80, 443)
+				ic.Status.EndpointPublishingStrategy.Type = operatorv1.LoadBalancerServiceStrategyType
+				return ic
+			}(),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			existing := append([]operatorv1.IngressController{tc.desired}, tc.existing...)
+			err := validateBindAddressPolicy(&tc.desired, existing)
+			switch {
+			case tc.expectError && err == nil:
+				t.Error("expected error, got nil")
+			case !tc.expectError && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_computeBindAddressCondition verifies that a bind address policy is
+// reported as pending router support and that it does not change the router
+// deployment.
+func Test_computeBindAddressCondition(t *testing.T) {
+	testCases := []struct {
+		description     string
+		annotations     map[string]string
+		expectCondition bool
+	}{
+		{
+			description: "no policy",
+		},
+		{
+			description:     "CIDR",
+			annotations:     map[string]string{RouterBindAddressCIDRAnnotation: "192.0.2.17/24"},
+			expectCondition: true,
+		},
+		{
+			description:     "node annotation",
+			annotations:     map[string]string{RouterBindAddressNodeAnnotationAnnotation: "example.com/ingress-address"},
+			expectCondition: true,
+		},
+		{
+			description: "invalid CIDR",
+			annotations: map[string]string{RouterBindAddressCIDRAnnotation: "192.0.2.0"},
+		},
+	}
+
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Status.EndpointPublishingStrategy.Type = operatorv1.HostNetworkStrategyType
+	expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := ic.DeepCopy()
+			ic.Annotations = tc.annotations
+			cond := computeBindAddressCondition(ic)
+			switch {
+			case !tc.expectCondition && cond != nil:
+				t.Fatalf("expected no condition, got %+v", *cond)
+			case !tc.expectCondition:
+				return
+			case cond == nil:
+				t.Fatal("expected a condition, got nil")
+			}
+			if cond.Status != operatorv1.ConditionFalse || cond.Reason != "RouterSupportPending" {
+				t.Errorf("expected status False with reason RouterSupportPending, got %s/%s", cond.Status, cond.Reason)
+			}
+
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+				t.Error("expected the bind address policy not to change the router deployment")
+			}
+		})
+	}
+}
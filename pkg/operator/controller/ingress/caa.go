@@ -0,0 +1,94 @@
+package ingress
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CAAIssuersAnnotation is an annotation on an ingresscontroller that
+	// specifies a comma-separated list of the domain names of the
+	// certificate authorities that may issue certificates for the
+	// ingresscontroller's domain, for example "letsencrypt.org".  If it is
+	// specified, and the ingresscontroller's DNS records are managed, the
+	// operator publishes CAA "issue" and "issuewild" records for each
+	// issuer at the ingresscontroller's domain in the public zone.
+	CAAIssuersAnnotation = "ingress.operator.openshift.io/caa-issuers"
+	// CAAIodefAnnotation is an annotation on an ingresscontroller that
+	// specifies a "mailto:" or "https:" URL to which certificate
+	// authorities report certificate requests that violate the CAA
+	// policy.  It is only used if CAAIssuersAnnotation is specified.
+	CAAIodefAnnotation = "ingress.operator.openshift.io/caa-iodef"
+)
+
+// caaRecordsForIngressController returns the CAA records that the given
+// ingresscontroller's annotations request, or nil if the ingresscontroller
+// does not request any.
+func caaRecordsForIngressController(ic *operatorv1.IngressController) ([]dns.CAARecord, error) {
+	value, ok := ic.Annotations[CAAIssuersAnnotation]
+	if !ok {
+		if _, ok := ic.Annotations[CAAIodefAnnotation]; ok {
+			return nil, fmt.Errorf("the %s annotation requires the %s annotation", CAAIodefAnnotation, CAAIssuersAnnotation)
+		}
+		return nil, nil
+	}
+
+	var records []dns.CAARecord
+	seen := map[string]bool{}
+	for _, issuer := range strings.Split(value, ",") {
+		issuer = strings.ToLower(strings.TrimSpace(issuer))
+		if len(issuer) == 0 {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(issuer); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid issuer %q in the %s annotation: %s", issuer, CAAIssuersAnnotation, strings.Join(errs, ", "))
+		}
+		if seen[issuer] {
+			continue
+		}
+		seen[issuer] = true
+		records = append(records,
+			dns.CAARecord{Tag: "issue", Value: issuer},
+			dns.CAARecord{Tag: "issuewild", Value: issuer},
+		)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the %s annotation must specify at least one issuer", CAAIssuersAnnotation)
+	}
+
+	if iodef, ok := ic.Annotations[CAAIodefAnnotation]; ok {
+		u, err := url.Parse(iodef)
+		if err != nil || (u.Scheme != "mailto" && u.Scheme != "https") || (len(u.Opaque) == 0 && len(u.Host) == 0) {
+			return nil, fmt.Errorf("invalid %s annotation %q: must be a mailto: or https: URL", CAAIodefAnnotation, iodef)
+		}
+		records = append(records, dns.CAARecord{Tag: "iodef", Value: iodef})
+	}
+
+	return records, nil
+}
+
+// validateCAAPolicy validates the ingresscontroller's CAA annotations.
+func validateCAAPolicy(ic *operatorv1.IngressController) error {
+	_, err := caaRecordsForIngressController(ic)
+	return err
+}
+
+// ensureWildcardDNSRecordCAA ensures that the given wildcard DNSRecord requests
+// the CAA records that the ingresscontroller's annotations specify.  The DNS
+// controller publishes the records.
+func (r *reconciler) ensureWildcardDNSRecordCAA(ic *operatorv1.IngressController, record *iov1.DNSRecord) error {
+	records, err := caaRecordsForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	_, err = dnsrecord.EnsureDNSRecordCAARecords(r.client, record, records)
+	return err
+}
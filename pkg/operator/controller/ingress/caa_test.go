@@ -0,0 +1,83 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_caaRecordsForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expected    []dns.CAARecord
+		expectError bool
+	}{
+		{
+			description: "no annotations",
+		},
+		{
+			description: "issuers",
+			annotations: map[string]string{CAAIssuersAnnotation: "letsencrypt.org, DigiCert.com,letsencrypt.org"},
+			expected: []dns.CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+				{Tag: "issuewild", Value: "letsencrypt.org"},
+				{Tag: "issue", Value: "digicert.com"},
+				{Tag: "issuewild", Value: "digicert.com"},
+			},
+		},
+		{
+			description: "issuer and iodef",
+			annotations: map[string]string{
+				CAAIssuersAnnotation: "letsencrypt.org",
+				CAAIodefAnnotation:   "mailto:security@example.com",
+			},
+			expected: []dns.CAARecord{
+				{Tag: "issue", Value: "letsencrypt.org"},
+				{Tag: "issuewild", Value: "letsencrypt.org"},
+				{Tag: "iodef", Value: "mailto:security@example.com"},
+			},
+		},
+		{
+			description: "empty issuers",
+			annotations: map[string]string{CAAIssuersAnnotation: " , "},
+			expectError: true,
+		},
+		{
+			description: "invalid issuer",
+			annotations: map[string]string{CAAIssuersAnnotation: "lets encrypt"},
+			expectError: true,
+		},
+		{
+			description: "invalid iodef",
+			annotations: map[string]string{
+				CAAIssuersAnnotation: "letsencrypt.org",
+				CAAIodefAnnotation:   "http://example.com/report",
+			},
+			expectError: true,
+		},
+		{
+			description: "iodef without issuers",
+			annotations: map[string]string{CAAIodefAnnotation: "mailto:security@example.com"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			records, err := caaRecordsForIngressController(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatalf("expected an error, got %v", records)
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case !tc.expectError && !dns.CAARecordsEqual(records, tc.expected):
+				t.Errorf("expected %v, got %v", tc.expected, records)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package ingress
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp/syntax"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ClientTLSScopesAnnotation is an annotation on an ingresscontroller
+	// that specifies a JSON array of client TLS scopes.  Each scope applies
+	// its own client certificate policy, client CA, and allowed subject
+	// patterns to the routes whose hosts match the scope's hostname
+	// patterns or whose labels match the scope's route selector, for
+	// example:
+	//
+	//	[{"name": "payments",
+	//	  "hostnames": ["pay.apps.example.com", "*.secure.apps.example.com"],
+	//	  "clientCertificatePolicy": "Required",
+	//	  "clientCA": "payments-client-ca",
+	//	  "allowedSubjectPatterns": ["^/CN=payments-.*$"]}]
+	//
+	// The client CA is the name of a configmap in the openshift-config
+	// namespace with a "ca-bundle.pem" key, as for spec.clientTLS.clientCA.
+	// Routes that no scope matches use spec.clientTLS.  If several scopes
+	// match a route, the first one applies.
+	//
+	// The router image does not support client TLS scopes yet, so the
+	// operator validates the annotation, publishes the scopes configmap,
+	// and reports the scopes in the ClientTLSScopesApplied status
+	// condition, but it does not change the router deployment.
+	ClientTLSScopesAnnotation = "ingress.operator.openshift.io/client-tls-scopes"
+
+	// ClientTLSScopesConfigKey is the key in the client TLS scopes
+	// configmap whose value is the router's client TLS scopes
+	// configuration.
+	ClientTLSScopesConfigKey = "scopes.json"
+
+	// clientTLSScopesMountPath is the path at which the router container
+	// is to mount the client TLS scopes configmap.  The rendered
+	// configuration refers to the scopes' CA bundles under this path.
+	clientTLSScopesMountPath = "/etc/pki/tls/client-tls-scopes"
+
+	// maxClientTLSScopes is the maximum number of scopes that
+	// ClientTLSScopesAnnotation may specify.
+	maxClientTLSScopes = 32
+)
+
+// ClientTLSScope is a client certificate policy that applies to a subset of an
+// ingresscontroller's routes.
+type ClientTLSScope struct {
+	// Name identifies the scope.  It must be a DNS label.
+	Name string `json:"name"`
+	// Hostnames are the hosts to which the scope applies.  A hostname
+	// may be a wildcard such as "*.example.com", which matches the
+	// subdomains of example.com.
+	Hostnames []string `json:"hostnames,omitempty"`
+	// RouteSelector selects the routes to which the scope applies by
+	// their labels.
+	RouteSelector *metav1.LabelSelector `json:"routeSelector,omitempty"`
+	// ClientCertificatePolicy is "Required" or "Optional".  The default
+	// is "Required".
+	ClientCertificatePolicy operatorv1.ClientCertificatePolicy `json:"clientCertificatePolicy,omitempty"`
+	// ClientCA is the name of the configmap in the openshift-config
+	// namespace with the CA bundle for verifying client certificates.
+	ClientCA string `json:"clientCA"`
+	// AllowedSubjectPatterns are regular expressions, one of which a
+	// client certificate's subject must match.
+	AllowedSubjectPatterns []string `json:"allowedSubjectPatterns,omitempty"`
+}
+
+// routerClientTLSScope is the router's configuration for a client TLS scope.
+type routerClientTLSScope struct {
+	Name          string   `json:"name"`
+	Hostnames     []string `json:"hostnames,omitempty"`
+	RouteSelector string   `json:"routeSelector,omitempty"`
+	Verify        string   `json:"verify"`
+	CAFile        string   `json:"caFile"`
+	SubjectFilter string   `json:"subjectFilter,omitempty"`
+}
+
+// ClientTLSScopesForIngressController returns the client TLS scopes that the
+// given ingresscontroller's annotation specifies, with defaults applied, or nil
+// if the annotation is absent.
+func ClientTLSScopesForIngressController(ic *operatorv1.IngressController) ([]ClientTLSScope, error) {
+	value, ok := ic.Annotations[ClientTLSScopesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var scopes []ClientTLSScope
+	if err := json.Unmarshal([]byte(value), &scopes); err != nil {
+		return nil, fmt.Errorf("invalid value for annotation %s: %w", ClientTLSScopesAnnotation, err)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("invalid value for annotation %s: at least one scope must be specified", ClientTLSScopesAnnotation)
+	}
+	if len(scopes) > maxClientTLSScopes {
+		return nil, fmt.Errorf("invalid value for annotation %s: at most %d scopes may be specified, got %d", ClientTLSScopesAnnotation, maxClientTLSScopes, len(scopes))
+	}
+
+	names := map[string]bool{}
+	hostnames := map[string]string{}
+	for i := range scopes {
+		scope := &scopes[i]
+		if errs := validation.IsDNS1123Label(scope.Name); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid value for annotation %s: invalid scope name %q: %s", ClientTLSScopesAnnotation, scope.Name, strings.Join(errs, ", "))
+		}
+		if names[scope.Name] {
+			return nil, fmt.Errorf("invalid value for annotation %s: duplicate scope name %q", ClientTLSScopesAnnotation, scope.Name)
+		}
+		names[scope.Name] = true
+
+		if len(scope.Hostnames) == 0 && scope.RouteSelector == nil {
+			return nil, fmt.Errorf("invalid value for annotation %s: scope %q must specify hostnames or a route selector", ClientTLSScopesAnnotation, scope.Name)
+		}
+		for j, hostname := range scope.Hostnames {
+			hostname = strings.ToLower(hostname)
+			if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(hostname, "*.")); len(errs) != 0 {
+				return nil, fmt.Errorf("invalid value for annotation %s: invalid hostname %q in scope %q: %s", ClientTLSScopesAnnotation, hostname, scope.Name, strings.Join(errs, ", "))
+			}
+			if other, ok := hostnames[hostname]; ok {
+				return nil, fmt.Errorf("invalid value for annotation %s: hostname %q is in both scope %q and scope %q", ClientTLSScopesAnnotation, hostname, other, scope.Name)
+			}
+			hostnames[hostname] = scope.Name
+			scope.Hostnames[j] = hostname
+		}
+		if scope.RouteSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(scope.RouteSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for annotation %s: invalid route selector in scope %q: %w", ClientTLSScopesAnnotation, scope.Name, err)
+			}
+			if selector.Empty() {
+				return nil, fmt.Errorf("invalid value for annotation %s: the route selector in scope %q matches every route; use spec.clientTLS instead", ClientTLSScopesAnnotation, scope.Name)
+			}
+		}
+
+		switch scope.ClientCertificatePolicy {
+		case "":
+			scope.ClientCertificatePolicy = operatorv1.ClientCertificatePolicyRequired
+		case operatorv1.ClientCertificatePolicyRequired, operatorv1.ClientCertificatePolicyOptional:
+		default:
+			return nil, fmt.Errorf("invalid value for annotation %s: invalid client certificate policy %q in scope %q", ClientTLSScopesAnnotation, scope.ClientCertificatePolicy, scope.Name)
+		}
+		if errs := validation.IsDNS1123Subdomain(scope.ClientCA); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid value for annotation %s: invalid client CA configmap name %q in scope %q: %s", ClientTLSScopesAnnotation, scope.ClientCA, scope.Name, strings.Join(errs, ", "))
+		}
+		for j, pattern := range scope.AllowedSubjectPatterns {
+			if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+				return nil, fmt.Errorf("invalid value for annotation %s: failed to parse allowedSubjectPatterns[%d] in scope %q: %w", ClientTLSScopesAnnotation, j, scope.Name, err)
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// validateClientTLSScopes validates the ingresscontroller's client TLS scopes
+// annotation.
+func validateClientTLSScopes(ic *operatorv1.IngressController) error {
+	_, err := ClientTLSScopesForIngressController(ic)
+	return err
+}
+
+// ClientTLSScopeCABundleKey returns the key in the client TLS scopes configmap
+// whose value is the given scope's client CA bundle.
+func ClientTLSScopeCABundleKey(scope string) string {
+	return scope + "-ca-bundle.pem"
+}
+
+// RenderClientTLSScopesConfig returns the router's client TLS scopes
+// configuration for the given scopes, which refers to the CA bundles in the
+// client TLS scopes configmap at the path at which the router container is to
+// mount it.
+func RenderClientTLSScopesConfig(scopes []ClientTLSScope) (string, error) {
+	config := make([]routerClientTLSScope, 0, len(scopes))
+	for _, scope := range scopes {
+		s := routerClientTLSScope{
+			Name:      scope.Name,
+			Hostnames: scope.Hostnames,
+			Verify:    "required",
+			CAFile:    filepath.Join(clientTLSScopesMountPath, ClientTLSScopeCABundleKey(scope.Name)),
+		}
+		if scope.ClientCertificatePolicy == operatorv1.ClientCertificatePolicyOptional {
+			s.Verify = "optional"
+		}
+		if scope.RouteSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(scope.RouteSelector)
+			if err != nil {
+				return "", err
+			}
+			s.RouteSelector = selector.String()
+		}
+		if len(scope.AllowedSubjectPatterns) != 0 {
+			s.SubjectFilter = "(?:" + strings.Join(scope.AllowedSubjectPatterns, "|") + ")"
+		}
+		config = append(config, s)
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// computeClientTLSScopesCondition computes the ingresscontroller's client TLS
+// scopes status condition.  The router image does not support client TLS
+// scopes yet, so routes that a scope matches still use spec.clientTLS, and the
+// condition says so.  If no valid scopes are configured, nil is returned.
+func computeClientTLSScopesCondition(ic *operatorv1.IngressController) *operatorv1.OperatorCondition {
+	scopes, err := ClientTLSScopesForIngressController(ic)
+	if err != nil || len(scopes) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, scope.Name)
+	}
+	return &operatorv1.OperatorCondition{
+		Type:    IngressControllerClientTLSScopesConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "RouterSupportPending",
+		Message: fmt.Sprintf("Client TLS scopes %s are configured, but the router image does not support them yet, so all routes use spec.clientTLS.", strings.Join(names, ", ")),
+	}
+}
@@ -0,0 +1,125 @@
+package ingress
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func Test_ClientTLSScopesForIngressController(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  *string
+		expectError bool
+	}{
+		{name: "no annotation"},
+		{name: "hostnames", annotation: pointer.String(`[{"name":"payments","hostnames":["pay.apps.example.com","*.secure.apps.example.com"],"clientCA":"payments-ca"}]`)},
+		{name: "route selector", annotation: pointer.String(`[{"name":"partners","routeSelector":{"matchExpressions":[{"key":"mtls","operator":"In","values":["partners"]}]},"clientCertificatePolicy":"Optional","clientCA":"partners-ca","allowedSubjectPatterns":["^/CN=partner-.*$"]}]`)},
+		{name: "malformed JSON", annotation: pointer.String(`[{"name":`), expectError: true},
+		{name: "empty list", annotation: pointer.String(`[]`), expectError: true},
+		{name: "invalid scope name", annotation: pointer.String(`[{"name":"Payments","hostnames":["pay.apps.example.com"],"clientCA":"payments-ca"}]`), expectError: true},
+		{name: "duplicate scope name", annotation: pointer.String(`[{"name":"a","hostnames":["a.example.com"],"clientCA":"ca"},{"name":"a","hostnames":["b.example.com"],"clientCA":"ca"}]`), expectError: true},
+		{name: "no hostnames or selector", annotation: pointer.String(`[{"name":"a","clientCA":"ca"}]`), expectError: true},
+		{name: "hostname in two scopes", annotation: pointer.String(`[{"name":"a","hostnames":["x.example.com"],"clientCA":"ca"},{"name":"b","hostnames":["X.example.com"],"clientCA":"ca"}]`), expectError: true},
+		{name: "invalid hostname", annotation: pointer.String(`[{"name":"a","hostnames":["x_y.example.com"],"clientCA":"ca"}]`), expectError: true},
+		{name: "empty selector", annotation: pointer.String(`[{"name":"a","routeSelector":{},"clientCA":"ca"}]`), expectError: true},
+		{name: "invalid policy", annotation: pointer.String(`[{"name":"a","hostnames":["x.example.com"],"clientCertificatePolicy":"Sometimes","clientCA":"ca"}]`), expectError: true},
+		{name: "missing client CA", annotation: pointer.String(`[{"name":"a","hostnames":["x.example.com"]}]`), expectError: true},
+		{name: "invalid subject pattern", annotation: pointer.String(`[{"name":"a","hostnames":["x.example.com"],"clientCA":"ca","allowedSubjectPatterns":["("]}]`), expectError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if tc.annotation != nil {
+				ic.Annotations = map[string]string{ClientTLSScopesAnnotation: *tc.annotation}
+			}
+			_, err := ClientTLSScopesForIngressController(ic)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func Test_RenderClientTLSScopesConfig(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ClientTLSScopesAnnotation: `[{"name":"payments","hostnames":["Pay.apps.example.com"],"clientCA":"payments-ca","allowedSubjectPatterns":["^/CN=a$","^/CN=b$"]},{"name":"partners","routeSelector":{"matchLabels":{"mtls":"partners"}},"clientCertificatePolicy":"Optional","clientCA":"partners-ca"}]`,
+			},
+		},
+	}
+	scopes, err := ClientTLSScopesForIngressController(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := RenderClientTLSScopesConfig(scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rendered []routerClientTLSScope
+	if err := json.Unmarshal([]byte(config), &rendered); err != nil {
+		t.Fatal(err)
+	}
+	expected := []routerClientTLSScope{{
+		Name:          "payments",
+		Hostnames:     []string{"pay.apps.example.com"},
+		Verify:        "required",
+		CAFile:        "/etc/pki/tls/client-tls-scopes/payments-ca-bundle.pem",
+		SubjectFilter: "(?:^/CN=a$|^/CN=b$)",
+	}, {
+		Name:          "partners",
+		RouteSelector: "mtls=partners",
+		Verify:        "optional",
+		CAFile:        "/etc/pki/tls/client-tls-scopes/partners-ca-bundle.pem",
+	}}
+	if len(rendered) != len(expected) {
+		t.Fatalf("expected %d scopes, got %d: %s", len(expected), len(rendered), config)
+	}
+	for i := range expected {
+		if rendered[i].Name != expected[i].Name || rendered[i].RouteSelector != expected[i].RouteSelector || rendered[i].Verify != expected[i].Verify || rendered[i].CAFile != expected[i].CAFile || rendered[i].SubjectFilter != expected[i].SubjectFilter || len(rendered[i].Hostnames) != len(expected[i].Hostnames) {
+			t.Errorf("expected scope %+v, got %+v", expected[i], rendered[i])
+		}
+	}
+	if rendered[0].Hostnames[0] != "pay.apps.example.com" {
+		t.Errorf("expected a lowercase hostname, got %q", rendered[0].Hostnames[0])
+	}
+}
+
+// Test_computeClientTLSScopesCondition verifies that client TLS scopes are
+// reported as pending router support and that they do not change the router
+// deployment.
+func Test_computeClientTLSScopesCondition(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := computeClientTLSScopesCondition(ic); cond != nil {
+		t.Errorf("expected no condition without client TLS scopes, got %+v", *cond)
+	}
+
+	ic.Annotations = map[string]string{ClientTLSScopesAnnotation: `[{"name":"payments","hostnames":["pay.apps.example.com"],"clientCA":"payments-ca"}]`}
+	cond := computeClientTLSScopesCondition(ic)
+	switch {
+	case cond == nil:
+		t.Fatal("expected a condition, got nil")
+	case cond.Status != operatorv1.ConditionFalse || cond.Reason != "RouterSupportPending":
+		t.Errorf("expected status False and reason RouterSupportPending, got %+v", *cond)
+	case !strings.Contains(cond.Message, "payments"):
+		t.Errorf("expected the message to name the payments scope, got %q", cond.Message)
+	}
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+		t.Error("expected client TLS scopes not to change the router deployment")
+	}
+}
@@ -60,7 +60,19 @@ const (
 	IngressControllerDeploymentRollingOutConditionType           = "DeploymentRollingOut"
 	IngressControllerLoadBalancerProgressingConditionType        = "LoadBalancerProgressing"
 	IngressControllerCanaryCheckSuccessConditionType             = "CanaryChecksSucceeding"
+	IngressControllerCanaryRoutePropagationConditionType         = "CanaryRoutePropagationWithinBound"
+	IngressControllerProxyProtocolConsistentConditionType        = "ProxyProtocolConsistent"
+	IngressControllerCanaryHeaderPoliciesConditionType           = "CanaryHeaderPoliciesHonored"
+	IngressControllerTLSProfileConformantConditionType           = "TLSProfileConformant"
 	IngressControllerEvaluationConditionsDetectedConditionType   = "EvaluationConditionsDetected"
+	IngressControllerHTTPHardeningConditionType                  = "HTTPHardeningApplied"
+	IngressControllerGeoIPDatabaseReadyConditionType             = "GeoIPDatabaseReady"
+	IngressControllerForwardedHeaderPolicyConditionType          = "ForwardedHeaderPolicyApplied"
+	IngressControllerRouterImagePrePulledConditionType           = "RouterImagePrePulled"
+	IngressControllerClientTLSScopesConditionType                = "ClientTLSScopesApplied"
+	IngressControllerHTTPSOnlyConditionType                      = "HTTPSOnlyApplied"
+	IngressControllerLoadBalancerProbeSuccessConditionType       = "LoadBalancerProbesSucceeding"
+	IngressControllerBindAddressConditionType                    = "HostNetworkBindAddressApplied"
 
 	routerDefaultHeaderBufferSize           = 32768
 	routerDefaultHeaderBufferMaxRewriteSize = 8192
@@ -95,6 +107,10 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 		client:   mgr.GetClient(),
 		cache:    operatorCache,
 		recorder: mgr.GetEventRecorderFor(controllerName),
+
+		apiReader: mgr.GetAPIReader(),
+
+		nodePortInventoryDeliverer: newNodePortInventoryDeliverer(),
 	}
 	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
 	if err != nil {
@@ -111,11 +127,18 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(source.Kind(operatorCache, &corev1.Service{}), enqueueRequestForOwningIngressController(config.Namespace)); err != nil {
 		return nil, err
 	}
-	// Add watch for deleted pods specifically for ensuring ingress deletion.
+	// Watch router image pre-pull daemonsets so that the router deployment
+	// is updated as soon as the pre-pull completes.
+	if err := c.Watch(source.Kind(operatorCache, &appsv1.DaemonSet{}), enqueueRequestForOwningIngressController(config.Namespace)); err != nil {
+		return nil, err
+	}
+	// Add watch for deleted pods specifically for ensuring ingress deletion,
+	// and for router pods whose load balancer readiness gate is pending and
+	// whose containers become ready.
 	if err := c.Watch(source.Kind(operatorCache, &corev1.Pod{}), enqueueRequestForOwningIngressController(config.Namespace), predicate.Funcs{
 		CreateFunc:  func(e event.CreateEvent) bool { return false },
 		DeleteFunc:  func(e event.DeleteEvent) bool { return true },
-		UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+		UpdateFunc:  routerPodContainersReadyChanged,
 		GenericFunc: func(e event.GenericEvent) bool { return false },
 	}); err != nil {
 		return nil, err
@@ -134,6 +157,18 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(source.Kind(operatorCache, &configv1.Proxy{}), handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController)); err != nil {
 		return nil, err
 	}
+	// Watch for changes to secrets and configmaps that ingresscontrollers
+	// use as their GeoIP databases so that the GeoIPDatabaseReady status
+	// condition is kept up to date.
+	isOperandNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == operatorcontroller.DefaultOperandNamespace
+	})
+	if err := c.Watch(source.Kind(operatorCache, &corev1.Secret{}), handler.EnqueueRequestsFromMapFunc(reconciler.geoIPDatabaseToIngressController), isOperandNamespace); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind(operatorCache, &corev1.ConfigMap{}), handler.EnqueueRequestsFromMapFunc(reconciler.geoIPDatabaseToIngressController), isOperandNamespace); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -200,6 +235,14 @@ type reconciler struct {
 	client   client.Client
 	cache    cache.Cache
 	recorder record.EventRecorder
+	// apiReader reads objects directly from the API, for objects such as
+	// nodes that the operator has no RBAC to watch and therefore does not
+	// cache.
+	apiReader client.Reader
+
+	// nodePortInventoryDeliverer delivers NodePort backend inventories
+	// to webhooks.
+	nodePortInventoryDeliverer *nodePortInventoryDeliverer
 }
 
 // admissionRejection is an error type for ingresscontroller admission
@@ -396,11 +439,17 @@ func (r *reconciler) admit(current *operatorv1.IngressController, ingressConfig
 // order to revalidate mutable fields that are subject to admission checks.  The
 // determination whether re-admission is needed is based on the
 // ingresscontroller's current generation and the observed generation recorded
-// in its status.
+// in its status.  Because updating an annotation does not increment the
+// generation, an ingresscontroller whose access log redaction annotations are
+// invalid also needs re-admission; otherwise the router would log the values
+// that the annotations were meant to redact.
 func needsReadmission(ic *operatorv1.IngressController) bool {
 	if ic.Generation != ic.Status.ObservedGeneration {
 		return true
 	}
+	if err := validateAccessLogRedaction(ic); err != nil {
+		return true
+	}
 	return false
 }
 
@@ -798,6 +847,48 @@ func (r *reconciler) validate(ic *operatorv1.IngressController) error {
 	if err := validateClientTLS(ic); err != nil {
 		errors = append(errors, err)
 	}
+	if err := validateBindAddressPolicy(ic, ingresses.Items); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateAccessLogRedaction(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateHTTPHardening(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateGeoIPPolicy(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateCAAPolicy(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateForwardedHeaderTrustedProxies(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateClientTLSScopes(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateNodePortInventoryWebhook(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := routemetrics.ValidateRouteQuota(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateRouterImagePrePull(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateAWSNLBZonalDNS(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := routemetrics.ValidateHTTPSOnly(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateLoadBalancerReadinessGate(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateDNSFailover(ic); err != nil {
+		errors = append(errors, err)
+	}
 	if err := utilerrors.NewAggregate(errors); err != nil {
 		return &admissionRejection{err.Error()}
 	}
@@ -982,9 +1073,14 @@ func (r *reconciler) ensureIngressDeleted(ingress *operatorv1.IngressController)
 	// Delete the metrics related to the ingresscontroller
 	DeleteIngressControllerConditionsMetric(ingress)
 	DeleteActiveNLBMetrics(ingress)
+	DeleteGeoIPDatabaseMetrics(ingress)
+	DeleteRouterRolloutMetrics(ingress)
+	DeleteTLSProfileDeviationsMetric(ingress)
 
 	// Delete the RoutesPerShard metric label corresponding to the Ingress Controller.
 	routemetrics.DeleteRouteMetricsControllerRoutesPerShardMetric(ingress.Name)
+	routemetrics.DeleteRouteQuotaMetrics(ingress.Name)
+	routemetrics.DeleteHTTPSOnlyMetrics(ingress.Name)
 
 	if len(errs) == 0 {
 		// Remove the ingresscontroller finalizer.
@@ -1055,7 +1151,12 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 	}
 
 	haveDepl, deployment, err := r.ensureRouterDeployment(ci, infraConfig, ingressConfig, apiConfig, networkConfig, haveClientCAConfigmap, clientCAConfigmap, platformStatus, clusterProxyConfig)
-	if err != nil {
+	if _, ok := err.(retryable.Error); ok && haveDepl {
+		// Part of the deployment update is deferred, for example the
+		// router image change until the new image is pre-pulled, so
+		// continue with the current deployment and requeue.
+		errs = append(errs, err)
+	} else if err != nil {
 		errs = append(errs, fmt.Errorf("failed to ensure deployment: %v", err))
 		return utilerrors.NewAggregate(errs)
 	} else if !haveDepl {
@@ -1093,11 +1194,23 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 			errs = append(errs, fmt.Errorf("failed to ensure wildcard dnsrecord for %s: %v", ci.Name, err))
 		} else {
 			wildcardRecord = record
+			if record != nil {
+				if err := r.ensureWildcardDNSRecordCAA(ci, record); err != nil {
+					errs = append(errs, fmt.Errorf("failed to ensure CAA records for %s: %v", ci.Name, err))
+				}
+				if err := r.ensureWildcardDNSRecordZonalRecords(ci, record); err != nil {
+					errs = append(errs, fmt.Errorf("failed to ensure zonal DNS records for %s: %v", ci.Name, err))
+				}
+				if err := r.ensureWildcardDNSRecordFailoverPolicy(ci, record); err != nil {
+					errs = append(errs, fmt.Errorf("failed to ensure DNS failover policy for %s: %v", ci.Name, err))
+				}
+			}
 		}
 	}
 
-	if _, _, err := r.ensureNodePortService(ci, deploymentRef); err != nil {
-		errs = append(errs, err)
+	haveNodePortSvc, nodePortSvc, nodePortSvcErr := r.ensureNodePortService(ci, deploymentRef)
+	if nodePortSvcErr != nil {
+		errs = append(errs, nodePortSvcErr)
 	}
 
 	if haveSvc, internalSvc, err := r.ensureInternalIngressControllerService(ci, deploymentRef); err != nil {
@@ -1126,6 +1239,22 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 		errs = append(errs, fmt.Errorf("failed to list pods in namespace %q: %v", operatorcontroller.DefaultOperatorNamespace, err))
 	}
 
+	if nodePortSvcErr == nil {
+		if err := r.ensureNodePortInventory(ci, deploymentRef, haveNodePortSvc, nodePortSvc, pods.Items); err != nil {
+			errs = append(errs, fmt.Errorf("failed to ensure NodePort backend inventory for ingresscontroller %s: %w", ci.Name, err))
+		}
+	}
+
+	if requeue, err := r.ensureLoadBalancerReadinessGates(ci, lbService, pods.Items); err != nil {
+		errs = append(errs, retryable.New(fmt.Errorf("failed to ensure load balancer readiness gates for ingresscontroller %s: %w", ci.Name, err), 5*time.Second))
+	} else if requeue {
+		errs = append(errs, retryable.New(fmt.Errorf("waiting for router pods' nodes to pass the load balancer health check for ingresscontroller %s", ci.Name), 5*time.Second))
+	}
+
+	if err := r.ensureRouterRolloutTimeline(ci, deployment, pods.Items, operandEvents.Items); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update router rollout timeline for ingresscontroller %s: %w", ci.Name, err))
+	}
+
 	syncStatusErr, updated := r.syncIngressControllerStatus(ci, deployment, deploymentRef, pods.Items, lbService, operandEvents.Items, wildcardRecord, dnsConfig, platformStatus)
 	errs = append(errs, syncStatusErr)
 
@@ -1265,5 +1394,4 @@ func computeUpdatedInfraFromService(service *corev1.Service, infraConfig *config
 	default:
 		return false, nil
 	}
-	return false, nil
 }
@@ -1,6 +1,7 @@
 package ingress
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -14,8 +15,20 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
+
 // Test_setDefaultDomain verifies that setDefaultDomain behaves correctly.
 func Test_setDefaultDomain(t *testing.T) {
 	ingressConfig := &configv1.Ingress{
@@ -1684,3 +1697,73 @@ func Test_computeUpdatedInfraFromService(t *testing.T) {
 		})
 	}
 }
+
+// Test_needsReadmission verifies that an admitted ingresscontroller is
+// re-admitted, and rejected, when an access log redaction annotation is set to
+// an invalid value, even though the ingresscontroller's generation does not
+// change, and that other annotations do not cause re-admission.
+func Test_needsReadmission(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "openshift-ingress-operator",
+			Name:       "default",
+			Generation: 1,
+		},
+		Status: operatorv1.IngressControllerStatus{
+			Domain:             "apps.example.com",
+			ObservedGeneration: 1,
+			Conditions: []operatorv1.OperatorCondition{{
+				Type:   IngressControllerAdmittedConditionType,
+				Status: operatorv1.ConditionTrue,
+			}},
+		},
+	}
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ic).WithStatusSubresource(ic).Build()
+	r := &reconciler{
+		config:   Config{Namespace: "openshift-ingress-operator"},
+		client:   cl,
+		cache:    fakeCache{Reader: cl},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	if needsReadmission(ic) {
+		t.Fatal("expected a valid admitted ingresscontroller not to need re-admission")
+	}
+	ic.Generation = 2
+	if !needsReadmission(ic) {
+		t.Fatal("expected an ingresscontroller with a new generation to need re-admission")
+	}
+	ic.Generation = 1
+
+	ic.Annotations = map[string]string{
+		DNSFailoverStandbyAnnotation:   "standby",
+		DNSFailoverThresholdAnnotation: "soon",
+	}
+	if needsReadmission(ic) {
+		t.Fatal("expected an invalid annotation that is unrelated to access log redaction not to cause re-admission")
+	}
+
+	ic.Annotations = map[string]string{
+		RouterAccessLogRedactHeadersAnnotation: "Authorization",
+	}
+	if !needsReadmission(ic) {
+		t.Fatal("expected an ingresscontroller with an invalid access log redaction annotation to need re-admission")
+	}
+	ingressConfig := &configv1.Ingress{Spec: configv1.IngressSpec{Domain: "apps.example.com"}}
+	platformStatus := &configv1.PlatformStatus{Type: configv1.NonePlatformType}
+	err := r.admit(ic, ingressConfig, platformStatus, &configv1.DNS{}, true)
+	if _, ok := err.(*admissionRejection); !ok {
+		t.Fatalf("expected an admission rejection, got %v", err)
+	}
+	updated := &operatorv1.IngressController{}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}, updated); err != nil {
+		t.Fatal(err)
+	}
+	for _, cond := range updated.Status.Conditions {
+		if cond.Type == IngressControllerAdmittedConditionType && cond.Status != operatorv1.ConditionFalse {
+			t.Errorf("expected the ingresscontroller not to be admitted, got %+v", cond)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	retryable "github.com/openshift/cluster-ingress-operator/pkg/util/retryableerror"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
@@ -131,10 +132,25 @@ func (r *reconciler) ensureRouterDeployment(ci *operatorv1.IngressController, in
 		}
 		return r.currentRouterDeployment(ci)
 	case haveDepl:
+		wait, err := r.ensureRouterImagePrePull(ci, current, desired, time.Now())
+		if err != nil {
+			return true, current, err
+		}
+		image := routerContainerImage(desired)
+		if wait {
+			// Apply any other changes now, and only defer the
+			// image change until the pre-pull finishes.
+			desired = withCurrentRouterImage(desired, current)
+		}
 		if updated, err := r.updateRouterDeployment(current, desired); err != nil {
 			return true, current, err
 		} else if updated {
-			return r.currentRouterDeployment(ci)
+			if haveDepl, current, err = r.currentRouterDeployment(ci); err != nil || !haveDepl {
+				return haveDepl, current, err
+			}
+		}
+		if wait {
+			return true, current, retryable.New(fmt.Errorf("waiting for router image %s to be pre-pulled before updating the router image", image), routerImagePrePullPollInterval)
 		}
 	}
 	return true, current, nil
@@ -270,6 +286,12 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 			1) * /* we could miss one */
 			10 /* the longest health check interval on any platform */
 
+	// Hold router pods out of service until their nodes pass the load
+	// balancer's health check.
+	if useLoadBalancerReadinessGate(ci) {
+		deployment.Spec.Template.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: LoadBalancerReadyConditionType}}
+	}
+
 	volumes := deployment.Spec.Template.Spec.Volumes
 	routerVolumeMounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
 
@@ -853,8 +875,16 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 			)
 		}
 
-		if len(accessLogging.HttpLogFormat) > 0 {
-			env = append(env, corev1.EnvVar{Name: RouterSyslogFormatEnvName, Value: fmt.Sprintf("%q", accessLogging.HttpLogFormat)})
+		redaction, err := accessLogRedactionPolicy(ci)
+		if err != nil {
+			return nil, err
+		}
+		httpLogFormat := accessLogging.HttpLogFormat
+		if redaction != nil {
+			httpLogFormat = redactedHTTPLogFormat(httpLogFormat, accessLogging, redaction)
+		}
+		if len(httpLogFormat) > 0 {
+			env = append(env, corev1.EnvVar{Name: RouterSyslogFormatEnvName, Value: fmt.Sprintf("%q", httpLogFormat)})
 		}
 		if val := serializeCaptureHeaders(accessLogging.HTTPCaptureHeaders.Request); len(val) != 0 {
 			env = append(env, corev1.EnvVar{
@@ -868,7 +898,9 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 				Value: val,
 			})
 		}
-		if len(accessLogging.HTTPCaptureCookies) > 0 {
+		// A redacted cookie is not captured at all so that its value never
+		// reaches the router's logs.
+		if len(accessLogging.HTTPCaptureCookies) > 0 && (redaction == nil || !redaction.redactsCapturedCookie(accessLogging.HTTPCaptureCookies[0])) {
 			var (
 				cookieName string
 				maxLength  = accessLogging.HTTPCaptureCookies[0].MaxLength
@@ -976,12 +1008,8 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		env = append(env, corev1.EnvVar{Name: WildcardRouteAdmissionPolicy, Value: "false"})
 	}
 
-	forwardedHeaderPolicy := operatorv1.AppendHTTPHeaderPolicy
-	if ci.Spec.HTTPHeaders != nil && len(ci.Spec.HTTPHeaders.ForwardedHeaderPolicy) != 0 {
-		forwardedHeaderPolicy = ci.Spec.HTTPHeaders.ForwardedHeaderPolicy
-	}
 	routerForwardedHeadersPolicyValue := "append"
-	switch forwardedHeaderPolicy {
+	switch forwardedHeaderPolicy(ci) {
 	case operatorv1.AppendHTTPHeaderPolicy:
 		// Nothing to do.
 	case operatorv1.ReplaceHTTPHeaderPolicy:
@@ -1373,6 +1401,7 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 	}
 	hashableDeployment.Spec.Template.Spec.TopologySpreadConstraints = topologySpreadConstraints
 	hashableDeployment.Spec.Template.Spec.NodeSelector = deployment.Spec.Template.Spec.NodeSelector
+	hashableDeployment.Spec.Template.Spec.ReadinessGates = deployment.Spec.Template.Spec.ReadinessGates
 	containers := make([]corev1.Container, len(deployment.Spec.Template.Spec.Containers))
 	for i, container := range deployment.Spec.Template.Spec.Containers {
 		env := container.Env
@@ -1396,6 +1425,17 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 		return containers[i].Name < containers[j].Name
 	})
 	hashableDeployment.Spec.Template.Spec.Containers = containers
+	initContainers := make([]corev1.Container, len(deployment.Spec.Template.Spec.InitContainers))
+	for i, container := range deployment.Spec.Template.Spec.InitContainers {
+		initContainers[i] = corev1.Container{
+			Command:         container.Command,
+			Env:             container.Env,
+			Image:           container.Image,
+			ImagePullPolicy: container.ImagePullPolicy,
+			Name:            container.Name,
+		}
+	}
+	hashableDeployment.Spec.Template.Spec.InitContainers = initContainers
 	hashableDeployment.Spec.Template.Spec.DNSPolicy = deployment.Spec.Template.Spec.DNSPolicy
 	hashableDeployment.Spec.Template.Spec.HostNetwork = deployment.Spec.Template.Spec.HostNetwork
 	volumes := make([]corev1.Volume, len(deployment.Spec.Template.Spec.Volumes))
@@ -1578,6 +1618,11 @@ func deploymentConfigChanged(current, expected *appsv1.Deployment) (bool, *appsv
 		containers[i+1] = *container.DeepCopy()
 	}
 	updated.Spec.Template.Spec.Containers = containers
+	initContainers := make([]corev1.Container, len(expected.Spec.Template.Spec.InitContainers))
+	for i, container := range expected.Spec.Template.Spec.InitContainers {
+		initContainers[i] = *container.DeepCopy()
+	}
+	updated.Spec.Template.Spec.InitContainers = initContainers
 	updated.Spec.Template.Spec.DNSPolicy = expected.Spec.Template.Spec.DNSPolicy
 	updated.Spec.Template.Labels = expected.Spec.Template.Labels
 
@@ -1602,6 +1647,7 @@ func deploymentConfigChanged(current, expected *appsv1.Deployment) (bool, *appsv
 	}
 	updated.Spec.Template.Spec.Volumes = volumes
 	updated.Spec.Template.Spec.NodeSelector = expected.Spec.Template.Spec.NodeSelector
+	updated.Spec.Template.Spec.ReadinessGates = expected.Spec.Template.Spec.ReadinessGates
 	updated.Spec.Template.Spec.Containers[0].SecurityContext = expected.Spec.Template.Spec.Containers[0].SecurityContext
 	updated.Spec.Template.Spec.Containers[0].Env = expected.Spec.Template.Spec.Containers[0].Env
 	updated.Spec.Template.Spec.Containers[0].Image = expected.Spec.Template.Spec.Containers[0].Image
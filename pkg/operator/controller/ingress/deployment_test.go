@@ -1237,6 +1237,17 @@ func Test_deploymentConfigChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if an init container is added",
+			mutate: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.InitContainers = []corev1.Container{{
+					Name:    "init",
+					Image:   "openshift/origin-cli:v4.0",
+					Command: []string{"/bin/true"},
+				}}
+			},
+			expect: true,
+		},
 		{
 			description: "if the default-certificates default mode value is omitted",
 			mutate: func(deployment *appsv1.Deployment) {
@@ -1252,6 +1263,13 @@ func Test_deploymentConfigChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if .spec.template.spec.readinessGates changes",
+			mutate: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: LoadBalancerReadyConditionType}}
+			},
+			expect: true,
+		},
 		{
 			description: "if .spec.template.spec.tolerations change",
 			mutate: func(deployment *appsv1.Deployment) {
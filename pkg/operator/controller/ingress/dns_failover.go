@@ -0,0 +1,109 @@
+package ingress
+
+import (
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DNSFailoverStandbyAnnotation is an annotation on an
+	// ingresscontroller that designates a standby ingresscontroller, by
+	// name, for DNS failover.  The standby should serve the same routes as
+	// the ingresscontroller, for example by having overlapping route or
+	// namespace selectors.  If the ingresscontroller stays unhealthy,
+	// meaning that it is degraded or that the canary controller's probes
+	// through its own load balancer are failing, for longer than the
+	// failover threshold, the DNS controller points
+	// the ingresscontroller's wildcard DNS record at the standby's load
+	// balancer.  Once the ingresscontroller has been healthy for the
+	// failback delay, the DNS controller points the record back at the
+	// ingresscontroller's own load balancer.  The DNS controller records
+	// each switch as an event on the ingresscontroller.
+	DNSFailoverStandbyAnnotation = "ingress.operator.openshift.io/dns-failover-standby"
+	// DNSFailoverThresholdAnnotation is an annotation on an
+	// ingresscontroller that specifies, as a duration such as "5m", how
+	// long the ingresscontroller must be unhealthy before its wildcard DNS
+	// record fails over to the standby.  The default is 5 minutes.
+	DNSFailoverThresholdAnnotation = "ingress.operator.openshift.io/dns-failover-threshold"
+	// DNSFailoverFailbackDelayAnnotation is an annotation on an
+	// ingresscontroller that specifies, as a duration such as "15m", how
+	// long the ingresscontroller must be healthy again before its wildcard
+	// DNS record fails back from the standby.  The default is 15 minutes.
+	DNSFailoverFailbackDelayAnnotation = "ingress.operator.openshift.io/dns-failover-failback-delay"
+
+	defaultDNSFailoverThreshold     = 5 * time.Minute
+	defaultDNSFailoverFailbackDelay = 15 * time.Minute
+)
+
+// dnsFailoverPolicyForIngressController returns the DNS failover policy that
+// the given ingresscontroller's annotations specify, or nil if the
+// ingresscontroller does not designate a standby.
+func dnsFailoverPolicyForIngressController(ic *operatorv1.IngressController) (*dnsrecord.FailoverPolicy, error) {
+	threshold, err := dnsFailoverDuration(ic, DNSFailoverThresholdAnnotation, defaultDNSFailoverThreshold)
+	if err != nil {
+		return nil, err
+	}
+	failbackDelay, err := dnsFailoverDuration(ic, DNSFailoverFailbackDelayAnnotation, defaultDNSFailoverFailbackDelay)
+	if err != nil {
+		return nil, err
+	}
+	standby, ok := ic.Annotations[DNSFailoverStandbyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if errs := validation.IsDNS1123Subdomain(standby); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid value for annotation %s: %q is not a valid ingresscontroller name: %v", DNSFailoverStandbyAnnotation, standby, errs)
+	}
+	if standby == ic.Name {
+		return nil, fmt.Errorf("invalid value for annotation %s: an ingresscontroller cannot be its own standby", DNSFailoverStandbyAnnotation)
+	}
+	return &dnsrecord.FailoverPolicy{
+		Standby:       standby,
+		Threshold:     metav1.Duration{Duration: threshold},
+		FailbackDelay: metav1.Duration{Duration: failbackDelay},
+	}, nil
+}
+
+// dnsFailoverDuration returns the positive duration in the given annotation on
+// the given ingresscontroller, or the given default if the annotation is not
+// set.
+func dnsFailoverDuration(ic *operatorv1.IngressController, annotation string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := ic.Annotations[annotation]
+	if !ok {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for annotation %s: %v", annotation, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid value for annotation %s: %q is not a positive duration", annotation, value)
+	}
+	return d, nil
+}
+
+// validateDNSFailover validates the ingresscontroller's DNS failover
+// annotations.
+func validateDNSFailover(ic *operatorv1.IngressController) error {
+	_, err := dnsFailoverPolicyForIngressController(ic)
+	return err
+}
+
+// ensureWildcardDNSRecordFailoverPolicy ensures that the given wildcard
+// DNSRecord has the DNS failover policy that the ingresscontroller's
+// annotations specify.  The DNS controller performs the failover.
+func (r *reconciler) ensureWildcardDNSRecordFailoverPolicy(ic *operatorv1.IngressController, record *iov1.DNSRecord) error {
+	policy, err := dnsFailoverPolicyForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	_, err = dnsrecord.EnsureDNSRecordFailoverPolicy(r.client, record, policy)
+	return err
+}
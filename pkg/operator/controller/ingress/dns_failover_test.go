@@ -0,0 +1,97 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_dnsFailoverPolicyForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expect      *dnsrecord.FailoverPolicy
+		expectError bool
+	}{
+		{
+			description: "no annotations",
+		},
+		{
+			description: "threshold without a standby",
+			annotations: map[string]string{DNSFailoverThresholdAnnotation: "1m"},
+		},
+		{
+			description: "standby with defaults",
+			annotations: map[string]string{DNSFailoverStandbyAnnotation: "standby"},
+			expect: &dnsrecord.FailoverPolicy{
+				Standby:       "standby",
+				Threshold:     metav1.Duration{Duration: 5 * time.Minute},
+				FailbackDelay: metav1.Duration{Duration: 15 * time.Minute},
+			},
+		},
+		{
+			description: "standby with custom durations",
+			annotations: map[string]string{
+				DNSFailoverStandbyAnnotation:       "standby",
+				DNSFailoverThresholdAnnotation:     "90s",
+				DNSFailoverFailbackDelayAnnotation: "1h",
+			},
+			expect: &dnsrecord.FailoverPolicy{
+				Standby:       "standby",
+				Threshold:     metav1.Duration{Duration: 90 * time.Second},
+				FailbackDelay: metav1.Duration{Duration: time.Hour},
+			},
+		},
+		{
+			description: "invalid standby name",
+			annotations: map[string]string{DNSFailoverStandbyAnnotation: "Standby!"},
+			expectError: true,
+		},
+		{
+			description: "ingresscontroller is its own standby",
+			annotations: map[string]string{DNSFailoverStandbyAnnotation: "default"},
+			expectError: true,
+		},
+		{
+			description: "invalid threshold",
+			annotations: map[string]string{
+				DNSFailoverStandbyAnnotation:   "standby",
+				DNSFailoverThresholdAnnotation: "soon",
+			},
+			expectError: true,
+		},
+		{
+			description: "zero failback delay",
+			annotations: map[string]string{
+				DNSFailoverStandbyAnnotation:       "standby",
+				DNSFailoverFailbackDelayAnnotation: "0s",
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: tc.annotations},
+			}
+			policy, err := dnsFailoverPolicyForIngressController(ic)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got policy %+v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.expect, policy) {
+				t.Errorf("expected policy %+v, got %+v", tc.expect, policy)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package ingress
+
+import (
+	"reflect"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lastAppliedConfigAnnotation is the annotation that "oc apply" writes to
+// record the last configuration that was applied to an object.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// ExportIngressController returns a minimal manifest for the given
+// ingresscontroller.  The manifest omits status, server-populated metadata,
+// and spec fields that the operator or API server would default to the same
+// values for the cluster, so that applying the manifest on the cluster yields
+// the same effective configuration as the given ingresscontroller.
+//
+// ExportIngressController also returns a Boolean value indicating whether the
+// ingresscontroller's spec reproduces the domain and endpoint publishing
+// strategy that the operator admitted, as recorded in the ingresscontroller's
+// status.  If the ingresscontroller was admitted with defaults that have since
+// changed, for example if the cluster ingress config has changed the default
+// AWS load balancer type, creating the ingresscontroller anew from its spec
+// would yield a different configuration, so the admitted domain and endpoint
+// publishing strategy are written into the exported spec instead.
+func ExportIngressController(ic *operatorv1.IngressController, ingressConfig *configv1.Ingress, infraConfig *configv1.Infrastructure, dnsConfig *configv1.DNS) (*operatorv1.IngressController, bool) {
+	exported := &operatorv1.IngressController{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: operatorv1.GroupVersion.String(),
+			Kind:       "IngressController",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ic.Namespace,
+			Name:      ic.Name,
+		},
+		Spec: *ic.Spec.DeepCopy(),
+	}
+	for k, v := range ic.Labels {
+		if exported.Labels == nil {
+			exported.Labels = map[string]string{}
+		}
+		exported.Labels[k] = v
+	}
+	for k, v := range ic.Annotations {
+		if k == lastAppliedConfigAnnotation {
+			continue
+		}
+		if exported.Annotations == nil {
+			exported.Annotations = map[string]string{}
+		}
+		exported.Annotations[k] = v
+	}
+
+	platformStatus := infraConfig.Status.PlatformStatus
+	if platformStatus == nil {
+		platformStatus = &configv1.PlatformStatus{}
+	}
+
+	want := effectiveIngressControllerStatus(exported, ingressConfig, platformStatus, dnsConfig)
+
+	// The operator sets status.endpointPublishingStrategy.loadBalancer.allowedSourceRanges
+	// from the service rather than from the spec when it updates status,
+	// so ignore that field when comparing with the admitted configuration.
+	reproducible := true
+	if ic.Status.EndpointPublishingStrategy != nil {
+		admitted := ic.Status.EndpointPublishingStrategy.DeepCopy()
+		if admitted.LoadBalancer != nil && want.EndpointPublishingStrategy.LoadBalancer != nil {
+			admitted.LoadBalancer.AllowedSourceRanges = want.EndpointPublishingStrategy.LoadBalancer.AllowedSourceRanges
+		}
+		reproducible = ic.Status.Domain == want.Domain && reflect.DeepEqual(admitted, want.EndpointPublishingStrategy)
+		if !reproducible {
+			// Pin the admitted configuration in the spec, taking the
+			// allowed source ranges from the spec, as the admitted
+			// ones may come from the service.
+			if admitted.LoadBalancer != nil {
+				admitted.LoadBalancer.AllowedSourceRanges = nil
+				if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.LoadBalancer != nil {
+					admitted.LoadBalancer.AllowedSourceRanges = eps.LoadBalancer.AllowedSourceRanges
+				}
+			}
+			exported.Spec.Domain = ic.Status.Domain
+			exported.Spec.EndpointPublishingStrategy = admitted
+			want = effectiveIngressControllerStatus(exported, ingressConfig, platformStatus, dnsConfig)
+		}
+	}
+
+	// Try removing each defaultable field, starting with the outermost, and
+	// keep the removal if the effective configuration is unchanged.
+	for _, prune := range exportPruners {
+		candidate := exported.DeepCopy()
+		if !prune(&candidate.Spec) {
+			continue
+		}
+		if reflect.DeepEqual(want, effectiveIngressControllerStatus(candidate, ingressConfig, platformStatus, dnsConfig)) {
+			exported = candidate
+		}
+	}
+
+	if exported.Spec.Replicas != nil && *exported.Spec.Replicas == DetermineReplicas(ingressConfig, infraConfig) {
+		exported.Spec.Replicas = nil
+	}
+	// The API server defaults httpEmptyRequestsPolicy to "Respond".
+	if exported.Spec.HTTPEmptyRequestsPolicy == operatorv1.HTTPEmptyRequestsPolicyRespond {
+		exported.Spec.HTTPEmptyRequestsPolicy = ""
+	}
+
+	return exported, reproducible
+}
+
+// effectiveIngressControllerStatus returns the domain and endpoint publishing
+// strategy that the operator would set in status when admitting the given
+// ingresscontroller as a new ingresscontroller.
+func effectiveIngressControllerStatus(ic *operatorv1.IngressController, ingressConfig *configv1.Ingress, platformStatus *configv1.PlatformStatus, dnsConfig *configv1.DNS) operatorv1.IngressControllerStatus {
+	admitted := &operatorv1.IngressController{Spec: *ic.Spec.DeepCopy()}
+	setDefaultDomain(admitted, ingressConfig)
+	domainMatchesBaseDomain := dnsrecord.ManageDNSForDomain(admitted.Status.Domain, platformStatus, dnsConfig)
+	setDefaultPublishingStrategy(admitted, platformStatus, domainMatchesBaseDomain, ingressConfig, false)
+	return operatorv1.IngressControllerStatus{
+		Domain:                     admitted.Status.Domain,
+		EndpointPublishingStrategy: admitted.Status.EndpointPublishingStrategy,
+	}
+}
+
+// exportPruners is the list of functions that ExportIngressController uses to
+// remove fields that the operator may default.  Each function removes a field
+// from the given spec and returns a Boolean value indicating whether the field
+// was set.  The list is ordered so that enclosing fields are tried before the
+// fields that they enclose.
+var exportPruners = []func(spec *operatorv1.IngressControllerSpec) bool{
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		if len(spec.Domain) == 0 {
+			return false
+		}
+		spec.Domain = ""
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		if spec.EndpointPublishingStrategy == nil {
+			return false
+		}
+		spec.EndpointPublishingStrategy = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.LoadBalancer == nil {
+			return false
+		}
+		eps.LoadBalancer = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.LoadBalancer == nil || eps.LoadBalancer.ProviderParameters == nil {
+			return false
+		}
+		eps.LoadBalancer.ProviderParameters = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.LoadBalancer == nil || eps.LoadBalancer.ProviderParameters == nil {
+			return false
+		}
+		aws := eps.LoadBalancer.ProviderParameters.AWS
+		if aws == nil || aws.ClassicLoadBalancerParameters == nil {
+			return false
+		}
+		aws.ClassicLoadBalancerParameters = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.NodePort == nil {
+			return false
+		}
+		eps.NodePort = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.Private == nil {
+			return false
+		}
+		eps.Private = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.HostNetwork == nil {
+			return false
+		}
+		eps.HostNetwork = nil
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.HostNetwork == nil || eps.HostNetwork.Protocol == operatorv1.DefaultProtocol {
+			return false
+		}
+		eps.HostNetwork.Protocol = operatorv1.DefaultProtocol
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.HostNetwork == nil || eps.HostNetwork.HTTPPort == 0 {
+			return false
+		}
+		eps.HostNetwork.HTTPPort = 0
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.HostNetwork == nil || eps.HostNetwork.HTTPSPort == 0 {
+			return false
+		}
+		eps.HostNetwork.HTTPSPort = 0
+		return true
+	},
+	func(spec *operatorv1.IngressControllerSpec) bool {
+		eps := spec.EndpointPublishingStrategy
+		if eps == nil || eps.HostNetwork == nil || eps.HostNetwork.StatsPort == 0 {
+			return false
+		}
+		eps.HostNetwork.StatsPort = 0
+		return true
+	},
+}
@@ -0,0 +1,248 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func Test_ExportIngressController(t *testing.T) {
+	awsIngressConfig := &configv1.Ingress{
+		Spec: configv1.IngressSpec{
+			Domain: "apps.example.com",
+			LoadBalancer: configv1.LoadBalancer{
+				Platform: configv1.IngressPlatformSpec{
+					Type: configv1.AWSPlatformType,
+					AWS:  &configv1.AWSIngressSpec{Type: configv1.NLB},
+				},
+			},
+		},
+	}
+	awsInfraConfig := &configv1.Infrastructure{
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus:         &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+			InfrastructureTopology: configv1.HighlyAvailableTopologyMode,
+		},
+	}
+	noneIngressConfig := &configv1.Ingress{Spec: configv1.IngressSpec{Domain: "apps.example.com"}}
+	noneInfraConfig := &configv1.Infrastructure{
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus:         &configv1.PlatformStatus{Type: configv1.NonePlatformType},
+			InfrastructureTopology: configv1.HighlyAvailableTopologyMode,
+		},
+	}
+	dnsConfig := &configv1.DNS{Spec: configv1.DNSSpec{BaseDomain: "example.com"}}
+	nlb := &operatorv1.ProviderLoadBalancerParameters{
+		Type: operatorv1.AWSLoadBalancerProvider,
+		AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSNetworkLoadBalancer},
+	}
+
+	testCases := []struct {
+		description        string
+		ingressConfig      *configv1.Ingress
+		infraConfig        *configv1.Infrastructure
+		spec               operatorv1.IngressControllerSpec
+		status             operatorv1.IngressControllerStatus
+		expectSpec         operatorv1.IngressControllerSpec
+		expectReproducible bool
+	}{
+		{
+			description:   "spec with only platform defaults",
+			ingressConfig: awsIngressConfig,
+			infraConfig:   awsInfraConfig,
+			spec: operatorv1.IngressControllerSpec{
+				Domain:   "apps.example.com",
+				Replicas: pointer.Int32(2),
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.ExternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+						ProviderParameters:  nlb,
+					},
+				},
+				HTTPEmptyRequestsPolicy: operatorv1.HTTPEmptyRequestsPolicyRespond,
+			},
+			expectSpec:         operatorv1.IngressControllerSpec{},
+			expectReproducible: true,
+		},
+		{
+			description:   "internal load balancer",
+			ingressConfig: awsIngressConfig,
+			infraConfig:   awsInfraConfig,
+			spec: operatorv1.IngressControllerSpec{
+				Domain:   "internal.example.com",
+				Replicas: pointer.Int32(3),
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.InternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+						ProviderParameters:  nlb,
+					},
+				},
+				HTTPEmptyRequestsPolicy: operatorv1.HTTPEmptyRequestsPolicyIgnore,
+			},
+			expectSpec: operatorv1.IngressControllerSpec{
+				Domain:   "internal.example.com",
+				Replicas: pointer.Int32(3),
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.InternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+					},
+				},
+				HTTPEmptyRequestsPolicy: operatorv1.HTTPEmptyRequestsPolicyIgnore,
+			},
+			expectReproducible: true,
+		},
+		{
+			description:   "host network with default ports",
+			ingressConfig: noneIngressConfig,
+			infraConfig:   noneInfraConfig,
+			spec: operatorv1.IngressControllerSpec{
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.HostNetworkStrategyType,
+					HostNetwork: &operatorv1.HostNetworkStrategy{
+						Protocol:  operatorv1.TCPProtocol,
+						HTTPPort:  80,
+						HTTPSPort: 443,
+						StatsPort: 1936,
+					},
+				},
+			},
+			expectSpec:         operatorv1.IngressControllerSpec{},
+			expectReproducible: true,
+		},
+		{
+			description:   "host network with a custom port",
+			ingressConfig: noneIngressConfig,
+			infraConfig:   noneInfraConfig,
+			spec: operatorv1.IngressControllerSpec{
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.HostNetworkStrategyType,
+					HostNetwork: &operatorv1.HostNetworkStrategy{
+						Protocol:  operatorv1.TCPProtocol,
+						HTTPPort:  8080,
+						HTTPSPort: 443,
+						StatsPort: 1936,
+					},
+				},
+			},
+			expectSpec: operatorv1.IngressControllerSpec{
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type:        operatorv1.HostNetworkStrategyType,
+					HostNetwork: &operatorv1.HostNetworkStrategy{HTTPPort: 8080},
+				},
+			},
+			expectReproducible: true,
+		},
+		{
+			description:   "admitted with a default that has since changed",
+			ingressConfig: awsIngressConfig,
+			infraConfig:   awsInfraConfig,
+			status: operatorv1.IngressControllerStatus{
+				Domain: "apps.example.com",
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.ExternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+						ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+							Type: operatorv1.AWSLoadBalancerProvider,
+							AWS: &operatorv1.AWSLoadBalancerParameters{
+								Type:                          operatorv1.AWSClassicLoadBalancer,
+								ClassicLoadBalancerParameters: &operatorv1.AWSClassicLoadBalancerParameters{},
+							},
+						},
+						AllowedSourceRanges: []operatorv1.CIDR{"10.0.0.0/8"},
+					},
+				},
+			},
+			expectSpec: operatorv1.IngressControllerSpec{
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.ExternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+						ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+							Type: operatorv1.AWSLoadBalancerProvider,
+							AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSClassicLoadBalancer},
+						},
+					},
+				},
+			},
+			expectReproducible: false,
+		},
+		{
+			description:   "admitted with the current defaults",
+			ingressConfig: awsIngressConfig,
+			infraConfig:   awsInfraConfig,
+			status: operatorv1.IngressControllerStatus{
+				Domain: "apps.example.com",
+				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+					Type: operatorv1.LoadBalancerServiceStrategyType,
+					LoadBalancer: &operatorv1.LoadBalancerStrategy{
+						Scope:               operatorv1.ExternalLoadBalancer,
+						DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+						ProviderParameters:  nlb,
+						AllowedSourceRanges: []operatorv1.CIDR{"10.0.0.0/8"},
+					},
+				},
+			},
+			expectSpec:         operatorv1.IngressControllerSpec{},
+			expectReproducible: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "openshift-ingress-operator",
+					Name:            "test",
+					UID:             "1",
+					ResourceVersion: "2",
+					Generation:      3,
+					Finalizers:      []string{"ingresscontroller.operator.openshift.io/finalizer-ingresscontroller"},
+					Labels:          map[string]string{"team": "web"},
+					Annotations: map[string]string{
+						"example.com/owner":         "web",
+						lastAppliedConfigAnnotation: "{}",
+					},
+				},
+				Spec:   tc.spec,
+				Status: tc.status,
+			}
+			exported, reproducible := ExportIngressController(ic, tc.ingressConfig, tc.infraConfig, dnsConfig)
+			if diff := cmp.Diff(tc.expectSpec, exported.Spec); diff != "" {
+				t.Errorf("unexpected spec (-want +got):\n%s", diff)
+			}
+			if reproducible != tc.expectReproducible {
+				t.Errorf("expected reproducible %t, got %t", tc.expectReproducible, reproducible)
+			}
+			expectMeta := metav1.ObjectMeta{
+				Namespace:   "openshift-ingress-operator",
+				Name:        "test",
+				Labels:      map[string]string{"team": "web"},
+				Annotations: map[string]string{"example.com/owner": "web"},
+			}
+			if diff := cmp.Diff(expectMeta, exported.ObjectMeta); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+			if exported.Kind != "IngressController" || exported.APIVersion != "operator.openshift.io/v1" {
+				t.Errorf("unexpected type meta: %+v", exported.TypeMeta)
+			}
+			if !cmp.Equal(exported.Status, operatorv1.IngressControllerStatus{}) {
+				t.Errorf("expected empty status, got %+v", exported.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// ForwardedHeaderTrustedProxiesAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of CIDRs or
+	// IP addresses of trusted proxies, such as a CDN.  If it is specified,
+	// the routers apply the ingresscontroller's forwarded header policy
+	// only to connections from these addresses and replace the
+	// X-Forwarded-For, Forwarded, and related headers on all other
+	// connections, so that clients cannot spoof them.  The annotation
+	// requires the "Append" or "IfNone" forwarded header policy; the other
+	// policies never keep client-supplied headers.
+	//
+	// The router image does not support trusted proxies yet, so the
+	// operator validates the annotation and reports it in the
+	// ForwardedHeaderPolicyApplied status condition, but it does not change
+	// the router deployment.
+	ForwardedHeaderTrustedProxiesAnnotation = "ingress.operator.openshift.io/forwarded-header-trusted-proxies"
+
+	// maxForwardedHeaderTrustedProxies is the maximum number of CIDRs that
+	// ForwardedHeaderTrustedProxiesAnnotation may specify.  HAProxy
+	// evaluates the list for every request, and CDNs publish their ranges
+	// as a few dozen CIDRs.
+	maxForwardedHeaderTrustedProxies = 256
+)
+
+// forwardedHeaderPolicy returns the ingresscontroller's effective forwarded
+// header policy.
+func forwardedHeaderPolicy(ic *operatorv1.IngressController) operatorv1.IngressControllerHTTPHeaderPolicy {
+	if ic.Spec.HTTPHeaders != nil && len(ic.Spec.HTTPHeaders.ForwardedHeaderPolicy) != 0 {
+		return ic.Spec.HTTPHeaders.ForwardedHeaderPolicy
+	}
+	return operatorv1.AppendHTTPHeaderPolicy
+}
+
+// ForwardedHeaderTrustedProxies returns the canonical CIDRs that the given
+// ingresscontroller's trusted proxies annotation specifies, or nil if the
+// annotation is absent.  Bare IP addresses are converted to single-address
+// CIDRs.
+func ForwardedHeaderTrustedProxies(ic *operatorv1.IngressController) ([]string, error) {
+	value, ok := ic.Annotations[ForwardedHeaderTrustedProxiesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	switch policy := forwardedHeaderPolicy(ic); policy {
+	case operatorv1.AppendHTTPHeaderPolicy, operatorv1.IfNoneHTTPHeaderPolicy:
+	default:
+		return nil, fmt.Errorf("annotation %s requires the %q or %q forwarded header policy, but the policy is %q", ForwardedHeaderTrustedProxiesAnnotation, operatorv1.AppendHTTPHeaderPolicy, operatorv1.IfNoneHTTPHeaderPolicy, policy)
+	}
+
+	var cidrs []string
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid value for annotation %s: %q is not an IP address or CIDR", ForwardedHeaderTrustedProxiesAnnotation, entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for annotation %s: %w", ForwardedHeaderTrustedProxiesAnnotation, err)
+		}
+		if ones, _ := cidr.Mask.Size(); ones == 0 {
+			return nil, fmt.Errorf("invalid value for annotation %s: %s matches every address; use the %q forwarded header policy without trusted proxies instead", ForwardedHeaderTrustedProxiesAnnotation, cidr, forwardedHeaderPolicy(ic))
+		}
+		if seen[cidr.String()] {
+			continue
+		}
+		seen[cidr.String()] = true
+		cidrs = append(cidrs, cidr.String())
+	}
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("invalid value for annotation %s: at least one CIDR must be specified", ForwardedHeaderTrustedProxiesAnnotation)
+	}
+	if len(cidrs) > maxForwardedHeaderTrustedProxies {
+		return nil, fmt.Errorf("invalid value for annotation %s: at most %d CIDRs may be specified, got %d", ForwardedHeaderTrustedProxiesAnnotation, maxForwardedHeaderTrustedProxies, len(cidrs))
+	}
+	return cidrs, nil
+}
+
+// validateForwardedHeaderTrustedProxies validates the ingresscontroller's
+// trusted proxies annotation.
+func validateForwardedHeaderTrustedProxies(ic *operatorv1.IngressController) error {
+	_, err := ForwardedHeaderTrustedProxies(ic)
+	return err
+}
+
+// computeForwardedHeaderPolicyCondition computes the ingresscontroller's
+// forwarded header policy status condition, which reports the configured
+// policy and trusted proxies.  Because the router image does not support
+// trusted proxies yet, the condition reports that they are not applied rather
+// than claiming that the routers replace forwarded headers from other
+// addresses.  If no valid trusted proxies are configured, nil is returned.
+func computeForwardedHeaderPolicyCondition(ic *operatorv1.IngressController) *operatorv1.OperatorCondition {
+	trustedProxies, err := ForwardedHeaderTrustedProxies(ic)
+	if err != nil || len(trustedProxies) == 0 {
+		return nil
+	}
+	return &operatorv1.OperatorCondition{
+		Type:    IngressControllerForwardedHeaderPolicyConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "RouterSupportPending",
+		Message: fmt.Sprintf("The %q forwarded header policy is configured to apply only to connections from the trusted proxies %s, but the router image does not support trusted proxies yet, so the policy applies to all connections.", forwardedHeaderPolicy(ic), strings.Join(trustedProxies, ", ")),
+	}
+}
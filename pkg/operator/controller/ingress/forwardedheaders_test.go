@@ -0,0 +1,134 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ForwardedHeaderTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		description string
+		policy      operatorv1.IngressControllerHTTPHeaderPolicy
+		annotations map[string]string
+		expected    []string
+		expectError bool
+	}{
+		{
+			description: "no annotation",
+		},
+		{
+			description: "CIDRs and addresses with the default policy",
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "192.0.2.0/24, 198.51.100.7,2001:db8::/32, 192.0.2.0/24"},
+			expected:    []string{"192.0.2.0/24", "198.51.100.7/32", "2001:db8::/32"},
+		},
+		{
+			description: "non-canonical CIDR with the IfNone policy",
+			policy:      operatorv1.IfNoneHTTPHeaderPolicy,
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "10.1.2.3/8"},
+			expected:    []string{"10.0.0.0/8"},
+		},
+		{
+			description: "Replace policy",
+			policy:      operatorv1.ReplaceHTTPHeaderPolicy,
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "10.0.0.0/8"},
+			expectError: true,
+		},
+		{
+			description: "invalid address",
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "cdn.example.com"},
+			expectError: true,
+		},
+		{
+			description: "every address",
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "0.0.0.0/0"},
+			expectError: true,
+		},
+		{
+			description: "empty list",
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: ","},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if len(tc.policy) != 0 {
+				ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{ForwardedHeaderPolicy: tc.policy}
+			}
+			cidrs, err := ForwardedHeaderTrustedProxies(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatalf("expected an error, got %v", cidrs)
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case !tc.expectError && !reflect.DeepEqual(cidrs, tc.expected):
+				t.Errorf("expected %v, got %v", tc.expected, cidrs)
+			}
+		})
+	}
+}
+
+// Test_computeForwardedHeaderPolicyCondition verifies that trusted proxies are
+// reported as pending router support and that they do not change the router
+// deployment.
+func Test_computeForwardedHeaderPolicyCondition(t *testing.T) {
+	testCases := []struct {
+		description     string
+		policy          operatorv1.IngressControllerHTTPHeaderPolicy
+		annotations     map[string]string
+		expectCondition bool
+	}{
+		{
+			description: "no trusted proxies",
+			policy:      operatorv1.ReplaceHTTPHeaderPolicy,
+		},
+		{
+			description:     "append from trusted proxies",
+			policy:          operatorv1.AppendHTTPHeaderPolicy,
+			annotations:     map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "192.0.2.0/24, 2001:db8::/32"},
+			expectCondition: true,
+		},
+		{
+			description: "invalid trusted proxies",
+			policy:      operatorv1.ReplaceHTTPHeaderPolicy,
+			annotations: map[string]string{ForwardedHeaderTrustedProxiesAnnotation: "192.0.2.0/24"},
+		},
+	}
+
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := ic.DeepCopy()
+			ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{ForwardedHeaderPolicy: tc.policy}
+			expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ic.Annotations = tc.annotations
+			cond := computeForwardedHeaderPolicyCondition(ic)
+			switch {
+			case !tc.expectCondition && cond != nil:
+				t.Errorf("expected no condition, got %+v", *cond)
+			case tc.expectCondition && cond == nil:
+				t.Error("expected a condition, got nil")
+			case tc.expectCondition && (cond.Status != operatorv1.ConditionFalse || cond.Reason != "RouterSupportPending"):
+				t.Errorf("expected status False and reason RouterSupportPending, got %+v", *cond)
+			}
+
+			if tc.expectCondition {
+				deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+					t.Error("expected the trusted proxies not to change the router deployment")
+				}
+			}
+		})
+	}
+}
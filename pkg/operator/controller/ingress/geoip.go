@@ -0,0 +1,427 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// GeoIPDatabaseAnnotation is an annotation on an ingresscontroller that
+	// specifies the secret or configmap that contains the GeoIP database
+	// that the ingresscontroller's routers use for country-based access
+	// control.  The value has the form "Secret/<name>" or
+	// "ConfigMap/<name>", and the object must be in the operand namespace.
+	// The user is responsible for keeping the database up to date.  The
+	// router image does not support country-based access control yet, so
+	// the GeoIP annotations are validated and the database is checked and
+	// reported in the GeoIPDatabaseReady status condition, but the router
+	// deployment is not changed.
+	GeoIPDatabaseAnnotation = "ingress.operator.openshift.io/geoip-database"
+	// GeoIPDatabaseKeyAnnotation is an annotation on an ingresscontroller
+	// that specifies the key of the database within the object that
+	// GeoIPDatabaseAnnotation specifies.  The default is
+	// defaultGeoIPDatabaseKey.
+	GeoIPDatabaseKeyAnnotation = "ingress.operator.openshift.io/geoip-database-key"
+	// GeoIPAllowCountriesAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of ISO 3166-1
+	// alpha-2 country codes.  If it is specified, the routers only admit
+	// requests from clients in these countries.
+	GeoIPAllowCountriesAnnotation = "ingress.operator.openshift.io/geoip-allow-countries"
+	// GeoIPDenyCountriesAnnotation is an annotation on an ingresscontroller
+	// that specifies a comma-separated list of ISO 3166-1 alpha-2 country
+	// codes.  If it is specified, the routers reject requests from clients
+	// in these countries.  This annotation and
+	// GeoIPAllowCountriesAnnotation are mutually exclusive.
+	GeoIPDenyCountriesAnnotation = "ingress.operator.openshift.io/geoip-deny-countries"
+	// RouteGeoIPExemptAnnotation is an annotation on a route that, if set
+	// to "true", exempts the route from its ingresscontroller's
+	// country-based access control.  The router evaluates this annotation.
+	RouteGeoIPExemptAnnotation = "haproxy.router.openshift.io/geoip-exempt"
+
+	// defaultGeoIPDatabaseKey is the default key of the GeoIP database
+	// within the secret or configmap.
+	defaultGeoIPDatabaseKey = "GeoLite2-Country.mmdb"
+	// geoIPDatabaseMaxAge is the age after which a GeoIP database is
+	// reported as stale.  Commercial and free databases are updated
+	// weekly, so a database that is this old has missed several updates.
+	geoIPDatabaseMaxAge = 30 * 24 * time.Hour
+)
+
+var (
+	// geoIPCountryCodeRegexp matches an ISO 3166-1 alpha-2 country code.
+	geoIPCountryCodeRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+	// geoIPDatabaseBuildTimestamp reports the build time of each
+	// ingresscontroller's GeoIP database.  The database's age is the
+	// difference between the current time and this value.
+	geoIPDatabaseBuildTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_geoip_database_build_timestamp_seconds",
+		Help: "Report the build time of the GeoIP database of ingress controllers, in seconds since the Unix epoch.",
+	}, []string{"name"})
+)
+
+// geoIPPolicy describes an ingresscontroller's country-based access control.
+type geoIPPolicy struct {
+	// kind is "Secret" or "ConfigMap".
+	kind string
+	// name is the name of the secret or configmap.
+	name string
+	// key is the key of the database within the secret or configmap.
+	key string
+	// allow and deny are the lists of allowed and denied country codes.
+	// At most one is non-empty.
+	allow []string
+	deny  []string
+}
+
+// geoIPPolicyForIngressController returns the country-based access control
+// policy that the given ingresscontroller specifies, or nil if it specifies
+// none.  An error is returned if the annotations are malformed or
+// inconsistent.
+func geoIPPolicyForIngressController(ic *operatorv1.IngressController) (*geoIPPolicy, error) {
+	database, haveDatabase := ic.Annotations[GeoIPDatabaseAnnotation]
+	allowValue, haveAllow := ic.Annotations[GeoIPAllowCountriesAnnotation]
+	denyValue, haveDeny := ic.Annotations[GeoIPDenyCountriesAnnotation]
+	if !haveDatabase && !haveAllow && !haveDeny {
+		return nil, nil
+	}
+	if !haveDatabase {
+		return nil, fmt.Errorf("annotation %s is required when %s or %s is specified", GeoIPDatabaseAnnotation, GeoIPAllowCountriesAnnotation, GeoIPDenyCountriesAnnotation)
+	}
+	if haveAllow && haveDeny {
+		return nil, fmt.Errorf("annotations %s and %s are mutually exclusive", GeoIPAllowCountriesAnnotation, GeoIPDenyCountriesAnnotation)
+	}
+
+	policy := &geoIPPolicy{key: defaultGeoIPDatabaseKey}
+	kind, name, ok := strings.Cut(database, "/")
+	if !ok || (kind != "Secret" && kind != "ConfigMap") || len(name) == 0 {
+		return nil, fmt.Errorf("invalid value for annotation %s: %q (must be \"Secret/<name>\" or \"ConfigMap/<name>\")", GeoIPDatabaseAnnotation, database)
+	}
+	policy.kind, policy.name = kind, name
+	if key, ok := ic.Annotations[GeoIPDatabaseKeyAnnotation]; ok {
+		if len(key) == 0 || strings.Contains(key, "/") {
+			return nil, fmt.Errorf("invalid value for annotation %s: %q", GeoIPDatabaseKeyAnnotation, key)
+		}
+		policy.key = key
+	}
+
+	var err error
+	if haveAllow {
+		if policy.allow, err = parseCountryCodes(GeoIPAllowCountriesAnnotation, allowValue); err != nil {
+			return nil, err
+		}
+	}
+	if haveDeny {
+		if policy.deny, err = parseCountryCodes(GeoIPDenyCountriesAnnotation, denyValue); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// parseCountryCodes parses the comma-separated list of country codes in the
+// given annotation value.
+func parseCountryCodes(annotation, value string) ([]string, error) {
+	var codes []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if len(code) == 0 {
+			continue
+		}
+		if !geoIPCountryCodeRegexp.MatchString(code) {
+			return nil, fmt.Errorf("invalid country code %q in annotation %s: must be an upper-case ISO 3166-1 alpha-2 code", code, annotation)
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("annotation %s must specify at least one country code", annotation)
+	}
+	return codes, nil
+}
+
+// validateGeoIPPolicy returns an error if the given ingresscontroller specifies
+// a malformed or inconsistent country-based access control policy.
+func validateGeoIPPolicy(ic *operatorv1.IngressController) error {
+	_, err := geoIPPolicyForIngressController(ic)
+	return err
+}
+
+// currentGeoIPDatabase returns the contents of the GeoIP database that the
+// given policy specifies.  An error is returned if the object or key does not
+// exist.
+func (r *reconciler) currentGeoIPDatabase(policy *geoIPPolicy, namespace string) ([]byte, error) {
+	name := types.NamespacedName{Namespace: namespace, Name: policy.name}
+	switch policy.kind {
+	case "Secret":
+		secret := &corev1.Secret{}
+		if err := r.client.Get(context.TODO(), name, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		if data, ok := secret.Data[policy.key]; ok {
+			return data, nil
+		}
+	case "ConfigMap":
+		cm := &corev1.ConfigMap{}
+		if err := r.client.Get(context.TODO(), name, cm); err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		if data, ok := cm.BinaryData[policy.key]; ok {
+			return data, nil
+		}
+		if data, ok := cm.Data[policy.key]; ok {
+			return []byte(data), nil
+		}
+	}
+	return nil, errors.NewNotFound(corev1.Resource(strings.ToLower(policy.kind)+"s"), fmt.Sprintf("%s key %q", name, policy.key))
+}
+
+// computeGeoIPDatabaseCondition computes the ingresscontroller's GeoIP database
+// status condition and updates its build timestamp metric.  The condition
+// reports whether the database exists and can be loaded, and whether it is
+// stale; it does not report that the routers enforce the policy.  If the
+// ingresscontroller has no GeoIP policy, nil is returned.
+func (r *reconciler) computeGeoIPDatabaseCondition(ic *operatorv1.IngressController, deployment *appsv1.Deployment, now time.Time) *operatorv1.OperatorCondition {
+	policy, err := geoIPPolicyForIngressController(ic)
+	if err != nil || policy == nil {
+		geoIPDatabaseBuildTimestamp.DeleteLabelValues(ic.Name)
+		return nil
+	}
+	cond := &operatorv1.OperatorCondition{Type: IngressControllerGeoIPDatabaseReadyConditionType}
+	data, err := r.currentGeoIPDatabase(policy, deployment.Namespace)
+	if err != nil {
+		geoIPDatabaseBuildTimestamp.DeleteLabelValues(ic.Name)
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "DatabaseNotFound"
+		cond.Message = fmt.Sprintf("The GeoIP database could not be found: %v", err)
+		return cond
+	}
+	buildTime, databaseType, err := parseMaxMindDBMetadata(data)
+	if err != nil {
+		geoIPDatabaseBuildTimestamp.DeleteLabelValues(ic.Name)
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "DatabaseLoadFailed"
+		cond.Message = fmt.Sprintf("The GeoIP database in %s/%s key %q could not be loaded: %v", policy.kind, policy.name, policy.key, err)
+		return cond
+	}
+	geoIPDatabaseBuildTimestamp.WithLabelValues(ic.Name).Set(float64(buildTime.Unix()))
+	age := now.Sub(buildTime).Truncate(time.Hour)
+	if age > geoIPDatabaseMaxAge {
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "DatabaseStale"
+		cond.Message = fmt.Sprintf("The %s GeoIP database was built at %s and is %s old, which exceeds the maximum age of %s; update %s/%s", databaseType, buildTime.UTC().Format(time.RFC3339), age, geoIPDatabaseMaxAge, policy.kind, policy.name)
+		return cond
+	}
+	cond.Status = operatorv1.ConditionTrue
+	cond.Reason = "DatabaseLoaded"
+	cond.Message = fmt.Sprintf("The %s GeoIP database was built at %s and is %s old; the router image does not enforce country-based access control yet", databaseType, buildTime.UTC().Format(time.RFC3339), age)
+	return cond
+}
+
+// DeleteGeoIPDatabaseMetrics deletes the GeoIP database metrics for the given
+// ingresscontroller.
+func DeleteGeoIPDatabaseMetrics(ic *operatorv1.IngressController) {
+	geoIPDatabaseBuildTimestamp.DeleteLabelValues(ic.Name)
+}
+
+// geoIPDatabaseToIngressController maps a secret or configmap in the operand
+// namespace to reconcile requests for the ingresscontrollers that use it as
+// their GeoIP database.
+func (r *reconciler) geoIPDatabaseToIngressController(ctx context.Context, o client.Object) []reconcile.Request {
+	var kind string
+	switch o.(type) {
+	case *corev1.Secret:
+		kind = "Secret"
+	case *corev1.ConfigMap:
+		kind = "ConfigMap"
+	default:
+		return nil
+	}
+	var requests []reconcile.Request
+	controllers := &operatorv1.IngressControllerList{}
+	if err := r.cache.List(ctx, controllers, client.InNamespace(r.config.Namespace)); err != nil {
+		log.Error(err, "failed to list ingresscontrollers for GeoIP database", "related", o.GetSelfLink())
+		return requests
+	}
+	for _, ic := range controllers.Items {
+		if ic.Annotations[GeoIPDatabaseAnnotation] != kind+"/"+o.GetName() {
+			continue
+		}
+		log.Info("queueing ingresscontroller", "name", ic.Name, "related", o.GetSelfLink())
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: ic.Namespace,
+				Name:      ic.Name,
+			},
+		})
+	}
+	return requests
+}
+
+// maxMindDBMetadataMarker precedes the metadata section at the end of a
+// MaxMind DB file.
+var maxMindDBMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// parseMaxMindDBMetadata parses the metadata section of the given MaxMind DB
+// file and returns the database's build time and type.  Only the metadata is
+// decoded; this is sufficient to verify that the file is a MaxMind DB file
+// and to report its age.
+func parseMaxMindDBMetadata(data []byte) (time.Time, string, error) {
+	i := bytes.LastIndex(data, maxMindDBMetadataMarker)
+	if i < 0 {
+		return time.Time{}, "", fmt.Errorf("metadata marker not found; the file is not a MaxMind DB file")
+	}
+	decoder := &maxMindDBDecoder{data: data[i+len(maxMindDBMetadataMarker):]}
+	value, err := decoder.decode()
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	metadata, ok := value.(map[string]interface{})
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("metadata is not a map")
+	}
+	buildEpoch, ok := metadata["build_epoch"].(uint64)
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("metadata has no build_epoch")
+	}
+	databaseType, _ := metadata["database_type"].(string)
+	return time.Unix(int64(buildEpoch), 0), databaseType, nil
+}
+
+// maxMindDBDecoder decodes values in the MaxMind DB data section format.
+// Pointers are not supported as they do not occur in the metadata section.
+type maxMindDBDecoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *maxMindDBDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.offset+n > len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data at offset %d", d.offset)
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b, nil
+}
+
+func (d *maxMindDBDecoder) decode() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	control := b[0]
+	typ := int(control >> 5)
+	if typ == 0 {
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		typ = 7 + int(b[0])
+	}
+	if typ == 1 {
+		return nil, fmt.Errorf("unsupported pointer at offset %d", d.offset-1)
+	}
+	size := int(control & 0x1f)
+	switch size {
+	case 29, 30, 31:
+		n := size - 28
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		extra := 0
+		for _, c := range b {
+			extra = extra<<8 | int(c)
+		}
+		size = []int{29, 285, 65821}[n-1] + extra
+	}
+
+	switch typ {
+	case 2: // UTF-8 string
+		b, err := d.next(size)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 3: // double
+		b, err := d.next(size)
+		if err != nil || size != 8 {
+			return nil, fmt.Errorf("invalid double at offset %d", d.offset)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 4: // bytes
+		return d.next(size)
+	case 5, 6, 9, 10: // unsigned integers
+		b, err := d.next(size)
+		if err != nil || size > 16 {
+			return nil, fmt.Errorf("invalid unsigned integer at offset %d", d.offset)
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, nil
+	case 8: // int32
+		b, err := d.next(size)
+		if err != nil || size > 4 {
+			return nil, fmt.Errorf("invalid int32 at offset %d", d.offset)
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), nil
+	case 7: // map
+		m := make(map[string]interface{}, size)
+		for j := 0; j < size; j++ {
+			key, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key at offset %d is not a string", d.offset)
+			}
+			if m[k], err = d.decode(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case 11: // array
+		a := make([]interface{}, 0, size)
+		for j := 0; j < size; j++ {
+			v, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case 14: // boolean
+		return size != 0, nil
+	case 15: // float
+		b, err := d.next(size)
+		if err != nil || size != 4 {
+			return nil, fmt.Errorf("invalid float at offset %d", d.offset)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	}
+	return nil, fmt.Errorf("unsupported type %d at offset %d", typ, d.offset)
+}
@@ -0,0 +1,206 @@
+package ingress
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeMaxMindDB returns a minimal MaxMind DB file with the given build time and
+// database type in its metadata section.
+func fakeMaxMindDB(buildTime time.Time, databaseType string) []byte {
+	data := []byte("search tree and data section")
+	data = append(data, maxMindDBMetadataMarker...)
+	// A map with three entries.
+	data = append(data, 0xe0|3)
+	appendString := func(s string) {
+		data = append(data, 0x40|byte(len(s)))
+		data = append(data, s...)
+	}
+	appendString("build_epoch")
+	data = append(data, 0x08, 0x02) // extended type uint64 (7+2), 8 bytes
+	data = binary.BigEndian.AppendUint64(data, uint64(buildTime.Unix()))
+	appendString("database_type")
+	appendString(databaseType)
+	appendString("ip_version")
+	data = append(data, 0xa0|2, 0x00, 0x06) // uint16
+	return data
+}
+
+func Test_parseMaxMindDBMetadata(t *testing.T) {
+	buildTime := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	actualTime, databaseType, err := parseMaxMindDBMetadata(fakeMaxMindDB(buildTime, "GeoLite2-Country"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actualTime.Equal(buildTime) || databaseType != "GeoLite2-Country" {
+		t.Errorf("expected build time %v and type %q, got %v and %q", buildTime, "GeoLite2-Country", actualTime, databaseType)
+	}
+
+	if _, _, err := parseMaxMindDBMetadata([]byte("not a database")); err == nil {
+		t.Error("expected an error for a file without metadata")
+	}
+	truncated := fakeMaxMindDB(buildTime, "GeoLite2-Country")
+	if _, _, err := parseMaxMindDBMetadata(truncated[:len(truncated)-10]); err == nil {
+		t.Error("expected an error for truncated metadata")
+	}
+}
+
+func Test_geoIPPolicyForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expected    *geoIPPolicy
+		expectError bool
+	}{
+		{
+			description: "no annotations",
+		},
+		{
+			description: "allow list from a secret",
+			annotations: map[string]string{
+				GeoIPDatabaseAnnotation:       "Secret/geoip",
+				GeoIPAllowCountriesAnnotation: "US, CA",
+			},
+			expected: &geoIPPolicy{kind: "Secret", name: "geoip", key: defaultGeoIPDatabaseKey, allow: []string{"US", "CA"}},
+		},
+		{
+			description: "deny list from a configmap with a key",
+			annotations: map[string]string{
+				GeoIPDatabaseAnnotation:      "ConfigMap/geoip",
+				GeoIPDatabaseKeyAnnotation:   "country.mmdb",
+				GeoIPDenyCountriesAnnotation: "KP",
+			},
+			expected: &geoIPPolicy{kind: "ConfigMap", name: "geoip", key: "country.mmdb", deny: []string{"KP"}},
+		},
+		{
+			description: "allow and deny lists",
+			annotations: map[string]string{
+				GeoIPDatabaseAnnotation:       "Secret/geoip",
+				GeoIPAllowCountriesAnnotation: "US",
+				GeoIPDenyCountriesAnnotation:  "KP",
+			},
+			expectError: true,
+		},
+		{
+			description: "country list without a database",
+			annotations: map[string]string{GeoIPAllowCountriesAnnotation: "US"},
+			expectError: true,
+		},
+		{
+			description: "invalid database reference",
+			annotations: map[string]string{GeoIPDatabaseAnnotation: "geoip"},
+			expectError: true,
+		},
+		{
+			description: "invalid country code",
+			annotations: map[string]string{
+				GeoIPDatabaseAnnotation:       "Secret/geoip",
+				GeoIPAllowCountriesAnnotation: "usa",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			policy, err := geoIPPolicyForIngressController(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected an error")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectError {
+				return
+			}
+			if (policy == nil) != (tc.expected == nil) || (policy != nil && (policy.kind != tc.expected.kind || policy.name != tc.expected.name || policy.key != tc.expected.key || len(policy.allow) != len(tc.expected.allow) || len(policy.deny) != len(tc.expected.deny))) {
+				t.Errorf("expected %+v, got %+v", tc.expected, policy)
+			}
+		})
+	}
+}
+
+func Test_computeGeoIPDatabaseCondition(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	secret := func(data []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "geoip"},
+			Data:       map[string][]byte{defaultGeoIPDatabaseKey: data},
+		}
+	}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+			Annotations: map[string]string{
+				GeoIPDatabaseAnnotation:       "Secret/geoip",
+				GeoIPAllowCountriesAnnotation: "US",
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress"}}
+
+	testCases := []struct {
+		description    string
+		objects        []runtime.Object
+		expectedStatus operatorv1.ConditionStatus
+		expectedReason string
+	}{
+		{"database not found", nil, operatorv1.ConditionFalse, "DatabaseNotFound"},
+		{"database invalid", []runtime.Object{secret([]byte("garbage"))}, operatorv1.ConditionFalse, "DatabaseLoadFailed"},
+		{"database stale", []runtime.Object{secret(fakeMaxMindDB(now.Add(-60*24*time.Hour), "GeoLite2-Country"))}, operatorv1.ConditionFalse, "DatabaseStale"},
+		{"database loaded", []runtime.Object{secret(fakeMaxMindDB(now.Add(-3*24*time.Hour), "GeoLite2-Country"))}, operatorv1.ConditionTrue, "DatabaseLoaded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := &reconciler{client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tc.objects...).Build()}
+			cond := r.computeGeoIPDatabaseCondition(ic, deployment, now)
+			if cond == nil {
+				t.Fatal("expected a condition")
+			}
+			if cond.Status != tc.expectedStatus || cond.Reason != tc.expectedReason {
+				t.Errorf("expected status %s and reason %s, got status %s and reason %s: %s", tc.expectedStatus, tc.expectedReason, cond.Status, cond.Reason, cond.Message)
+			}
+		})
+	}
+
+	r := &reconciler{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	if cond := r.computeGeoIPDatabaseCondition(&operatorv1.IngressController{}, deployment, now); cond != nil {
+		t.Errorf("expected no condition without a GeoIP policy, got %+v", cond)
+	}
+}
+
+// Test_desiredRouterDeploymentGeoIP verifies that a GeoIP policy does not
+// change the router deployment, so that router pods are neither rolled out for
+// nor blocked on a database that the router image does not use yet.
+func Test_desiredRouterDeploymentGeoIP(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic.Annotations = map[string]string{
+		GeoIPDatabaseAnnotation:      "ConfigMap/geoip",
+		GeoIPDenyCountriesAnnotation: "AQ",
+	}
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+		t.Error("expected the GeoIP policy not to change the router deployment")
+	}
+}
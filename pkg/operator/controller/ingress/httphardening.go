@@ -0,0 +1,191 @@
+package ingress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// HTTPHardeningProfileAnnotation is an annotation on an
+	// ingresscontroller that selects a vetted set of HTTP protocol
+	// hardening options for the ingresscontroller's routers.  The value
+	// must be HTTPHardeningProfileStrict or HTTPHardeningProfileCompatible.
+	// Individual options may be overridden using the other
+	// http-hardening-* annotations, which may also be used without a
+	// profile.  The router image does not support these options yet, so
+	// the annotations are validated and reported in the
+	// HTTPHardeningApplied status condition but do not change the router
+	// deployment.
+	HTTPHardeningProfileAnnotation = "ingress.operator.openshift.io/http-hardening-profile"
+	// HTTPHardeningHeaderNamesAnnotation overrides how the routers treat
+	// request header names that contain characters other than
+	// alphanumerics and hyphens, such as underscores.  The value must be
+	// "Preserve", "Delete", or "Reject".
+	HTTPHardeningHeaderNamesAnnotation = "ingress.operator.openshift.io/http-hardening-header-names"
+	// HTTPHardeningMaxHeadersAnnotation overrides the maximum number of
+	// headers that the routers accept in a request.
+	HTTPHardeningMaxHeadersAnnotation = "ingress.operator.openshift.io/http-hardening-max-headers"
+	// HTTPHardeningMaxURILengthAnnotation overrides the maximum length in
+	// bytes of a request URI that the routers accept.
+	HTTPHardeningMaxURILengthAnnotation = "ingress.operator.openshift.io/http-hardening-max-uri-length"
+	// HTTPHardeningHTTP10Annotation overrides whether the routers accept
+	// HTTP/1.0 requests.  The value must be "Allow" or "Deny".
+	HTTPHardeningHTTP10Annotation = "ingress.operator.openshift.io/http-hardening-http10"
+	// HTTPHardeningStrictRequestFramingAnnotation overrides whether the
+	// routers reject requests with ambiguous framing, such as requests
+	// with multiple Content-Length headers or with both Content-Length
+	// and Transfer-Encoding headers, which can be used for request
+	// smuggling.  The value must be "true" or "false".
+	HTTPHardeningStrictRequestFramingAnnotation = "ingress.operator.openshift.io/http-hardening-strict-request-framing"
+
+	// HTTPHardeningProfileStrict rejects anything that is not strictly
+	// compliant.
+	HTTPHardeningProfileStrict = "Strict"
+	// HTTPHardeningProfileCompatible strips rather than rejects where
+	// possible and keeps accepting legacy clients.
+	HTTPHardeningProfileCompatible = "Compatible"
+)
+
+// httpHardeningOptions describes the HTTP protocol hardening options for an
+// ingresscontroller's routers.  The zero value leaves the router's defaults in
+// place.
+type httpHardeningOptions struct {
+	// headerNames is "preserve", "delete", or "reject", or empty to use
+	// the router's default.
+	headerNames string
+	// maxHeaders is the maximum number of request headers, or 0 to use
+	// the router's default.
+	maxHeaders int
+	// maxURILength is the maximum request URI length, or 0 for no limit.
+	maxURILength int
+	// denyHTTP10 rejects HTTP/1.0 requests.
+	denyHTTP10 bool
+	// strictRequestFraming rejects requests with ambiguous framing.
+	strictRequestFraming bool
+}
+
+// httpHardeningProfiles maps each HTTP hardening profile name to its options.
+var httpHardeningProfiles = map[string]httpHardeningOptions{
+	HTTPHardeningProfileStrict: {
+		headerNames:          "reject",
+		maxHeaders:           64,
+		maxURILength:         8192,
+		denyHTTP10:           true,
+		strictRequestFraming: true,
+	},
+	HTTPHardeningProfileCompatible: {
+		headerNames:          "delete",
+		maxHeaders:           101,
+		maxURILength:         16384,
+		denyHTTP10:           false,
+		strictRequestFraming: true,
+	},
+}
+
+// httpHardeningForIngressController returns the HTTP hardening options that the
+// given ingresscontroller specifies, starting from the selected profile, if
+// any, and applying any per-option overrides.  An error is returned if any of
+// the annotations has an invalid value.
+func httpHardeningForIngressController(ic *operatorv1.IngressController) (httpHardeningOptions, error) {
+	var options httpHardeningOptions
+	if name, ok := ic.Annotations[HTTPHardeningProfileAnnotation]; ok {
+		profile, ok := httpHardeningProfiles[name]
+		if !ok {
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be %q or %q)", HTTPHardeningProfileAnnotation, name, HTTPHardeningProfileStrict, HTTPHardeningProfileCompatible)
+		}
+		options = profile
+	}
+	if value, ok := ic.Annotations[HTTPHardeningHeaderNamesAnnotation]; ok {
+		switch value {
+		case "Preserve", "Delete", "Reject":
+			options.headerNames = strings.ToLower(value)
+		default:
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be \"Preserve\", \"Delete\", or \"Reject\")", HTTPHardeningHeaderNamesAnnotation, value)
+		}
+	}
+	if value, ok := ic.Annotations[HTTPHardeningMaxHeadersAnnotation]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 32767 {
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be an integer between 1 and 32767)", HTTPHardeningMaxHeadersAnnotation, value)
+		}
+		options.maxHeaders = n
+	}
+	if value, ok := ic.Annotations[HTTPHardeningMaxURILengthAnnotation]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be a non-negative integer)", HTTPHardeningMaxURILengthAnnotation, value)
+		}
+		options.maxURILength = n
+	}
+	if value, ok := ic.Annotations[HTTPHardeningHTTP10Annotation]; ok {
+		switch value {
+		case "Allow":
+			options.denyHTTP10 = false
+		case "Deny":
+			options.denyHTTP10 = true
+		default:
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be \"Allow\" or \"Deny\")", HTTPHardeningHTTP10Annotation, value)
+		}
+	}
+	if value, ok := ic.Annotations[HTTPHardeningStrictRequestFramingAnnotation]; ok {
+		strict, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("invalid value for annotation %s: %q (must be \"true\" or \"false\")", HTTPHardeningStrictRequestFramingAnnotation, value)
+		}
+		options.strictRequestFraming = strict
+	}
+	return options, nil
+}
+
+// validateHTTPHardening returns an error if the given ingresscontroller
+// specifies an invalid HTTP hardening profile or override.
+func validateHTTPHardening(ic *operatorv1.IngressController) error {
+	_, err := httpHardeningForIngressController(ic)
+	return err
+}
+
+// String returns a human-readable description of the options.
+func (o httpHardeningOptions) String() string {
+	headerNames := o.headerNames
+	if len(headerNames) == 0 {
+		headerNames = "default"
+	}
+	maxHeaders, maxURILength := "default", "unlimited"
+	if o.maxHeaders != 0 {
+		maxHeaders = strconv.Itoa(o.maxHeaders)
+	}
+	if o.maxURILength != 0 {
+		maxURILength = strconv.Itoa(o.maxURILength)
+	}
+	return fmt.Sprintf("headerNames=%s, maxHeaders=%s, maxURILength=%s, denyHTTP10=%t, strictRequestFraming=%t", headerNames, maxHeaders, maxURILength, o.denyHTTP10, o.strictRequestFraming)
+}
+
+// computeHTTPHardeningCondition computes the ingresscontroller's HTTP
+// hardening status condition, which reports the configured hardening profile
+// and options.  If no HTTP hardening options are configured, or if the
+// annotations are invalid, nil is returned.
+//
+// The router image does not support the HTTP hardening options yet, so the
+// router deployment is not changed and the condition reports that the options
+// are not applied rather than claiming that the routers enforce them.
+func computeHTTPHardeningCondition(ic *operatorv1.IngressController) *operatorv1.OperatorCondition {
+	options, err := httpHardeningForIngressController(ic)
+	if err != nil || options == (httpHardeningOptions{}) {
+		return nil
+	}
+	profile := "custom"
+	for name, p := range httpHardeningProfiles {
+		if options == p {
+			profile = name
+		}
+	}
+	return &operatorv1.OperatorCondition{
+		Type:    IngressControllerHTTPHardeningConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "RouterSupportPending",
+		Message: fmt.Sprintf("The %s HTTP hardening options (%s) are configured, but the router image does not enforce them yet.", profile, options),
+	}
+}
@@ -0,0 +1,127 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_httpHardeningForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		expected    httpHardeningOptions
+		expectError bool
+	}{
+		{
+			description: "no annotations",
+		},
+		{
+			description: "strict profile",
+			annotations: map[string]string{HTTPHardeningProfileAnnotation: "Strict"},
+			expected:    httpHardeningProfiles[HTTPHardeningProfileStrict],
+		},
+		{
+			description: "compatible profile with overrides",
+			annotations: map[string]string{
+				HTTPHardeningProfileAnnotation:              "Compatible",
+				HTTPHardeningHeaderNamesAnnotation:          "Reject",
+				HTTPHardeningMaxURILengthAnnotation:         "0",
+				HTTPHardeningHTTP10Annotation:               "Deny",
+				HTTPHardeningStrictRequestFramingAnnotation: "false",
+			},
+			expected: httpHardeningOptions{
+				headerNames: "reject",
+				maxHeaders:  101,
+				denyHTTP10:  true,
+			},
+		},
+		{
+			description: "override without a profile",
+			annotations: map[string]string{HTTPHardeningMaxHeadersAnnotation: "50"},
+			expected:    httpHardeningOptions{maxHeaders: 50},
+		},
+		{
+			description: "unknown profile",
+			annotations: map[string]string{HTTPHardeningProfileAnnotation: "strict"},
+			expectError: true,
+		},
+		{
+			description: "invalid header names override",
+			annotations: map[string]string{HTTPHardeningHeaderNamesAnnotation: "Strip"},
+			expectError: true,
+		},
+		{
+			description: "invalid max headers override",
+			annotations: map[string]string{HTTPHardeningMaxHeadersAnnotation: "0"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+			}
+			options, err := httpHardeningForIngressController(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected an error")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case !tc.expectError && options != tc.expected:
+				t.Errorf("expected %+v, got %+v", tc.expected, options)
+			}
+		})
+	}
+}
+
+// Test_computeHTTPHardeningCondition verifies that HTTP hardening options are
+// reported as pending router support and that they do not change the router
+// deployment.
+func Test_computeHTTPHardeningCondition(t *testing.T) {
+	testCases := []struct {
+		description     string
+		annotations     map[string]string
+		expectCondition bool
+		expectedMessage string
+	}{
+		{"not configured", nil, false, ""},
+		{"strict", map[string]string{HTTPHardeningProfileAnnotation: "Strict"}, true, "The Strict HTTP hardening options"},
+		{"compatible", map[string]string{HTTPHardeningProfileAnnotation: "Compatible"}, true, "The Compatible HTTP hardening options"},
+		{"custom", map[string]string{HTTPHardeningMaxHeadersAnnotation: "50"}, true, "The custom HTTP hardening options"},
+		{"invalid", map[string]string{HTTPHardeningProfileAnnotation: "strict"}, false, ""},
+	}
+
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := ic.DeepCopy()
+			ic.Annotations = tc.annotations
+			cond := computeHTTPHardeningCondition(ic)
+			switch {
+			case !tc.expectCondition && cond != nil:
+				t.Errorf("expected no condition, got %+v", *cond)
+			case tc.expectCondition && cond == nil:
+				t.Error("expected a condition, got nil")
+			case tc.expectCondition && (cond.Status != operatorv1.ConditionFalse || cond.Reason != "RouterSupportPending" || !strings.HasPrefix(cond.Message, tc.expectedMessage)):
+				t.Errorf("expected status False, reason RouterSupportPending, and message starting with %q, got %+v", tc.expectedMessage, *cond)
+			}
+
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+				t.Error("expected the HTTP hardening options not to change the router deployment")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routemetrics "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/route-metrics"
+)
+
+// computeHTTPSOnlyCondition computes the ingresscontroller's HTTPS-only status
+// condition.  The router image does not support HTTPS-only mode yet, so the
+// routers still serve insecure requests as each route specifies, and the
+// condition says so.  If HTTPS-only mode is not enabled, nil is returned.
+func computeHTTPSOnlyCondition(ic *operatorv1.IngressController) *operatorv1.OperatorCondition {
+	if enabled, err := routemetrics.HTTPSOnlyForIngressController(ic); err != nil || !enabled {
+		return nil
+	}
+	return &operatorv1.OperatorCondition{
+		Type:    IngressControllerHTTPSOnlyConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "RouterSupportPending",
+		Message: fmt.Sprintf("HTTPS-only mode is enabled by the %s annotation, but the router image does not enforce it yet, so insecure requests are still served as each route specifies.", routemetrics.HTTPSOnlyAnnotation),
+	}
+}
@@ -0,0 +1,64 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routemetrics "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/route-metrics"
+)
+
+// Test_computeHTTPSOnlyCondition verifies that HTTPS-only mode is reported as
+// pending router support and that it does not change the router deployment.
+func Test_computeHTTPSOnlyCondition(t *testing.T) {
+	testCases := []struct {
+		description     string
+		annotations     map[string]string
+		expectCondition bool
+	}{
+		{
+			description: "no annotation",
+		},
+		{
+			description: "disabled",
+			annotations: map[string]string{routemetrics.HTTPSOnlyAnnotation: "false"},
+		},
+		{
+			description:     "enabled",
+			annotations:     map[string]string{routemetrics.HTTPSOnlyAnnotation: "true"},
+			expectCondition: true,
+		},
+		{
+			description: "invalid",
+			annotations: map[string]string{routemetrics.HTTPSOnlyAnnotation: "on"},
+		},
+	}
+
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := ic.DeepCopy()
+			ic.Annotations = tc.annotations
+			cond := computeHTTPSOnlyCondition(ic)
+			switch {
+			case !tc.expectCondition && cond != nil:
+				t.Errorf("expected no condition, got %+v", *cond)
+			case tc.expectCondition && cond == nil:
+				t.Error("expected a condition, got nil")
+			case tc.expectCondition && (cond.Status != operatorv1.ConditionFalse || cond.Reason != "RouterSupportPending"):
+				t.Errorf("expected status False and reason RouterSupportPending, got %+v", *cond)
+			}
+
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed, _ := deploymentConfigChanged(expected, deployment); changed {
+				t.Error("expected HTTPS-only mode not to change the router deployment")
+			}
+		})
+	}
+}
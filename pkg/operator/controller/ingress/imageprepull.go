@@ -0,0 +1,326 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// RouterImagePrePullAnnotation is an annotation on an ingresscontroller
+	// that, if set to "false", disables pre-pulling a new router image
+	// before the router deployment is updated to use it.
+	RouterImagePrePullAnnotation = "ingress.operator.openshift.io/router-image-prepull"
+	// RouterImagePrePullTimeoutAnnotation is an annotation on an
+	// ingresscontroller that specifies how long the operator waits for a
+	// new router image to be pre-pulled before it updates the router
+	// deployment anyway, as a duration such as "15m".
+	RouterImagePrePullTimeoutAnnotation = "ingress.operator.openshift.io/router-image-prepull-timeout"
+
+	// routerImagePrePullLabel is the label on the pre-pull daemonset's
+	// pods whose value is the name of the ingresscontroller.
+	routerImagePrePullLabel = "ingresscontroller.operator.openshift.io/router-image-prepull"
+	// routerImagePrePullStartedAnnotation is the annotation on the
+	// pre-pull daemonset whose value is the time at which the pre-pull of
+	// the daemonset's image started.
+	routerImagePrePullStartedAnnotation = "ingress.operator.openshift.io/router-image-prepull-started"
+
+	// defaultRouterImagePrePullTimeout is the default time that the
+	// operator waits for a new router image to be pre-pulled.
+	defaultRouterImagePrePullTimeout = 10 * time.Minute
+	// routerImagePrePullPollInterval is the interval at which the operator
+	// checks the progress of a pre-pull.
+	routerImagePrePullPollInterval = 30 * time.Second
+
+	// routerImagePrePullInProgress, routerImagePrePullCompleted, and
+	// routerImagePrePullTimedOut are the states of a pre-pull.
+	routerImagePrePullInProgress = "InProgress"
+	routerImagePrePullCompleted  = "Completed"
+	routerImagePrePullTimedOut   = "TimedOut"
+)
+
+// routerImagePrePullEnabled returns a Boolean value indicating whether the
+// given ingresscontroller pre-pulls new router images.
+func routerImagePrePullEnabled(ic *operatorv1.IngressController) bool {
+	if value, ok := ic.Annotations[RouterImagePrePullAnnotation]; ok {
+		enabled, err := strconv.ParseBool(value)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// routerImagePrePullTimeout returns the given ingresscontroller's pre-pull
+// timeout.
+func routerImagePrePullTimeout(ic *operatorv1.IngressController) (time.Duration, error) {
+	value, ok := ic.Annotations[RouterImagePrePullTimeoutAnnotation]
+	if !ok {
+		return defaultRouterImagePrePullTimeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid value for annotation %s: %q is not a positive duration", RouterImagePrePullTimeoutAnnotation, value)
+	}
+	return timeout, nil
+}
+
+// validateRouterImagePrePull validates the ingresscontroller's pre-pull
+// annotations.
+func validateRouterImagePrePull(ic *operatorv1.IngressController) error {
+	if value, ok := ic.Annotations[RouterImagePrePullAnnotation]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid value for annotation %s: %q is not a Boolean value", RouterImagePrePullAnnotation, value)
+		}
+	}
+	_, err := routerImagePrePullTimeout(ic)
+	return err
+}
+
+// routerContainerImage returns the image of the given deployment's router
+// container.
+func routerContainerImage(deployment *appsv1.Deployment) string {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == "router" {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// withCurrentRouterImage returns a copy of the desired router deployment that
+// keeps the current deployment's router image in every container that would
+// otherwise use the desired router image, so that the other changes in the
+// desired deployment can be applied while a pre-pull is in progress.
+func withCurrentRouterImage(desired, current *appsv1.Deployment) *appsv1.Deployment {
+	newImage, oldImage := routerContainerImage(desired), routerContainerImage(current)
+	updated := desired.DeepCopy()
+	for i := range updated.Spec.Template.Spec.InitContainers {
+		if updated.Spec.Template.Spec.InitContainers[i].Image == newImage {
+			updated.Spec.Template.Spec.InitContainers[i].Image = oldImage
+		}
+	}
+	for i := range updated.Spec.Template.Spec.Containers {
+		if updated.Spec.Template.Spec.Containers[i].Image == newImage {
+			updated.Spec.Template.Spec.Containers[i].Image = oldImage
+		}
+	}
+	return updated
+}
+
+// ensureRouterImagePrePull ensures that, if the desired router deployment uses
+// a different router image than the current one, the new image is pre-pulled
+// on every node that is eligible to run the router pods, using a daemonset.  It
+// returns a Boolean value indicating whether the router image change must wait
+// for the pre-pull.  The pre-pull daemonset is deleted once the current
+// deployment uses the desired image.
+func (r *reconciler) ensureRouterImagePrePull(ic *operatorv1.IngressController, current, desired *appsv1.Deployment, now time.Time) (bool, error) {
+	name := operatorcontroller.RouterImagePrePullDaemonSetName(ic)
+	ds := &appsv1.DaemonSet{}
+	haveDS := true
+	if err := r.client.Get(context.TODO(), name, ds); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get daemonset %s: %w", name, err)
+		}
+		haveDS = false
+	}
+
+	image := routerContainerImage(desired)
+	if !routerImagePrePullEnabled(ic) || routerContainerImage(current) == image || current.Spec.Replicas == nil || *current.Spec.Replicas == 0 {
+		if haveDS {
+			if err := r.client.Delete(context.TODO(), ds); err != nil && !errors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to delete daemonset %s: %w", name, err)
+			}
+			log.Info("deleted router image pre-pull daemonset", "namespace", ds.Namespace, "name", ds.Name)
+		}
+		return false, nil
+	}
+
+	wantDS := desiredRouterImagePrePullDaemonSet(ic, current, desired, now)
+	if !haveDS {
+		if err := r.client.Create(context.TODO(), wantDS); err != nil {
+			return false, fmt.Errorf("failed to create daemonset %s: %w", name, err)
+		}
+		log.Info("created router image pre-pull daemonset", "namespace", wantDS.Namespace, "name", wantDS.Name, "image", image)
+		r.recorder.Eventf(ic, "Normal", "RouterImagePrePullStarted", "Pre-pulling router image %s before updating the router deployment", image)
+		return true, nil
+	}
+	if routerImagePrePullDaemonSetImage(ds) != image {
+		// The desired image changed again before the previous
+		// pre-pull finished, so start over with the new image.
+		updated := ds.DeepCopy()
+		updated.Annotations = wantDS.Annotations
+		updated.Spec.Template = wantDS.Spec.Template
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return false, fmt.Errorf("failed to update daemonset %s: %w", name, err)
+		}
+		log.Info("updated router image pre-pull daemonset", "namespace", updated.Namespace, "name", updated.Name, "image", image)
+		r.recorder.Eventf(ic, "Normal", "RouterImagePrePullStarted", "Pre-pulling router image %s before updating the router deployment", image)
+		return true, nil
+	}
+
+	timeout, err := routerImagePrePullTimeout(ic)
+	if err != nil {
+		return false, err
+	}
+	state, ready, scheduled := routerImagePrePullState(ds, timeout, now)
+	switch state {
+	case routerImagePrePullCompleted:
+		r.recorder.Eventf(ic, "Normal", "RouterImagePrePullCompleted", "Pre-pulled router image %s on %d nodes", image, scheduled)
+		return false, nil
+	case routerImagePrePullTimedOut:
+		r.recorder.Eventf(ic, "Warning", "RouterImagePrePullTimedOut", "Router image %s was pre-pulled on %d of %d nodes within %s; updating the router deployment anyway", image, ready, scheduled, timeout)
+		return false, nil
+	}
+	return true, nil
+}
+
+// routerImagePrePullState returns the state of the pre-pull that the given
+// daemonset performs, along with the number of nodes that have pulled the image
+// and the number of nodes that should pull it.
+func routerImagePrePullState(ds *appsv1.DaemonSet, timeout time.Duration, now time.Time) (string, int32, int32) {
+	ready, scheduled := ds.Status.NumberReady, ds.Status.DesiredNumberScheduled
+	// The daemonset controller has not observed a new daemonset until its
+	// observed generation is set.
+	observed := ds.Status.ObservedGeneration != 0 && ds.Status.ObservedGeneration >= ds.Generation
+	if observed && ds.Status.UpdatedNumberScheduled == scheduled && ready == scheduled {
+		return routerImagePrePullCompleted, ready, scheduled
+	}
+	started, err := time.Parse(time.RFC3339, ds.Annotations[routerImagePrePullStartedAnnotation])
+	if err != nil || now.Sub(started) >= timeout {
+		return routerImagePrePullTimedOut, ready, scheduled
+	}
+	return routerImagePrePullInProgress, ready, scheduled
+}
+
+// routerImagePrePullDaemonSetImage returns the image that the given pre-pull
+// daemonset pulls.
+func routerImagePrePullDaemonSetImage(ds *appsv1.DaemonSet) string {
+	for _, container := range ds.Spec.Template.Spec.Containers {
+		return container.Image
+	}
+	return ""
+}
+
+// desiredRouterImagePrePullDaemonSet returns the daemonset that pre-pulls the
+// desired router deployment's router image on the nodes that the desired
+// deployment's node selector and tolerations allow.  The daemonset's pods run
+// a shell that sleeps, so a ready pod means the node has the image.
+func desiredRouterImagePrePullDaemonSet(ic *operatorv1.IngressController, current, desired *appsv1.Deployment, now time.Time) *appsv1.DaemonSet {
+	name := operatorcontroller.RouterImagePrePullDaemonSetName(ic)
+	trueVar := true
+	podLabels := map[string]string{routerImagePrePullLabel: ic.Name}
+	podAnnotations := map[string]string{}
+	if value, ok := desired.Spec.Template.Annotations[WorkloadPartitioningManagement]; ok {
+		podAnnotations[WorkloadPartitioningManagement] = value
+	}
+	maxUnavailable := intstr.FromString("100%")
+	var pullPolicy corev1.PullPolicy
+	for _, container := range desired.Spec.Template.Spec.Containers {
+		if container.Name == "router" {
+			pullPolicy = container.ImagePullPolicy
+		}
+	}
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels: map[string]string{
+				manifests.OwningIngressControllerLabel: ic.Name,
+			},
+			Annotations: map[string]string{
+				routerImagePrePullStartedAnnotation: now.UTC().Format(time.RFC3339),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       current.Name,
+				UID:        current.UID,
+				Controller: &trueVar,
+			}},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+				Type:          appsv1.RollingUpdateDaemonSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxUnavailable: &maxUnavailable},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: podAnnotations,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName:            desired.Spec.Template.Spec.ServiceAccountName,
+					NodeSelector:                  desired.Spec.Template.Spec.NodeSelector,
+					Tolerations:                   desired.Spec.Template.Spec.Tolerations,
+					PriorityClassName:             desired.Spec.Template.Spec.PriorityClassName,
+					TerminationGracePeriodSeconds: pointer.Int64(1),
+					Containers: []corev1.Container{{
+						Name:            "prepull",
+						Image:           routerContainerImage(desired),
+						ImagePullPolicy: pullPolicy,
+						Command:         []string{"/bin/sh", "-c", "exec sleep infinity"},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1m"),
+								corev1.ResourceMemory: resource.MustParse("10Mi"),
+							},
+						},
+						SecurityContext: &corev1.SecurityContext{
+							AllowPrivilegeEscalation: pointer.Bool(false),
+							Capabilities: &corev1.Capabilities{
+								Drop: []corev1.Capability{"ALL"},
+							},
+						},
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// computeRouterImagePrePullCondition computes the ingresscontroller's router
+// image pre-pull status condition, or returns nil if no pre-pull is in
+// progress.
+func (r *reconciler) computeRouterImagePrePullCondition(ic *operatorv1.IngressController, now time.Time) *operatorv1.OperatorCondition {
+	ds := &appsv1.DaemonSet{}
+	if err := r.client.Get(context.TODO(), operatorcontroller.RouterImagePrePullDaemonSetName(ic), ds); err != nil {
+		return nil
+	}
+	timeout, err := routerImagePrePullTimeout(ic)
+	if err != nil {
+		timeout = defaultRouterImagePrePullTimeout
+	}
+	image := routerImagePrePullDaemonSetImage(ds)
+	state, ready, scheduled := routerImagePrePullState(ds, timeout, now)
+	cond := &operatorv1.OperatorCondition{
+		Type:   IngressControllerRouterImagePrePulledConditionType,
+		Reason: state,
+	}
+	switch state {
+	case routerImagePrePullCompleted:
+		cond.Status = operatorv1.ConditionTrue
+		cond.Message = fmt.Sprintf("Router image %s has been pre-pulled on %d nodes.", image, scheduled)
+	case routerImagePrePullTimedOut:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Message = fmt.Sprintf("Router image %s was pre-pulled on %d of %d nodes within %s; the router deployment was updated anyway.", image, ready, scheduled, timeout)
+	default:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Message = fmt.Sprintf("Pre-pulling router image %s: %d of %d nodes have pulled the image.  The router deployment will be updated when every node has pulled the image or after %s.", image, ready, scheduled, timeout)
+	}
+	return cond
+}
@@ -0,0 +1,169 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_validateRouterImagePrePull(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectError bool
+	}{
+		{name: "no annotations"},
+		{name: "disabled", annotations: map[string]string{RouterImagePrePullAnnotation: "false"}},
+		{name: "timeout", annotations: map[string]string{RouterImagePrePullTimeoutAnnotation: "15m"}},
+		{name: "invalid Boolean", annotations: map[string]string{RouterImagePrePullAnnotation: "sometimes"}, expectError: true},
+		{name: "invalid timeout", annotations: map[string]string{RouterImagePrePullTimeoutAnnotation: "soon"}, expectError: true},
+		{name: "negative timeout", annotations: map[string]string{RouterImagePrePullTimeoutAnnotation: "-1m"}, expectError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if err := validateRouterImagePrePull(ic); tc.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func Test_ensureRouterImagePrePull(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"}}
+	deployment := func(image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default", UID: "1"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(2),
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+						Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}},
+						Containers:   []corev1.Container{{Name: "router", Image: image}},
+					},
+				},
+			},
+		}
+	}
+	current, desired := deployment("router:old"), deployment("router:new")
+	start := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&appsv1.DaemonSet{}).Build()
+	r := &reconciler{client: cl, recorder: record.NewFakeRecorder(10)}
+	name := operatorcontroller.RouterImagePrePullDaemonSetName(ic)
+
+	// A new image starts a pre-pull.
+	if wait, err := r.ensureRouterImagePrePull(ic, current, desired, start); err != nil || !wait {
+		t.Fatalf("expected to wait for a new pre-pull, got %t, %v", wait, err)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := cl.Get(context.Background(), name, ds); err != nil {
+		t.Fatal(err)
+	}
+	spec := ds.Spec.Template.Spec
+	if spec.Containers[0].Image != "router:new" || spec.NodeSelector["node-role.kubernetes.io/infra"] != "" || len(spec.Tolerations) != 1 {
+		t.Errorf("expected the daemonset to pull the new image with the router's placement, got %+v", spec)
+	}
+	if cond := r.computeRouterImagePrePullCondition(ic, start); cond == nil || cond.Reason != routerImagePrePullInProgress {
+		t.Errorf("expected an InProgress condition, got %+v", cond)
+	}
+
+	// The pre-pull is still in progress while some nodes lack the image.
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: ds.Generation + 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberReady: 2}
+	if err := cl.Status().Update(context.Background(), ds); err != nil {
+		t.Fatal(err)
+	}
+	if wait, err := r.ensureRouterImagePrePull(ic, current, desired, start.Add(time.Minute)); err != nil || !wait {
+		t.Fatalf("expected to wait for an incomplete pre-pull, got %t, %v", wait, err)
+	}
+
+	// After the timeout, the deployment is updated anyway.
+	if wait, err := r.ensureRouterImagePrePull(ic, current, desired, start.Add(defaultRouterImagePrePullTimeout)); err != nil || wait {
+		t.Fatalf("expected not to wait after the timeout, got %t, %v", wait, err)
+	}
+	if cond := r.computeRouterImagePrePullCondition(ic, start.Add(defaultRouterImagePrePullTimeout)); cond == nil || cond.Reason != routerImagePrePullTimedOut {
+		t.Errorf("expected a TimedOut condition, got %+v", cond)
+	}
+
+	// Once every node has the image, the deployment is updated.
+	ds.Status.NumberReady = 3
+	if err := cl.Status().Update(context.Background(), ds); err != nil {
+		t.Fatal(err)
+	}
+	if wait, err := r.ensureRouterImagePrePull(ic, current, desired, start.Add(2*time.Minute)); err != nil || wait {
+		t.Fatalf("expected not to wait for a completed pre-pull, got %t, %v", wait, err)
+	}
+
+	// Once the deployment uses the new image, the daemonset is deleted.
+	if wait, err := r.ensureRouterImagePrePull(ic, desired, desired, start.Add(3*time.Minute)); err != nil || wait {
+		t.Fatalf("expected not to wait, got %t, %v", wait, err)
+	}
+	if err := cl.Get(context.Background(), name, &appsv1.DaemonSet{}); err == nil {
+		t.Error("expected the daemonset to be deleted")
+	}
+	if cond := r.computeRouterImagePrePullCondition(ic, start); cond != nil {
+		t.Errorf("expected no condition without a pre-pull, got %+v", cond)
+	}
+
+	// A pre-pull is not started if it is disabled.
+	ic.Annotations = map[string]string{RouterImagePrePullAnnotation: "false"}
+	if wait, err := r.ensureRouterImagePrePull(ic, current, desired, start); err != nil || wait {
+		t.Fatalf("expected not to wait when pre-pull is disabled, got %t, %v", wait, err)
+	}
+}
+
+// Test_withCurrentRouterImage verifies that only the router image change is
+// withheld from the desired deployment while a pre-pull is in progress.
+func Test_withCurrentRouterImage(t *testing.T) {
+	current := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "router", Image: "router:old"}},
+				},
+			},
+		},
+	}
+	desired := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(3),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "router", Image: "router:new", Env: []corev1.EnvVar{{Name: "ROUTER_THREADS", Value: "4"}}},
+						{Name: "logs", Image: "router:new"},
+						{Name: "sidecar", Image: "sidecar:latest"},
+					},
+				},
+			},
+		},
+	}
+
+	updated := withCurrentRouterImage(desired, current)
+	containers := updated.Spec.Template.Spec.Containers
+	if containers[0].Image != "router:old" || containers[1].Image != "router:old" || containers[2].Image != "sidecar:latest" {
+		t.Errorf("expected only the router image to be kept, got %+v", containers)
+	}
+	if len(containers[0].Env) != 1 || *updated.Spec.Replicas != 3 {
+		t.Errorf("expected the other changes to be kept, got %+v", updated.Spec)
+	}
+	if desired.Spec.Template.Spec.Containers[0].Image != "router:new" {
+		t.Errorf("expected the desired deployment not to be modified, got %+v", desired.Spec.Template.Spec.Containers)
+	}
+}
@@ -0,0 +1,414 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// LoadBalancerReadinessGateAnnotation is an annotation on an
+	// ingresscontroller that, if set to "true", adds a readiness gate to
+	// the router pods of an ingresscontroller that uses the
+	// LoadBalancerService endpoint publishing strategy.  The operator
+	// sets the gate's condition once the pod's endpoint is registered for
+	// the load balancer service and the pod's node has kept passing the
+	// load balancer's health check, as reported by the service's
+	// health-check node port, for the load balancer's healthy-threshold
+	// interval, so that a rollout does not remove old pods before the load
+	// balancer sends traffic to the new ones.
+	//
+	// The operator queries the health-check node port itself, at each
+	// node's internal IP address, so the pod network must allow
+	// connections from the operator pod to that port on the nodes.  If it
+	// does not, the operator cannot confirm the health check and sets each
+	// gate only after loadBalancerHealthCheckTimeout, with the
+	// HealthCheckUnreachable reason.
+	LoadBalancerReadinessGateAnnotation = "ingress.operator.openshift.io/load-balancer-readiness-gate"
+
+	// LoadBalancerReadyConditionType is the type of the router pod
+	// condition for the load balancer readiness gate.
+	LoadBalancerReadyConditionType corev1.PodConditionType = "ingress.operator.openshift.io/load-balancer-ready"
+
+	// loadBalancerHealthCheckTimeout is how long after a router pod's
+	// containers become ready the operator waits for the pod's node to
+	// pass the load balancer health check before it sets the readiness
+	// gate anyway, so that an unreachable health check does not stall a
+	// rollout indefinitely.
+	loadBalancerHealthCheckTimeout = 2 * time.Minute
+
+	// loadBalancerHealthyThresholdInterval is how long a node must keep
+	// passing the health check before the load balancer is assumed to have
+	// brought it into rotation.  As with the router deployment's
+	// minReadySeconds, this is based on the slowest cloud: up to 2 healthy
+	// checks at 10-second intervals, plus one that we could miss.
+	loadBalancerHealthyThresholdInterval = (2 + 1) * 10 * time.Second
+
+	// loadBalancerReadyReasonRegistering and
+	// loadBalancerReadyReasonEnteringRotation are the reasons of the load
+	// balancer readiness gate condition while the operator waits for the
+	// pod's node to pass the health check for
+	// loadBalancerHealthyThresholdInterval.  The condition's LastProbeTime
+	// is the time at which the node started passing.
+	loadBalancerReadyReasonRegistering      = "LoadBalancerRegistering"
+	loadBalancerReadyReasonEnteringRotation = "NodeEnteringRotation"
+)
+
+// loadBalancerHealthCheckUnreachableError is the error that
+// checkLoadBalancerHealth returns if the operator cannot connect to the
+// health-check node port, as opposed to the node failing the health check.
+type loadBalancerHealthCheckUnreachableError struct {
+	err error
+}
+
+// Error returns the error from connecting to the health-check node port.
+func (e *loadBalancerHealthCheckUnreachableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error from connecting to the health-check node port.
+func (e *loadBalancerHealthCheckUnreachableError) Unwrap() error {
+	return e.err
+}
+
+// loadBalancerHealthCheckClient is the HTTP client for querying the load
+// balancer service's health-check node port on router pods' nodes.
+var loadBalancerHealthCheckClient = &http.Client{
+	Timeout: 2 * time.Second,
+}
+
+// loadBalancerReadinessGateEnabled returns a Boolean value indicating whether
+// the given ingresscontroller's annotation enables the load balancer readiness
+// gate.
+func loadBalancerReadinessGateEnabled(ic *operatorv1.IngressController) (bool, error) {
+	switch value, ok := ic.Annotations[LoadBalancerReadinessGateAnnotation]; {
+	case !ok, value == "false":
+		return false, nil
+	case value == "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for annotation %s: %q is not \"true\" or \"false\"", LoadBalancerReadinessGateAnnotation, value)
+	}
+}
+
+// validateLoadBalancerReadinessGate validates the ingresscontroller's load
+// balancer readiness gate annotation.
+func validateLoadBalancerReadinessGate(ic *operatorv1.IngressController) error {
+	_, err := loadBalancerReadinessGateEnabled(ic)
+	return err
+}
+
+// useLoadBalancerReadinessGate returns a Boolean value indicating whether the
+// given ingresscontroller's router pods should have the load balancer
+// readiness gate.
+func useLoadBalancerReadinessGate(ic *operatorv1.IngressController) bool {
+	enabled, _ := loadBalancerReadinessGateEnabled(ic)
+	eps := ic.Status.EndpointPublishingStrategy
+	return enabled && eps != nil && eps.Type == operatorv1.LoadBalancerServiceStrategyType
+}
+
+// hasLoadBalancerReadinessGate returns a Boolean value indicating whether the
+// given pod has the load balancer readiness gate.
+func hasLoadBalancerReadinessGate(pod *corev1.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == LoadBalancerReadyConditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodCondition returns the pod's condition of the given type, or nil.
+func getPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// loadBalancerReadinessGatePending returns a Boolean value indicating whether
+// the given pod has the load balancer readiness gate and the operator has yet
+// to confirm that the load balancer sends traffic to the pod's node.  This
+// includes a pod whose gate is set only so that its node can enter rotation.
+func loadBalancerReadinessGatePending(pod *corev1.Pod) bool {
+	if !hasLoadBalancerReadinessGate(pod) || pod.DeletionTimestamp != nil {
+		return false
+	}
+	cond := getPodCondition(pod, LoadBalancerReadyConditionType)
+	return cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason == loadBalancerReadyReasonEnteringRotation
+}
+
+// routerPodContainersReadyChanged returns a Boolean value indicating whether
+// the given update changes the ContainersReady condition of a router pod whose
+// load balancer readiness gate is pending.  The operator sets the gate's
+// condition only after the pod's containers are ready.
+func routerPodContainersReadyChanged(e event.UpdateEvent) bool {
+	oldPod, ok := e.ObjectOld.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	newPod, ok := e.ObjectNew.(*corev1.Pod)
+	if !ok || !loadBalancerReadinessGatePending(newPod) {
+		return false
+	}
+	oldCond := getPodCondition(oldPod, corev1.ContainersReady)
+	newCond := getPodCondition(newPod, corev1.ContainersReady)
+	return (oldCond == nil) != (newCond == nil) || (newCond != nil && oldCond.Status != newCond.Status)
+}
+
+// loadBalancerHealthCheckStatus is the response of kube-proxy's health-check
+// node port.
+type loadBalancerHealthCheckStatus struct {
+	LocalEndpoints int `json:"localEndpoints"`
+}
+
+// checkLoadBalancerHealth queries the given health-check node port on the given
+// node, returning a Boolean value indicating whether the node passes the load
+// balancer's health check and, if it does not, the number of local endpoints
+// that kube-proxy reports.
+func checkLoadBalancerHealth(ctx context.Context, node *corev1.Node, port int32) (bool, int, error) {
+	var address string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			address = addr.Address
+			break
+		}
+	}
+	if len(address) == 0 {
+		return false, 0, fmt.Errorf("node %s has no internal IP address", node.Name)
+	}
+	url := "http://" + net.JoinHostPort(address, strconv.Itoa(int(port))) + "/healthz"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	resp, err := loadBalancerHealthCheckClient.Do(req)
+	if err != nil {
+		return false, 0, &loadBalancerHealthCheckUnreachableError{err: err}
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, 0, nil
+	case http.StatusServiceUnavailable:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err != nil {
+			return false, 0, err
+		}
+		var status loadBalancerHealthCheckStatus
+		if err := json.Unmarshal(body, &status); err != nil {
+			return false, 0, fmt.Errorf("failed to parse health check response from %s: %w", url, err)
+		}
+		return false, status.LocalEndpoints, nil
+	default:
+		return false, 0, fmt.Errorf("unexpected status from %s: %s", url, resp.Status)
+	}
+}
+
+// routerPodEndpointRegistered returns a Boolean value indicating whether the
+// given service's endpointslices have an endpoint for the given pod, whether or
+// not the endpoint is ready.
+func (r *reconciler) routerPodEndpointRegistered(ctx context.Context, pod *corev1.Pod, service *corev1.Service) (bool, error) {
+	slices := &discoveryv1.EndpointSliceList{}
+	if err := r.client.List(ctx, slices, client.InNamespace(service.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: service.Name}); err != nil {
+		return false, fmt.Errorf("failed to list endpointslices for service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if ref := endpoint.TargetRef; ref != nil && ref.Kind == "Pod" && ref.Namespace == pod.Namespace && ref.Name == pod.Name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// desiredLoadBalancerReadyCondition returns the load balancer readiness gate
+// condition for the given router pod, and a Boolean value indicating whether
+// the condition should be checked again later.
+//
+// kube-proxy's health check passes if the node has any ready local endpoint, so
+// a passing check alone says nothing about the given pod.  The gate is set only
+// once the pod's endpoint is registered and the node has kept passing the check
+// for loadBalancerHealthyThresholdInterval.  If the node has no ready local
+// endpoint, it cannot pass the check until this pod is ready, so the gate is set
+// to let the node enter rotation, and the pod is checked until the node has
+// passed for loadBalancerHealthyThresholdInterval; the deployment's
+// minReadySeconds keeps the pod from counting as available in the meantime.
+func (r *reconciler) desiredLoadBalancerReadyCondition(ctx context.Context, pod *corev1.Pod, service *corev1.Service, now time.Time) (corev1.PodCondition, bool) {
+	cond := corev1.PodCondition{Type: LoadBalancerReadyConditionType}
+	containersReady := getPodCondition(pod, corev1.ContainersReady)
+	if containersReady == nil || containersReady.Status != corev1.ConditionTrue {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "ContainersNotReady"
+		cond.Message = "The router container is not ready"
+		return cond, false
+	}
+
+	// Without a health-check node port, the load balancer's health check
+	// does not depend on the nodes on which router pods run.
+	if service != nil && service.Spec.HealthCheckNodePort == 0 {
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "NoHealthCheckNodePort"
+		cond.Message = "The load balancer service has no health-check node port"
+		return cond, false
+	}
+
+	current := getPodCondition(pod, LoadBalancerReadyConditionType)
+	enteringRotation := current != nil && current.Status == corev1.ConditionTrue && current.Reason == loadBalancerReadyReasonEnteringRotation
+
+	var (
+		registered     bool
+		healthy        bool
+		localEndpoints int
+		err            error
+	)
+	if service == nil {
+		err = fmt.Errorf("the load balancer service does not exist")
+	} else if registered, err = r.routerPodEndpointRegistered(ctx, pod, service); err == nil && registered {
+		node := &corev1.Node{}
+		if err = r.apiReader.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err == nil {
+			healthy, localEndpoints, err = checkLoadBalancerHealth(ctx, node, service.Spec.HealthCheckNodePort)
+		}
+	}
+	switch {
+	case err == nil && healthy:
+		since := now
+		if current != nil && !current.LastProbeTime.IsZero() && (current.Reason == loadBalancerReadyReasonRegistering || current.Reason == loadBalancerReadyReasonEnteringRotation) {
+			since = current.LastProbeTime.Time
+		}
+		if now.Sub(since) >= loadBalancerHealthyThresholdInterval {
+			cond.Status = corev1.ConditionTrue
+			cond.Reason = "LoadBalancerHealthy"
+			cond.Message = fmt.Sprintf("Node %s has passed the load balancer health check for %v since this pod's endpoint was registered", pod.Spec.NodeName, loadBalancerHealthyThresholdInterval)
+			return cond, false
+		}
+		cond.LastProbeTime = metav1.NewTime(since)
+		if enteringRotation {
+			// Keep the gate set so that the node stays in
+			// rotation.
+			cond.Status = corev1.ConditionTrue
+			cond.Reason = loadBalancerReadyReasonEnteringRotation
+			cond.Message = fmt.Sprintf("Node %s entered load balancer rotation with this pod and must keep passing the health check for %v", pod.Spec.NodeName, loadBalancerHealthyThresholdInterval)
+			return cond, true
+		}
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = loadBalancerReadyReasonRegistering
+		cond.Message = fmt.Sprintf("Node %s passes the load balancer health check and must keep passing it for %v", pod.Spec.NodeName, loadBalancerHealthyThresholdInterval)
+		return cond, true
+	case now.Sub(containersReady.LastTransitionTime.Time) >= loadBalancerHealthCheckTimeout:
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "HealthCheckTimeout"
+		cond.Message = fmt.Sprintf("Node %s did not pass the load balancer health check within %v", pod.Spec.NodeName, loadBalancerHealthCheckTimeout)
+		var unreachable *loadBalancerHealthCheckUnreachableError
+		switch {
+		case errors.As(err, &unreachable):
+			cond.Reason = "HealthCheckUnreachable"
+			cond.Message = fmt.Sprintf("The operator could not connect to the load balancer health check for node %s within %v; check that the pod network allows connections from the operator to the health-check node port %d on the node's internal IP address: %v", pod.Spec.NodeName, loadBalancerHealthCheckTimeout, service.Spec.HealthCheckNodePort, err)
+		case err != nil:
+			cond.Message = fmt.Sprintf("%s: %v", cond.Message, err)
+		}
+		return cond, false
+	case enteringRotation:
+		// Unsetting the gate would take the node out of rotation
+		// again, so keep it set until the node passes the health check
+		// for long enough or the timeout expires.
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = loadBalancerReadyReasonEnteringRotation
+		cond.Message = fmt.Sprintf("Node %s has no other ready router pods and enters load balancer rotation when this pod is ready", pod.Spec.NodeName)
+		return cond, true
+	case err != nil:
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "HealthCheckFailed"
+		cond.Message = fmt.Sprintf("Failed to query the load balancer health check for node %s: %v", pod.Spec.NodeName, err)
+		return cond, true
+	case !registered:
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "EndpointNotRegistered"
+		cond.Message = "The load balancer service has no endpoint for this pod yet"
+		return cond, true
+	case localEndpoints == 0:
+		// The node cannot pass the health check until this pod is
+		// ready, so waiting for it would deadlock.  Set the gate so
+		// that the node can enter rotation, and keep checking it.
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = loadBalancerReadyReasonEnteringRotation
+		cond.Message = fmt.Sprintf("Node %s has no other ready router pods and enters load balancer rotation when this pod is ready", pod.Spec.NodeName)
+		return cond, true
+	default:
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "LoadBalancerUnhealthy"
+		cond.Message = fmt.Sprintf("Node %s does not yet pass the load balancer health check", pod.Spec.NodeName)
+		return cond, true
+	}
+}
+
+// ensureLoadBalancerReadinessGates sets the load balancer readiness gate
+// condition on the given ingresscontroller's router pods that have the gate.
+// Returns a Boolean value indicating whether any pod's gate should be checked
+// again later, and an error value.
+func (r *reconciler) ensureLoadBalancerReadinessGates(ic *operatorv1.IngressController, service *corev1.Service, pods []corev1.Pod) (bool, error) {
+	ctx := context.TODO()
+	now := time.Now()
+	requeue := false
+	var errs []error
+	for i := range pods {
+		pod := &pods[i]
+		if !routerPodIsReadyCandidate(ic, pod) || !loadBalancerReadinessGatePending(pod) {
+			continue
+		}
+		desired, retry := r.desiredLoadBalancerReadyCondition(ctx, pod, service, now)
+		requeue = requeue || retry
+		current := getPodCondition(pod, LoadBalancerReadyConditionType)
+		if current != nil && current.Status == desired.Status && current.Reason == desired.Reason && current.Message == desired.Message && current.LastProbeTime.Equal(&desired.LastProbeTime) {
+			continue
+		}
+		desired.LastTransitionTime = metav1.NewTime(now)
+		if current != nil && current.Status == desired.Status {
+			desired.LastTransitionTime = current.LastTransitionTime
+		}
+		updated := pod.DeepCopy()
+		if cond := getPodCondition(updated, LoadBalancerReadyConditionType); cond != nil {
+			*cond = desired
+		} else {
+			updated.Status.Conditions = append(updated.Status.Conditions, desired)
+		}
+		if err := r.client.Status().Patch(ctx, updated, client.StrategicMergeFrom(pod)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update condition %s on pod %s/%s: %w", LoadBalancerReadyConditionType, pod.Namespace, pod.Name, err))
+			continue
+		}
+		log.Info("updated load balancer readiness gate", "namespace", pod.Namespace, "pod", pod.Name, "status", desired.Status, "reason", desired.Reason)
+	}
+	if len(errs) != 0 {
+		return true, utilerrors.NewAggregate(errs)
+	}
+	return requeue, nil
+}
+
+// routerPodIsReadyCandidate returns a Boolean value indicating whether the
+// given pod is a scheduled, running router pod of the given ingresscontroller.
+func routerPodIsReadyCandidate(ic *operatorv1.IngressController, pod *corev1.Pod) bool {
+	return pod.Labels[operatorcontroller.ControllerDeploymentLabel] == operatorcontroller.IngressControllerDeploymentLabel(ic) &&
+		pod.DeletionTimestamp == nil && len(pod.Spec.NodeName) != 0
+}
@@ -0,0 +1,305 @@
+package ingress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newHealthCheckServer returns a server that responds to health checks with
+// the given status code and local endpoint count, and the server's port.
+func newHealthCheckServer(t *testing.T, code, localEndpoints int) (*httptest.Server, int32) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write([]byte(`{"service":{"namespace":"openshift-ingress","name":"router-default"},"localEndpoints":` + strconv.Itoa(localEndpoints) + `}`))
+	}))
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, int32(n)
+}
+
+func Test_desiredLoadBalancerReadyCondition(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "127.0.0.1"},
+		}},
+	}
+	healthyServer, healthyPort := newHealthCheckServer(t, http.StatusOK, 1)
+	defer healthyServer.Close()
+	noEndpointsServer, noEndpointsPort := newHealthCheckServer(t, http.StatusServiceUnavailable, 0)
+	defer noEndpointsServer.Close()
+	unhealthyServer, unhealthyPort := newHealthCheckServer(t, http.StatusServiceUnavailable, 1)
+	defer unhealthyServer.Close()
+	closedServer, closedPort := newHealthCheckServer(t, http.StatusOK, 1)
+	closedServer.Close()
+
+	service := func(port int32) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default"},
+			Spec:       corev1.ServiceSpec{HealthCheckNodePort: port},
+		}
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "router-default-abcde",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "router-default"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses: []string{"10.128.2.10"},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "openshift-ingress", Name: "router-default-1"},
+		}},
+	}
+	gate := func(status corev1.ConditionStatus, reason string, since time.Time) *corev1.PodCondition {
+		return &corev1.PodCondition{
+			Type:          LoadBalancerReadyConditionType,
+			Status:        status,
+			Reason:        reason,
+			LastProbeTime: metav1.NewTime(since),
+		}
+	}
+
+	testCases := []struct {
+		description     string
+		containersReady *corev1.PodCondition
+		current         *corev1.PodCondition
+		unregistered    bool
+		service         *corev1.Service
+		expectStatus    corev1.ConditionStatus
+		expectReason    string
+		expectRequeue   bool
+	}{
+		{
+			description:  "containers not ready",
+			service:      service(healthyPort),
+			expectStatus: corev1.ConditionFalse,
+			expectReason: "ContainersNotReady",
+		},
+		{
+			description:  "no health-check node port",
+			service:      service(0),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: "NoHealthCheckNodePort",
+		},
+		{
+			description:   "pod's endpoint is not registered",
+			unregistered:  true,
+			service:       service(healthyPort),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "EndpointNotRegistered",
+			expectRequeue: true,
+		},
+		{
+			description:   "node starts passing the health check",
+			service:       service(healthyPort),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "LoadBalancerRegistering",
+			expectRequeue: true,
+		},
+		{
+			description:   "node has not passed the health check for long enough",
+			current:       gate(corev1.ConditionFalse, "LoadBalancerRegistering", now.Add(-loadBalancerHealthyThresholdInterval/2)),
+			service:       service(healthyPort),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "LoadBalancerRegistering",
+			expectRequeue: true,
+		},
+		{
+			description:  "node has passed the health check for long enough",
+			current:      gate(corev1.ConditionFalse, "LoadBalancerRegistering", now.Add(-loadBalancerHealthyThresholdInterval)),
+			service:      service(healthyPort),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: "LoadBalancerHealthy",
+		},
+		{
+			description:   "node has no other local endpoints",
+			service:       service(noEndpointsPort),
+			expectStatus:  corev1.ConditionTrue,
+			expectReason:  "NodeEnteringRotation",
+			expectRequeue: true,
+		},
+		{
+			description:   "node entering rotation starts passing the health check",
+			current:       gate(corev1.ConditionTrue, "NodeEnteringRotation", time.Time{}),
+			service:       service(healthyPort),
+			expectStatus:  corev1.ConditionTrue,
+			expectReason:  "NodeEnteringRotation",
+			expectRequeue: true,
+		},
+		{
+			description:  "node entering rotation has passed the health check for long enough",
+			current:      gate(corev1.ConditionTrue, "NodeEnteringRotation", now.Add(-loadBalancerHealthyThresholdInterval)),
+			service:      service(healthyPort),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: "LoadBalancerHealthy",
+		},
+		{
+			description:   "node entering rotation fails the health check",
+			current:       gate(corev1.ConditionTrue, "NodeEnteringRotation", time.Time{}),
+			service:       service(closedPort),
+			expectStatus:  corev1.ConditionTrue,
+			expectReason:  "NodeEnteringRotation",
+			expectRequeue: true,
+		},
+		{
+			description:   "node fails the health check",
+			service:       service(unhealthyPort),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "LoadBalancerUnhealthy",
+			expectRequeue: true,
+		},
+		{
+			description:   "health check is unreachable",
+			service:       service(closedPort),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "HealthCheckFailed",
+			expectRequeue: true,
+		},
+		{
+			description:   "load balancer service does not exist",
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "HealthCheckFailed",
+			expectRequeue: true,
+		},
+		{
+			description: "health check is unreachable for too long",
+			containersReady: &corev1.PodCondition{
+				Type:               corev1.ContainersReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-loadBalancerHealthCheckTimeout)),
+			},
+			service:      service(closedPort),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: "HealthCheckUnreachable",
+		},
+		{
+			description: "node fails the health check for too long",
+			containersReady: &corev1.PodCondition{
+				Type:               corev1.ContainersReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-loadBalancerHealthCheckTimeout)),
+			},
+			service:      service(unhealthyPort),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: "HealthCheckTimeout",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default-1"},
+				Spec:       corev1.PodSpec{NodeName: node.Name},
+			}
+			if tc.unregistered {
+				pod.Name = "router-default-2"
+			}
+			if tc.containersReady != nil {
+				pod.Status.Conditions = []corev1.PodCondition{*tc.containersReady}
+			} else if tc.expectReason != "ContainersNotReady" {
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:               corev1.ContainersReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now),
+				}}
+			}
+			if tc.current != nil {
+				pod.Status.Conditions = append(pod.Status.Conditions, *tc.current)
+			}
+			cl := fake.NewClientBuilder().WithObjects(node.DeepCopy(), endpointSlice.DeepCopy()).Build()
+			r := &reconciler{client: cl, apiReader: cl}
+			cond, requeue := r.desiredLoadBalancerReadyCondition(context.Background(), pod, tc.service, now)
+			if cond.Status != tc.expectStatus || cond.Reason != tc.expectReason {
+				t.Errorf("expected status %s and reason %s, got status %s and reason %s: %s", tc.expectStatus, tc.expectReason, cond.Status, cond.Reason, cond.Message)
+			}
+			if requeue != tc.expectRequeue {
+				t.Errorf("expected requeue %t, got %t", tc.expectRequeue, requeue)
+			}
+		})
+	}
+}
+
+func Test_ensureLoadBalancerReadinessGates(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	pod := func(name string, gate bool) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: operatorcontroller.DefaultOperandNamespace,
+				Name:      name,
+				Labels:    map[string]string{operatorcontroller.ControllerDeploymentLabel: operatorcontroller.IngressControllerDeploymentLabel(ic)},
+			},
+			Spec: corev1.PodSpec{NodeName: "worker-1"},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.ContainersReady, Status: corev1.ConditionTrue},
+			}},
+		}
+		if gate {
+			p.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: LoadBalancerReadyConditionType}}
+		}
+		return p
+	}
+	gated, ungated := pod("router-default-a", true), pod("router-default-b", false)
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&corev1.Pod{}).WithObjects(gated, ungated).Build()
+	r := &reconciler{client: cl}
+	// A service without a health-check node port does not require
+	// querying the node.
+	service := &corev1.Service{}
+
+	requeue, err := r.ensureLoadBalancerReadinessGates(ic, service, []corev1.Pod{*gated, *ungated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requeue {
+		t.Error("expected no requeue")
+	}
+
+	for _, tc := range []struct {
+		name       string
+		expectCond bool
+	}{{gated.Name, true}, {ungated.Name, false}} {
+		current := &corev1.Pod{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: gated.Namespace, Name: tc.name}, current); err != nil {
+			t.Fatal(err)
+		}
+		cond := getPodCondition(current, LoadBalancerReadyConditionType)
+		switch {
+		case tc.expectCond && (cond == nil || cond.Status != corev1.ConditionTrue):
+			t.Errorf("expected pod %s to have condition %s=True, got %+v", tc.name, LoadBalancerReadyConditionType, cond)
+		case !tc.expectCond && cond != nil:
+			t.Errorf("expected pod %s not to have condition %s, got %+v", tc.name, LoadBalancerReadyConditionType, cond)
+		}
+		if getPodCondition(current, corev1.ContainersReady) == nil {
+			t.Errorf("expected pod %s to keep its ContainersReady condition", tc.name)
+		}
+	}
+}
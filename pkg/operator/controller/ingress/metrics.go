@@ -31,16 +31,28 @@ var (
 		Help: "Report the number of active NLBs on AWS clusters.",
 	}, []string{"name"})
 
+	// tlsProfileDeviations reports the number of deviations from each
+	// ingresscontroller's TLS profile that the canary controller's last
+	// TLS conformance probe found.  It is defined in this package so that
+	// it is deleted along with the ingresscontroller's other metrics.
+	tlsProfileDeviations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_tls_profile_deviations",
+		Help: "The number of deviations from an ingresscontroller's TLS profile that the last TLS conformance probe found in the TLS versions and ciphers that the routers negotiate",
+	}, []string{"ingresscontroller"})
+
 	// metricsList is a list of metrics for this package.
 	metricsList = []prometheus.Collector{
 		ingressControllerConditions,
 		activeNLBs,
+		geoIPDatabaseBuildTimestamp,
+		routerRolloutDuration,
+		tlsProfileDeviations,
 	}
 )
 
 // reportedConditions is the set of ingresscontroller status conditions that are
 // reported in the ingress_controller_conditions metric.
-var reportedConditions = sets.NewString("Available", "Degraded")
+var reportedConditions = sets.NewString("Available", "Degraded", IngressControllerGeoIPDatabaseReadyConditionType)
 
 // SetIngressControllerConditionsMetric updates the
 // ingress_controller_conditions metric values for the given IngressController.
@@ -78,6 +90,18 @@ func DeleteActiveNLBMetrics(ic *operatorv1.IngressController) {
 	activeNLBs.DeleteLabelValues(ic.Name)
 }
 
+// SetTLSProfileDeviationsMetric sets the number of TLS profile deviations that
+// were found for the given ingresscontroller.
+func SetTLSProfileDeviationsMetric(ic *operatorv1.IngressController, deviations int) {
+	tlsProfileDeviations.WithLabelValues(ic.Name).Set(float64(deviations))
+}
+
+// DeleteTLSProfileDeviationsMetric deletes the TLS profile deviations metric
+// for the given ingresscontroller.
+func DeleteTLSProfileDeviationsMetric(ic *operatorv1.IngressController) {
+	tlsProfileDeviations.DeleteLabelValues(ic.Name)
+}
+
 func SetIngressControllerNLBMetric(ci *operatorv1.IngressController) {
 	labelVal := 0
 	if ci.Status.EndpointPublishingStrategy != nil &&
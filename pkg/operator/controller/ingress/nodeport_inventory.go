@@ -0,0 +1,432 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NodePortInventoryWebhookAnnotation is an annotation on an
+	// ingresscontroller with the NodePortService endpoint publishing
+	// strategy that specifies an http or https URL.  If it is specified,
+	// the operator POSTs the ingresscontroller's NodePort backend inventory
+	// to the URL as JSON whenever the inventory changes, so that external
+	// load balancer pools can be kept up to date.
+	NodePortInventoryWebhookAnnotation = "ingress.operator.openshift.io/nodeport-inventory-webhook"
+	// NodePortInventoryWebhookSecretAnnotation is an annotation on an
+	// ingresscontroller that specifies the name of a secret in the
+	// ingresscontroller's namespace.  If it is specified, the operator
+	// sends the value of the secret's "token" key as a bearer token in
+	// requests to the inventory webhook.
+	NodePortInventoryWebhookSecretAnnotation = "ingress.operator.openshift.io/nodeport-inventory-webhook-secret"
+
+	// nodePortInventoryKey is the key in the NodePort inventory configmap
+	// whose value is the inventory.
+	nodePortInventoryKey = "inventory.json"
+	// nodePortInventoryDeliveredAnnotation is an annotation on the
+	// NodePort inventory configmap whose value is the digest of the
+	// webhook URL and inventory that were last delivered successfully.
+	nodePortInventoryDeliveredAnnotation = "ingress.operator.openshift.io/webhook-delivered"
+	// nodePortInventoryWebhookTokenKey is the key in the webhook secret
+	// whose value is the bearer token.
+	nodePortInventoryWebhookTokenKey = "token"
+
+	// routerReadinessPath is the path of the router's readiness endpoint
+	// on the metrics port.
+	routerReadinessPath = "/healthz/ready"
+)
+
+// nodePortInventoryWebhookClient is the HTTP client that the operator uses to
+// deliver inventories to webhooks.
+var nodePortInventoryWebhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	},
+}
+
+// nodePortInventoryDeliveryBackoff is the backoff with which a failed
+// delivery is retried.  Once the retries are exhausted, the next reconcile
+// starts a new delivery.
+var nodePortInventoryDeliveryBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
+	Factor:   2,
+	Steps:    5,
+}
+
+// nodePortInventoryDelivery is an inventory to be delivered to a webhook.
+type nodePortInventoryDelivery struct {
+	ic         *operatorv1.IngressController
+	webhookURL string
+	inventory  []byte
+	nodes      int
+	// digest is the value of the configmap's delivered annotation once
+	// the inventory has been delivered.
+	digest string
+}
+
+// nodePortInventoryDeliverer delivers inventories to webhooks outside of the
+// reconcile loop, so that a slow or unreachable webhook does not hold up
+// reconciliation.  Deliveries for each ingresscontroller are made one at a
+// time, and only the latest inventory that is waiting to be delivered is
+// kept.
+type nodePortInventoryDeliverer struct {
+	lock sync.Mutex
+	// pending is the latest inventory waiting to be delivered for each
+	// ingresscontroller.
+	pending map[types.NamespacedName]*nodePortInventoryDelivery
+	// running is the set of ingresscontrollers for which a delivery
+	// goroutine is running.
+	running map[types.NamespacedName]bool
+	// inflight tracks the delivery goroutines so that tests can wait for
+	// them.
+	inflight sync.WaitGroup
+}
+
+func newNodePortInventoryDeliverer() *nodePortInventoryDeliverer {
+	return &nodePortInventoryDeliverer{
+		pending: map[types.NamespacedName]*nodePortInventoryDelivery{},
+		running: map[types.NamespacedName]bool{},
+	}
+}
+
+// enqueue schedules the given delivery, replacing any delivery for the same
+// ingresscontroller that has not yet started, and starts a delivery goroutine
+// for the ingresscontroller if none is running.  If the given delivery is the
+// one already waiting or in progress, enqueue does nothing.
+func (d *nodePortInventoryDeliverer) enqueue(r *reconciler, delivery *nodePortInventoryDelivery) {
+	key := types.NamespacedName{Namespace: delivery.ic.Namespace, Name: delivery.ic.Name}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if pending, ok := d.pending[key]; ok && pending.digest == delivery.digest {
+		return
+	}
+	d.pending[key] = delivery
+	if d.running[key] {
+		return
+	}
+	d.running[key] = true
+	d.inflight.Add(1)
+	go func() {
+		defer d.inflight.Done()
+		for {
+			d.lock.Lock()
+			next, ok := d.pending[key]
+			if !ok {
+				delete(d.running, key)
+				d.lock.Unlock()
+				return
+			}
+			d.lock.Unlock()
+			r.deliverNodePortInventoryWithRetries(next)
+			d.lock.Lock()
+			if d.pending[key] == next {
+				delete(d.pending, key)
+			}
+			d.lock.Unlock()
+		}
+	}()
+}
+
+// wait blocks until every delivery goroutine has finished.
+func (d *nodePortInventoryDeliverer) wait() {
+	d.inflight.Wait()
+}
+
+// deliverNodePortInventoryWithRetries delivers the given inventory to its
+// webhook, retrying with nodePortInventoryDeliveryBackoff, and records the
+// delivery on the inventory configmap once it succeeds.
+func (r *reconciler) deliverNodePortInventoryWithRetries(delivery *nodePortInventoryDelivery) {
+	err := wait.ExponentialBackoff(nodePortInventoryDeliveryBackoff, func() (bool, error) {
+		if err := r.deliverNodePortInventory(delivery.ic, delivery.webhookURL, delivery.inventory); err != nil {
+			log.Error(err, "failed to deliver NodePort backend inventory", "ingresscontroller", delivery.ic.Name, "webhook", delivery.webhookURL)
+			r.recorder.Eventf(delivery.ic, "Warning", "NodePortInventoryDeliveryFailed", "Failed to deliver the NodePort backend inventory to %s: %v", delivery.webhookURL, err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+	r.recorder.Eventf(delivery.ic, "Normal", "NodePortInventoryDelivered", "Delivered the NodePort backend inventory with %d nodes to %s", delivery.nodes, delivery.webhookURL)
+
+	name := operatorcontroller.NodePortInventoryConfigMapName(delivery.ic)
+	current := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), name, current); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get configmap", "namespace", name.Namespace, "name", name.Name)
+		}
+		return
+	}
+	if current.Annotations[nodePortInventoryDeliveredAnnotation] == delivery.digest {
+		return
+	}
+	updated := current.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[nodePortInventoryDeliveredAnnotation] = delivery.digest
+	if err := r.client.Patch(context.TODO(), updated, client.MergeFrom(current)); err != nil {
+		log.Error(err, "failed to update configmap", "namespace", name.Namespace, "name", name.Name)
+	}
+}
+
+// nodePortInventory is the backend inventory of an ingresscontroller's NodePort
+// service, which an external load balancer needs in order to send traffic to
+// the ingresscontroller.
+type nodePortInventory struct {
+	IngressController string `json:"ingressController"`
+	Service           string `json:"service"`
+	// Ports maps the NodePort service's port names ("http", "https", and
+	// "metrics") to node ports.
+	Ports map[string]int32 `json:"ports"`
+	// HealthCheck is the endpoint that the load balancer should probe on
+	// each node.  Because the service uses the "Local" external traffic
+	// policy, the probe fails on nodes without a ready router pod.  It is
+	// omitted if the service has no metrics port.
+	HealthCheck *nodePortInventoryHealthCheck `json:"healthCheck,omitempty"`
+	// Nodes are the nodes with ready router pods, sorted by name.
+	Nodes []nodePortInventoryNode `json:"nodes"`
+}
+
+// nodePortInventoryHealthCheck is a health check endpoint on each node.
+type nodePortInventoryHealthCheck struct {
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+	Path     string `json:"path"`
+}
+
+// nodePortInventoryNode is a node with ready router pods.
+type nodePortInventoryNode struct {
+	Name string `json:"name"`
+	// Addresses maps node address types, such as "InternalIP", to
+	// addresses.
+	Addresses map[corev1.NodeAddressType]string `json:"addresses"`
+	// Pods are the names of the ready router pods on the node.
+	Pods []string `json:"pods"`
+}
+
+// validateNodePortInventoryWebhook validates the ingresscontroller's inventory
+// webhook annotations.
+func validateNodePortInventoryWebhook(ic *operatorv1.IngressController) error {
+	value, ok := ic.Annotations[NodePortInventoryWebhookAnnotation]
+	if !ok {
+		if _, ok := ic.Annotations[NodePortInventoryWebhookSecretAnnotation]; ok {
+			return fmt.Errorf("the %s annotation requires the %s annotation", NodePortInventoryWebhookSecretAnnotation, NodePortInventoryWebhookAnnotation)
+		}
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("invalid %s annotation %q: must be an http or https URL", NodePortInventoryWebhookAnnotation, value)
+	}
+	return nil
+}
+
+// ensureNodePortInventory ensures that the NodePort inventory configmap has the
+// current backend inventory of the ingresscontroller's NodePort service if the
+// ingresscontroller uses the NodePortService endpoint publishing strategy, and
+// that the configmap does not exist otherwise.  If the ingresscontroller
+// specifies an inventory webhook, ensureNodePortInventory also schedules the
+// delivery of the inventory to the webhook if it has not yet been delivered.
+// The delivery happens asynchronously.
+func (r *reconciler) ensureNodePortInventory(ic *operatorv1.IngressController, deploymentRef metav1.OwnerReference, haveService bool, service *corev1.Service, pods []corev1.Pod) error {
+	name := operatorcontroller.NodePortInventoryConfigMapName(ic)
+	current := &corev1.ConfigMap{}
+	haveCurrent := true
+	if err := r.client.Get(context.TODO(), name, current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		haveCurrent = false
+	}
+
+	if !haveService {
+		if !haveCurrent {
+			return nil
+		}
+		if err := r.client.Delete(context.TODO(), current); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete configmap %s: %w", name, err)
+		}
+		log.Info("deleted configmap", "namespace", current.Namespace, "name", current.Name)
+		return nil
+	}
+
+	nodes := map[string]*corev1.Node{}
+	for _, pod := range pods {
+		if !routerPodIsReady(ic, &pod) {
+			continue
+		}
+		if _, ok := nodes[pod.Spec.NodeName]; ok {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := r.apiReader.Get(context.TODO(), types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			return fmt.Errorf("failed to get node %s: %w", pod.Spec.NodeName, err)
+		}
+		nodes[node.Name] = node
+	}
+	data, err := json.MarshalIndent(buildNodePortInventory(ic, service, pods, nodes), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	inventory := string(data)
+
+	if !haveCurrent {
+		current = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name.Name,
+				Namespace:       name.Namespace,
+				OwnerReferences: []metav1.OwnerReference{deploymentRef},
+			},
+			Data: map[string]string{nodePortInventoryKey: inventory},
+		}
+		if err := r.client.Create(context.TODO(), current); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+		log.Info("created configmap", "namespace", current.Namespace, "name", current.Name)
+	} else if current.Data[nodePortInventoryKey] != inventory {
+		current.Data = map[string]string{nodePortInventoryKey: inventory}
+		if err := r.client.Update(context.TODO(), current); err != nil {
+			return fmt.Errorf("failed to update configmap %s: %w", name, err)
+		}
+		log.Info("updated configmap", "namespace", current.Namespace, "name", current.Name)
+	}
+
+	webhookURL, ok := ic.Annotations[NodePortInventoryWebhookAnnotation]
+	if !ok {
+		return nil
+	}
+	digest := sha256.Sum256([]byte(webhookURL + "\n" + inventory))
+	delivered := hex.EncodeToString(digest[:])
+	if current.Annotations[nodePortInventoryDeliveredAnnotation] == delivered {
+		return nil
+	}
+	r.nodePortInventoryDeliverer.enqueue(r, &nodePortInventoryDelivery{
+		ic:         ic.DeepCopy(),
+		webhookURL: webhookURL,
+		inventory:  data,
+		nodes:      len(nodes),
+		digest:     delivered,
+	})
+	return nil
+}
+
+// deliverNodePortInventory POSTs the given inventory to the given webhook URL.
+func (r *reconciler) deliverNodePortInventory(ic *operatorv1.IngressController, webhookURL string, inventory []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(inventory))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if secretName, ok := ic.Annotations[NodePortInventoryWebhookSecretAnnotation]; ok {
+		secret := &corev1.Secret{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: ic.Namespace, Name: secretName}, secret); err != nil {
+			return fmt.Errorf("failed to get secret %s/%s: %w", ic.Namespace, secretName, err)
+		}
+		token := secret.Data[nodePortInventoryWebhookTokenKey]
+		if len(token) == 0 {
+			return fmt.Errorf("secret %s/%s has no %q key", ic.Namespace, secretName, nodePortInventoryWebhookTokenKey)
+		}
+		request.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+	}
+	response, err := nodePortInventoryWebhookClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", response.Status)
+	}
+	return nil
+}
+
+// buildNodePortInventory returns the backend inventory for the given NodePort
+// service, router pods, and the nodes of the ready router pods.
+func buildNodePortInventory(ic *operatorv1.IngressController, service *corev1.Service, pods []corev1.Pod, nodes map[string]*corev1.Node) *nodePortInventory {
+	inventory := &nodePortInventory{
+		IngressController: ic.Name,
+		Service:           service.Name,
+		Ports:             map[string]int32{},
+		Nodes:             []nodePortInventoryNode{},
+	}
+	for _, port := range service.Spec.Ports {
+		if port.NodePort == 0 {
+			continue
+		}
+		inventory.Ports[port.Name] = port.NodePort
+		if port.Name == "metrics" {
+			inventory.HealthCheck = &nodePortInventoryHealthCheck{
+				Protocol: "HTTP",
+				Port:     port.NodePort,
+				Path:     routerReadinessPath,
+			}
+		}
+	}
+
+	podsByNode := map[string][]string{}
+	for _, pod := range pods {
+		if _, ok := nodes[pod.Spec.NodeName]; ok && routerPodIsReady(ic, &pod) {
+			podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod.Name)
+		}
+	}
+	for nodeName, podNames := range podsByNode {
+		node := nodePortInventoryNode{
+			Name:      nodeName,
+			Addresses: map[corev1.NodeAddressType]string{},
+			Pods:      podNames,
+		}
+		for _, address := range nodes[nodeName].Status.Addresses {
+			if _, ok := node.Addresses[address.Type]; !ok {
+				node.Addresses[address.Type] = address.Address
+			}
+		}
+		sort.Strings(node.Pods)
+		inventory.Nodes = append(inventory.Nodes, node)
+	}
+	sort.Slice(inventory.Nodes, func(i, j int) bool {
+		return inventory.Nodes[i].Name < inventory.Nodes[j].Name
+	})
+	return inventory
+}
+
+// routerPodIsReady returns a Boolean value indicating whether the given pod is
+// a ready, scheduled, and not terminating router pod of the given
+// ingresscontroller.
+func routerPodIsReady(ic *operatorv1.IngressController, pod *corev1.Pod) bool {
+	if pod.Labels[operatorcontroller.ControllerDeploymentLabel] != operatorcontroller.IngressControllerDeploymentLabel(ic) {
+		return false
+	}
+	if pod.DeletionTimestamp != nil || len(pod.Spec.NodeName) == 0 {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_validateNodePortInventoryWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectError bool
+	}{
+		{name: "no annotations"},
+		{name: "https URL", annotations: map[string]string{NodePortInventoryWebhookAnnotation: "https://lb.example.com/pools/apps"}},
+		{name: "http URL with secret", annotations: map[string]string{NodePortInventoryWebhookAnnotation: "http://10.0.0.5:8080/inventory", NodePortInventoryWebhookSecretAnnotation: "lb-token"}},
+		{name: "unsupported scheme", annotations: map[string]string{NodePortInventoryWebhookAnnotation: "ftp://lb.example.com/"}, expectError: true},
+		{name: "no host", annotations: map[string]string{NodePortInventoryWebhookAnnotation: "https:///inventory"}, expectError: true},
+		{name: "secret without URL", annotations: map[string]string{NodePortInventoryWebhookSecretAnnotation: "lb-token"}, expectError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if err := validateNodePortInventoryWebhook(ic); tc.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func Test_ensureNodePortInventory(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []*http.Request
+		bodies   []nodePortInventory
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var inventory nodePortInventory
+		if err := json.Unmarshal(body, &inventory); err != nil {
+			t.Errorf("failed to parse webhook request body: %v", err)
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, req)
+		bodies = append(bodies, inventory)
+	}))
+	defer server.Close()
+
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "edge",
+			Annotations: map[string]string{
+				NodePortInventoryWebhookAnnotation:       server.URL,
+				NodePortInventoryWebhookSecretAnnotation: "lb-token",
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-nodeport-edge"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", NodePort: 30080},
+				{Name: "https", NodePort: 30443},
+				{Name: "metrics", NodePort: 31936},
+			},
+		},
+	}
+	pod := func(name, node string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      name,
+				Labels:    map[string]string{operatorcontroller.ControllerDeploymentLabel: operatorcontroller.IngressControllerDeploymentLabel(ic)},
+			},
+			Spec:   corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+	node := func(name, ip string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: ip},
+				{Type: corev1.NodeHostName, Address: name},
+			}},
+		}
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "lb-token"},
+		Data:       map[string][]byte{"token": []byte("s3cret\n")},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(node("worker-b", "10.0.0.2"), node("worker-a", "10.0.0.1"), node("worker-c", "10.0.0.3"), secret).Build()
+	r := &reconciler{client: cl, apiReader: cl, recorder: record.NewFakeRecorder(10), nodePortInventoryDeliverer: newNodePortInventoryDeliverer()}
+	deploymentRef := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "router-edge"}
+
+	// ensure reconciles the inventory and waits for the asynchronous
+	// webhook delivery to finish.
+	ensure := func(haveService bool, service *corev1.Service, pods []corev1.Pod) {
+		t.Helper()
+		if err := r.ensureNodePortInventory(ic, deploymentRef, haveService, service, pods); err != nil {
+			t.Fatal(err)
+		}
+		r.nodePortInventoryDeliverer.wait()
+		// Synchronize with the webhook handler's writes.
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	pods := []corev1.Pod{pod("router-edge-1", "worker-b", true), pod("router-edge-2", "worker-a", true), pod("router-edge-3", "worker-c", false)}
+	ensure(true, service, pods)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 webhook request, got %d", len(requests))
+	}
+	if auth := requests[0].Header.Get("Authorization"); auth != "Bearer s3cret" {
+		t.Errorf("expected bearer token, got %q", auth)
+	}
+	inventory := bodies[0]
+	if len(inventory.Nodes) != 2 || inventory.Nodes[0].Name != "worker-a" || inventory.Nodes[1].Name != "worker-b" {
+		t.Fatalf("expected ready nodes worker-a and worker-b, got %+v", inventory.Nodes)
+	}
+	if inventory.Nodes[0].Addresses[corev1.NodeInternalIP] != "10.0.0.1" || inventory.Nodes[0].Pods[0] != "router-edge-2" {
+		t.Errorf("unexpected node entry %+v", inventory.Nodes[0])
+	}
+	if inventory.Ports["https"] != 30443 || inventory.HealthCheck == nil || inventory.HealthCheck.Port != 31936 || inventory.HealthCheck.Path != "/healthz/ready" {
+		t.Errorf("unexpected ports %v and health check %+v", inventory.Ports, inventory.HealthCheck)
+	}
+
+	// An unchanged inventory is not delivered again.
+	ensure(true, service, pods)
+	if len(requests) != 1 {
+		t.Fatalf("expected no new webhook request for an unchanged inventory, got %d requests", len(requests))
+	}
+
+	// A pod that becomes ready adds its node.
+	pods[2] = pod("router-edge-3", "worker-c", true)
+	ensure(true, service, pods)
+	if len(requests) != 2 || len(bodies[1].Nodes) != 3 {
+		t.Fatalf("expected a second webhook request with 3 nodes, got %d requests", len(requests))
+	}
+
+	// The configmap is deleted when the service is gone.
+	ensure(false, nil, pods)
+	if err := cl.Get(context.Background(), operatorcontroller.NodePortInventoryConfigMapName(ic), &corev1.ConfigMap{}); err == nil {
+		t.Error("expected the inventory configmap to be deleted")
+	}
+}
+
+// Test_nodePortInventoryDeliveryRetries verifies that a failed webhook delivery
+// is retried outside of the reconcile loop and that the successful delivery is
+// recorded on the inventory configmap.
+func Test_nodePortInventoryDeliveryRetries(t *testing.T) {
+	backoff := nodePortInventoryDeliveryBackoff
+	nodePortInventoryDeliveryBackoff.Duration = time.Millisecond
+	defer func() {
+		nodePortInventoryDeliveryBackoff = backoff
+	}()
+
+	var (
+		lock     sync.Mutex
+		attempts int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "openshift-ingress-operator",
+			Name:        "edge",
+			Annotations: map[string]string{NodePortInventoryWebhookAnnotation: server.URL},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-nodeport-edge"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", NodePort: 30080}}},
+	}
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{client: cl, apiReader: cl, recorder: recorder, nodePortInventoryDeliverer: newNodePortInventoryDeliverer()}
+
+	if err := r.ensureNodePortInventory(ic, metav1.OwnerReference{}, true, service, nil); err != nil {
+		t.Fatalf("expected the reconcile not to wait for or fail on the webhook, got %v", err)
+	}
+	r.nodePortInventoryDeliverer.wait()
+
+	lock.Lock()
+	if attempts != 3 {
+		t.Errorf("expected 3 delivery attempts, got %d", attempts)
+	}
+	lock.Unlock()
+	cm := &corev1.ConfigMap{}
+	if err := cl.Get(context.Background(), operatorcontroller.NodePortInventoryConfigMapName(ic), cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Annotations[nodePortInventoryDeliveredAnnotation]) == 0 {
+		t.Error("expected the delivery to be recorded on the configmap")
+	}
+	var reasons []string
+	for len(recorder.Events) != 0 {
+		reasons = append(reasons, strings.Fields(<-recorder.Events)[1])
+	}
+	expected := []string{"NodePortInventoryDeliveryFailed", "NodePortInventoryDeliveryFailed", "NodePortInventoryDelivered"}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected events %v, got %v", expected, reasons)
+	}
+}
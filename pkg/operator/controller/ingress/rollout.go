@@ -0,0 +1,464 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// rolloutTimelinesKey is the key in the rollout timeline configmap
+	// whose value is a JSON array of rolloutTimeline values, most recent
+	// first.
+	rolloutTimelinesKey = "rollouts.json"
+	// maxRolloutTimelines is the number of rollouts that the rollout
+	// timeline configmap records.
+	maxRolloutTimelines = 5
+	// maxRolloutPods is the number of new and old pods that each rollout
+	// timeline records, to bound the size of the configmap for
+	// ingresscontrollers with many replicas.
+	maxRolloutPods = 20
+
+	// rolloutOutcomeCompleted means that every pod of the deployment was
+	// updated and available and every old pod was gone.
+	rolloutOutcomeCompleted = "Completed"
+	// rolloutOutcomeSuperseded means that another rollout started before
+	// this one completed.
+	rolloutOutcomeSuperseded = "Superseded"
+	// rolloutOutcomePreExisting means that the rollout had already
+	// completed when the operator first observed the deployment, so its
+	// timeline is incomplete and it is not reported.
+	rolloutOutcomePreExisting = "PreExisting"
+)
+
+var (
+	// routerRolloutDuration reports how long each rollout of each
+	// ingresscontroller's router deployment took, from the creation of the
+	// first pod with the new pod template until every old pod was gone.
+	routerRolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ingress_controller_router_rollout_duration_seconds",
+		Help:    "Time in seconds from the start of a rollout of an ingresscontroller's router deployment until the rollout completed.",
+		Buckets: []float64{15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"name"})
+
+	// pulledImageDurationRegexp matches the pull duration in the message of
+	// the kubelet's "Pulled" event, for example `Successfully pulled image
+	// "..." in 2.5s (2.5s including waiting)`.
+	pulledImageDurationRegexp = regexp.MustCompile(` in ([0-9][0-9.]*(?:ns|us|µs|ms|s|m|h)(?:[0-9][0-9.]*(?:ns|us|µs|ms|s|m|h))*)`)
+)
+
+// rolloutTimeline records the progress of one rollout of a router deployment.
+type rolloutTimeline struct {
+	// TemplateHash is the pod-template-hash label of the rollout's pods.
+	TemplateHash string `json:"templateHash"`
+	// Started is the creation time of the first pod with the new pod
+	// template.
+	Started metav1.Time `json:"started"`
+	// Completed is the time at which the operator observed that the
+	// rollout completed.
+	Completed *metav1.Time `json:"completed,omitempty"`
+	// DurationSeconds is the time from Started to Completed.
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	// Outcome is empty while the rollout is in progress and one of
+	// "Completed", "Superseded", or "PreExisting" afterwards.
+	Outcome string `json:"outcome,omitempty"`
+	// Pods are the pods with the new pod template.
+	Pods []rolloutPod `json:"pods,omitempty"`
+	// OldPods are the pods with other pod templates that the rollout
+	// replaced.
+	OldPods []rolloutOldPod `json:"oldPods,omitempty"`
+}
+
+// rolloutPod records when a new router pod reached each stage of startup.
+type rolloutPod struct {
+	Name string `json:"name"`
+	Node string `json:"node,omitempty"`
+	// Created is the pod's creation time.
+	Created metav1.Time `json:"created"`
+	// Scheduled is the time at which the pod was bound to a node.
+	Scheduled *metav1.Time `json:"scheduled,omitempty"`
+	// ImagePullSeconds is the image pull time that the kubelet reported,
+	// or zero if the image was already present.
+	ImagePullSeconds *float64 `json:"imagePullSeconds,omitempty"`
+	// Started is the time at which the router container started.
+	Started *metav1.Time `json:"started,omitempty"`
+	// Ready is the time at which the pod became ready, which includes
+	// HAProxy startup and the readiness probe.
+	Ready *metav1.Time `json:"ready,omitempty"`
+}
+
+// rolloutOldPod records how long an old router pod took to terminate.
+type rolloutOldPod struct {
+	Name string `json:"name"`
+	// TerminationStarted is the pod's deletion timestamp, if the operator
+	// observed the pod while it was terminating.
+	TerminationStarted *metav1.Time `json:"terminationStarted,omitempty"`
+	// Terminated is the time at which the operator observed that the pod
+	// was gone.
+	Terminated *metav1.Time `json:"terminated,omitempty"`
+	// TerminationSeconds is the time from TerminationStarted to
+	// Terminated.
+	TerminationSeconds float64 `json:"terminationSeconds,omitempty"`
+}
+
+// rolloutEvent is an event about a rollout to be recorded on the
+// ingresscontroller.
+type rolloutEvent struct {
+	reason  string
+	message string
+}
+
+// ensureRouterRolloutTimeline updates the timeline of the current rollout of
+// the ingresscontroller's router deployment in the rollout timeline configmap
+// and then records events for the rollout's progress and observes the rollout
+// duration metric when the rollout completes.
+func (r *reconciler) ensureRouterRolloutTimeline(ic *operatorv1.IngressController, deployment *appsv1.Deployment, pods []corev1.Pod, events []corev1.Event) error {
+	name := operatorcontroller.RouterRolloutTimelineConfigMapName(ic)
+	current := &corev1.ConfigMap{}
+	haveCurrent := true
+	if err := r.client.Get(context.TODO(), name, current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		haveCurrent = false
+	}
+
+	var timelines []rolloutTimeline
+	if haveCurrent {
+		if err := json.Unmarshal([]byte(current.Data[rolloutTimelinesKey]), &timelines); err != nil {
+			log.Error(err, "failed to parse rollout timelines; discarding them", "configmap", name)
+			timelines = nil
+		}
+	}
+
+	var icPods []corev1.Pod
+	for i := range pods {
+		if pods[i].Labels[operatorcontroller.ControllerDeploymentLabel] == operatorcontroller.IngressControllerDeploymentLabel(ic) {
+			icPods = append(icPods, pods[i])
+		}
+	}
+	updated, rolloutEvents := updateRolloutTimelines(timelines, deployment, icPods, imagePullDurations(events), time.Now())
+	if len(updated) == 0 {
+		return nil
+	}
+	if !haveCurrent || !cmp.Equal(timelines, updated) {
+		if err := r.writeRouterRolloutTimelines(ic, current, haveCurrent, updated); err != nil {
+			return err
+		}
+	}
+
+	// Record events and observe the metric only once the timeline is
+	// persisted so that a failed write, which is retried on the next
+	// reconcile, does not record them twice.
+	for _, e := range rolloutEvents {
+		r.recorder.Event(ic, "Normal", e.reason, e.message)
+	}
+	if updated[0].Outcome == rolloutOutcomeCompleted && (len(timelines) == 0 || timelines[0].Outcome != rolloutOutcomeCompleted || timelines[0].TemplateHash != updated[0].TemplateHash) {
+		routerRolloutDuration.WithLabelValues(ic.Name).Observe(updated[0].DurationSeconds)
+	}
+	return nil
+}
+
+// writeRouterRolloutTimelines creates the ingresscontroller's rollout timeline
+// configmap with the given timelines, or updates the current configmap if it
+// exists.
+func (r *reconciler) writeRouterRolloutTimelines(ic *operatorv1.IngressController, current *corev1.ConfigMap, haveCurrent bool, timelines []rolloutTimeline) error {
+	name := operatorcontroller.RouterRolloutTimelineConfigMapName(ic)
+	data, err := json.MarshalIndent(timelines, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollout timelines: %w", err)
+	}
+	if !haveCurrent {
+		trueVar := true
+		desired := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.Name,
+				Namespace: name.Namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: operatorv1.GroupVersion.String(),
+					Kind:       "IngressController",
+					Name:       ic.Name,
+					UID:        ic.UID,
+					Controller: &trueVar,
+				}},
+			},
+			Data: map[string]string{rolloutTimelinesKey: string(data)},
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+		log.Info("created router rollout timeline configmap", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	modified := current.DeepCopy()
+	modified.Data = map[string]string{rolloutTimelinesKey: string(data)}
+	if err := r.client.Update(context.TODO(), modified); err != nil {
+		return fmt.Errorf("failed to update configmap %s: %w", name, err)
+	}
+	return nil
+}
+
+// updateRolloutTimelines returns the given timelines, most recent first,
+// updated with the current state of the deployment and its pods, along with
+// events for any progress since the timelines were last updated.  The pods
+// must all belong to the deployment.  The rollout is identified by the
+// pod-template-hash label of the most recently created pod.
+func updateRolloutTimelines(timelines []rolloutTimeline, deployment *appsv1.Deployment, pods []corev1.Pod, pullDurations map[string]time.Duration, now time.Time) ([]rolloutTimeline, []rolloutEvent) {
+	if len(pods) == 0 {
+		return timelines, nil
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if !pods[i].CreationTimestamp.Equal(&pods[j].CreationTimestamp) {
+			return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	hash := pods[len(pods)-1].Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	if len(hash) == 0 {
+		return timelines, nil
+	}
+
+	var events []rolloutEvent
+	timelines = append([]rolloutTimeline(nil), timelines...)
+	if len(timelines) == 0 || timelines[0].TemplateHash != hash {
+		var started metav1.Time
+		for _, pod := range pods {
+			if pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash {
+				started = pod.CreationTimestamp
+				break
+			}
+		}
+		timeline := rolloutTimeline{TemplateHash: hash, Started: started}
+		switch {
+		case len(timelines) == 0 && rolloutComplete(deployment, pods, hash):
+			timeline.Outcome = rolloutOutcomePreExisting
+		default:
+			if len(timelines) != 0 && len(timelines[0].Outcome) == 0 {
+				timelines[0].Outcome = rolloutOutcomeSuperseded
+			}
+			events = append(events, rolloutEvent{
+				reason:  "RouterRolloutStarted",
+				message: fmt.Sprintf("Rollout of router pod template %s started at %s", hash, started.UTC().Format(time.RFC3339)),
+			})
+		}
+		timelines = append([]rolloutTimeline{timeline}, timelines...)
+		if len(timelines) > maxRolloutTimelines {
+			timelines = timelines[:maxRolloutTimelines]
+		}
+	}
+
+	timeline := timelines[0]
+	if len(timeline.Outcome) != 0 {
+		return timelines, events
+	}
+	timeline.Pods = append([]rolloutPod(nil), timeline.Pods...)
+	timeline.OldPods = append([]rolloutOldPod(nil), timeline.OldPods...)
+
+	present := map[string]bool{}
+	oldPodsRemain := false
+	for _, pod := range pods {
+		present[pod.Name] = true
+		if pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] != hash {
+			oldPodsRemain = true
+			i := findRolloutOldPod(timeline.OldPods, pod.Name)
+			if i < 0 {
+				if len(timeline.OldPods) >= maxRolloutPods {
+					continue
+				}
+				timeline.OldPods = append(timeline.OldPods, rolloutOldPod{Name: pod.Name})
+				i = len(timeline.OldPods) - 1
+			}
+			if pod.DeletionTimestamp != nil && timeline.OldPods[i].TerminationStarted == nil {
+				timeline.OldPods[i].TerminationStarted = pod.DeletionTimestamp.DeepCopy()
+			}
+			continue
+		}
+
+		i := findRolloutPod(timeline.Pods, pod.Name)
+		if i < 0 {
+			if len(timeline.Pods) >= maxRolloutPods {
+				continue
+			}
+			timeline.Pods = append(timeline.Pods, rolloutPod{Name: pod.Name, Created: pod.CreationTimestamp})
+			i = len(timeline.Pods) - 1
+		}
+		entry := &timeline.Pods[i]
+		wasReady := entry.Ready != nil
+		updateRolloutPod(entry, &pod, pullDurations)
+		if !wasReady && entry.Ready != nil {
+			events = append(events, rolloutEvent{
+				reason:  "RouterRolloutPodReady",
+				message: fmt.Sprintf("Pod %s became ready %s after the rollout started (%s)", entry.Name, entry.Ready.Sub(timeline.Started.Time).Round(time.Second), entry.stages()),
+			})
+		}
+	}
+
+	nowTime := metav1.NewTime(now)
+	for i := range timeline.OldPods {
+		oldPod := &timeline.OldPods[i]
+		if present[oldPod.Name] || oldPod.Terminated != nil {
+			continue
+		}
+		oldPod.Terminated = nowTime.DeepCopy()
+		message := fmt.Sprintf("Old pod %s is gone", oldPod.Name)
+		if oldPod.TerminationStarted != nil {
+			oldPod.TerminationSeconds = now.Sub(oldPod.TerminationStarted.Time).Round(time.Second).Seconds()
+			message = fmt.Sprintf("Old pod %s terminated in about %s", oldPod.Name, time.Duration(oldPod.TerminationSeconds)*time.Second)
+		}
+		events = append(events, rolloutEvent{reason: "RouterRolloutPodTerminated", message: message})
+	}
+
+	if !oldPodsRemain && rolloutComplete(deployment, pods, hash) {
+		timeline.Outcome = rolloutOutcomeCompleted
+		timeline.Completed = nowTime.DeepCopy()
+		timeline.DurationSeconds = now.Sub(timeline.Started.Time).Round(time.Second).Seconds()
+		events = append(events, rolloutEvent{
+			reason:  "RouterRolloutCompleted",
+			message: fmt.Sprintf("Rollout of router pod template %s completed in %s", hash, time.Duration(timeline.DurationSeconds)*time.Second),
+		})
+	}
+
+	timelines[0] = timeline
+	return timelines, events
+}
+
+// rolloutComplete returns a Boolean value indicating whether the deployment
+// has observed its latest spec, every replica has the given pod template hash
+// and is available, and no pod with another pod template hash remains.
+func rolloutComplete(deployment *appsv1.Deployment, pods []corev1.Pod, hash string) bool {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas != replicas || deployment.Status.AvailableReplicas != replicas || deployment.Status.Replicas != replicas {
+		return false
+	}
+	for _, pod := range pods {
+		if pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// updateRolloutPod fills in the stages that the given pod has reached since
+// the entry was last updated.
+func updateRolloutPod(entry *rolloutPod, pod *corev1.Pod, pullDurations map[string]time.Duration) {
+	if len(pod.Spec.NodeName) != 0 {
+		entry.Node = pod.Spec.NodeName
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case corev1.PodScheduled:
+			if entry.Scheduled == nil {
+				entry.Scheduled = cond.LastTransitionTime.DeepCopy()
+			}
+		case corev1.PodReady:
+			if entry.Ready == nil {
+				entry.Ready = cond.LastTransitionTime.DeepCopy()
+			}
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "router" && status.State.Running != nil && entry.Started == nil {
+			entry.Started = status.State.Running.StartedAt.DeepCopy()
+		}
+	}
+	if d, ok := pullDurations[pod.Name]; ok && entry.ImagePullSeconds == nil {
+		seconds := d.Seconds()
+		entry.ImagePullSeconds = &seconds
+	}
+}
+
+// stages describes how long after its creation the pod reached each stage.
+func (p *rolloutPod) stages() string {
+	var stages []string
+	if p.Scheduled != nil {
+		stages = append(stages, fmt.Sprintf("scheduled after %s", p.Scheduled.Sub(p.Created.Time).Round(time.Second)))
+	}
+	if p.ImagePullSeconds != nil {
+		stages = append(stages, fmt.Sprintf("image pulled in %s", (time.Duration(*p.ImagePullSeconds*float64(time.Second))).Round(100*time.Millisecond)))
+	}
+	if p.Started != nil {
+		stages = append(stages, fmt.Sprintf("router started after %s", p.Started.Sub(p.Created.Time).Round(time.Second)))
+	}
+	if p.Ready != nil {
+		stages = append(stages, fmt.Sprintf("ready after %s", p.Ready.Sub(p.Created.Time).Round(time.Second)))
+	}
+	if len(p.Node) != 0 {
+		stages = append(stages, "node "+p.Node)
+	}
+	return strings.Join(stages, ", ")
+}
+
+func findRolloutPod(pods []rolloutPod, name string) int {
+	for i := range pods {
+		if pods[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findRolloutOldPod(pods []rolloutOldPod, name string) int {
+	for i := range pods {
+		if pods[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// imagePullDurations returns the image pull time for each pod that has a
+// "Pulled" event from the kubelet among the given events.  A pod whose image
+// was already present on its node has a pull time of zero.
+func imagePullDurations(events []corev1.Event) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	for _, event := range events {
+		if event.Reason != "Pulled" || event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		if strings.Contains(event.Message, "already present on machine") {
+			if _, ok := durations[event.InvolvedObject.Name]; !ok {
+				durations[event.InvolvedObject.Name] = 0
+			}
+			continue
+		}
+		match := pulledImageDurationRegexp.FindStringSubmatch(event.Message)
+		if match == nil {
+			continue
+		}
+		if d, err := time.ParseDuration(match[1]); err == nil {
+			durations[event.InvolvedObject.Name] += d
+		}
+	}
+	return durations
+}
+
+// DeleteRouterRolloutMetrics deletes the router rollout metrics for the given
+// ingresscontroller.
+func DeleteRouterRolloutMetrics(ic *operatorv1.IngressController) {
+	routerRolloutDuration.DeleteLabelValues(ic.Name)
+}
@@ -0,0 +1,218 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_imagePullDurations(t *testing.T) {
+	events := []corev1.Event{
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "router-a"},
+			Reason:         "Pulled",
+			Message:        `Successfully pulled image "quay.io/openshift/router:latest" in 1m2.5s (1m2.5s including waiting)`,
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "router-b"},
+			Reason:         "Pulled",
+			Message:        `Container image "quay.io/openshift/router:latest" already present on machine`,
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "router-c"},
+			Reason:         "Started",
+			Message:        "Started container router",
+		},
+	}
+	durations := imagePullDurations(events)
+	if d, ok := durations["router-a"]; !ok || d != 62500*time.Millisecond {
+		t.Errorf("expected router-a pull duration 1m2.5s, got %v", d)
+	}
+	if d, ok := durations["router-b"]; !ok || d != 0 {
+		t.Errorf("expected router-b pull duration 0, got %v (present: %t)", d, ok)
+	}
+	if _, ok := durations["router-c"]; ok {
+		t.Error("expected no pull duration for router-c")
+	}
+}
+
+func Test_updateRolloutTimelines(t *testing.T) {
+	start := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time { return metav1.NewTime(start.Add(time.Duration(seconds) * time.Second)) }
+	replicas := int32(1)
+	deployment := func(updated, available, total int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				UpdatedReplicas:   updated,
+				AvailableReplicas: available,
+				Replicas:          total,
+			},
+		}
+	}
+	pod := func(name, hash string, created metav1.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: created,
+				Labels:            map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+			},
+		}
+	}
+	oldPod := pod("router-old", "old", at(-3600))
+	reasons := func(events []rolloutEvent) []string {
+		var r []string
+		for _, e := range events {
+			r = append(r, e.reason)
+		}
+		return r
+	}
+
+	// The first observation of a deployment that has already rolled out
+	// is recorded without events.
+	timelines, events := updateRolloutTimelines(nil, deployment(1, 1, 1), []corev1.Pod{oldPod}, nil, start.Add(-time.Hour))
+	if len(timelines) != 1 || timelines[0].Outcome != rolloutOutcomePreExisting || len(events) != 0 {
+		t.Fatalf("expected a pre-existing timeline and no events, got %+v and %v", timelines, reasons(events))
+	}
+
+	// A pod with a new template hash starts a rollout.
+	newPod := pod("router-new", "new", at(0))
+	timelines, events = updateRolloutTimelines(timelines, deployment(1, 1, 2), []corev1.Pod{oldPod, newPod}, nil, start.Add(time.Second))
+	if len(timelines) != 2 || timelines[0].TemplateHash != "new" || !timelines[0].Started.Equal(&newPod.CreationTimestamp) {
+		t.Fatalf("expected a new rollout timeline, got %+v", timelines)
+	}
+	if r := reasons(events); len(r) != 1 || r[0] != "RouterRolloutStarted" {
+		t.Fatalf("expected a RouterRolloutStarted event, got %v", r)
+	}
+
+	// The new pod becomes ready, and the old pod starts terminating.
+	newPod.Spec.NodeName = "worker-0"
+	newPod.Status.Conditions = []corev1.PodCondition{
+		{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: at(1)},
+		{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: at(40)},
+	}
+	newPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "router",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: at(20)}},
+	}}
+	deletion := at(41)
+	oldPod.DeletionTimestamp = &deletion
+	pulls := map[string]time.Duration{"router-new": 15 * time.Second}
+	timelines, events = updateRolloutTimelines(timelines, deployment(1, 1, 1), []corev1.Pod{oldPod, newPod}, pulls, start.Add(41*time.Second))
+	if r := reasons(events); len(r) != 1 || r[0] != "RouterRolloutPodReady" {
+		t.Fatalf("expected a RouterRolloutPodReady event, got %v", r)
+	}
+	p := timelines[0].Pods[0]
+	if p.Node != "worker-0" || p.Scheduled == nil || p.Started == nil || p.Ready == nil || p.ImagePullSeconds == nil || *p.ImagePullSeconds != 15 {
+		t.Errorf("expected every stage of the new pod to be recorded, got %+v", p)
+	}
+	if o := timelines[0].OldPods; len(o) != 1 || o[0].TerminationStarted == nil {
+		t.Errorf("expected the old pod's termination to be recorded, got %+v", o)
+	}
+
+	// The old pod is gone, so the rollout is complete.
+	timelines, events = updateRolloutTimelines(timelines, deployment(1, 1, 1), []corev1.Pod{newPod}, pulls, start.Add(71*time.Second))
+	if r := reasons(events); len(r) != 2 || r[0] != "RouterRolloutPodTerminated" || r[1] != "RouterRolloutCompleted" {
+		t.Fatalf("expected RouterRolloutPodTerminated and RouterRolloutCompleted events, got %v", r)
+	}
+	if timelines[0].Outcome != rolloutOutcomeCompleted || timelines[0].DurationSeconds != 71 || timelines[0].OldPods[0].TerminationSeconds != 30 {
+		t.Errorf("expected a completed rollout of 71s with a 30s termination, got %+v", timelines[0])
+	}
+
+	// Further updates do not change a completed rollout.
+	if again, events := updateRolloutTimelines(timelines, deployment(1, 1, 1), []corev1.Pod{newPod}, pulls, start.Add(time.Hour)); len(events) != 0 || again[0].DurationSeconds != 71 {
+		t.Errorf("expected no change to a completed rollout, got %+v and %v", again[0], reasons(events))
+	}
+
+	// The number of recorded rollouts is bounded.
+	for i := 0; i < 2*maxRolloutTimelines; i++ {
+		p := pod("router-x", string(rune('a'+i)), at(100+i))
+		timelines, _ = updateRolloutTimelines(timelines, deployment(0, 0, 1), []corev1.Pod{p}, nil, start.Add(time.Duration(100+i)*time.Second))
+	}
+	if len(timelines) != maxRolloutTimelines || timelines[1].Outcome != rolloutOutcomeSuperseded {
+		t.Errorf("expected %d timelines with superseded rollouts, got %d: %+v", maxRolloutTimelines, len(timelines), timelines[1])
+	}
+}
+
+// Test_ensureRouterRolloutTimeline verifies that rollout events are recorded
+// only after the timeline is persisted, so that a failed write, which is
+// retried, does not record them twice.
+func Test_ensureRouterRolloutTimeline(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "openshift-ingress-operator"},
+	}
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1, Replicas: 1},
+	}
+	pod := func(name, hash string, created time.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+				Labels: map[string]string{
+					appsv1.DefaultDeploymentUniqueLabelKey:       hash,
+					operatorcontroller.ControllerDeploymentLabel: operatorcontroller.IngressControllerDeploymentLabel(ic),
+				},
+			},
+		}
+	}
+	oldPod := pod("router-old", "old", time.Now().Add(-time.Hour))
+	newPod := pod("router-new", "new", time.Now())
+
+	failUpdates := true
+	cl := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if failUpdates {
+				return fmt.Errorf("conflict")
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{client: cl, recorder: recorder}
+
+	// The first observation creates the configmap without events.
+	if err := r.ensureRouterRolloutTimeline(ic, deployment, []corev1.Pod{oldPod}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no events, got %d", len(recorder.Events))
+	}
+
+	// A new rollout starts, but the timeline cannot be persisted.
+	deployment.Status.Replicas = 2
+	if err := r.ensureRouterRolloutTimeline(ic, deployment, []corev1.Pod{oldPod, newPod}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no events after a failed write, got %d", len(recorder.Events))
+	}
+
+	// The retry persists the timeline and records the event once.
+	failUpdates = false
+	if err := r.ensureRouterRolloutTimeline(ic, deployment, []corev1.Pod{oldPod, newPod}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(recorder.Events))
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Normal RouterRolloutStarted ") {
+		t.Errorf("expected a RouterRolloutStarted event, got %q", e)
+	}
+}
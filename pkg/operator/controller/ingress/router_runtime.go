@@ -0,0 +1,244 @@
+package ingress
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RouterRuntimeSocketPath is the path of HAProxy's runtime API socket
+	// in the router container.
+	RouterRuntimeSocketPath = "/var/lib/haproxy/run/haproxy.sock"
+
+	// RouterRuntimeServersStateCommand is the HAProxy runtime API command
+	// that lists the servers of every backend.  Its output is the servers
+	// state that RouterRuntimeOperation.Commands expects.
+	RouterRuntimeServersStateCommand = "show servers state"
+)
+
+// routerRuntimeNameRegexp matches the names of HAProxy backends and servers
+// that the router generates, such as "be_secure:ns:name" and
+// "pod:name:svc:https:10.128.0.5:8443".  Notably, it does not match
+// whitespace or ";", which would allow a name to inject additional runtime API
+// commands.
+var routerRuntimeNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:\[\]-]+$`)
+
+// routeBackendPrefixes are the prefixes of the names of the HAProxy backends
+// that the router generates for a route, which are followed by
+// ":<namespace>:<name>".
+var routeBackendPrefixes = []string{"be_http", "be_edge_http", "be_secure", "be_tcp"}
+
+// RouterRuntimeOperation is an operation that the "router-ctl" subcommand
+// performs on routers using HAProxy's runtime API.
+type RouterRuntimeOperation struct {
+	// Usage describes the operation's argument, if it has one.
+	Usage string
+	// Description describes the operation.
+	Description string
+	// ReadOnly indicates whether the operation only queries HAProxy.
+	ReadOnly bool
+	// NeedsServersState indicates whether the operation's commands depend
+	// on the output of the "show servers state" command.
+	NeedsServersState bool
+
+	// commands returns the runtime API commands for the operation given
+	// its arguments and, if NeedsServersState is true, the output of the
+	// "show servers state" command.
+	commands func(args []string, serversState string) ([]string, error)
+}
+
+// RouterRuntimeOperations is the vetted set of runtime API operations that the
+// "router-ctl" subcommand allows, keyed by operation name.
+var RouterRuntimeOperations = map[string]RouterRuntimeOperation{
+	"show-info": {
+		Description: "show HAProxy process information",
+		ReadOnly:    true,
+		commands:    fixedRouterRuntimeCommand("show info"),
+	},
+	"show-stat": {
+		Description: "show statistics for frontends, backends, and servers in CSV format",
+		ReadOnly:    true,
+		commands:    fixedRouterRuntimeCommand("show stat"),
+	},
+	"show-sessions": {
+		Description: "dump the current sessions",
+		ReadOnly:    true,
+		commands:    fixedRouterRuntimeCommand("show sess"),
+	},
+	"show-servers": {
+		Usage:       "[<backend>]",
+		Description: "show the state of the servers of every backend or of the given backend",
+		ReadOnly:    true,
+		commands: func(args []string, _ string) ([]string, error) {
+			switch len(args) {
+			case 0:
+				return []string{RouterRuntimeServersStateCommand}, nil
+			case 1:
+				if !routerRuntimeNameRegexp.MatchString(args[0]) {
+					return nil, fmt.Errorf("invalid backend name: %q", args[0])
+				}
+				return []string{RouterRuntimeServersStateCommand + " " + args[0]}, nil
+			default:
+				return nil, fmt.Errorf("expected at most 1 argument, got %d", len(args))
+			}
+		},
+	},
+	"drain-server": {
+		Usage:       "<backend>/<server>",
+		Description: "stop sending new connections to the given server while letting existing connections finish",
+		commands:    setServerStateCommand("drain"),
+	},
+	"disable-server": {
+		Usage:       "<backend>/<server>",
+		Description: "put the given server in maintenance mode",
+		commands:    setServerStateCommand("maint"),
+	},
+	"enable-server": {
+		Usage:       "<backend>/<server>",
+		Description: "return the given server to the ready state",
+		commands:    setServerStateCommand("ready"),
+	},
+	"disable-route": {
+		Usage:             "<namespace>/<route>",
+		Description:       "put every server of the given route's backends in maintenance mode",
+		NeedsServersState: true,
+		commands:          setRouteStateCommands("maint"),
+	},
+	"enable-route": {
+		Usage:             "<namespace>/<route>",
+		Description:       "return every server of the given route's backends to the ready state",
+		NeedsServersState: true,
+		commands:          setRouteStateCommands("ready"),
+	},
+}
+
+// Validate returns an error if the given arguments are not valid for the
+// operation.
+func (op RouterRuntimeOperation) Validate(args []string) error {
+	// Operations that need the servers state cannot find any servers in
+	// an empty state, so ignore that error.
+	_, err := op.commands(args, "")
+	if _, ok := err.(*routerRuntimeNoServersError); ok {
+		return nil
+	}
+	return err
+}
+
+// Commands returns the HAProxy runtime API commands that perform the operation
+// with the given arguments.  If the operation's NeedsServersState field is
+// true, serversState must be the output of the "show servers state" command
+// from the router on which the commands are to be run.
+func (op RouterRuntimeOperation) Commands(args []string, serversState string) ([]string, error) {
+	return op.commands(args, serversState)
+}
+
+// routerRuntimeNoServersError indicates that a route has no servers in the
+// servers state.
+type routerRuntimeNoServersError struct {
+	route string
+}
+
+func (e *routerRuntimeNoServersError) Error() string {
+	return fmt.Sprintf("no backend servers found for route %s", e.route)
+}
+
+// fixedRouterRuntimeCommand returns a commands function for an operation that
+// takes no arguments and runs the given command.
+func fixedRouterRuntimeCommand(command string) func([]string, string) ([]string, error) {
+	return func(args []string, _ string) ([]string, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected no arguments, got %d", len(args))
+		}
+		return []string{command}, nil
+	}
+}
+
+// setServerStateCommand returns a commands function for an operation that sets
+// the state of the server named by a "<backend>/<server>" argument.
+func setServerStateCommand(state string) func([]string, string) ([]string, error) {
+	return func(args []string, _ string) ([]string, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		backend, server, ok := strings.Cut(args[0], "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q: expected <backend>/<server>", args[0])
+		}
+		if !routerRuntimeNameRegexp.MatchString(backend) {
+			return nil, fmt.Errorf("invalid backend name: %q", backend)
+		}
+		if !routerRuntimeNameRegexp.MatchString(server) {
+			return nil, fmt.Errorf("invalid server name: %q", server)
+		}
+		return []string{fmt.Sprintf("set server %s/%s state %s", backend, server, state)}, nil
+	}
+}
+
+// setRouteStateCommands returns a commands function for an operation that sets
+// the state of every server in the backends for the route named by a
+// "<namespace>/<route>" argument.
+func setRouteStateCommands(state string) func([]string, string) ([]string, error) {
+	return func(args []string, serversState string) ([]string, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		namespace, name, ok := strings.Cut(args[0], "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q: expected <namespace>/<route>", args[0])
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid route name %q: %s", name, strings.Join(errs, ", "))
+		}
+		backends := map[string]struct{}{}
+		for _, prefix := range routeBackendPrefixes {
+			backends[fmt.Sprintf("%s:%s:%s", prefix, namespace, name)] = struct{}{}
+		}
+		var commands []string
+		for _, s := range parseServersState(serversState) {
+			if _, ok := backends[s.backend]; !ok {
+				continue
+			}
+			if !routerRuntimeNameRegexp.MatchString(s.server) {
+				return nil, fmt.Errorf("invalid server name in backend %s: %q", s.backend, s.server)
+			}
+			commands = append(commands, fmt.Sprintf("set server %s/%s state %s", s.backend, s.server, state))
+		}
+		if len(commands) == 0 {
+			return nil, &routerRuntimeNoServersError{route: args[0]}
+		}
+		sort.Strings(commands)
+		return commands, nil
+	}
+}
+
+// backendServer is a server in an HAProxy backend.
+type backendServer struct {
+	backend string
+	server  string
+}
+
+// parseServersState parses the output of the "show servers state" command,
+// which is a version line followed by a header comment and a line for each
+// server of the form "<be_id> <be_name> <srv_id> <srv_name> ...".
+func parseServersState(serversState string) []backendServer {
+	var servers []backendServer
+	for i, line := range strings.Split(serversState, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 || len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		servers = append(servers, backendServer{backend: fields[1], server: fields[3]})
+	}
+	return servers
+}
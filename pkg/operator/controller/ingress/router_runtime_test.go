@@ -0,0 +1,119 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_RouterRuntimeOperations(t *testing.T) {
+	serversState := `1
+# be_id be_name srv_id srv_name srv_addr srv_op_state srv_admin_state srv_uweight srv_iweight srv_time_since_last_change srv_check_status srv_check_result srv_check_health srv_check_state srv_agent_state bk_f_forced_id srv_f_forced_id srv_fqdn srv_port srvrecord
+5 be_http:app:web 1 pod:web-1:web:http:10.128.0.5:8080 10.128.0.5 2 0 256 256 30 6 3 4 6 0 0 0 - 8080 -
+5 be_http:app:web 2 pod:web-2:web:http:10.129.0.7:8080 10.129.0.7 2 0 256 256 30 6 3 4 6 0 0 0 - 8080 -
+6 be_secure:app:web-secure 1 pod:web-1:web:https:10.128.0.5:8443 10.128.0.5 2 0 256 256 30 6 3 4 6 0 0 0 - 8443 -
+7 be_http:other:web 1 pod:web-3:web:http:10.130.0.2:8080 10.130.0.2 2 0 256 256 30 6 3 4 6 0 0 0 - 8080 -
+`
+
+	testCases := []struct {
+		description string
+		operation   string
+		args        []string
+		expect      []string
+		expectError bool
+		expectValid bool
+	}{
+		{
+			description: "show info",
+			operation:   "show-info",
+			expect:      []string{"show info"},
+			expectValid: true,
+		},
+		{
+			description: "show info with an argument",
+			operation:   "show-info",
+			args:        []string{"extra"},
+			expectError: true,
+		},
+		{
+			description: "show servers of a backend",
+			operation:   "show-servers",
+			args:        []string{"be_http:app:web"},
+			expect:      []string{"show servers state be_http:app:web"},
+			expectValid: true,
+		},
+		{
+			description: "drain a server",
+			operation:   "drain-server",
+			args:        []string{"be_http:app:web/pod:web-1:web:http:10.128.0.5:8080"},
+			expect:      []string{"set server be_http:app:web/pod:web-1:web:http:10.128.0.5:8080 state drain"},
+			expectValid: true,
+		},
+		{
+			description: "server name that injects a command",
+			operation:   "disable-server",
+			args:        []string{"be_http:app:web/web-1;shutdown frontend public"},
+			expectError: true,
+		},
+		{
+			description: "server without a backend",
+			operation:   "enable-server",
+			args:        []string{"web-1"},
+			expectError: true,
+		},
+		{
+			description: "disable a route",
+			operation:   "disable-route",
+			args:        []string{"app/web"},
+			expect: []string{
+				"set server be_http:app:web/pod:web-1:web:http:10.128.0.5:8080 state maint",
+				"set server be_http:app:web/pod:web-2:web:http:10.129.0.7:8080 state maint",
+			},
+			expectValid: true,
+		},
+		{
+			description: "enable a route with a secure backend",
+			operation:   "enable-route",
+			args:        []string{"app/web-secure"},
+			expect:      []string{"set server be_secure:app:web-secure/pod:web-1:web:https:10.128.0.5:8443 state ready"},
+			expectValid: true,
+		},
+		{
+			description: "route without servers",
+			operation:   "disable-route",
+			args:        []string{"app/missing"},
+			expectError: true,
+			expectValid: true,
+		},
+		{
+			description: "invalid route namespace",
+			operation:   "disable-route",
+			args:        []string{"App;x/web"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			op, ok := RouterRuntimeOperations[tc.operation]
+			if !ok {
+				t.Fatalf("unknown operation %q", tc.operation)
+			}
+			if err := op.Validate(tc.args); (err == nil) != tc.expectValid {
+				t.Errorf("expected valid %t, got error %v", tc.expectValid, err)
+			}
+			commands, err := op.Commands(tc.args, serversState)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got commands %q", commands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.expect, commands) {
+				t.Errorf("expected commands %q, got %q", tc.expect, commands)
+			}
+		})
+	}
+}
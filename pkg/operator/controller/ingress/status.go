@@ -89,6 +89,46 @@ func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressControlle
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, degradedCondition)
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeIngressUpgradeableCondition(ic, deploymentRef, service, platformStatus, secret))
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeIngressEvaluationConditionsDetectedCondition(ic, service))
+	if httpHardeningCondition := computeHTTPHardeningCondition(ic); httpHardeningCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *httpHardeningCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerHTTPHardeningConditionType)
+	}
+	if forwardedHeaderPolicyCondition := computeForwardedHeaderPolicyCondition(ic); forwardedHeaderPolicyCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *forwardedHeaderPolicyCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerForwardedHeaderPolicyConditionType)
+	}
+	if clientTLSScopesCondition := computeClientTLSScopesCondition(ic); clientTLSScopesCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *clientTLSScopesCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerClientTLSScopesConditionType)
+	}
+	// The canary controller sets the load balancer probe condition only on
+	// ingresscontrollers that have a DNS failover standby.
+	if _, ok := ic.Annotations[DNSFailoverStandbyAnnotation]; !ok {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerLoadBalancerProbeSuccessConditionType)
+	}
+	if httpsOnlyCondition := computeHTTPSOnlyCondition(ic); httpsOnlyCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *httpsOnlyCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerHTTPSOnlyConditionType)
+	}
+	if geoIPCondition := r.computeGeoIPDatabaseCondition(ic, deployment, time.Now()); geoIPCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *geoIPCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerGeoIPDatabaseReadyConditionType)
+	}
+	if bindAddressCondition := computeBindAddressCondition(ic); bindAddressCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *bindAddressCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerBindAddressConditionType)
+	}
+	if prePullCondition := r.computeRouterImagePrePullCondition(ic, time.Now()); prePullCondition != nil {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, *prePullCondition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerRouterImagePrePulledConditionType)
+	}
 
 	updated.Status.Conditions = PruneConditions(updated.Status.Conditions)
 
@@ -161,6 +201,17 @@ func PruneConditions(conditions []operatorv1.OperatorCondition) []operatorv1.Ope
 	return conditions
 }
 
+// removeCondition returns the given conditions without any condition of the
+// given type.
+func removeCondition(conditions []operatorv1.OperatorCondition, conditionType string) []operatorv1.OperatorCondition {
+	for i, condition := range conditions {
+		if condition.Type == conditionType {
+			return append(conditions[:i:i], conditions[i+1:]...)
+		}
+	}
+	return conditions
+}
+
 // computeIngressTLSProfile computes the ingresscontroller's current TLS
 // profile.  If the deployment is ready, then the TLS profile is inferred from
 // deployment's pod template spec.  Otherwise the previous TLS profile is used.
@@ -583,9 +634,28 @@ func computeIngressDegradedCondition(conditions []operatorv1.OperatorCondition,
 		},
 	}
 
+	// An ingresscontroller that has a DNS failover standby has the load
+	// balancer probe status condition.  The canary checks resolve the
+	// ingresscontroller's domain, which points at the standby while the
+	// ingresscontroller is failed over, so check the load balancer probes
+	// instead of the canary checks.
+	haveLoadBalancerProbes := false
+	for _, cond := range conditions {
+		if cond.Type == IngressControllerLoadBalancerProbeSuccessConditionType {
+			haveLoadBalancerProbes = true
+		}
+	}
+	if haveLoadBalancerProbes {
+		expectedConditions = append(expectedConditions, expectedCondition{
+			condition:   IngressControllerLoadBalancerProbeSuccessConditionType,
+			status:      operatorv1.ConditionTrue,
+			gracePeriod: time.Second * 60,
+		})
+	}
+
 	// Only check the default ingress controller for the canary
 	// success status condition.
-	if icName == manifests.DefaultIngressControllerName {
+	if icName == manifests.DefaultIngressControllerName && !haveLoadBalancerProbes {
 		canaryCond := struct {
 			condition        string
 			status           operatorv1.ConditionStatus
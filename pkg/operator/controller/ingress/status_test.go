@@ -465,6 +465,26 @@ func Test_computeIngressDegradedCondition(t *testing.T) {
 			expectRequeue:               false,
 			icName:                      "default",
 		},
+		{
+			name: "load balancer probes failing",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerLoadBalancerProbeSuccessConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Second*-61)),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectRequeue:               true,
+			icName:                      "primary",
+			expectAfter:                 time.Minute,
+		},
+		{
+			name: "default ingress controller with load balancer probes, canary check failing",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerCanaryCheckSuccessConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Second*-61)),
+				cond(IngressControllerLoadBalancerProbeSuccessConditionType, operatorv1.ConditionTrue, "", clock.Now().Add(time.Minute*-1)),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionFalse,
+			expectRequeue:               false,
+			icName:                      "default",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -0,0 +1,68 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+)
+
+// AWSNLBZonalDNSAnnotation is an annotation on an ingresscontroller that, if
+// set to "true", requests that the operator publish a wildcard DNS record for
+// each availability zone of the ingresscontroller's AWS network load balancer,
+// in addition to the wildcard record for the load balancer's regional DNS name.
+// For the domain "apps.example.com" and the zone "us-east-1a", the record is
+// "*.us-east-1a.apps.example.com", and it points to the load balancer's DNS
+// name for that zone.  The annotation has no effect unless the
+// ingresscontroller uses an AWS network load balancer and its DNS records are
+// managed.  The DNS controller reports the zonal records on the wildcard
+// DNSRecord's status.
+const AWSNLBZonalDNSAnnotation = "ingress.operator.openshift.io/aws-nlb-zonal-dns"
+
+// zonalDNSEnabledForIngressController returns a Boolean value indicating
+// whether the given ingresscontroller's annotation requests zonal DNS records.
+func zonalDNSEnabledForIngressController(ic *operatorv1.IngressController) (bool, error) {
+	switch value, ok := ic.Annotations[AWSNLBZonalDNSAnnotation]; {
+	case !ok, value == "false":
+		return false, nil
+	case value == "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for annotation %s: %q is not \"true\" or \"false\"", AWSNLBZonalDNSAnnotation, value)
+	}
+}
+
+// validateAWSNLBZonalDNS validates the ingresscontroller's zonal DNS
+// annotation.
+func validateAWSNLBZonalDNS(ic *operatorv1.IngressController) error {
+	_, err := zonalDNSEnabledForIngressController(ic)
+	return err
+}
+
+// usesAWSNLB returns a Boolean value indicating whether the given
+// ingresscontroller's status indicates that it uses an AWS network load
+// balancer.
+func usesAWSNLB(ic *operatorv1.IngressController) bool {
+	eps := ic.Status.EndpointPublishingStrategy
+	return eps != nil &&
+		eps.Type == operatorv1.LoadBalancerServiceStrategyType &&
+		eps.LoadBalancer != nil &&
+		eps.LoadBalancer.ProviderParameters != nil &&
+		eps.LoadBalancer.ProviderParameters.Type == operatorv1.AWSLoadBalancerProvider &&
+		eps.LoadBalancer.ProviderParameters.AWS != nil &&
+		eps.LoadBalancer.ProviderParameters.AWS.Type == operatorv1.AWSNetworkLoadBalancer
+}
+
+// ensureWildcardDNSRecordZonalRecords ensures that the given wildcard
+// DNSRecord requests zonal records if the ingresscontroller's annotation
+// requests them and the ingresscontroller uses an AWS network load balancer.
+// The DNS controller publishes the records.
+func (r *reconciler) ensureWildcardDNSRecordZonalRecords(ic *operatorv1.IngressController, record *iov1.DNSRecord) error {
+	enabled, err := zonalDNSEnabledForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	_, err = dnsrecord.EnsureDNSRecordZonalRecords(r.client, record, enabled && usesAWSNLB(ic))
+	return err
+}
@@ -0,0 +1,115 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_ensureWildcardDNSRecordZonalRecords(t *testing.T) {
+	nlb := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSNetworkLoadBalancer},
+			},
+		},
+	}
+	clb := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSClassicLoadBalancer},
+			},
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		annotation       string
+		eps              *operatorv1.EndpointPublishingStrategy
+		recordAnnotated  bool
+		expectError      bool
+		expectAnnotation bool
+	}{
+		{
+			description: "no annotation",
+			eps:         nlb,
+		},
+		{
+			description:      "enabled with an NLB",
+			annotation:       "true",
+			eps:              nlb,
+			expectAnnotation: true,
+		},
+		{
+			description:     "enabled with a classic load balancer",
+			annotation:      "true",
+			eps:             clb,
+			recordAnnotated: true,
+		},
+		{
+			description:     "disabled",
+			annotation:      "false",
+			eps:             nlb,
+			recordAnnotated: true,
+		},
+		{
+			description: "invalid annotation",
+			annotation:  "yes",
+			eps:         nlb,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{}},
+				Status:     operatorv1.IngressControllerStatus{EndpointPublishingStrategy: tc.eps},
+			}
+			if len(tc.annotation) != 0 {
+				ic.Annotations[AWSNLBZonalDNSAnnotation] = tc.annotation
+			}
+			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default-wildcard"},
+			}
+			if tc.recordAnnotated {
+				record.Annotations = map[string]string{dnsrecord.ZonalRecordsAnnotation: "true"}
+			}
+			scheme := runtime.NewScheme()
+			iov1.AddToScheme(scheme)
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(record).Build()
+			r := &reconciler{client: cl}
+
+			err := r.ensureWildcardDNSRecordZonalRecords(ic, record)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var current iov1.DNSRecord
+			if err := cl.Get(context.Background(), types.NamespacedName{Namespace: record.Namespace, Name: record.Name}, &current); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := current.Annotations[dnsrecord.ZonalRecordsAnnotation]; ok != tc.expectAnnotation {
+				t.Errorf("expected zonal records annotation to be present: %t, got annotations %v", tc.expectAnnotation, current.Annotations)
+			}
+		})
+	}
+}
@@ -81,6 +81,15 @@ func FeatureGateClusterConfigName() types.NamespacedName {
 	}
 }
 
+// RouterImagePrePullDaemonSetName returns the namespaced name for the daemonset
+// that pre-pulls a new router image for the given ingresscontroller.
+func RouterImagePrePullDaemonSetName(ci *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultOperandNamespace,
+		Name:      "router-prepull-" + ci.Name,
+	}
+}
+
 // RouterDeploymentName returns the namespaced name for the router deployment.
 func RouterDeploymentName(ci *operatorv1.IngressController) types.NamespacedName {
 	return types.NamespacedName{
@@ -129,6 +138,26 @@ func RouterOperatorGeneratedDefaultCertificateSecretName(ci *operatorv1.IngressC
 	}
 }
 
+// RouterOperatorGeneratedDefaultCertificateNextKeySecretName returns the
+// namespaced name for the secret that holds the pre-generated key pair onto which
+// the operator-generated router default certificate will next be rotated.
+func RouterOperatorGeneratedDefaultCertificateNextKeySecretName(ci *operatorv1.IngressController, namespace string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: namespace,
+		Name:      fmt.Sprintf("router-certs-%s-next-key", ci.Name),
+	}
+}
+
+// DefaultCertificatePinsConfigMapName returns the namespaced name for the
+// configmap that publishes the SPKI pins of the ingresscontroller's
+// operator-generated default certificate's current and next keys.
+func DefaultCertificatePinsConfigMapName(ingressControllerName string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: GlobalMachineSpecifiedConfigNamespace,
+		Name:      fmt.Sprintf("%s-ingress-cert-pins", ingressControllerName),
+	}
+}
+
 // ClientCAConfigMapName returns the namespaced name for the operator-managed
 // client CA configmap, which is a copy of the user-managed configmap from the
 // openshift-config namespace.
@@ -139,6 +168,16 @@ func ClientCAConfigMapName(ic *operatorv1.IngressController) types.NamespacedNam
 	}
 }
 
+// ClientTLSScopesConfigMapName returns the namespaced name for the
+// operator-managed configmap with the router's client TLS scopes configuration
+// and the scopes' client CA bundles.
+func ClientTLSScopesConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultOperandNamespace,
+		Name:      "router-client-tls-scopes-" + ic.Name,
+	}
+}
+
 // CRLConfigMapName returns the namespaced name for the CRL configmap.
 func CRLConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
 	return types.NamespacedName{
@@ -222,6 +261,12 @@ func NodePortServiceName(ic *operatorv1.IngressController) types.NamespacedName
 	return types.NamespacedName{Namespace: DefaultOperandNamespace, Name: "router-nodeport-" + ic.Name}
 }
 
+// NodePortInventoryConfigMapName returns the namespaced name for the configmap
+// with the backend inventory of the given ingresscontroller's NodePort service.
+func NodePortInventoryConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{Namespace: DefaultOperandNamespace, Name: "router-nodeport-inventory-" + ic.Name}
+}
+
 func WildcardDNSRecordName(ic *operatorv1.IngressController) types.NamespacedName {
 	return types.NamespacedName{
 		Namespace: ic.Namespace,
@@ -258,6 +303,27 @@ func CanaryRouteName() types.NamespacedName {
 	}
 }
 
+// CanaryComparisonReportConfigMapName returns the namespaced name for the
+// configmap in which the canary controller reports the results of comparing
+// routes served by the given ingresscontroller with those served by another
+// ingresscontroller.
+func CanaryComparisonReportConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: ic.Namespace,
+		Name:      "canary-comparison-" + ic.Name,
+	}
+}
+
+// RouterRolloutTimelineConfigMapName returns the namespaced name for the
+// configmap in which the ingress controller records the timelines of the most
+// recent rollouts of the given ingresscontroller's router deployment.
+func RouterRolloutTimelineConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: ic.Namespace,
+		Name:      "router-rollouts-" + ic.Name,
+	}
+}
+
 func IngressClassName(ingressControllerName string) types.NamespacedName {
 	return types.NamespacedName{Name: "openshift-" + ingressControllerName}
 }
@@ -17,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -53,7 +54,11 @@ func New(mgr manager.Manager, namespace string) (controller.Controller, error) {
 	reconciler := &reconciler{
 		cache:            newCache,
 		namespace:        namespace,
+		recorder:         mgr.GetEventRecorderFor(controllerName),
 		routeToIngresses: make(map[types.NamespacedName]sets.String),
+		quotaNamespaces:  make(map[string]sets.String),
+		routesOverQuota:  make(map[string]sets.String),
+		httpsOnlyRoutes:  make(map[string]map[string]string),
 	}
 	c, err := controller.New(controllerName, mgr, controller.Options{
 		Reconciler: reconciler,
@@ -148,8 +153,19 @@ func (r *reconciler) routeToIngressController(context context.Context, obj clien
 type reconciler struct {
 	cache     cache.Cache
 	namespace string
+	recorder  record.EventRecorder
 	// routeToIngresses stores the Ingress Controllers that have admitted a given route.
 	routeToIngresses map[types.NamespacedName]sets.String
+	// quotaNamespaces stores, for each Ingress Controller with a route quota, the
+	// namespaces for which route quota metrics are reported.
+	quotaNamespaces map[string]sets.String
+	// routesOverQuota stores, for each Ingress Controller with a route quota, the
+	// routes that exceed the quota, as "namespace/name" strings.
+	routesOverQuota map[string]sets.String
+	// httpsOnlyRoutes stores, for each Ingress Controller in HTTPS-only mode,
+	// the effect of HTTPS-only mode on each affected route, keyed by
+	// "namespace/name" strings.
+	httpsOnlyRoutes map[string]map[string]string
 }
 
 // Reconcile expects request to refer to an Ingress Controller resource, and will do all the work to gather metrics related to
@@ -222,6 +238,10 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	// Variable to store the number of routes admitted by the Shard (Ingress Controller).
 	routesAdmitted := 0
+	// Store the admitted routes by namespace for the route quota.
+	routesByNamespace := map[string][]routev1.Route{}
+	// Store the admitted routes for HTTPS-only mode.
+	var routesAdmittedList []routev1.Route
 
 	// Iterate through the list Routes.
 	for _, route := range routeList.Items {
@@ -230,12 +250,32 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		if namespacesSet.Has(route.Namespace) && routeStatusAdmitted(route, ingressController.Name) {
 			// If the Route is admitted then, the routesAdmitted should be incremented by 1 for the Shard.
 			routesAdmitted++
+			routesByNamespace[route.Namespace] = append(routesByNamespace[route.Namespace], route)
+			routesAdmittedList = append(routesAdmittedList, route)
 		}
 	}
 
 	// Set the value of the metric to the number of routesAdmitted for the corresponding Shard (Ingress Controller).
 	SetRouteMetricsControllerRoutesPerShardMetric(request.Name, float64(routesAdmitted))
 
+	// Report the usage of the route quota, if the Ingress Controller has one.  An
+	// invalid quota causes the Ingress Controller to be rejected, so it is ignored
+	// here.
+	quota, err := routeQuotaForIngressController(ingressController)
+	if err != nil {
+		log.Error(err, "ingresscontroller has an invalid route quota", "ingresscontroller", ingressController.Name)
+		quota = nil
+	}
+	r.reportRouteQuota(ingressController, quota, routesByNamespace)
+
+	// Report the routes that HTTPS-only mode affects, if the Ingress Controller
+	// is in HTTPS-only mode.
+	httpsOnly, err := HTTPSOnlyForIngressController(ingressController)
+	if err != nil {
+		log.Error(err, "ingresscontroller has an invalid HTTPS-only annotation", "ingresscontroller", ingressController.Name)
+	}
+	r.reportHTTPSOnly(ingressController, httpsOnly, routesAdmittedList)
+
 	return reconcile.Result{}, nil
 }
 
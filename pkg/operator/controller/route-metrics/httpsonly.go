@@ -0,0 +1,118 @@
+package routemetrics
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+const (
+	// HTTPSOnlyAnnotation is an annotation on an ingresscontroller that, if
+	// set to "true", puts the ingresscontroller in HTTPS-only mode.  In
+	// HTTPS-only mode, the routers are to redirect insecure requests for
+	// every edge-terminated and reencrypt route to HTTPS, regardless of the
+	// route's insecureEdgeTerminationPolicy, and refuse to serve routes
+	// without TLS.  Routes with the HTTPSOnlyExemptLabel label are served
+	// as they specify.
+	//
+	// The router image does not enforce HTTPS-only mode yet, so the
+	// operator only reports the routes that HTTPS-only mode would affect.
+	HTTPSOnlyAnnotation = "ingress.operator.openshift.io/https-only"
+	// HTTPSOnlyExemptLabel is a label that, if set to "true" on a route,
+	// exempts the route from HTTPS-only mode.
+	HTTPSOnlyExemptLabel = "ingress.operator.openshift.io/https-only-exempt"
+
+	// httpsOnlyRedirected is the effect of HTTPS-only mode on a route
+	// whose insecure requests are redirected to HTTPS but would otherwise
+	// be served or rejected.
+	httpsOnlyRedirected = "redirected"
+	// httpsOnlyRefused is the effect of HTTPS-only mode on a route without
+	// TLS, which the routers would refuse to serve.
+	httpsOnlyRefused = "refused"
+	// httpsOnlyExempt is the effect of HTTPS-only mode on a route that has
+	// the HTTPSOnlyExemptLabel label.
+	httpsOnlyExempt = "exempt"
+)
+
+// HTTPSOnlyForIngressController returns a Boolean value indicating whether the
+// given ingresscontroller's annotation enables HTTPS-only mode.
+func HTTPSOnlyForIngressController(ic *operatorv1.IngressController) (bool, error) {
+	switch value, ok := ic.Annotations[HTTPSOnlyAnnotation]; {
+	case !ok, value == "false":
+		return false, nil
+	case value == "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for annotation %s: %q is not \"true\" or \"false\"", HTTPSOnlyAnnotation, value)
+	}
+}
+
+// ValidateHTTPSOnly validates the ingresscontroller's HTTPS-only annotation.
+func ValidateHTTPSOnly(ic *operatorv1.IngressController) error {
+	_, err := HTTPSOnlyForIngressController(ic)
+	return err
+}
+
+// httpsOnlyEffect returns the effect that HTTPS-only mode has on the given
+// route, or the empty string if the route is already served only over HTTPS.
+func httpsOnlyEffect(route *routev1.Route) string {
+	if route.Labels[HTTPSOnlyExemptLabel] == "true" {
+		return httpsOnlyExempt
+	}
+	tls := route.Spec.TLS
+	if tls == nil {
+		return httpsOnlyRefused
+	}
+	switch tls.Termination {
+	case routev1.TLSTerminationEdge, routev1.TLSTerminationReencrypt:
+		if tls.InsecureEdgeTerminationPolicy != routev1.InsecureEdgeTerminationPolicyRedirect {
+			return httpsOnlyRedirected
+		}
+	}
+	return ""
+}
+
+// reportHTTPSOnly sets the metrics for the routes that HTTPS-only mode would
+// affect on the given ingresscontroller and records an event on each route
+// whose treatment under HTTPS-only mode has changed.  Because the router image
+// does not enforce HTTPS-only mode yet, the events say what would happen once
+// it does rather than what the routers do now.  If HTTPS-only mode is not
+// enabled, the metrics are deleted.
+func (r *reconciler) reportHTTPSOnly(ic *operatorv1.IngressController, enabled bool, routes []routev1.Route) {
+	if !enabled {
+		DeleteHTTPSOnlyMetrics(ic.Name)
+		delete(r.httpsOnlyRoutes, ic.Name)
+		return
+	}
+
+	counts := map[string]int{httpsOnlyRedirected: 0, httpsOnlyRefused: 0, httpsOnlyExempt: 0}
+	previous := r.httpsOnlyRoutes[ic.Name]
+	current := map[string]string{}
+	for i := range routes {
+		route := &routes[i]
+		effect := httpsOnlyEffect(route)
+		if len(effect) == 0 {
+			continue
+		}
+		counts[effect]++
+		key := route.Namespace + "/" + route.Name
+		current[key] = effect
+		if previous[key] == effect {
+			continue
+		}
+		log.Info("route would be affected by HTTPS-only mode once the router enforces it", "ingresscontroller", ic.Name, "namespace", route.Namespace, "route", route.Name, "effect", effect)
+		switch effect {
+		case httpsOnlyRedirected:
+			r.recorder.Eventf(route, "Normal", "HTTPSOnlyRedirected", "Ingresscontroller %s is in HTTPS-only mode and would redirect insecure requests for this route to HTTPS once the router enforces HTTPS-only mode; requests are served as before until then", ic.Name)
+		case httpsOnlyRefused:
+			r.recorder.Eventf(route, "Warning", "HTTPSOnlyRefused", "Ingresscontroller %s is in HTTPS-only mode and would refuse to serve this route because it does not use TLS once the router enforces HTTPS-only mode; the route is served as before until then", ic.Name)
+		case httpsOnlyExempt:
+			r.recorder.Eventf(route, "Normal", "HTTPSOnlyExempt", "Ingresscontroller %s is in HTTPS-only mode, but this route would be exempt once the router enforces HTTPS-only mode because it has the %s=true label", ic.Name, HTTPSOnlyExemptLabel)
+		}
+	}
+	for effect, count := range counts {
+		httpsOnlyRoutes.WithLabelValues(ic.Name, effect).Set(float64(count))
+	}
+	r.httpsOnlyRoutes[ic.Name] = current
+}
@@ -0,0 +1,101 @@
+package routemetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_httpsOnlyEffect(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		tls    *routev1.TLSConfig
+		expect string
+	}{
+		{name: "insecure route", expect: httpsOnlyRefused},
+		{name: "exempt insecure route", labels: map[string]string{HTTPSOnlyExemptLabel: "true"}, expect: httpsOnlyExempt},
+		{name: "edge route allowing HTTP", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow}, expect: httpsOnlyRedirected},
+		{name: "edge route without insecure policy", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}, expect: httpsOnlyRedirected},
+		{name: "reencrypt route redirecting HTTP", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect}},
+		{name: "passthrough route", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough}},
+		{name: "exempt label with other value", labels: map[string]string{HTTPSOnlyExemptLabel: "yes"}, expect: httpsOnlyRefused},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Labels: tc.labels},
+				Spec:       routev1.RouteSpec{TLS: tc.tls},
+			}
+			if actual := httpsOnlyEffect(route); actual != tc.expect {
+				t.Errorf("expected %q, got %q", tc.expect, actual)
+			}
+		})
+	}
+}
+
+func Test_reportHTTPSOnly(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "secure"}}
+	route := func(name string, tls *routev1.TLSConfig) routev1.Route {
+		return routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: name},
+			Spec:       routev1.RouteSpec{TLS: tls},
+		}
+	}
+	routes := []routev1.Route{
+		route("plain", nil),
+		route("edge", &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}),
+		route("redirect", &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect}),
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		recorder:        recorder,
+		httpsOnlyRoutes: map[string]map[string]string{},
+	}
+	defer DeleteHTTPSOnlyMetrics(ic.Name)
+
+	r.reportHTTPSOnly(ic, true, routes)
+	if len(recorder.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(recorder.Events))
+	}
+	events := <-recorder.Events + "\n" + <-recorder.Events
+	for _, reason := range []string{"HTTPSOnlyRefused", "HTTPSOnlyRedirected"} {
+		if !strings.Contains(events, reason) {
+			t.Errorf("expected a %s event, got %q", reason, events)
+		}
+	}
+	expected := `
+	# HELP route_metrics_controller_https_only_routes Report the number of routes that HTTPS-only mode would redirect, refuse, or exempt for shards (ingress controllers) in HTTPS-only mode, once the router enforces HTTPS-only mode.
+	# TYPE route_metrics_controller_https_only_routes gauge
+	route_metrics_controller_https_only_routes{effect="exempt",shard_name="secure"} 0
+	route_metrics_controller_https_only_routes{effect="redirected",shard_name="secure"} 1
+	route_metrics_controller_https_only_routes{effect="refused",shard_name="secure"} 1
+	`
+	if err := testutil.CollectAndCompare(httpsOnlyRoutes, strings.NewReader(expected), "route_metrics_controller_https_only_routes"); err != nil {
+		t.Error(err)
+	}
+
+	// Routes whose treatment has not changed are not reported again, and
+	// exempting a route is reported.
+	routes[0].Labels = map[string]string{HTTPSOnlyExemptLabel: "true"}
+	r.reportHTTPSOnly(ic, true, routes)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "HTTPSOnlyExempt") {
+		t.Errorf("expected an HTTPSOnlyExempt event, got %q", event)
+	}
+
+	// Disabling HTTPS-only mode deletes the metrics.
+	r.reportHTTPSOnly(ic, false, routes)
+	if n := testutil.CollectAndCount(httpsOnlyRoutes); n != 0 {
+		t.Errorf("expected no HTTPS-only series, got %d", n)
+	}
+}
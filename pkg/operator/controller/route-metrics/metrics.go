@@ -12,9 +12,35 @@ var (
 		Help: "Report the number of routes for shards (ingress controllers).",
 	}, []string{"shard_name"})
 
+	// routeQuotaUsage reports the number of routes from each namespace
+	// that each ingresscontroller with a route quota has admitted.
+	routeQuotaUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_routes_per_namespace",
+		Help: "Report the number of routes per namespace for shards (ingress controllers) with a route quota.",
+	}, []string{"shard_name", "namespace"})
+
+	// routeQuotaLimit reports the route quota of each namespace for each
+	// ingresscontroller with a route quota.
+	routeQuotaLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_route_quota_per_namespace",
+		Help: "Report the maximum number of routes per namespace for shards (ingress controllers) with a route quota.",
+	}, []string{"shard_name", "namespace"})
+
+	// httpsOnlyRoutes reports the number of admitted routes that
+	// HTTPS-only mode would redirect to HTTPS, refuse to serve, or exempt,
+	// for each ingresscontroller in HTTPS-only mode, once the router image
+	// enforces it.
+	httpsOnlyRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_https_only_routes",
+		Help: "Report the number of routes that HTTPS-only mode would redirect, refuse, or exempt for shards (ingress controllers) in HTTPS-only mode, once the router enforces HTTPS-only mode.",
+	}, []string{"shard_name", "effect"})
+
 	// metricsList is a list of metrics for this package.
 	metricsList = []prometheus.Collector{
 		routeMetricsControllerRoutesPerShard,
+		routeQuotaUsage,
+		routeQuotaLimit,
+		httpsOnlyRoutes,
 	}
 )
 
@@ -26,6 +52,19 @@ func DeleteRouteMetricsControllerRoutesPerShardMetric(shardName string) {
 	routeMetricsControllerRoutesPerShard.DeleteLabelValues(shardName)
 }
 
+// DeleteRouteQuotaMetrics deletes the route quota usage and limit metrics for
+// the given shard.
+func DeleteRouteQuotaMetrics(shardName string) {
+	routeQuotaUsage.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+	routeQuotaLimit.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+}
+
+// DeleteHTTPSOnlyMetrics deletes the HTTPS-only route metrics for the given
+// shard.
+func DeleteHTTPSOnlyMetrics(shardName string) {
+	httpsOnlyRoutes.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+}
+
 // RegisterMetrics calls prometheus.Register on each metric in metricsList, and
 // returns on errors.
 func RegisterMetrics() error {
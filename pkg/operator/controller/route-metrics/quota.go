@@ -0,0 +1,159 @@
+package routemetrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RouteQuotaAnnotation is an annotation on an ingresscontroller that
+	// specifies the maximum number of routes from each namespace that the
+	// ingresscontroller should admit.  The operator reports the routes that
+	// exceed the quota with events and metrics; the routes themselves are
+	// still served.
+	RouteQuotaAnnotation = "ingress.operator.openshift.io/max-routes-per-namespace"
+	// RouteQuotaOverridesAnnotation is an annotation on an
+	// ingresscontroller that specifies a comma-separated list of
+	// "namespace=limit" pairs that override RouteQuotaAnnotation for
+	// particular namespaces.
+	RouteQuotaOverridesAnnotation = "ingress.operator.openshift.io/max-routes-per-namespace-overrides"
+)
+
+// routeQuota is the maximum number of routes per namespace for an
+// ingresscontroller.
+type routeQuota struct {
+	// limit is the default limit.
+	limit int
+	// overrides maps namespaces to their limits.
+	overrides map[string]int
+}
+
+// limitFor returns the route limit for the given namespace.
+func (q *routeQuota) limitFor(namespace string) int {
+	if limit, ok := q.overrides[namespace]; ok {
+		return limit
+	}
+	return q.limit
+}
+
+// routeQuotaForIngressController returns the route quota that the given
+// ingresscontroller's annotations specify, or nil if they specify none.
+func routeQuotaForIngressController(ic *operatorv1.IngressController) (*routeQuota, error) {
+	value, ok := ic.Annotations[RouteQuotaAnnotation]
+	if !ok {
+		if _, ok := ic.Annotations[RouteQuotaOverridesAnnotation]; ok {
+			return nil, fmt.Errorf("the %s annotation requires the %s annotation", RouteQuotaOverridesAnnotation, RouteQuotaAnnotation)
+		}
+		return nil, nil
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || limit < 1 {
+		return nil, fmt.Errorf("invalid value for annotation %s: %q is not a positive integer", RouteQuotaAnnotation, value)
+	}
+	quota := &routeQuota{limit: limit, overrides: map[string]int{}}
+	for _, entry := range strings.Split(ic.Annotations[RouteQuotaOverridesAnnotation], ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		namespace, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid value for annotation %s: %q is not of the form namespace=limit", RouteQuotaOverridesAnnotation, entry)
+		}
+		namespace = strings.TrimSpace(namespace)
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid value for annotation %s: invalid namespace %q: %s", RouteQuotaOverridesAnnotation, namespace, strings.Join(errs, ", "))
+		}
+		if _, ok := quota.overrides[namespace]; ok {
+			return nil, fmt.Errorf("invalid value for annotation %s: duplicate namespace %q", RouteQuotaOverridesAnnotation, namespace)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid value for annotation %s: invalid limit %q for namespace %q", RouteQuotaOverridesAnnotation, value, namespace)
+		}
+		quota.overrides[namespace] = limit
+	}
+	return quota, nil
+}
+
+// ValidateRouteQuota validates the ingresscontroller's route quota
+// annotations.
+func ValidateRouteQuota(ic *operatorv1.IngressController) error {
+	_, err := routeQuotaForIngressController(ic)
+	return err
+}
+
+// routesExceedingQuota returns the routes in each namespace that exceed the
+// namespace's limit, keyed by namespace.  The oldest routes in a namespace are
+// within the quota, so that creating a new route does not displace existing
+// ones.
+func routesExceedingQuota(quota *routeQuota, routesByNamespace map[string][]routev1.Route) map[string][]routev1.Route {
+	excess := map[string][]routev1.Route{}
+	for namespace, routes := range routesByNamespace {
+		limit := quota.limitFor(namespace)
+		if len(routes) <= limit {
+			continue
+		}
+		sorted := make([]routev1.Route, len(routes))
+		copy(sorted, routes)
+		sort.Slice(sorted, func(i, j int) bool {
+			if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+				return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+			}
+			return sorted[i].Name < sorted[j].Name
+		})
+		excess[namespace] = sorted[limit:]
+	}
+	return excess
+}
+
+// reportRouteQuota sets the route quota usage and limit metrics for the given
+// ingresscontroller, deletes the metrics for namespaces that no longer have
+// admitted routes, and records an event on each route that newly exceeds the
+// quota.
+func (r *reconciler) reportRouteQuota(ic *operatorv1.IngressController, quota *routeQuota, routesByNamespace map[string][]routev1.Route) {
+	if quota == nil {
+		DeleteRouteQuotaMetrics(ic.Name)
+		delete(r.quotaNamespaces, ic.Name)
+		delete(r.routesOverQuota, ic.Name)
+		return
+	}
+
+	namespaces := sets.NewString()
+	for namespace, routes := range routesByNamespace {
+		namespaces.Insert(namespace)
+		routeQuotaUsage.WithLabelValues(ic.Name, namespace).Set(float64(len(routes)))
+		routeQuotaLimit.WithLabelValues(ic.Name, namespace).Set(float64(quota.limitFor(namespace)))
+	}
+	for namespace := range r.quotaNamespaces[ic.Name] {
+		if !namespaces.Has(namespace) {
+			routeQuotaUsage.DeleteLabelValues(ic.Name, namespace)
+			routeQuotaLimit.DeleteLabelValues(ic.Name, namespace)
+		}
+	}
+	r.quotaNamespaces[ic.Name] = namespaces
+
+	previous := r.routesOverQuota[ic.Name]
+	current := sets.NewString()
+	for namespace, routes := range routesExceedingQuota(quota, routesByNamespace) {
+		for i := range routes {
+			route := &routes[i]
+			key := route.Namespace + "/" + route.Name
+			current.Insert(key)
+			if previous.Has(key) {
+				continue
+			}
+			log.Info("route exceeds quota", "ingresscontroller", ic.Name, "namespace", namespace, "route", route.Name)
+			r.recorder.Eventf(route, "Warning", "RouteQuotaExceeded", "Namespace %s has %d routes on ingresscontroller %s, which exceeds its quota of %d routes", namespace, len(routesByNamespace[namespace]), ic.Name, quota.limitFor(namespace))
+		}
+	}
+	r.routesOverQuota[ic.Name] = current
+}
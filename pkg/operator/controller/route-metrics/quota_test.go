@@ -0,0 +1,125 @@
+package routemetrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_routeQuotaForIngressController(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectNil   bool
+		expectError bool
+		expect      map[string]int
+	}{
+		{name: "no annotations", expectNil: true},
+		{name: "limit", annotations: map[string]string{RouteQuotaAnnotation: "100"}, expect: map[string]int{"a": 100}},
+		{name: "limit with overrides", annotations: map[string]string{RouteQuotaAnnotation: "100", RouteQuotaOverridesAnnotation: "big = 1000, blocked=0"}, expect: map[string]int{"a": 100, "big": 1000, "blocked": 0}},
+		{name: "zero limit", annotations: map[string]string{RouteQuotaAnnotation: "0"}, expectError: true},
+		{name: "non-numeric limit", annotations: map[string]string{RouteQuotaAnnotation: "lots"}, expectError: true},
+		{name: "overrides without limit", annotations: map[string]string{RouteQuotaOverridesAnnotation: "a=1"}, expectError: true},
+		{name: "malformed override", annotations: map[string]string{RouteQuotaAnnotation: "100", RouteQuotaOverridesAnnotation: "a"}, expectError: true},
+		{name: "negative override", annotations: map[string]string{RouteQuotaAnnotation: "100", RouteQuotaOverridesAnnotation: "a=-1"}, expectError: true},
+		{name: "duplicate override", annotations: map[string]string{RouteQuotaAnnotation: "100", RouteQuotaOverridesAnnotation: "a=1,a=2"}, expectError: true},
+		{name: "invalid namespace", annotations: map[string]string{RouteQuotaAnnotation: "100", RouteQuotaOverridesAnnotation: "A_B=1"}, expectError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			quota, err := routeQuotaForIngressController(ic)
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			if tc.expectNil != (quota == nil) {
+				t.Fatalf("expected nil quota %t, got %+v", tc.expectNil, quota)
+			}
+			for namespace, limit := range tc.expect {
+				if actual := quota.limitFor(namespace); actual != limit {
+					t.Errorf("expected limit %d for namespace %q, got %d", limit, namespace, actual)
+				}
+			}
+		})
+	}
+}
+
+func Test_reportRouteQuota(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "shared"}}
+	start := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	route := func(namespace, name string, age int) routev1.Route {
+		return routev1.Route{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(start.Add(-time.Duration(age) * time.Hour)),
+		}}
+	}
+	routesByNamespace := map[string][]routev1.Route{
+		"tenant-a": {route("tenant-a", "new", 1), route("tenant-a", "old", 3), route("tenant-a", "middle", 2)},
+		"tenant-b": {route("tenant-b", "only", 1)},
+	}
+	quota := &routeQuota{limit: 2, overrides: map[string]int{}}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		recorder:        recorder,
+		quotaNamespaces: map[string]sets.String{},
+		routesOverQuota: map[string]sets.String{},
+	}
+	defer DeleteRouteQuotaMetrics(ic.Name)
+
+	r.reportRouteQuota(ic, quota, routesByNamespace)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "RouteQuotaExceeded") {
+		t.Errorf("expected a RouteQuotaExceeded event, got %q", event)
+	}
+	if !r.routesOverQuota[ic.Name].Has("tenant-a/new") || r.routesOverQuota[ic.Name].Len() != 1 {
+		t.Errorf("expected only the newest route to exceed the quota, got %v", r.routesOverQuota[ic.Name].List())
+	}
+	expected := `
+	# HELP route_metrics_controller_route_quota_per_namespace Report the maximum number of routes per namespace for shards (ingress controllers) with a route quota.
+	# TYPE route_metrics_controller_route_quota_per_namespace gauge
+	route_metrics_controller_route_quota_per_namespace{namespace="tenant-a",shard_name="shared"} 2
+	route_metrics_controller_route_quota_per_namespace{namespace="tenant-b",shard_name="shared"} 2
+	# HELP route_metrics_controller_routes_per_namespace Report the number of routes per namespace for shards (ingress controllers) with a route quota.
+	# TYPE route_metrics_controller_routes_per_namespace gauge
+	route_metrics_controller_routes_per_namespace{namespace="tenant-a",shard_name="shared"} 3
+	route_metrics_controller_routes_per_namespace{namespace="tenant-b",shard_name="shared"} 1
+	`
+	if err := testutil.CollectAndCompare(routeQuotaUsage, strings.NewReader(expected), "route_metrics_controller_routes_per_namespace"); err != nil {
+		t.Error(err)
+	}
+	if err := testutil.CollectAndCompare(routeQuotaLimit, strings.NewReader(expected), "route_metrics_controller_route_quota_per_namespace"); err != nil {
+		t.Error(err)
+	}
+
+	// A route that still exceeds the quota is not reported again, and
+	// namespaces without routes are no longer reported.
+	delete(routesByNamespace, "tenant-b")
+	r.reportRouteQuota(ic, quota, routesByNamespace)
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no new events, got %d", len(recorder.Events))
+	}
+	if n := testutil.CollectAndCount(routeQuotaUsage); n != 1 {
+		t.Errorf("expected 1 usage series, got %d", n)
+	}
+
+	// Removing the quota deletes the metrics.
+	r.reportRouteQuota(ic, nil, routesByNamespace)
+	if n := testutil.CollectAndCount(routeQuotaUsage) + testutil.CollectAndCount(routeQuotaLimit); n != 0 {
+		t.Errorf("expected no route quota series, got %d", n)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	corev1 "k8s.io/api/core/v1"
@@ -32,6 +33,22 @@ var log = logf.Logger.WithName("dnsrecord")
 // [1] https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/resource-record-sets-choosing-alias-non-alias.html
 const defaultRecordTTL int64 = 30
 
+// CAARecordsAnnotation is the key of an annotation on a DNSRecord that
+// requests that the DNS controller publish CAA records for the record's
+// domain.  The value is a newline-separated list of CAA records in
+// presentation format, for example `0 issue "letsencrypt.org"`.  For a
+// wildcard record, the CAA records are published at the parent domain of the
+// wildcard.
+const CAARecordsAnnotation = "ingress.operator.openshift.io/caa-records"
+
+// ZonalRecordsAnnotation is the key of an annotation on a DNSRecord that
+// requests that the DNS controller publish a record for each availability zone
+// of the load balancer that is the record's target.  The only valid value is
+// "true".  For the wildcard record "*.apps.example.com", the record for the
+// zone "us-east-1a" is "*.us-east-1a.apps.example.com", and it points to the
+// load balancer's DNS name for that zone.
+const ZonalRecordsAnnotation = "ingress.operator.openshift.io/zonal-records"
+
 // EnsureWildcardDNSRecord will create wildcard DNS records for the given LB
 // service.  If service is nil (haveLBS is false), nothing is done.
 func EnsureWildcardDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, endpointPublishingStrategy *operatorv1.EndpointPublishingStrategy, service *corev1.Service, haveLBS bool) (bool, *iov1.DNSRecord, error) {
@@ -63,6 +80,62 @@ func EnsureWildcardDNSRecord(client client.Client, name types.NamespacedName, dn
 	return haveWC, current, nil
 }
 
+// EnsureDNSRecordCAARecords sets or removes the CAA annotation on the given
+// DNSRecord so that it requests the given CAA records.  If records is empty,
+// the annotation is removed.  The DNSRecord is patched rather than updated so
+// that the update does not conflict with other changes to its annotations
+// that were made using the same copy of the DNSRecord.  Returns a Boolean
+// value indicating whether the DNSRecord was updated, and an error value.
+func EnsureDNSRecordCAARecords(cl client.Client, record *iov1.DNSRecord, records []dns.CAARecord) (bool, error) {
+	current, haveCurrent := record.Annotations[CAARecordsAnnotation]
+	desired := dns.FormatCAARecords(records)
+	if (len(records) == 0 && !haveCurrent) || (len(records) != 0 && haveCurrent && current == desired) {
+		return false, nil
+	}
+
+	updated := record.DeepCopy()
+	if len(records) == 0 {
+		delete(updated.Annotations, CAARecordsAnnotation)
+	} else {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[CAARecordsAnnotation] = desired
+	}
+	if err := cl.Patch(context.TODO(), updated, client.MergeFrom(record)); err != nil {
+		return false, fmt.Errorf("failed to update CAA annotation on dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("updated CAA annotation on dnsrecord", "namespace", record.Namespace, "name", record.Name, "caaRecords", desired)
+	return true, nil
+}
+
+// EnsureDNSRecordZonalRecords sets or removes the zonal records annotation on
+// the given DNSRecord.  The DNSRecord is patched rather than updated so that
+// the update does not conflict with other changes to its annotations that were
+// made using the same copy of the DNSRecord.  Returns a Boolean value indicating whether the
+// DNSRecord was updated, and an error value.
+func EnsureDNSRecordZonalRecords(cl client.Client, record *iov1.DNSRecord, enabled bool) (bool, error) {
+	_, haveCurrent := record.Annotations[ZonalRecordsAnnotation]
+	if enabled == haveCurrent && (!enabled || record.Annotations[ZonalRecordsAnnotation] == "true") {
+		return false, nil
+	}
+
+	updated := record.DeepCopy()
+	if !enabled {
+		delete(updated.Annotations, ZonalRecordsAnnotation)
+	} else {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[ZonalRecordsAnnotation] = "true"
+	}
+	if err := cl.Patch(context.TODO(), updated, client.MergeFrom(record)); err != nil {
+		return false, fmt.Errorf("failed to update zonal records annotation on dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("updated zonal records annotation on dnsrecord", "namespace", record.Namespace, "name", record.Name, "enabled", enabled)
+	return true, nil
+}
+
 // EnsureDNSRecord will create DNS records for the given LB service.  If service
 // is nil (haveLBS is false), nothing is done.
 func EnsureDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, dnsPolicy iov1.DNSManagementPolicy, service *corev1.Service) (bool, *iov1.DNSRecord, error) {
@@ -0,0 +1,88 @@
+package dnsrecord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FailoverPolicyAnnotation is the key of an annotation on a DNSRecord that
+// requests that the DNS controller point the record at a standby
+// ingresscontroller's load balancer while the ingresscontroller that owns the
+// record is unhealthy.  The value is a JSON FailoverPolicy value.
+const FailoverPolicyAnnotation = "ingress.operator.openshift.io/dns-failover-policy"
+
+// FailoverPolicy describes when the DNS controller fails a DNSRecord over to a
+// standby ingresscontroller and back.
+type FailoverPolicy struct {
+	// Standby is the name of the standby ingresscontroller, which must be
+	// in the same namespace as the DNSRecord.  While the DNSRecord is
+	// failed over, it has the targets of the standby's wildcard DNSRecord.
+	Standby string `json:"standby"`
+	// Threshold is how long the ingresscontroller that owns the DNSRecord
+	// must be unhealthy before the DNSRecord is failed over.
+	Threshold metav1.Duration `json:"threshold"`
+	// FailbackDelay is how long the ingresscontroller that owns the
+	// DNSRecord must be healthy again before the DNSRecord is failed back.
+	FailbackDelay metav1.Duration `json:"failbackDelay"`
+}
+
+// FailoverPolicyForDNSRecord returns the policy from the given DNSRecord's
+// failover policy annotation, or nil if the DNSRecord does not have the
+// annotation.
+func FailoverPolicyForDNSRecord(record *iov1.DNSRecord) (*FailoverPolicy, error) {
+	value, ok := record.Annotations[FailoverPolicyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var policy FailoverPolicy
+	if err := json.Unmarshal([]byte(value), &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %w", FailoverPolicyAnnotation, err)
+	}
+	if len(policy.Standby) == 0 {
+		return nil, fmt.Errorf("invalid value for annotation %s: standby is required", FailoverPolicyAnnotation)
+	}
+	return &policy, nil
+}
+
+// EnsureDNSRecordFailoverPolicy sets or removes the failover policy annotation
+// on the given DNSRecord so that it requests the given policy.  If policy is
+// nil, the annotation is removed.  The DNSRecord is patched rather than updated
+// so that the update does not conflict with other changes to its annotations
+// that were made using the same copy of the DNSRecord.  Returns a Boolean value
+// indicating whether the DNSRecord was updated, and an error value.
+func EnsureDNSRecordFailoverPolicy(cl client.Client, record *iov1.DNSRecord, policy *FailoverPolicy) (bool, error) {
+	current, haveCurrent := record.Annotations[FailoverPolicyAnnotation]
+	var desired string
+	if policy != nil {
+		value, err := json.Marshal(policy)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal failover policy: %w", err)
+		}
+		desired = string(value)
+	}
+	if (policy == nil && !haveCurrent) || (policy != nil && haveCurrent && current == desired) {
+		return false, nil
+	}
+
+	updated := record.DeepCopy()
+	if policy == nil {
+		delete(updated.Annotations, FailoverPolicyAnnotation)
+	} else {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[FailoverPolicyAnnotation] = desired
+	}
+	if err := cl.Patch(context.TODO(), updated, client.MergeFrom(record)); err != nil {
+		return false, fmt.Errorf("failed to update failover policy annotation on dnsrecord %s/%s: %w", record.Namespace, record.Name, err)
+	}
+	log.Info("updated failover policy annotation on dnsrecord", "namespace", record.Namespace, "name", record.Name, "policy", desired)
+	return true, nil
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	canarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/canary"
+)
+
+// contextKey is the type of the keys that this package stores in request
+// contexts.
+type contextKey string
+
+const (
+	// connContextKey is the context key for the recordingConn on which a
+	// request was received.
+	connContextKey contextKey = "conn"
+	// rawHeadersContextKey is the context key for the request headers as
+	// they were received, with their original names.
+	rawHeadersContextKey contextKey = "raw-headers"
+)
+
+// recordingListener wraps a listener so that every connection that it accepts
+// records the request heads that are read from it.  net/http canonicalizes
+// header names, so the raw bytes are needed in order to echo header names
+// exactly as the router sent them.
+type recordingListener struct {
+	net.Listener
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, recording: true}, nil
+}
+
+// headTerminator is the blank line that ends an HTTP request head.
+var headTerminator = []byte("\r\n\r\n")
+
+// recordingConn is a connection that records the bytes that are read from it
+// until it has recorded a complete request head.  Recording resumes when the
+// head is taken, so that the head of the next request on the connection is
+// recorded, but request bodies are never recorded.
+type recordingConn struct {
+	net.Conn
+
+	mu        sync.Mutex
+	recording bool
+	recorded  bytes.Buffer
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mu.Lock()
+		if c.recording {
+			c.recorded.Write(b[:n])
+			if i := bytes.Index(c.recorded.Bytes(), headTerminator); i >= 0 {
+				c.recorded.Truncate(i + len(headTerminator))
+				c.recording = false
+			}
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// take returns the request head that has been recorded since the last call to
+// take and resumes recording.
+func (c *recordingConn) take() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	recorded := append([]byte{}, c.recorded.Bytes()...)
+	c.recorded.Reset()
+	c.recording = true
+	return recorded
+}
+
+// withRawHeaders wraps the given handler so that the headers of each request,
+// as they were received, are available from the request's context.
+func withRawHeaders(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, ok := r.Context().Value(connContextKey).(*recordingConn); ok {
+			headers := parseRawHeaders(conn.take())
+			r = r.WithContext(context.WithValue(r.Context(), rawHeadersContextKey, headers))
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// parseRawHeaders returns the headers of the request head at the start of the
+// given bytes, with names exactly as they were received.  Anything after the
+// blank line that ends the head is ignored.  No headers are returned if the
+// bytes do not start with a complete request head, which can happen if the
+// server read a pipelined request before the previous request's head was
+// taken.
+func parseRawHeaders(raw []byte) []canarycontroller.EchoedHeader {
+	i := bytes.Index(raw, headTerminator)
+	if i < 0 {
+		return nil
+	}
+	lines := strings.Split(string(raw[:i]), "\r\n")
+	if len(lines) < 2 || !strings.Contains(lines[0], " HTTP/") {
+		return nil
+	}
+	var headers []canarycontroller.EchoedHeader
+	for _, line := range lines[1:] {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		headers = append(headers, canarycontroller.EchoedHeader{
+			Name:  parts[0],
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+	return headers
+}
+
+// requestEchoHandler responds with a RequestEcho that describes the request.
+func requestEchoHandler(w http.ResponseWriter, r *http.Request) {
+	response := os.Getenv("RESPONSE")
+	if len(response) == 0 {
+		response = canarycontroller.CanaryHealthcheckResponse
+	}
+	echo := canarycontroller.RequestEcho{
+		Response:   response,
+		RemoteAddr: r.RemoteAddr,
+	}
+	if headers, ok := r.Context().Value(rawHeadersContextKey).([]canarycontroller.EchoedHeader); ok {
+		echo.Headers = headers
+	}
+
+	for _, name := range strings.Split(r.Header.Get(canarycontroller.CanaryEchoResponseHeadersHeader), ",") {
+		if name = strings.TrimSpace(name); len(name) != 0 {
+			w.Header().Set(name, canarycontroller.CanaryEchoResponseHeaderValue)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(echo); err != nil {
+		fmt.Printf("Could not serve canary request echo: %v\n", err)
+	}
+}
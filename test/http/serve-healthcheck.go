@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -35,7 +36,17 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 
 func listenAndServe(port string) {
 	fmt.Printf("serving on %s\n", port)
-	err := http.ListenAndServe(":"+port, nil)
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		panic("ListenAndServe: " + err.Error())
+	}
+	server := &http.Server{
+		Handler: withRawHeaders(http.DefaultServeMux),
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey, conn)
+		},
+	}
+	err = server.Serve(&recordingListener{listener})
 	if err != nil {
 		panic("ListenAndServe: " + err.Error())
 	}
@@ -56,6 +67,7 @@ func NewServeHealthCheckCommand() *cobra.Command {
 
 func serveHealthCheck() {
 	http.HandleFunc("/", healthCheckHandler)
+	http.HandleFunc(canarycontroller.CanaryRequestEchoPath, requestEchoHandler)
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
 		port = "8080"